@@ -1,13 +1,17 @@
 package buffalo
 
 import (
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/gobuffalo/buffalo/worker"
 	gcontext "github.com/gorilla/context"
 	"github.com/gorilla/mux"
 	"github.com/markbates/refresh/refresh/web"
@@ -22,10 +26,26 @@ type App struct {
 	// Middleware returns the current MiddlewareStack for the App/Group.
 	Middleware    *MiddlewareStack
 	ErrorHandlers ErrorHandlers
+	// RouteLimits are given to the routes added to the App/Group.
+	RouteLimits RouteLimits
+	// HealthChecks are run by the readiness endpoint, see HealthOptions.
+	HealthChecks  *HealthChecks
 	router        *mux.Router
+	hosts         *mux.Router
 	moot          *sync.Mutex
 	routes        RouteList
+	routeNames    map[string]RouteInfo
 	root          *App
+	parent        *App
+	mounted       []mountedApp
+	host          string
+	member        string
+	runtimeConfig *atomic.Value
+	reloading     *sync.Mutex
+	metrics       *atomic.Value
+	srv           *server
+	idx           *indexState
+	scheduled     []*ScheduledTask
 }
 
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -36,38 +56,84 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if a.MethodOverride != nil {
 		a.MethodOverride(w, r)
 	}
+	if a.serveHealth(ws, r) {
+		return
+	}
+	if a.servePathPolicy(ws, r) {
+		return
+	}
+	if (r.Method == "HEAD" || r.Method == "OPTIONS") && a.serveAutoMethod(ws, r) {
+		return
+	}
 	var h http.Handler
-	h = a.router
-	if a.Env == "development" {
+	h = indexedRouter{app: a}
+	if a.Env() == "development" {
 		h = web.ErrorChecker(h)
 	}
 	h.ServeHTTP(ws, r)
 }
 
+// Env is the environment the App is running in, see Options.Env.
+func (a *App) Env() string {
+	return a.Options.Env
+}
+
 // New returns a new instance of App, without any frills
 // or thrills. Most people will want to use Automatic which
 // adds some sane, and useful, defaults.
-func New(opts Options) *App {
-	opts = optionsWithDefaults(opts)
+/*
+	a := buffalo.New(buffalo.WithEnv("production"), buffalo.WithAddr(":8080"))
+
+	// or
+	a := buffalo.New(buffalo.Options{Env: "production", Addr: ":8080"})
+*/
+func New(opts ...Option) *App {
+	o := buildOptions(opts)
 
 	a := &App{
-		Options:    opts,
+		Options:    o,
 		Middleware: newMiddlewareStack(),
 		ErrorHandlers: ErrorHandlers{
 			404: NotFoundHandler,
+			405: MethodNotAllowedHandler,
+			422: ValidationErrorHandler,
 			500: defaultErrorHandler,
 		},
-		router: mux.NewRouter(),
-		moot:   &sync.Mutex{},
-		routes: RouteList{},
+		router:        mux.NewRouter(),
+		moot:          &sync.Mutex{},
+		routes:        RouteList{},
+		runtimeConfig: newRuntimeConfig(o),
+		reloading:     &sync.Mutex{},
+		metrics:       &atomic.Value{},
+		idx:           &indexState{},
+		HealthChecks:  newHealthChecks(o.Health),
 	}
+	a.Middleware.app = a
+	// host routes are matched before all other routes, no matter
+	// when they were added
+	a.hosts = a.router.NewRoute().Subrouter()
 	if a.Logger == nil {
-		a.Logger = NewLogger(opts.LogLevel)
+		a.Logger = NewLogger(o.LogLevel)
+	}
+	if w, ok := a.Worker.(*worker.Simple); ok && w.Logger == nil {
+		w.Logger = a.Logger
 	}
 	a.router.NotFoundHandler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		defer a.measure("NOT_FOUND", res, req)()
+		req, _ = a.resolveTenant(req)
 		c := a.newContext(RouteInfo{}, res, req)
+		defer releaseContext(c)
 		err := errors.Errorf("path not found: %s", req.URL.Path)
-		a.ErrorHandlers.Get(404)(404, err, c)
+		a.errorHandler(c, 404)(404, err, c)
+	})
+	a.router.MethodNotAllowedHandler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		defer a.measure("METHOD_NOT_ALLOWED", res, req)()
+		res.Header().Set("Allow", strings.Join(a.allowedMethods(req), ", "))
+		req, _ = a.resolveTenant(req)
+		c := a.newContext(RouteInfo{}, res, req)
+		defer releaseContext(c)
+		err := errors.Errorf("method %s not allowed for path: %s", req.Method, req.URL.Path)
+		a.errorHandler(c, 405)(405, err, c)
 	})
 
 	return a
@@ -79,8 +145,8 @@ func New(opts Options) *App {
 // all of the time to build your applications.
 //
 // https://www.youtube.com/watch?v=BKbOplYmjZM
-func Automatic(opts Options) *App {
-	opts = optionsWithDefaults(opts)
+func Automatic(options ...Option) *App {
+	opts := buildOptions(options)
 	if opts.MethodOverride == nil {
 		opts.MethodOverride = MethodOverrideFunc
 	}
@@ -96,7 +162,13 @@ func Automatic(opts Options) *App {
 			log.Fatal(err)
 		}
 
-		f, err := os.Create(filepath.Join(opts.LogDir, opts.Env+".log"))
+		var f io.Writer
+		lp := filepath.Join(opts.LogDir, opts.Env+".log")
+		if opts.LogRotation != (RotateOptions{}) {
+			f, err = NewRotatingFile(lp, opts.LogRotation)
+		} else {
+			f, err = os.Create(lp)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,110 @@
+// Package auth logs users in, and out, through the session, and
+// protects routes from anonymous users. It keeps no state of its own,
+// so with an encrypted cookie session store, see
+// github.com/gobuffalo/buffalo/sessionstore, the servers keep none
+// either.
+/*
+	func Login(c buffalo.Context) error {
+		u, err := users.Authenticate(c.Param("email"), c.Param("password"))
+		if err != nil {
+			return c.Render(422, r.HTML("sessions/new.html"))
+		}
+		auth.Login(c, u.ID)
+		return c.Redirect(303, auth.ReturnTo(c, "/"))
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(auth.RequireAuth("/login"))
+*/
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// SessionKey is the session key the ID of the logged in user is kept
+// under. It is the key the RequestLogger and middleware.RememberMe look
+// for.
+const SessionKey = "current_user_id"
+
+// returnToKey is the session key of the URL to go back to after logging
+// in.
+const returnToKey = "auth_return_to"
+
+// ErrUnauthorized is returned, with a 401 status, by RequireAuth to API
+// requests without a logged in user.
+var ErrUnauthorized = errors.New("you must be logged in")
+
+// Login logs the user in. The session is given a new ID first, so an ID
+// planted before logging in is worth nothing.
+func Login(c buffalo.Context, userID string) {
+	c.Session().Regenerate()
+	c.Session().Set(SessionKey, userID)
+}
+
+// CurrentUser returns the ID of the logged in user, false if there is
+// none.
+func CurrentUser(c buffalo.Context) (string, bool) {
+	id, ok := c.Session().GetString(SessionKey)
+	return id, ok && id != ""
+}
+
+// Logout logs the user out, emptying the session and giving it a new
+// ID. Stores that keep sessions on the server, see sessionstore.Store,
+// delete the old session, so a copy of the cookie from before logging
+// out is worth nothing either.
+func Logout(c buffalo.Context) {
+	c.Session().Clear()
+	c.Session().Regenerate()
+}
+
+// ReturnTo returns, and forgets, the URL RequireAuth sent the user away
+// from, so they can be sent back once logged in, or def if there is
+// none.
+func ReturnTo(c buffalo.Context, def string) string {
+	to, ok := c.Session().GetString(returnToKey)
+	if !ok {
+		return def
+	}
+	c.Session().Delete(returnToKey)
+	// only paths of this site, "//evil.com" is another site
+	if !strings.HasPrefix(to, "/") || strings.HasPrefix(to, "//") || strings.HasPrefix(to, "/\\") {
+		return def
+	}
+	return to
+}
+
+// RequireAuth returns a piece of buffalo.Middleware that only lets
+// logged in users through, and sets their ID on the Context as
+// "current_user_id". Anonymous users of HTML pages are redirected to
+// loginPath, and, for GETs, sent back once they log in, see ReturnTo.
+// API clients, those preferring JSON or XML, or making XHRs, get a 401.
+func RequireAuth(loginPath string) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			if id, ok := CurrentUser(c); ok {
+				c.Set(SessionKey, id)
+				return next(c)
+			}
+			req := c.Request()
+			if isAPI(c) {
+				return c.Error(http.StatusUnauthorized, ErrUnauthorized)
+			}
+			if req.Method == "GET" {
+				c.Session().Set(returnToKey, req.URL.RequestURI())
+			}
+			return c.Redirect(http.StatusSeeOther, loginPath)
+		}
+	}
+}
+
+func isAPI(c buffalo.Context) bool {
+	if c.Request().Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		return true
+	}
+	return c.Accepts("html", "json", "xml") != "html"
+}
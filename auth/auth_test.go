@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/auth"
+	"github.com/gobuffalo/buffalo/sessionstore"
+	"github.com/stretchr/testify/require"
+)
+
+func app(opts buffalo.Options) *buffalo.App {
+	a := buffalo.New(opts)
+	a.GET("/login", func(c buffalo.Context) error {
+		auth.Login(c, c.Param("id"))
+		return c.Redirect(303, auth.ReturnTo(c, "/"))
+	})
+	a.GET("/logout", func(c buffalo.Context) error {
+		auth.Logout(c)
+		return c.Redirect(303, "/")
+	})
+	g := a.Group("/account")
+	g.Use(auth.RequireAuth("/login"))
+	g.GET("/", func(c buffalo.Context) error {
+		id, _ := auth.CurrentUser(c)
+		return c.String(200, "%s %s", id, c.Value(auth.SessionKey))
+	})
+	return a
+}
+
+func serve(a *buffalo.App, path string, accept string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", accept)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_RequireAuth(t *testing.T) {
+	r := require.New(t)
+	a := app(buffalo.Options{})
+
+	// API clients get a 401
+	res := serve(a, "/account", "application/json", nil)
+	r.Equal(401, res.Code)
+
+	// browsers are sent to log in, and back
+	res = serve(a, "/account?tab=billing", "text/html", nil)
+	r.Equal(303, res.Code)
+	r.Equal("/login", res.Header().Get("Location"))
+
+	res = serve(a, "/login?id=u1", "text/html", res.Result().Cookies())
+	r.Equal(303, res.Code)
+	r.Equal("/account?tab=billing", res.Header().Get("Location"))
+	cookies := res.Result().Cookies()
+
+	res = serve(a, "/account", "text/html", cookies)
+	r.Equal(200, res.Code)
+	r.Equal("u1 u1", res.Body.String())
+
+	res = serve(a, "/logout", "text/html", cookies)
+	res = serve(a, "/account", "application/json", res.Result().Cookies())
+	r.Equal(401, res.Code)
+}
+
+func Test_Logout_ServerSession(t *testing.T) {
+	r := require.New(t)
+	a := app(buffalo.Options{
+		SessionStore: sessionstore.New(sessionstore.Memory(), []byte("secret")),
+	})
+
+	res := serve(a, "/login?id=u1", "text/html", nil)
+	cookies := res.Result().Cookies()
+	r.Equal(200, serve(a, "/account", "text/html", cookies).Code)
+
+	serve(a, "/logout", "text/html", cookies)
+	// the cookie from before logging out is worth nothing
+	r.Equal(401, serve(a, "/account", "application/json", cookies).Code)
+}
+
+func Test_ReturnTo_OtherSites(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.GET("/", func(c buffalo.Context) error {
+		c.Session().Set("auth_return_to", "//evil.com/")
+		return c.String(200, auth.ReturnTo(c, "/home"))
+	})
+	r.Equal("/home", serve(a, "/", "text/html", nil).Body.String())
+}
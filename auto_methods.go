@@ -0,0 +1,102 @@
+package buffalo
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// autoMethods are the methods checked, in order, when building the
+// Allow header for a path.
+var autoMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// serveAutoMethod answers HEAD requests with the GET route for the
+// path, and OPTIONS requests with the methods allowed for the path,
+// when there is no route for them. It returns false if the request
+// wasn't answered.
+func (a *App) serveAutoMethod(res http.ResponseWriter, req *http.Request) bool {
+	if a.matchMethod(req, req.Method) != nil {
+		return false
+	}
+	switch req.Method {
+	case "HEAD":
+		if a.DisableAutoHead {
+			return false
+		}
+		m := a.matchMethod(req, "GET")
+		if m == nil {
+			return false
+		}
+		hr := &buffaloResponse{ResponseWriter: headResponse{res}}
+		m.Handler.ServeHTTP(hr, mux.SetURLVars(req, m.Vars))
+		return true
+	case "OPTIONS":
+		if a.DisableAutoOptions {
+			return false
+		}
+		allowed := a.allowedMethods(req)
+		if len(allowed) == 0 {
+			return false
+		}
+		res.Header().Set("Allow", strings.Join(allowed, ", "))
+		h := a.OptionsHandler
+		if h == nil {
+			h = func(c Context) error {
+				c.Response().WriteHeader(http.StatusNoContent)
+				return nil
+			}
+		}
+		info := RouteInfo{
+			Method:      "OPTIONS",
+			Path:        req.URL.Path,
+			HandlerName: funcKey(h),
+			Handler:     h,
+			app:         a,
+		}
+		a.handlerToHandler(info, h).ServeHTTP(res, req)
+		return true
+	}
+	return false
+}
+
+// allowedMethods returns the methods that can be used with the path of
+// the request, including the ones answered automatically.
+func (a *App) allowedMethods(req *http.Request) []string {
+	allowed := []string{}
+	for _, method := range autoMethods {
+		ok := a.matchMethod(req, method) != nil
+		switch {
+		case method == "HEAD" && !a.DisableAutoHead:
+			ok = ok || a.matchMethod(req, "GET") != nil
+		case method == "OPTIONS" && !a.DisableAutoOptions:
+			ok = ok || len(allowed) > 0
+		}
+		if ok {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// matchMethod returns the route match for the path of the request
+// with a different method, or nil if no route matches.
+func (a *App) matchMethod(req *http.Request, method string) *mux.RouteMatch {
+	r := new(http.Request)
+	*r = *req
+	r.Method = method
+	m := &mux.RouteMatch{}
+	if !a.router.Match(r, m) || m.MatchErr != nil {
+		return nil
+	}
+	return m
+}
+
+// headResponse throws away the body of the response to a HEAD request.
+type headResponse struct {
+	http.ResponseWriter
+}
+
+func (w headResponse) Write(b []byte) (int, error) {
+	return len(b), nil
+}
@@ -0,0 +1,67 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func autoMethodsApp(opts Options) *App {
+	a := New(opts)
+	a.GET("/users/{id}", func(c Context) error {
+		c.Response().Header().Set("X-Method", c.Request().Method)
+		return c.Render(200, render.String("user {{params.id}}"))
+	})
+	a.PUT("/users/{id}", func(c Context) error {
+		return c.Render(200, render.String("updated"))
+	})
+	a.OPTIONS("/custom", func(c Context) error {
+		return c.Render(200, render.String("custom"))
+	})
+	return a
+}
+
+func serve(a *App, method, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_App_AutoHead(t *testing.T) {
+	r := require.New(t)
+
+	a := autoMethodsApp(Options{})
+	res := serve(a, "HEAD", "/users/1")
+	r.Equal(200, res.Code)
+	r.Equal("HEAD", res.Header().Get("X-Method"))
+	r.Empty(res.Body.String())
+
+	a = autoMethodsApp(Options{DisableAutoHead: true})
+	r.Equal(405, serve(a, "HEAD", "/users/1").Code)
+}
+
+func Test_App_AutoOptions(t *testing.T) {
+	r := require.New(t)
+
+	a := autoMethodsApp(Options{})
+	res := serve(a, "OPTIONS", "/users/1")
+	r.Equal(204, res.Code)
+	r.Equal("GET, HEAD, PUT, OPTIONS", res.Header().Get("Allow"))
+
+	r.Equal("custom", serve(a, "OPTIONS", "/custom").Body.String())
+	r.Equal(404, serve(a, "OPTIONS", "/nope").Code)
+
+	a = autoMethodsApp(Options{
+		OptionsHandler: func(c Context) error {
+			return c.Render(200, render.String(c.Response().Header().Get("Allow")))
+		},
+	})
+	r.Equal("GET, HEAD, PUT, OPTIONS", serve(a, "OPTIONS", "/users/1").Body.String())
+
+	a = autoMethodsApp(Options{DisableAutoOptions: true})
+	r.Equal(405, serve(a, "OPTIONS", "/users/1").Code)
+}
@@ -0,0 +1,178 @@
+package buffalo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BatchRequest is a single request inside of a batch.
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is sent as is, unless it is a JSON string, in which case the
+	// string itself is sent.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResponse is the response to a single BatchRequest.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	// Body is embedded as JSON if the response was JSON, otherwise it is
+	// a string.
+	Body json.RawMessage `json:"body"`
+}
+
+// BatchHandler returns a Handler that runs several requests in one round
+// trip, which is useful for mobile clients on slow networks. It expects a
+// JSON array of BatchRequests, runs each of them, in order, through the
+// App's router, and responds with a JSON array of BatchResponses.
+//
+// Every request in the batch gets the headers, including cookies and
+// "Authorization", of the batch request itself, so they all run as the
+// same user. A batch may contain at most max requests, and none of them
+// may be a batch.
+/*
+	a.POST("/batch", a.BatchHandler(20))
+
+	// POST /batch
+	[
+		{"method": "GET", "path": "/users/1"},
+		{"method": "POST", "path": "/widgets", "body": {"name": "thing"}}
+	]
+*/
+func (a *App) BatchHandler(max int) Handler {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	return func(c Context) error {
+		reqs := []BatchRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&reqs); err != nil {
+			return c.Error(400, errors.Wrap(err, "could not parse batch"))
+		}
+		if len(reqs) > max {
+			return c.Error(400, errors.Errorf("batch has %d requests, the most allowed is %d", len(reqs), max))
+		}
+		if c.Request().Context().Value(batchKey{}) != nil {
+			return c.Error(400, errBatchInBatch)
+		}
+		self := c.Request().URL.Path
+		resps := make([]BatchResponse, len(reqs))
+		for i, br := range reqs {
+			req, err := br.request(c.Request())
+			if err != nil {
+				return c.Error(400, err)
+			}
+			if req.URL.Path == self {
+				return c.Error(400, errBatchInBatch)
+			}
+			bw := &batchWriter{header: http.Header{}}
+			root.ServeHTTP(bw, req)
+			resps[i] = bw.response()
+		}
+		res := c.Response()
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(200)
+		return json.NewEncoder(res).Encode(resps)
+	}
+}
+
+// batchKey marks the requests of a batch, so a batch handler, whatever
+// its path, won't run inside of another.
+type batchKey struct{}
+
+var errBatchInBatch = errors.New("batches can't contain batches")
+
+func (br BatchRequest) request(parent *http.Request) (*http.Request, error) {
+	method := strings.ToUpper(br.Method)
+	if method == "" {
+		method = "GET"
+	}
+	if !strings.HasPrefix(br.Path, "/") {
+		return nil, errors.Errorf("invalid path %q", br.Path)
+	}
+	body := []byte(br.Body)
+	ct := "application/json"
+	if len(body) > 0 && body[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(body, &s); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		body = []byte(s)
+		ct = ""
+	}
+	req, err := http.NewRequest(method, br.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req = req.WithContext(context.WithValue(parent.Context(), batchKey{}, true))
+	req.RemoteAddr = parent.RemoteAddr
+	req.Host = parent.Host
+	for k, v := range parent.Header {
+		if k == "Content-Length" || k == "Content-Type" {
+			continue
+		}
+		req.Header[k] = v
+	}
+	if len(body) > 0 && ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+	for k, v := range br.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// batchWriter records the response to a request in a batch.
+type batchWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *batchWriter) response() BatchResponse {
+	r := BatchResponse{
+		Status:  w.status,
+		Headers: map[string]string{},
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	for k := range w.header {
+		r.Headers[k] = w.header.Get(k)
+	}
+	b := bytes.TrimSpace(w.body.Bytes())
+	switch {
+	case len(b) == 0:
+		r.Body = json.RawMessage(`""`)
+	case strings.Contains(w.header.Get("Content-Type"), "json") && json.Valid(b):
+		r.Body = json.RawMessage(b)
+	default:
+		r.Body, _ = json.Marshal(w.body.String())
+	}
+	return r
+}
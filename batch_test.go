@@ -0,0 +1,72 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_BatchHandler(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.POST("/batch", a.BatchHandler(3))
+	api := a.Group("/api")
+	api.GET("/users/{id}", func(c Context) error {
+		return c.Render(200, render.JSON(map[string]string{
+			"id":   c.Param("id"),
+			"auth": c.Request().Header.Get("Authorization"),
+		}))
+	})
+	api.POST("/widgets", func(c Context) error {
+		w := map[string]string{}
+		if err := c.Bind(&w); err != nil {
+			return err
+		}
+		return c.Render(201, render.String("created "+w["name"]))
+	})
+
+	w := willie.New(a)
+	req := w.JSON("/batch")
+	req.Headers["Authorization"] = "Bearer abc"
+	res := req.Post([]map[string]interface{}{
+		{"method": "GET", "path": "/api/users/1"},
+		{"method": "post", "path": "/api/widgets", "body": map[string]string{"name": "thing"}},
+		{"method": "GET", "path": "/nope"},
+	})
+	r.Equal(200, res.Code)
+
+	resps := []BatchResponse{}
+	r.NoError(json.Unmarshal(res.Body.Bytes(), &resps))
+	r.Len(resps, 3)
+
+	r.Equal(200, resps[0].Status)
+	user := map[string]string{}
+	r.NoError(json.Unmarshal(resps[0].Body, &user))
+	r.Equal("1", user["id"])
+	r.Equal("Bearer abc", user["auth"])
+
+	r.Equal(201, resps[1].Status)
+	r.Equal(`"created thing"`, string(resps[1].Body))
+
+	r.Equal(404, resps[2].Status)
+
+	res = w.JSON("/batch").Post([]map[string]interface{}{{}, {}, {}, {}})
+	r.Equal(400, res.Code)
+
+	for _, path := range []string{"/batch", "/%62atch", "/batch#x", "/batch?x=1"} {
+		res = w.JSON("/batch").Post([]map[string]interface{}{{"method": "POST", "path": path}})
+		r.Equal(400, res.Code, path)
+	}
+
+	// nor batches under another path
+	a.POST("/batch2", a.BatchHandler(3))
+	res = w.JSON("/batch").Post([]map[string]interface{}{{"method": "POST", "path": "/batch2", "body": "[]"}})
+	r.Equal(200, res.Code)
+	resps = []BatchResponse{}
+	r.NoError(json.Unmarshal(res.Body.Bytes(), &resps))
+	r.Equal(400, resps[0].Status)
+}
@@ -0,0 +1,107 @@
+/*
+Package benchmarks contains the benchmarks for Buffalo's hot code paths,
+routing, context creation, middleware, error handling, and rendering,
+along with a few helpers for benchmarking your own applications.
+
+	$ go test -run=XXX -bench=. -benchmem github.com/gobuffalo/buffalo/benchmarks
+
+The Baseline benchmarks serve the same requests with plain net/http and
+gorilla/mux, so the cost Buffalo adds on top of them is easy to see.
+
+Use Request, or Run, to measure your own stack:
+
+	func Benchmark_Widgets(b *testing.B) {
+		benchmarks.Request(b, actions.App(), "GET", "/widgets", nil)
+	}
+*/
+package benchmarks
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+// Request benchmarks sending the same request to h b.N times. The body,
+// if any, is sent with every request. The benchmark fails if h responds
+// with a 5xx status.
+func Request(b *testing.B, h http.Handler, method, path string, body []byte) {
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	Run(b, h, req, body)
+}
+
+// Run benchmarks serving req with h b.N times. Responses are discarded,
+// apart from their status, so the benchmark measures h and not the
+// cost of recording the response.
+func Run(b *testing.B, h http.Handler, req *http.Request, body []byte) {
+	w := &ResponseWriter{}
+	r := bytes.NewReader(body)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		if body != nil {
+			r.Reset(body)
+			req.Body = nopCloser{r}
+			req.ContentLength = int64(len(body))
+		}
+		h.ServeHTTP(w, req)
+		if w.Status >= 500 {
+			b.Fatalf("%s %s returned %d", req.Method, req.URL, w.Status)
+		}
+	}
+}
+
+// ResponseWriter is a http.ResponseWriter that throws away everything
+// but the status and the number of bytes written. It can be Reset and
+// reused between requests so it doesn't skew allocation counts.
+type ResponseWriter struct {
+	Status int
+	Size   int
+	header http.Header
+}
+
+// Header returns the response headers.
+func (w *ResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = http.Header{}
+	}
+	return w.header
+}
+
+// WriteHeader records the status.
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+}
+
+// Write counts, and discards, b.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	w.Size += len(b)
+	return len(b), nil
+}
+
+// Reset the ResponseWriter so it can be used for another request.
+func (w *ResponseWriter) Reset() {
+	w.Status = 0
+	w.Size = 0
+	for k := range w.header {
+		delete(w.header, k)
+	}
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
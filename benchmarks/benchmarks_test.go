@@ -0,0 +1,215 @@
+package benchmarks_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/benchmarks"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type widget struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Price float64  `json:"price"`
+}
+
+var widgets = func() []widget {
+	ws := make([]widget, 50)
+	for i := range ws {
+		ws[i] = widget{ID: i, Name: fmt.Sprintf("widget %d", i), Tags: []string{"a", "b"}, Price: 9.99}
+	}
+	return ws
+}()
+
+func newApp() *buffalo.App {
+	return buffalo.New(buffalo.Options{Env: "test"})
+}
+
+func ok(c buffalo.Context) error {
+	c.Response().WriteHeader(200)
+	return nil
+}
+
+// appWithRoutes returns an App with n resource style routes, plus "/".
+func appWithRoutes(n int) *buffalo.App {
+	a := newApp()
+	a.GET("/", ok)
+	for i := 0; i < n; i++ {
+		a.GET(fmt.Sprintf("/r%d", i), ok)
+		a.GET(fmt.Sprintf("/r%d/{id}", i), ok)
+	}
+	return a
+}
+
+func Benchmark_Baseline_NetHTTP(b *testing.B) {
+	m := http.NewServeMux()
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	})
+	benchmarks.Request(b, m, "GET", "/", nil)
+}
+
+func Benchmark_Baseline_Mux(b *testing.B) {
+	m := mux.NewRouter()
+	m.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = mux.Vars(r)["id"]
+		w.WriteHeader(200)
+	})
+	benchmarks.Request(b, m, "GET", "/users/42", nil)
+}
+
+func Benchmark_Baseline_JSON(b *testing.B) {
+	m := http.NewServeMux()
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(widgets)
+	})
+	benchmarks.Request(b, m, "GET", "/", nil)
+}
+
+func Benchmark_Routing_Static(b *testing.B) {
+	benchmarks.Request(b, appWithRoutes(0), "GET", "/", nil)
+}
+
+func Benchmark_Routing_Param(b *testing.B) {
+	a := newApp()
+	a.GET("/users/{id}", func(c buffalo.Context) error {
+		_ = c.Param("id")
+		return ok(c)
+	})
+	benchmarks.Request(b, a, "GET", "/users/42", nil)
+}
+
+func Benchmark_Routing_Routes(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		a := appWithRoutes(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			benchmarks.Request(b, a, "GET", fmt.Sprintf("/r%d/42", n-1), nil)
+		})
+	}
+}
+
+// resourceTable returns the paths of n resources, with the routes
+// Resource would add for them, under "/api/v1".
+func resourceTable(n int) [][2]string {
+	routes := [][2]string{}
+	for i := 0; i < n; i++ {
+		p := fmt.Sprintf("/api/v1/widgets%d", i)
+		routes = append(routes,
+			[2]string{"GET", p},
+			[2]string{"GET", p + "/new"},
+			[2]string{"GET", p + "/{widget_id}"},
+			[2]string{"GET", p + "/{widget_id}/edit"},
+			[2]string{"POST", p},
+			[2]string{"PUT", p + "/{widget_id}"},
+			[2]string{"DELETE", p + "/{widget_id}"},
+		)
+	}
+	return routes
+}
+
+// Benchmark_Routing_Table compares Buffalo's routing with mux's, on
+// tables of resource routes, for a route near the end of the table.
+func Benchmark_Routing_Table(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		routes := resourceTable(n)
+		path := fmt.Sprintf("/api/v1/widgets%d/42/edit", n-1)
+
+		a := newApp()
+		m := mux.NewRouter()
+		for _, rt := range routes {
+			switch rt[0] {
+			case "GET":
+				a.GET(rt[1], ok)
+			case "POST":
+				a.POST(rt[1], ok)
+			case "PUT":
+				a.PUT(rt[1], ok)
+			case "DELETE":
+				a.DELETE(rt[1], ok)
+			}
+			m.HandleFunc(rt[1], func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+			}).Methods(rt[0])
+		}
+		b.Run(fmt.Sprintf("buffalo/%d", len(routes)), func(b *testing.B) {
+			benchmarks.Request(b, a, "GET", path, nil)
+		})
+		b.Run(fmt.Sprintf("mux/%d", len(routes)), func(b *testing.B) {
+			benchmarks.Request(b, m, "GET", path, nil)
+		})
+	}
+}
+
+func Benchmark_Routing_NotFound(b *testing.B) {
+	benchmarks.Request(b, appWithRoutes(10), "GET", "/nope", nil)
+}
+
+func Benchmark_Context(b *testing.B) {
+	a := newApp()
+	a.GET("/", func(c buffalo.Context) error {
+		c.Set("name", "buffalo")
+		_ = c.Get("name")
+		_ = c.Param("q")
+		return ok(c)
+	})
+	benchmarks.Request(b, a, "GET", "/?q=search", nil)
+}
+
+func Benchmark_Middleware(b *testing.B) {
+	mw := func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			return next(c)
+		}
+	}
+	for _, n := range []int{0, 5, 10, 25} {
+		a := newApp()
+		for i := 0; i < n; i++ {
+			a.Use(mw)
+		}
+		a.GET("/", ok)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			benchmarks.Request(b, a, "GET", "/", nil)
+		})
+	}
+}
+
+func Benchmark_Error(b *testing.B) {
+	a := newApp()
+	a.ErrorHandlers[422] = func(status int, err error, c buffalo.Context) error {
+		c.Response().WriteHeader(status)
+		return nil
+	}
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Error(422, errors.New("boom"))
+	})
+	benchmarks.Request(b, a, "GET", "/", nil)
+}
+
+func Benchmark_Render_JSON(b *testing.B) {
+	a := newApp()
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.JSON(widgets))
+	})
+	benchmarks.Request(b, a, "GET", "/", nil)
+}
+
+func Benchmark_Bind_JSON(b *testing.B) {
+	body, _ := json.Marshal(widgets[0])
+	a := newApp()
+	a.POST("/", func(c buffalo.Context) error {
+		w := &widget{}
+		if err := c.Bind(w); err != nil {
+			return err
+		}
+		return ok(c)
+	})
+	benchmarks.Request(b, a, "POST", "/", body)
+}
@@ -0,0 +1,123 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+)
+
+// Binder decodes a request into a value, see RegisterBinder.
+type Binder func(req *http.Request, value interface{}) error
+
+var binders = map[string]Binder{
+	"application/json":                  jsonBinder,
+	"text/json":                         jsonBinder,
+	"json":                              jsonBinder,
+	"application/xml":                   xmlBinder,
+	"text/xml":                          xmlBinder,
+	"xml":                               xmlBinder,
+	"application/x-www-form-urlencoded": formBinder,
+	"multipart/form-data":               multipartBinder,
+}
+
+var bindersMoot = &sync.RWMutex{}
+
+// RegisterBinder sets the Binder that Bind uses for requests with the
+// content types, replacing the one already there.
+/*
+	buffalo.RegisterBinder(func(req *http.Request, v interface{}) error {
+		return msgpack.NewDecoder(req.Body).Decode(v)
+	}, "application/msgpack", "application/x-msgpack")
+*/
+func RegisterBinder(b Binder, contentTypes ...string) {
+	bindersMoot.Lock()
+	defer bindersMoot.Unlock()
+	for _, ct := range contentTypes {
+		binders[strings.ToLower(ct)] = b
+	}
+}
+
+// TimeFormats are the layouts that times in forms and query params are
+// parsed with, in order. They include the formats of HTML's "date" and
+// "datetime-local" inputs.
+var TimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"01/02/2006",
+}
+
+// FormDecoder decodes forms and query params into structs, using their
+// "schema" tags. Fields of nested structs are named like "Address.City",
+// and slices like "Items.0.Name". More converters can be registered on
+// it, for custom types.
+var FormDecoder = newFormDecoder()
+
+func newFormDecoder() *schema.Decoder {
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	dec.ZeroEmpty(true)
+	dec.RegisterConverter(time.Time{}, func(s string) reflect.Value {
+		for _, layout := range TimeFormats {
+			if t, err := time.Parse(layout, s); err == nil {
+				return reflect.ValueOf(t)
+			}
+		}
+		return reflect.Value{}
+	})
+	return dec
+}
+
+// bind decodes the request into value with the Binder for its
+// "Content-Type". Requests without a body have their query params
+// decoded, and anything else is decoded as a form.
+func bind(req *http.Request, value interface{}) error {
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	ct = strings.ToLower(ct)
+	if ct == "" && (req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0) {
+		return errors.WithStack(FormDecoder.Decode(value, req.URL.Query()))
+	}
+	bindersMoot.RLock()
+	b, ok := binders[ct]
+	bindersMoot.RUnlock()
+	if !ok {
+		b = formBinder
+	}
+	return b(req, value)
+}
+
+func jsonBinder(req *http.Request, value interface{}) error {
+	return errors.WithStack(json.NewDecoder(req.Body).Decode(value))
+}
+
+func xmlBinder(req *http.Request, value interface{}) error {
+	return errors.WithStack(xml.NewDecoder(req.Body).Decode(value))
+}
+
+func formBinder(req *http.Request, value interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(FormDecoder.Decode(value, req.PostForm))
+}
+
+// multipartMemory is how much of a multipart form is kept in memory,
+// the rest of its files are written to temporary files.
+const multipartMemory = 32 << 20
+
+func multipartBinder(req *http.Request, value interface{}) error {
+	if err := req.ParseMultipartForm(multipartMemory); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(FormDecoder.Decode(value, req.MultipartForm.Value))
+}
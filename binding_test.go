@@ -0,0 +1,131 @@
+package buffalo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type bindAddress struct {
+	City string `schema:"city" xml:"city"`
+}
+
+type bindUser struct {
+	Name      string        `schema:"name" json:"name" xml:"name"`
+	Age       int           `schema:"age" json:"age" xml:"age"`
+	Born      time.Time     `schema:"born"`
+	Tags      []string      `schema:"tags"`
+	Address   bindAddress   `schema:"address" xml:"address"`
+	Addresses []bindAddress `schema:"addresses"`
+}
+
+func Test_Bind_JSON_Charset(t *testing.T) {
+	r := require.New(t)
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"Mark","age":40}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("Mark", u.Name)
+	r.Equal(40, u.Age)
+}
+
+func Test_Bind_XML(t *testing.T) {
+	r := require.New(t)
+	body := `<user><name>Mark</name><age>40</age><address><city>Boston</city></address></user>`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/xml")
+
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("Mark", u.Name)
+	r.Equal(40, u.Age)
+	r.Equal("Boston", u.Address.City)
+}
+
+func Test_Bind_Form(t *testing.T) {
+	r := require.New(t)
+	form := url.Values{
+		"name":              {"Mark"},
+		"age":               {"40"},
+		"born":              {"1976-04-01"},
+		"tags":              {"a", "b"},
+		"address.city":      {"Boston"},
+		"addresses.0.city":  {"Paris"},
+		"addresses.1.city":  {"Rome"},
+		"something.unknown": {"x"},
+	}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("Mark", u.Name)
+	r.Equal(40, u.Age)
+	r.Equal(time.Date(1976, 4, 1, 0, 0, 0, 0, time.UTC), u.Born)
+	r.Equal([]string{"a", "b"}, u.Tags)
+	r.Equal("Boston", u.Address.City)
+	r.Equal([]bindAddress{{City: "Paris"}, {City: "Rome"}}, u.Addresses)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("born=yesterday"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Error(bind(req, &u))
+}
+
+func Test_Bind_Multipart(t *testing.T) {
+	r := require.New(t)
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "Mark")
+	mw.WriteField("born", "1976-04-01T10:30")
+	mw.Close()
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("Mark", u.Name)
+	r.Equal(time.Date(1976, 4, 1, 10, 30, 0, 0, time.UTC), u.Born)
+}
+
+func Test_Bind_Query(t *testing.T) {
+	r := require.New(t)
+	req := httptest.NewRequest("GET", "/?name=Mark&tags=a&tags=b&address.city=Boston", nil)
+
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("Mark", u.Name)
+	r.Equal([]string{"a", "b"}, u.Tags)
+	r.Equal("Boston", u.Address.City)
+}
+
+func Test_RegisterBinder(t *testing.T) {
+	r := require.New(t)
+	RegisterBinder(func(req *http.Request, v interface{}) error {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		v.(*bindUser).Name = strings.ToUpper(string(b))
+		return nil
+	}, "Text/Plain")
+	defer func() {
+		bindersMoot.Lock()
+		delete(binders, "text/plain")
+		bindersMoot.Unlock()
+	}()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("mark"))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	u := bindUser{}
+	r.NoError(bind(req, &u))
+	r.Equal("MARK", u.Name)
+}
@@ -161,6 +161,7 @@ func App() *buffalo.App {
 const nRender = `package actions
 
 import (
+	"log"
 	"net/http"
 
 	rice "github.com/GeertJohan/go.rice"
@@ -180,6 +181,13 @@ func init() {
 			}
 		},
 	})
+	if ENV == "production" {
+		// fail at boot, instead of on the first render, if any
+		// of the templates are broken.
+		if err := r.Precompile(); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
 func assetsPath() http.FileSystem {
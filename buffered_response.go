@@ -0,0 +1,175 @@
+package buffalo
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// BufferedResponse is the http.ResponseWriter handlers write to when
+// Options.ResponseBufferSize is set. The status, headers, and body are
+// held in memory until the handler, its middleware, and any ErrorHandler
+// have finished, so middleware can still change them after calling the
+// next Handler. This is what middleware that hashes (ETag), rewrites
+// (minification), or replaces (error pages) responses needs.
+//
+// Once the body grows past the buffer size, or the handler calls Flush,
+// the response switches to streaming: everything buffered so far is sent
+// and later writes go straight to the client.
+/*
+	func Minify(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			err := next(c)
+			if br, ok := buffalo.Buffered(c); ok && br.Buffering() {
+				br.SetBody(minify(br.Body()))
+			}
+			return err
+		}
+	}
+*/
+type BufferedResponse struct {
+	http.ResponseWriter
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	max       int
+	streaming bool
+}
+
+func newBufferedResponse(w http.ResponseWriter, max int) *BufferedResponse {
+	return &BufferedResponse{
+		ResponseWriter: w,
+		header:         http.Header{},
+		max:            max,
+	}
+}
+
+// Buffered returns the BufferedResponse behind the Context's response,
+// if Options.ResponseBufferSize is turned on.
+func Buffered(c Context) (*BufferedResponse, bool) {
+	var w http.ResponseWriter = c.Response()
+	for w != nil {
+		if br, ok := w.(*BufferedResponse); ok {
+			return br, true
+		}
+		u, ok := w.(interface {
+			Unwrap() http.ResponseWriter
+		})
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return nil, false
+}
+
+// Header returns the response headers. While buffering they can be
+// changed at any time.
+func (b *BufferedResponse) Header() http.Header {
+	if b.streaming {
+		return b.ResponseWriter.Header()
+	}
+	return b.header
+}
+
+// WriteHeader sets the status. While buffering it can be called again
+// to replace it.
+func (b *BufferedResponse) WriteHeader(status int) {
+	if b.streaming {
+		b.ResponseWriter.WriteHeader(status)
+		return
+	}
+	b.status = status
+}
+
+// Write appends p to the body, switching to streaming if the body
+// gets too large.
+func (b *BufferedResponse) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.ResponseWriter.Write(p)
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	if b.body.Len()+len(p) > b.max {
+		if err := b.stream(); err != nil {
+			return 0, err
+		}
+		return b.ResponseWriter.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+// Flush switches the response to streaming, and flushes it.
+func (b *BufferedResponse) Flush() {
+	b.stream()
+	if f, ok := b.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack switches the response to streaming, and hands over the
+// connection, for websockets and the like.
+func (b *BufferedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	b.streaming = true
+	if hj, ok := b.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.WithStack(errors.New("does not implement http.Hijack"))
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (b *BufferedResponse) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+// Buffering reports whether the response is still being held in memory.
+// Once it returns false the status, headers, and body have been sent.
+func (b *BufferedResponse) Buffering() bool {
+	return !b.streaming
+}
+
+// Status returns the status that will be sent, 0 if none has been set.
+func (b *BufferedResponse) Status() int {
+	return b.status
+}
+
+// Body returns the body buffered so far.
+func (b *BufferedResponse) Body() []byte {
+	return b.body.Bytes()
+}
+
+// SetBody replaces the buffered body.
+func (b *BufferedResponse) SetBody(p []byte) {
+	b.body.Reset()
+	b.body.Write(p)
+}
+
+// Reset throws away the buffered status and body, so a different
+// response can be written in their place. Headers are kept.
+func (b *BufferedResponse) Reset() {
+	b.status = 0
+	b.body.Reset()
+}
+
+// stream sends everything buffered so far and switches to streaming.
+func (b *BufferedResponse) stream() error {
+	if b.streaming {
+		return nil
+	}
+	b.streaming = true
+	h := b.ResponseWriter.Header()
+	for k, v := range b.header {
+		h[k] = v
+	}
+	if b.status == 0 {
+		return nil
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	_, err := b.ResponseWriter.Write(b.body.Bytes())
+	b.body.Reset()
+	return errors.WithStack(err)
+}
@@ -0,0 +1,91 @@
+package buffalo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BufferedResponse(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{ResponseBufferSize: 10})
+	a.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			err := next(c)
+			br, ok := Buffered(c)
+			r.True(ok)
+			if br.Buffering() {
+				br.Header().Set("X-Late", "yes")
+				br.WriteHeader(202)
+				br.SetBody([]byte(strings.ToUpper(string(br.Body()))))
+			}
+			return err
+		}
+	})
+	a.GET("/small", func(c Context) error {
+		return c.Render(200, render.String("hello"))
+	})
+	a.GET("/large", func(c Context) error {
+		return c.Render(200, render.String("hello there, world"))
+	})
+	a.GET("/flushed", func(c Context) error {
+		c.Response().Write([]byte("hi"))
+		c.Response().(http.Flusher).Flush()
+		return nil
+	})
+
+	w := willie.New(a)
+	res := w.Request("/small").Get()
+	r.Equal(202, res.Code)
+	r.Equal("yes", res.Header().Get("X-Late"))
+	r.Equal("HELLO", res.Body.String())
+
+	res = w.Request("/large").Get()
+	r.Equal(200, res.Code)
+	r.Empty(res.Header().Get("X-Late"))
+	r.Equal("hello there, world", res.Body.String())
+
+	res = w.Request("/flushed").Get()
+	r.Equal(200, res.Code)
+	r.Equal("hi", res.Body.String())
+}
+
+func Test_BufferedResponse_ErrorOverride(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{ResponseBufferSize: 1024})
+	a.ErrorHandlers[418] = func(status int, err error, c Context) error {
+		br, ok := Buffered(c)
+		r.True(ok)
+		br.Reset()
+		br.WriteHeader(status)
+		br.Write([]byte("teapot"))
+		return nil
+	}
+	a.GET("/", func(c Context) error {
+		c.Response().Write([]byte("partial"))
+		return c.Error(418, errors.New("short and stout"))
+	})
+
+	res := willie.New(a).Request("/").Get()
+	r.Equal(418, res.Code)
+	r.Equal("teapot", res.Body.String())
+}
+
+func Test_BufferedResponse_Off(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		_, ok := Buffered(c)
+		r.False(ok)
+		return nil
+	})
+	willie.New(a).Request("/").Get()
+}
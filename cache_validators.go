@@ -0,0 +1,153 @@
+package buffalo
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ETagFor derives a weak ETag from one or more models, or slices of
+// models. It is built from each model's type and its "ID", "Version" (or
+// "LockVersion"), and "UpdatedAt" fields, whichever are present, so it
+// changes whenever one of the models is saved.
+/*
+	func (v UsersResource) Show(c buffalo.Context) error {
+		u := &models.User{}
+		// ...
+		if c.FreshWhen(buffalo.ETagFor(u), buffalo.LastModifiedFor(u)) {
+			return nil
+		}
+		return c.Render(200, r.JSON(u))
+	}
+*/
+func ETagFor(models ...interface{}) string {
+	h := sha1.New()
+	for _, m := range models {
+		eachModel(reflect.ValueOf(m), func(v reflect.Value) {
+			fmt.Fprintf(h, "%s|", v.Type())
+			for _, name := range []string{"ID", "Version", "LockVersion"} {
+				// fields promoted from an unexported embedded struct
+				// can't be read
+				if f := v.FieldByName(name); f.IsValid() && f.CanInterface() {
+					fmt.Fprintf(h, "%s=%v|", name, f.Interface())
+				}
+			}
+			if t, ok := updatedAt(v); ok {
+				fmt.Fprintf(h, "UpdatedAt=%d|", t.UnixNano())
+			}
+		})
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// LastModifiedFor returns the latest "UpdatedAt" field of one or more
+// models, or slices of models. It returns the zero time if none of them
+// have one.
+func LastModifiedFor(models ...interface{}) time.Time {
+	lm := time.Time{}
+	for _, m := range models {
+		eachModel(reflect.ValueOf(m), func(v reflect.Value) {
+			if t, ok := updatedAt(v); ok && t.After(lm) {
+				lm = t
+			}
+		})
+	}
+	return lm
+}
+
+// eachModel calls fn for every struct in v, following pointers and
+// interfaces and ranging over slices and arrays.
+func eachModel(v reflect.Value, fn func(reflect.Value)) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fn(v)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			eachModel(v.Index(i), fn)
+		}
+	}
+}
+
+func updatedAt(v reflect.Value) (time.Time, bool) {
+	f := v.FieldByName("UpdatedAt")
+	if !f.IsValid() || !f.CanInterface() {
+		return time.Time{}, false
+	}
+	t, ok := f.Interface().(time.Time)
+	return t, ok && !t.IsZero()
+}
+
+// FreshWhen sets the "ETag" and "Last-Modified" headers, skipping either
+// if it is empty, and checks them against the request's "If-None-Match"
+// and "If-Modified-Since" headers. If the client's copy is still fresh a
+// 304 is written, and true returned, so the handler can return straight
+// away without rendering.
+func (d *DefaultContext) FreshWhen(etag string, lastModified time.Time) bool {
+	res := d.Response()
+	h := res.Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if !requestFresh(d.Request(), etag, lastModified) {
+		return false
+	}
+	res.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// Stale is the opposite of FreshWhen, for handlers that would rather
+// branch on the response needing to be rendered. It sets the same
+// headers, and writes the 304 when the client's copy is fresh.
+/*
+	if c.Stale(buffalo.ETagFor(u), u.UpdatedAt) {
+		return c.Render(200, r.JSON(u))
+	}
+	return nil
+*/
+func (d *DefaultContext) Stale(etag string, lastModified time.Time) bool {
+	return !d.FreshWhen(etag, lastModified)
+}
+
+// requestFresh reports whether the conditional headers of a GET or HEAD
+// request match the current validators. If-None-Match takes precedence
+// over If-Modified-Since, as per RFC 7232.
+func requestFresh(req *http.Request, etag string, lastModified time.Time) bool {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		etag = strings.TrimPrefix(etag, "W/")
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimSpace(t)
+			if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	ims := req.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,127 @@
+package buffalo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheWidget struct {
+	ID        int
+	Name      string
+	UpdatedAt time.Time
+}
+
+func Test_ETagFor(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Now()
+	w := cacheWidget{ID: 1, Name: "a", UpdatedAt: now}
+	tag := ETagFor(w)
+	r.Contains(tag, `W/"`)
+	r.Equal(tag, ETagFor(&w))
+
+	// only the metadata counts
+	w.Name = "b"
+	r.Equal(tag, ETagFor(w))
+
+	w.UpdatedAt = now.Add(time.Second)
+	r.NotEqual(tag, ETagFor(w))
+
+	list := []cacheWidget{{ID: 1, UpdatedAt: now}, {ID: 2, UpdatedAt: now}}
+	r.NotEqual(ETagFor(list), ETagFor(list[:1]))
+}
+
+func Test_LastModifiedFor(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Now()
+	list := []*cacheWidget{{ID: 1, UpdatedAt: now}, {ID: 2, UpdatedAt: now.Add(time.Hour)}, nil}
+	r.Equal(now.Add(time.Hour), LastModifiedFor(list))
+	r.True(LastModifiedFor(struct{ ID int }{1}).IsZero())
+}
+
+type cacheMeta struct {
+	ID        int
+	UpdatedAt time.Time
+}
+
+// Test_ETagFor_Embedded tests the fields of an unexported embedded
+// struct are used without panicking
+func Test_ETagFor_Embedded(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Now()
+	w := struct {
+		*cacheMeta
+		Name string
+	}{cacheMeta: &cacheMeta{ID: 1, UpdatedAt: now}}
+	r.NotPanics(func() {
+		r.Contains(ETagFor(w), `W/"`)
+		r.Equal(now, LastModifiedFor(w))
+	})
+}
+
+func Test_DefaultContext_FreshWhen(t *testing.T) {
+	r := require.New(t)
+
+	w := cacheWidget{ID: 1, UpdatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)}
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		if c.FreshWhen(ETagFor(w), LastModifiedFor(w)) {
+			return nil
+		}
+		return c.Render(200, render.String("widget"))
+	})
+
+	wl := willie.New(a)
+	res := wl.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal("widget", res.Body.String())
+	tag := res.Header().Get("ETag")
+	r.Equal(ETagFor(w), tag)
+	r.Equal("Sun, 01 Jan 2017 00:00:00 GMT", res.Header().Get("Last-Modified"))
+
+	req := wl.Request("/")
+	req.Headers["If-None-Match"] = tag
+	res = req.Get()
+	r.Equal(304, res.Code)
+	r.Empty(res.Body.String())
+
+	req = wl.Request("/")
+	req.Headers["If-Modified-Since"] = time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	r.Equal(304, req.Get().Code)
+
+	req = wl.Request("/")
+	req.Headers["If-None-Match"] = `"stale"`
+	req.Headers["If-Modified-Since"] = time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	r.Equal(200, req.Get().Code)
+}
+
+func Test_DefaultContext_Stale(t *testing.T) {
+	r := require.New(t)
+
+	lm := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		if c.Stale(`"v1"`, lm) {
+			return c.Render(200, render.String("widget"))
+		}
+		return nil
+	})
+
+	wl := willie.New(a)
+	res := wl.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal(`"v1"`, res.Header().Get("ETag"))
+
+	req := wl.Request("/")
+	req.Headers["If-None-Match"] = `W/"v1"`
+	res = req.Get()
+	r.Equal(304, res.Code)
+	r.Empty(res.Body.String())
+}
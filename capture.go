@@ -0,0 +1,150 @@
+package buffalo
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// CaptureResponse runs next with its response held in a BufferedResponse
+// of up to max bytes, for middleware that rewrites the response, such as
+// HTML post-processing. The middleware can change the status, headers,
+// and body, and must then call Send. Responses that grow past max, or
+// are flushed, are streamed as they are written, and Buffering reports
+// false. If the Context can't be forked next is run as it is, and the
+// BufferedResponse is nil.
+/*
+	func Minify(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			br, err := buffalo.CaptureResponse(c, 1<<20, next)
+			if br == nil || err != nil {
+				return err
+			}
+			if br.Buffering() && strings.HasPrefix(br.Header().Get("Content-Type"), "text/html") {
+				br.SetBody(minify(br.Body()))
+			}
+			return br.Send()
+		}
+	}
+*/
+func CaptureResponse(c Context, max int, next Handler) (*BufferedResponse, error) {
+	dc, ok := c.(*DefaultContext)
+	if !ok {
+		return nil, next(c)
+	}
+	br := newBufferedResponse(c.Response(), max)
+	fc := dc.Fork(c.Request(), br)
+	err := next(fc)
+	dc.Merge(fc)
+	return br, err
+}
+
+// Send the buffered status, headers, and body, and switch the response
+// to streaming. It does nothing if the response is already streaming.
+func (b *BufferedResponse) Send() error {
+	return b.stream()
+}
+
+// ResponseTee is a copy of the body of a response, see TeeResponse.
+type ResponseTee struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	max       int
+	status    int
+	truncated bool
+}
+
+// TeeResponse runs next, sending its response to the client as usual,
+// while keeping a copy of up to max bytes of the body, for middleware
+// that only needs to look at the response, such as audit logging.
+// Streaming responses pass straight through, and the copy stops at max,
+// see Truncated. If the Context can't be forked next is run as it is,
+// and the ResponseTee is nil.
+/*
+	func Audit(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			tee, err := buffalo.TeeResponse(c, 64<<10, next)
+			if tee != nil {
+				audit.Record(c.Request(), tee.Status(), tee.Body())
+			}
+			return err
+		}
+	}
+*/
+func TeeResponse(c Context, max int, next Handler) (*ResponseTee, error) {
+	dc, ok := c.(*DefaultContext)
+	if !ok {
+		return nil, next(c)
+	}
+	t := &ResponseTee{ResponseWriter: c.Response(), max: max}
+	fc := dc.Fork(c.Request(), t)
+	err := next(fc)
+	dc.Merge(fc)
+	return t, err
+}
+
+// WriteHeader records, and writes, the status.
+func (t *ResponseTee) WriteHeader(status int) {
+	if t.status == 0 && status >= 200 {
+		t.status = status
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes p to the response, and copies what fits into the tee.
+func (t *ResponseTee) Write(p []byte) (int, error) {
+	if t.status == 0 {
+		t.status = http.StatusOK
+	}
+	n, err := t.ResponseWriter.Write(p)
+	room := t.max - t.body.Len()
+	switch {
+	case room >= n:
+		t.body.Write(p[:n])
+	case room > 0:
+		t.body.Write(p[:room])
+		t.truncated = true
+	default:
+		t.truncated = n > 0 || t.truncated
+	}
+	return n, err
+}
+
+// Body returns the copy of the body.
+func (t *ResponseTee) Body() []byte {
+	return t.body.Bytes()
+}
+
+// Truncated reports whether the body was bigger than the copy kept of
+// it.
+func (t *ResponseTee) Truncated() bool {
+	return t.truncated
+}
+
+// Status returns the status of the response, 0 if none was written.
+func (t *ResponseTee) Status() int {
+	return t.status
+}
+
+// Flush the response.
+func (t *ResponseTee) Flush() {
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands over the connection, for websockets and the like.
+func (t *ResponseTee) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := t.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.WithStack(errors.New("does not implement http.Hijack"))
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (t *ResponseTee) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
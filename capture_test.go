@@ -0,0 +1,92 @@
+package buffalo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CaptureResponse(t *testing.T) {
+	r := require.New(t)
+
+	var buffering bool
+	a := New(Options{})
+	a.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			br, err := CaptureResponse(c, 16, next)
+			if br == nil || err != nil {
+				return err
+			}
+			buffering = br.Buffering()
+			if buffering {
+				br.Header().Set("X-Length", "upper")
+				br.SetBody(bytes.ToUpper(br.Body()))
+			}
+			return br.Send()
+		}
+	})
+	a.GET("/small", func(c Context) error {
+		return c.String(201, "hello")
+	})
+	a.GET("/big", func(c Context) error {
+		return c.String(200, strings.Repeat("x", 32))
+	})
+	a.GET("/stream", func(c Context) error {
+		c.Response().Write([]byte("a"))
+		c.Response().(http.Flusher).Flush()
+		c.Response().Write([]byte("b"))
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/small", nil))
+	r.True(buffering)
+	r.Equal(201, res.Code)
+	r.Equal("HELLO", res.Body.String())
+	r.Equal("upper", res.Header().Get("X-Length"))
+
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/big", nil))
+	r.False(buffering)
+	r.Equal(strings.Repeat("x", 32), res.Body.String())
+
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/stream", nil))
+	r.False(buffering)
+	r.Equal("ab", res.Body.String())
+	r.True(res.Flushed)
+}
+
+func Test_TeeResponse(t *testing.T) {
+	r := require.New(t)
+
+	var tee *ResponseTee
+	a := New(Options{})
+	a.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			var err error
+			tee, err = TeeResponse(c, 8, next)
+			return err
+		}
+	})
+	a.GET("/", func(c Context) error {
+		return c.String(202, c.Param("body"))
+	})
+
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/?body=hello", nil))
+	r.Equal("hello", res.Body.String())
+	r.Equal(202, tee.Status())
+	r.Equal("hello", string(tee.Body()))
+	r.False(tee.Truncated())
+
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/?body=hello+world", nil))
+	r.Equal("hello world", res.Body.String())
+	r.Equal("hello wo", string(tee.Body()))
+	r.True(tee.Truncated())
+}
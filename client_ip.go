@@ -0,0 +1,167 @@
+package buffalo
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// IPList is a list of IP addresses and CIDR ranges. It is safe to Set a
+// new list while requests are being checked against it, so lists can be
+// reloaded without restarting the App.
+type IPList struct {
+	nets []*net.IPNet
+	moot *sync.RWMutex
+}
+
+// NewIPList returns an IPList of the given addresses and CIDR ranges,
+// such as "10.0.0.0/8" or "2001:db8::1".
+func NewIPList(entries ...string) (*IPList, error) {
+	l := &IPList{moot: &sync.RWMutex{}}
+	return l, l.Set(entries...)
+}
+
+// Set replaces the contents of the list. If any entry is invalid the
+// list is left as it was.
+func (l *IPList) Set(entries ...string) error {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return errors.Errorf("invalid IP address %q", e)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		nets = append(nets, n)
+	}
+	l.moot.Lock()
+	defer l.moot.Unlock()
+	l.nets = nets
+	return nil
+}
+
+// Contains reports whether ip is in the list.
+func (l *IPList) Contains(ip net.IP) bool {
+	if l == nil || ip == nil {
+		return false
+	}
+	l.moot.RLock()
+	defer l.moot.RUnlock()
+	for _, n := range l.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of entries in the list.
+func (l *IPList) Len() int {
+	l.moot.RLock()
+	defer l.moot.RUnlock()
+	return len(l.nets)
+}
+
+// ClientIP returns the address of the client that made the request.
+// If the request came through one of the trusted proxies, the
+// "Forwarded", "X-Forwarded-For", or "X-Real-IP" headers are walked from
+// the nearest hop back, skipping over trusted proxies, to find the first
+// address that isn't one. Addresses a client may have put in those
+// headers itself are never trusted.
+func ClientIP(req *http.Request, trusted *IPList) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if !trusted.Contains(ip) {
+		return ip
+	}
+	hops := forwardedFor(req)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !trusted.Contains(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// forwardedFor returns the hops listed in the "Forwarded" header, or if
+// there isn't one, the "X-Forwarded-For", or "X-Real-IP", header,
+// nearest hop last.
+func forwardedFor(req *http.Request) []string {
+	hops := []string{}
+	if fwd := req.Header["Forwarded"]; len(fwd) > 0 {
+		for _, elem := range strings.Split(strings.Join(fwd, ","), ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				pair = strings.TrimSpace(pair)
+				if len(pair) < 4 || !strings.EqualFold(pair[:4], "for=") {
+					continue
+				}
+				hops = append(hops, forwardedHost(strings.Trim(pair[4:], `"`)))
+			}
+		}
+		return hops
+	}
+	for _, h := range req.Header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(h, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if len(hops) == 0 {
+		if h := strings.TrimSpace(req.Header.Get("X-Real-IP")); h != "" {
+			hops = append(hops, h)
+		}
+	}
+	return hops
+}
+
+// ClientIP returns the address of the client, see ClientIP and the
+// TrustedProxies option. It is the empty string if the address can't
+// be parsed.
+func (d *DefaultContext) ClientIP() string {
+	var trusted *IPList
+	if d.app != nil {
+		trusted = d.app.TrustedProxies
+	}
+	if ip := ClientIP(d.Request(), trusted); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// forwardedHost strips the port, and IPv6 brackets, from a "for=" value.
+func forwardedHost(s string) string {
+	if strings.HasPrefix(s, "[") {
+		if i := strings.Index(s, "]"); i > 0 {
+			return s[1:i]
+		}
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return host
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package buffalo
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DefaultContext_ClientIP(t *testing.T) {
+	r := require.New(t)
+	proxies, err := NewIPList("10.0.0.0/8")
+	r.NoError(err)
+
+	a := New(Options{TrustedProxies: proxies})
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String(c.ClientIP()))
+	})
+
+	table := []struct {
+		remote  string
+		headers map[string]string
+		ip      string
+	}{
+		{"203.0.113.9:1234", nil, "203.0.113.9"},
+		{"203.0.113.9:1234", map[string]string{"X-Real-IP": "1.2.3.4"}, "203.0.113.9"},
+		{"10.0.0.1:1234", map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7, 10.0.0.2", "X-Real-IP": "1.2.3.4"}, "198.51.100.7"},
+		{"[2001:db8::1]:1234", nil, "2001:db8::1"},
+		{"bad", nil, ""},
+	}
+	for _, tt := range table {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		r.Equal(tt.ip, res.Body.String())
+	}
+}
@@ -1,7 +1,10 @@
 package buffalo
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 
@@ -11,25 +14,74 @@ import (
 // Context holds on to information as you
 // pass it down through middleware, Handlers,
 // templates, etc... It strives to make your
-// life a happier one.
+// life a happier one. It is also a context.Context,
+// of the request, to hand to database calls and such.
 type Context interface {
+	context.Context
 	Response() http.ResponseWriter
 	Request() *http.Request
 	Session() *Session
+	Cookies() *Cookies
 	Params() ParamValues
 	Param(string) string
 	ParamInt(string) (int, error)
+	ParamIntOr(string, int) int
+	ParamInt64(string) (int64, error)
+	ParamInt64Or(string, int64) int64
+	ParamBool(string) (bool, error)
+	ParamBoolOr(string, bool) bool
+	ParamUUID(string) (string, error)
+	ParamUUIDOr(string, string) string
+	ParamTime(key string, layout string) (time.Time, error)
+	ParamTimeOr(key string, layout string, def time.Time) time.Time
 	Set(string, interface{})
 	Get(string) interface{}
 	LogField(string, interface{})
 	LogFields(map[string]interface{})
 	Logger() Logger
 	Bind(interface{}) error
+	File(name string) (File, error)
+	StreamFile(name string, dst func(File) (io.Writer, error)) (File, error)
 	Render(int, render.Renderer) error
 	Error(int, error) error
 	Websocket() (*websocket.Conn, error)
 	Redirect(int, string, ...interface{}) error
 	Data() map[string]interface{}
+	Env() string
+	ClientIP() string
+	FreshWhen(etag string, lastModified time.Time) bool
+	Stale(etag string, lastModified time.Time) bool
+	T(key string, args ...interface{}) string
+	LongPoll(topic string, timeout time.Duration) error
+	Respond(status int, v interface{}) error
+	RespondTo(handlers map[string]Handler) error
+	Accepts(offers ...string) string
+	AcceptsEncoding(offers ...string) string
+	AcceptsLanguage(offers ...string) string
+	OK(v interface{}) error
+	Created(v interface{}, location string) error
+	NoContent() error
+	JSON(status int, v interface{}) error
+	XML(status int, v interface{}) error
+	String(status int, format string, args ...interface{}) error
+	Stream(status int, contentType string, r io.Reader) error
+	SendFile(path string) error
+	Attachment(r io.Reader, filename string) error
+	URLFor(name string, params ...interface{}) (string, error)
+	AbsoluteURLFor(name string, params ...interface{}) (string, error)
+	Push(target string, opts *http.PushOptions) error
+	EarlyHints(links ...string) error
+	EventStream() (*EventStream, error)
+	AfterResponse(fn func())
+	Cleanup(fn func() error)
+	OnClose(c io.Closer)
+}
+
+// Translator translates keys into the language of the request. It
+// is what Context.T uses, and is set in the Context as "translator"
+// by i18n middleware, such as github.com/gobuffalo/buffalo/middleware/i18n.
+type Translator interface {
+	Translate(c Context, key string, args ...interface{}) string
 }
 
 // ParamValues will most commonly be url.Values,
@@ -0,0 +1,268 @@
+package buffalo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCookie is returned for signed, and encrypted, cookies that
+// have been tampered with, or were made with another CookieSecret.
+var ErrInvalidCookie = errors.New("cookie is invalid")
+
+// ErrNoCookieSecret is returned for signed, and encrypted, cookies when
+// the App has no CookieSecret.
+var ErrNoCookieSecret = errors.New("no CookieSecret is set")
+
+// CookieOptions are the attributes given to the cookies set through
+// Context.Cookies, see the Cookies option.
+type CookieOptions struct {
+	// Path of the cookies. Default is "/".
+	Path string
+	// Domain of the cookies. Default is the host of the request.
+	Domain string
+	// Secure cookies are only sent over HTTPS. They always are in
+	// production, unless Insecure is set.
+	Secure bool
+	// Insecure lets cookies be sent over plain HTTP in production.
+	Insecure bool
+	// AllowJS lets JavaScript read the cookies. By default they are
+	// HTTPOnly.
+	AllowJS bool
+	// SameSite of the cookies. Default is http.SameSiteLaxMode.
+	SameSite http.SameSite
+}
+
+// cookieOptionsWithDefaults fills in the defaults, so Secure is whether
+// the cookies are secure.
+func cookieOptionsWithDefaults(co CookieOptions, env string) CookieOptions {
+	if env == "production" && !co.Insecure {
+		co.Secure = true
+	}
+	if co.Path == "" {
+		co.Path = "/"
+	}
+	if co.SameSite == 0 {
+		co.SameSite = http.SameSiteLaxMode
+	}
+	return co
+}
+
+// Cookies reads, and writes, the cookies of a request, see
+// Context.Cookies.
+type Cookies struct {
+	req    *http.Request
+	res    http.ResponseWriter
+	opts   CookieOptions
+	secret string
+}
+
+// Cookies returns the Cookies of the request. Cookies that are set get
+// the attributes of the Cookies option of the App.
+/*
+	c.Cookies().Set("theme", "dark", 365*24*time.Hour)
+	theme, err := c.Cookies().Get("theme")
+
+	c.Cookies().SetSigned("user_id", "42", 0)
+	id, err := c.Cookies().GetSigned("user_id")
+*/
+func (d *DefaultContext) Cookies() *Cookies {
+	c := &Cookies{
+		req:  d.Request(),
+		res:  d.Response(),
+		opts: cookieOptionsWithDefaults(CookieOptions{}, ""),
+	}
+	if d.app != nil {
+		c.opts = d.app.Cookies
+		c.secret = d.app.CookieSecret
+	}
+	return c
+}
+
+// Get the value of the cookie. It returns http.ErrNoCookie if the
+// cookie isn't set.
+func (c *Cookies) Get(name string) (string, error) {
+	ck, err := c.req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return ck.Value, nil
+}
+
+// Set the cookie for maxAge. A maxAge of 0 sets a session cookie, which
+// is removed when the browser is closed.
+func (c *Cookies) Set(name, value string, maxAge time.Duration) {
+	ck := c.cookie(name, value)
+	if maxAge > 0 {
+		ck.MaxAge = int(maxAge.Seconds())
+		ck.Expires = time.Now().Add(maxAge)
+	}
+	http.SetCookie(c.res, ck)
+}
+
+// SetUntil sets the cookie until the time.
+func (c *Cookies) SetUntil(name, value string, expires time.Time) {
+	c.Set(name, value, time.Until(expires))
+}
+
+// SetCookie sets the cookie, filling in the attributes it doesn't have
+// from the Cookies option.
+func (c *Cookies) SetCookie(ck *http.Cookie) {
+	def := c.cookie(ck.Name, ck.Value)
+	if ck.Path == "" {
+		ck.Path = def.Path
+	}
+	if ck.Domain == "" {
+		ck.Domain = def.Domain
+	}
+	if ck.SameSite == 0 {
+		ck.SameSite = def.SameSite
+	}
+	ck.Secure = ck.Secure || def.Secure
+	ck.HttpOnly = ck.HttpOnly || def.HttpOnly
+	http.SetCookie(c.res, ck)
+}
+
+// Delete the cookie.
+func (c *Cookies) Delete(name string) {
+	ck := c.cookie(name, "")
+	ck.MaxAge = -1
+	ck.Expires = time.Unix(0, 0)
+	http.SetCookie(c.res, ck)
+}
+
+// GetSigned returns the value of a cookie set with SetSigned. It
+// returns ErrInvalidCookie if the cookie has been changed.
+func (c *Cookies) GetSigned(name string) (string, error) {
+	v, err := c.Get(name)
+	if err != nil {
+		return "", err
+	}
+	i := strings.LastIndexByte(v, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(v[:i])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(v[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	want, err := c.sign(name, value)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(sig, want) {
+		return "", ErrInvalidCookie
+	}
+	return string(value), nil
+}
+
+// SetSigned sets a cookie that can be read by the client, but not
+// changed, signed with an HMAC of the CookieSecret, see Set.
+func (c *Cookies) SetSigned(name, value string, maxAge time.Duration) error {
+	sig, err := c.sign(name, []byte(value))
+	if err != nil {
+		return err
+	}
+	enc := base64.RawURLEncoding
+	c.Set(name, enc.EncodeToString([]byte(value))+"."+enc.EncodeToString(sig), maxAge)
+	return nil
+}
+
+// GetEncrypted returns the value of a cookie set with SetEncrypted. It
+// returns ErrInvalidCookie if the cookie has been changed.
+func (c *Cookies) GetEncrypted(name string) (string, error) {
+	v, err := c.Get(name)
+	if err != nil {
+		return "", err
+	}
+	aead, err := c.aead()
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil || len(b) < aead.NonceSize() {
+		return "", ErrInvalidCookie
+	}
+	nonce, sealed := b[:aead.NonceSize()], b[aead.NonceSize():]
+	value, err := aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	return string(value), nil
+}
+
+// SetEncrypted sets a cookie that can't be read, or changed, by the
+// client, encrypted with AES-GCM and a key from the CookieSecret, see
+// Set.
+func (c *Cookies) SetEncrypted(name, value string, maxAge time.Duration) error {
+	aead, err := c.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.WithStack(err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	c.Set(name, base64.RawURLEncoding.EncodeToString(sealed), maxAge)
+	return nil
+}
+
+func (c *Cookies) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     c.opts.Path,
+		Domain:   c.opts.Domain,
+		Secure:   c.opts.Secure,
+		HttpOnly: !c.opts.AllowJS,
+		SameSite: c.opts.SameSite,
+	}
+}
+
+// key derives a key for the purpose from the CookieSecret, so the keys
+// for signing and encrypting differ.
+func (c *Cookies) key(purpose string) ([]byte, error) {
+	if c.secret == "" {
+		return nil, ErrNoCookieSecret
+	}
+	h := hmac.New(sha256.New, []byte(c.secret))
+	h.Write([]byte("buffalo cookies " + purpose))
+	return h.Sum(nil), nil
+}
+
+func (c *Cookies) sign(name string, value []byte) ([]byte, error) {
+	key, err := c.key("signing")
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "="))
+	h.Write(value)
+	return h.Sum(nil), nil
+}
+
+func (c *Cookies) aead() (cipher.AEAD, error) {
+	key, err := c.key("encryption")
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
@@ -0,0 +1,117 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func cookiesApp() *App {
+	a := New(Options{CookieSecret: "secret"})
+	a.GET("/set", func(c Context) error {
+		c.Cookies().Set("theme", "dark", time.Hour)
+		if err := c.Cookies().SetSigned("user_id", "42", 0); err != nil {
+			return err
+		}
+		if err := c.Cookies().SetEncrypted("token", "s3cr3t", 0); err != nil {
+			return err
+		}
+		c.Cookies().Delete("old")
+		return nil
+	})
+	a.GET("/get", func(c Context) error {
+		theme, _ := c.Cookies().Get("theme")
+		id, err := c.Cookies().GetSigned("user_id")
+		if err != nil {
+			return c.Error(400, err)
+		}
+		token, err := c.Cookies().GetEncrypted("token")
+		if err != nil {
+			return c.Error(400, err)
+		}
+		return c.Render(200, render.String(theme+" "+id+" "+token))
+	})
+	return a
+}
+
+func Test_Cookies(t *testing.T) {
+	r := require.New(t)
+	a := cookiesApp()
+
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/set", nil))
+	cookies := map[string]*http.Cookie{}
+	for _, ck := range (&http.Response{Header: res.Header()}).Cookies() {
+		cookies[ck.Name] = ck
+	}
+	r.Len(cookies, 4)
+
+	theme := cookies["theme"]
+	r.Equal("dark", theme.Value)
+	r.Equal(3600, theme.MaxAge)
+	r.Equal("/", theme.Path)
+	r.True(theme.HttpOnly)
+	r.False(theme.Secure)
+	r.Equal(http.SameSiteLaxMode, theme.SameSite)
+
+	r.NotContains(cookies["token"].Value, "s3cr3t")
+	r.Equal(-1, cookies["old"].MaxAge)
+
+	get := func(cks ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/get", nil)
+		for _, ck := range cks {
+			req.AddCookie(ck)
+		}
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+	res = get(theme, cookies["user_id"], cookies["token"])
+	r.Equal(200, res.Code)
+	r.Equal("dark 42 s3cr3t", res.Body.String())
+
+	forged := *cookies["user_id"]
+	forged.Value = "NDM" + forged.Value[3:]
+	r.Equal(400, get(&forged, cookies["token"]).Code)
+
+	// flip a character, the token is random so it may already be "A"
+	forged = *cookies["token"]
+	flip := "A"
+	if forged.Value[0] == 'A' {
+		flip = "B"
+	}
+	forged.Value = flip + forged.Value[1:]
+	r.Equal(400, get(cookies["user_id"], &forged).Code)
+
+	// cookies made with another secret
+	a.CookieSecret = "other"
+	r.Equal(400, get(cookies["user_id"], cookies["token"]).Code)
+}
+
+func Test_Cookies_Options(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Env: "production"})
+	r.True(a.Cookies.Secure)
+	r.False(a.Cookies.AllowJS)
+
+	// setting some attributes keeps the defaults of the others
+	a = New(Options{Env: "production", Cookies: CookieOptions{Path: "/app"}})
+	r.True(a.Cookies.Secure)
+	r.False(New(Options{Env: "production", Cookies: CookieOptions{Insecure: true}}).Cookies.Secure)
+
+	a = New(Options{Cookies: CookieOptions{Domain: "example.com", SameSite: http.SameSiteStrictMode}})
+	a.CookieSecret = ""
+	a.GET("/", func(c Context) error {
+		c.Cookies().SetCookie(&http.Cookie{Name: "a", Value: "b", HttpOnly: true})
+		r.Equal(ErrNoCookieSecret, c.Cookies().SetSigned("c", "d", 0))
+		return nil
+	})
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	r.Equal("a=b; Path=/; Domain=example.com; HttpOnly; SameSite=Strict", res.Header().Get("Set-Cookie"))
+}
@@ -0,0 +1,58 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/cron"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Next(t *testing.T) {
+	r := require.New(t)
+
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		r.NoError(err)
+		return t
+	}
+	table := []struct {
+		expr string
+		from string
+		next string
+	}{
+		{"*/5 * * * *", "2017-03-01 10:02", "2017-03-01 10:05"},
+		{"*/5 * * * *", "2017-03-01 10:05", "2017-03-01 10:10"},
+		{"0 9-17 * * MON-FRI", "2017-03-03 17:30", "2017-03-06 09:00"},
+		{"30 2 1 * *", "2017-03-01 03:00", "2017-04-01 02:30"},
+		{"@daily", "2017-12-31 23:59", "2018-01-01 00:00"},
+		{"0 0 29 2 *", "2017-03-01 00:00", "2020-02-29 00:00"},
+		{"0 12 13 * 5", "2017-03-01 00:00", "2017-03-03 12:00"},
+		{"15,45 */6 * JAN,jul 7", "2017-03-01 00:00", "2017-07-02 00:15"},
+	}
+	for _, tt := range table {
+		s, err := cron.Parse(tt.expr)
+		r.NoError(err, tt.expr)
+		r.Equal(at(tt.next), s.Next(at(tt.from)), tt.expr)
+	}
+
+	r.True(cron.MustParse("0 0 30 2 *").Next(at("2017-01-01 00:00")).IsZero())
+}
+
+func Test_Next_Location(t *testing.T) {
+	r := require.New(t)
+
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	s := cron.MustParse("0 9 * * *")
+	next := s.Next(time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC).In(loc))
+	r.Equal(time.Date(2017, 3, 1, 14, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func Test_Parse_Errors(t *testing.T) {
+	r := require.New(t)
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * FOO *"} {
+		_, err := cron.Parse(expr)
+		r.Error(err, expr)
+	}
+}
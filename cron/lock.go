@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/gobuffalo/buffalo/redisconn"
+	"github.com/pkg/errors"
+)
+
+// Locker makes sure only one instance of an App, of the many that may
+// be running, runs each due job. Acquire returns true to the first one
+// to ask for the key, until ttl has passed.
+type Locker interface {
+	Acquire(key string, ttl time.Duration) (bool, error)
+}
+
+// RedisConn is the small slice of a Redis client the Redis Locker
+// needs, see redisconn.Conn.
+type RedisConn = redisconn.Conn
+
+type redisLocker struct {
+	conn   RedisConn
+	prefix string
+}
+
+// RedisLocker returns a Locker that takes locks with Redis' SET NX,
+// under the prefix.
+func RedisLocker(conn RedisConn, prefix string) Locker {
+	return redisLocker{conn: conn, prefix: prefix}
+}
+
+func (r redisLocker) Acquire(key string, ttl time.Duration) (bool, error) {
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	reply, err := r.conn.Do("SET", r.prefix+key, "1", "NX", "PX", ms)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return reply != nil, nil
+}
@@ -0,0 +1,155 @@
+package buffalo
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DebugOptions configure the debug routes added by App.Debug.
+type DebugOptions struct {
+	// Prefix of the debug routes. Default is "/debug".
+	Prefix string
+	// Auth protects the debug routes, such as auth.RequireAuth with a
+	// check of the user. Without it the routes are only available in
+	// development, everywhere else they are a 404.
+	Auth MiddlewareFunc
+}
+
+// Debug adds a Group of routes for profiling, and looking into, the
+// running App, so production can be profiled without a separate
+// listener:
+//
+//	GET  {prefix}/pprof/            net/http/pprof, and its profiles
+//	GET  {prefix}/vars              expvar
+//	GET  {prefix}/gc                GC and memory stats, as JSON
+//	GET  {prefix}/goroutines        a dump of the stacks of every goroutine
+//	GET  {prefix}/build             the build info of the binary, as JSON
+//
+// It also turns on the ProfileLabels option, so the profiles can be
+// broken down by route. The Group is returned so more routes can be
+// added to it.
+/*
+	app.Debug(buffalo.DebugOptions{
+		Auth: func(next buffalo.Handler) buffalo.Handler {
+			return func(c buffalo.Context) error {
+				if !isAdmin(c) {
+					return c.Error(403, errors.New("forbidden"))
+				}
+				return next(c)
+			}
+		},
+	})
+
+	go tool pprof https://example.com/debug/pprof/heap
+*/
+func (a *App) Debug(opts DebugOptions) *App {
+	if opts.Prefix == "" {
+		opts.Prefix = "/debug"
+	}
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.ProfileLabels = true
+	g := a.Group(opts.Prefix)
+	if opts.Auth != nil {
+		g.Use(opts.Auth)
+	} else {
+		g.Use(developmentOnly)
+	}
+
+	g.GET("/pprof/cmdline", WrapHandlerFunc(pprof.Cmdline))
+	g.GET("/pprof/profile", WrapHandlerFunc(pprof.Profile)).Streaming()
+	g.GET("/pprof/symbol", WrapHandlerFunc(pprof.Symbol))
+	g.POST("/pprof/symbol", WrapHandlerFunc(pprof.Symbol))
+	g.GET("/pprof/trace", WrapHandlerFunc(pprof.Trace)).Streaming()
+	// the index links to the profiles relative to "pprof/", so it is
+	// served with the trailing slash, which routes don't otherwise match
+	g.GET("/pprof/{name:.*}", func(c Context) error {
+		name := c.Param("name")
+		if name == "" {
+			pprof.Index(c.Response(), c.Request())
+			return nil
+		}
+		pprof.Handler(name).ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+	g.GET("/vars", WrapHandler(expvar.Handler()))
+	g.GET("/gc", debugGC)
+	g.GET("/goroutines", debugGoroutines)
+	g.GET("/build", a.debugBuild)
+	return g
+}
+
+func developmentOnly(next Handler) Handler {
+	return func(c Context) error {
+		if c.Env() != "development" {
+			return c.Error(404, errors.Errorf("path not found: %s", c.Request().URL.Path))
+		}
+		return next(c)
+	}
+}
+
+func debugGC(c Context) error {
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return c.JSON(200, map[string]interface{}{
+		"num_gc":          gc.NumGC,
+		"last_gc":         gc.LastGC,
+		"pause_total":     gc.PauseTotal.String(),
+		"last_pause":      lastPause(gc),
+		"heap_alloc":      mem.HeapAlloc,
+		"heap_sys":        mem.HeapSys,
+		"heap_objects":    mem.HeapObjects,
+		"total_alloc":     mem.TotalAlloc,
+		"sys":             mem.Sys,
+		"next_gc":         mem.NextGC,
+		"gc_cpu_fraction": mem.GCCPUFraction,
+		"goroutines":      runtime.NumGoroutine(),
+	})
+}
+
+func lastPause(gc debug.GCStats) string {
+	if len(gc.Pause) == 0 {
+		return time.Duration(0).String()
+	}
+	return gc.Pause[0].String()
+}
+
+func debugGoroutines(c Context) error {
+	res := c.Response()
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	res.WriteHeader(200)
+	return rpprof.Lookup("goroutine").WriteTo(res, 2)
+}
+
+func (a *App) debugBuild(c Context) error {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return c.Error(404, errors.New("no build info in the binary"))
+	}
+	deps := map[string]string{}
+	for _, d := range info.Deps {
+		deps[d.Path] = d.Version
+	}
+	settings := map[string]string{}
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	return c.JSON(200, map[string]interface{}{
+		"go_version": info.GoVersion,
+		"path":       info.Path,
+		"main":       info.Main.Path + "@" + info.Main.Version,
+		"version":    a.Version,
+		"deps":       deps,
+		"settings":   settings,
+	})
+}
@@ -0,0 +1,85 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getDebug(a *App, path string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+	return res
+}
+
+func Test_App_Debug(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Env: "development"})
+	r.False(a.ProfileLabels)
+	a.Debug(DebugOptions{})
+	r.True(a.ProfileLabels)
+
+	res := getDebug(a, "/debug/pprof/")
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), "goroutine")
+
+	res = getDebug(a, "/debug/pprof/heap?debug=1")
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), "heap profile")
+
+	res = getDebug(a, "/debug/pprof/cmdline")
+	r.Equal(200, res.Code)
+
+	res = getDebug(a, "/debug/vars")
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), "memstats")
+
+	res = getDebug(a, "/debug/gc")
+	r.Equal(200, res.Code)
+	gc := map[string]interface{}{}
+	r.NoError(json.Unmarshal(res.Body.Bytes(), &gc))
+	r.Contains(gc, "num_gc")
+	r.Contains(gc, "heap_alloc")
+
+	res = getDebug(a, "/debug/goroutines")
+	r.Equal(200, res.Code)
+	r.True(strings.HasPrefix(res.Body.String(), "goroutine "))
+
+	res = getDebug(a, "/debug/build")
+	r.Contains([]int{200, 404}, res.Code)
+}
+
+func Test_App_Debug_Auth(t *testing.T) {
+	r := require.New(t)
+
+	// without Auth the routes are only there in development
+	a := New(Options{Env: "production"})
+	a.Debug(DebugOptions{})
+	r.Equal(404, getDebug(a, "/debug/gc").Code)
+
+	a = New(Options{Env: "production"})
+	a.Debug(DebugOptions{
+		Prefix: "/_ops",
+		Auth: func(next Handler) Handler {
+			return func(c Context) error {
+				if c.Request().Header.Get("X-Admin") != "yes" {
+					return c.Error(403, errors.New("forbidden"))
+				}
+				return next(c)
+			}
+		},
+	})
+	r.Equal(403, getDebug(a, "/_ops/gc").Code)
+
+	req := httptest.NewRequest("GET", "/_ops/pprof/goroutine?debug=1", nil)
+	req.Header.Set("X-Admin", "yes")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), "goroutine profile")
+}
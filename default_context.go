@@ -2,24 +2,27 @@ package buffalo
 
 import (
 	"bytes"
-	"encoding/json"
-	"encoding/xml"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gobuffalo/buffalo/render"
-	"github.com/gorilla/schema"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
 
 // DefaultContext is, as its name implies, a default
 // implementation of the Context interface.
+//
+// DefaultContexts are pooled, and reused for other requests once their
+// request has been handled. Don't keep a Context, or the map returned
+// by Data, after the Handler has returned; hand goroutines that outlive
+// the request a Detach, or the values they need, instead.
 type DefaultContext struct {
 	response    http.ResponseWriter
 	request     *http.Request
@@ -28,6 +31,9 @@ type DefaultContext struct {
 	session     *Session
 	contentType string
 	data        map[string]interface{}
+	app         *App
+	route       RouteInfo
+	hooks       *requestHooks
 }
 
 // Response returns the original Response for the request.
@@ -40,11 +46,55 @@ func (d *DefaultContext) Request() *http.Request {
 	return d.request
 }
 
+// Deadline returns the deadline of the request's context, see
+// context.Context. Contexts can be passed to anything that takes a
+// context.Context, such as database and HTTP client calls, which are
+// then canceled when the client goes away or the route times out.
+func (d *DefaultContext) Deadline() (time.Time, bool) {
+	return d.ctx().Deadline()
+}
+
+// Done returns the Done channel of the request's context, see
+// context.Context.
+func (d *DefaultContext) Done() <-chan struct{} {
+	return d.ctx().Done()
+}
+
+// Err returns the error of the request's context, see context.Context.
+func (d *DefaultContext) Err() error {
+	return d.ctx().Err()
+}
+
+// Value returns the value Set on the Context for string keys, and
+// otherwise the value of the request's context, see context.Context.
+func (d *DefaultContext) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if v := d.Get(k); v != nil {
+			return v
+		}
+	}
+	return d.ctx().Value(key)
+}
+
+func (d *DefaultContext) ctx() context.Context {
+	if d.request == nil {
+		return context.Background()
+	}
+	return d.request.Context()
+}
+
 // Params returns all of the parameters for the request,
 // including both named params and query string parameters.
 // These parameters are automatically available in templates
 // as "{{.params}}".
 func (d *DefaultContext) Params() ParamValues {
+	if d.params == nil && d.request != nil {
+		params := d.request.URL.Query()
+		for k, v := range mux.Vars(d.request) {
+			params.Set(k, v)
+		}
+		d.params = params
+	}
 	return d.params
 }
 
@@ -59,27 +109,56 @@ func (d *DefaultContext) Param(key string) string {
 	return d.Params().Get(key)
 }
 
-// ParamInt tries to convert the requested parameter to
-// an int. It will return an error if there is a problem.
-func (d *DefaultContext) ParamInt(key string) (int, error) {
-	k := d.Params().Get(key)
-	i, err := strconv.Atoi(k)
-	return i, errors.WithMessage(err, fmt.Sprintf("could not convert %s to an int", k))
-}
-
 // Set a value onto the Context. Any value set onto the Context
 // will be automatically available in templates.
 func (d *DefaultContext) Set(key string, value interface{}) {
+	if d.data == nil {
+		d.data = map[string]interface{}{}
+	}
 	d.data[key] = value
 }
 
-// Get a value that was previous set onto the Context.
+// Get a value that was previous set onto the Context. The "env",
+// "routes", "current_route", and "session" values are always set.
+//
+// Deprecated: for values read in Go, rather than templates, use typed
+// Keys with the Get function, such as buffalo.Get(c, EnvKey). String
+// keys panic on the wrong type, and collide across packages.
 func (d *DefaultContext) Get(key string) interface{} {
-	return d.data[key]
+	if v, ok := d.data[key]; ok {
+		return v
+	}
+	if d.app == nil {
+		return nil
+	}
+	switch key {
+	case "env":
+		return d.app.Env()
+	case "routes":
+		return d.app.Routes()
+	case "current_route":
+		return d.route
+	case "session":
+		return d.Session()
+	}
+	return nil
+}
+
+// Env is the environment the App is running in, such as "development"
+// or "production".
+func (d *DefaultContext) Env() string {
+	if d.app != nil {
+		return d.app.Env()
+	}
+	env, _ := Get(d, EnvKey)
+	return env
 }
 
 // Session for the associated Request.
 func (d *DefaultContext) Session() *Session {
+	if d.session == nil && d.app != nil {
+		d.session = d.app.getSession(d.request, d.response)
+	}
 	return d.session
 }
 
@@ -88,56 +167,77 @@ func (d *DefaultContext) Session() *Session {
 // "{{.params}}". Any values set onto the Context will also automatically
 // be made available to the render.Renderer. To render "no content" pass
 // in a nil render.Renderer.
+//
+// HTML, JSON, XML, text, and downloads all go through Render, and so
+// through the middleware wrapping the response, such as compression and
+// ETags. The body is rendered before anything is written, so a failed
+// render reaches the ErrorHandlers as a 500, unless the Renderer is a
+// render.StreamRenderer. A render.HeaderRenderer sets its headers too.
+/*
+	return c.Render(200, r.HTML("users/index.html"))
+	return c.Render(201, r.JSON(user))
+	return c.Render(200, r.Download("report.pdf", f))
+*/
 func (d *DefaultContext) Render(status int, rr render.Renderer) error {
 	now := time.Now()
 	defer func() {
 		d.LogField("render", time.Now().Sub(now))
 	}()
-	if rr != nil {
-		data := d.data
-		pp := map[string]string{}
-		for k, v := range d.params {
-			pp[k] = v[0]
-		}
-		data["params"] = pp
-		bb := &bytes.Buffer{}
-		err := rr.Render(bb, data)
-		if err != nil {
-			return httpError{Status: 500, Cause: errors.WithStack(err)}
-		}
-		d.Response().Header().Set("Content-Type", rr.ContentType())
+	if rr == nil {
 		d.Response().WriteHeader(status)
-		_, err = io.Copy(d.Response(), bb)
-		if err != nil {
-			return httpError{Status: 500, Cause: errors.WithStack(err)}
-		}
 		return nil
 	}
-	d.Response().WriteHeader(status)
+	data := d.Data()
+	pp := map[string]string{}
+	d.Params()
+	for k, v := range d.params {
+		pp[k] = v[0]
+	}
+	data["params"] = pp
+	res := d.Response()
+	if sr, ok := rr.(render.StreamRenderer); ok && sr.Stream() {
+		d.renderHeaders(rr)
+		res.WriteHeader(status)
+		return errors.WithStack(rr.Render(res, data))
+	}
+	bb := &bytes.Buffer{}
+	err := rr.Render(bb, data)
+	if err != nil {
+		return httpError{Status: 500, Cause: errors.WithStack(err)}
+	}
+	d.renderHeaders(rr)
+	res.WriteHeader(status)
+	_, err = io.Copy(res, bb)
+	if err != nil {
+		return httpError{Status: 500, Cause: errors.WithStack(err)}
+	}
 	return nil
 }
 
+// renderHeaders sets the Content-Type, and any other headers, of rr.
+func (d *DefaultContext) renderHeaders(rr render.Renderer) {
+	h := d.Response().Header()
+	h.Set("Content-Type", rr.ContentType())
+	if hr, ok := rr.(render.HeaderRenderer); ok {
+		for k, v := range hr.Headers() {
+			h[k] = v
+		}
+	}
+}
+
 // Bind the interface to the request.Body. The type of binding
 // is dependent on the "Content-Type" for the request. If the type
 // is "application/json" it will use "json.NewDecoder". If the type
-// is "application/xml" it will use "xml.NewDecoder". The default
-// binder is "http://www.gorillatoolkit.org/pkg/schema".
+// is "application/xml" it will use "xml.NewDecoder". Forms, and the
+// query params of requests without a body, are decoded with the
+// FormDecoder. More types can be added with RegisterBinder.
 func (d *DefaultContext) Bind(value interface{}) error {
-	switch strings.ToLower(d.Request().Header.Get("Content-Type")) {
-	case "application/json", "text/json", "json":
-		return json.NewDecoder(d.Request().Body).Decode(value)
-	case "application/xml", "text/xml", "xml":
-		return xml.NewDecoder(d.Request().Body).Decode(value)
-	default:
-		err := d.Request().ParseForm()
-		if err != nil {
-			return errors.WithStack(err)
+	if strings.HasPrefix(d.request.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := d.parseMultipartForm(); err != nil {
+			return err
 		}
-		dec := schema.NewDecoder()
-		dec.IgnoreUnknownKeys(true)
-		dec.ZeroEmpty(true)
-		return dec.Decode(value, d.Request().PostForm)
 	}
+	return bind(d.Request(), value)
 }
 
 // LogField adds the key/value pair onto the Logger to be printed out
@@ -158,23 +258,206 @@ func (d *DefaultContext) Error(status int, err error) error {
 	return httpError{Status: status, Cause: errors.WithStack(err)}
 }
 
+// T translates the key, using the "translator" set by i18n middleware.
+// Without one, the key is returned untranslated.
+func (d *DefaultContext) T(key string, args ...interface{}) string {
+	if t, ok := Get(d, TranslatorKey); ok {
+		return t.Translate(d, key, args...)
+	}
+	return key
+}
+
 // Websocket returns an upgraded github.com/gorilla/websocket.Conn
-// that can then be used to work with websockets easily.
+// that can then be used to work with websockets easily, see Socket.
+// The connection is closed when the App is stopped.
 func (d *DefaultContext) Websocket() (*websocket.Conn, error) {
-	return defaultUpgrader.Upgrade(d.Response(), d.Request(), nil)
+	conn, err := defaultUpgrader.Upgrade(d.Response(), d.Request(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.app != nil {
+		d.app.trackSocket(conn, d.Request().Context().Done())
+	}
+	return conn, nil
 }
 
-// Redirect a request with the given status to the given URL.
+// Redirect a request with the given status to the given URL. The URL
+// can be the name of a route, with its params, and any others for the
+// query string, given as a render.Data. Otherwise the args format the
+// URL, or, as a render.Data, are added to its query string.
+//
+// A Session that has been used is saved first, so flashes added before
+// redirecting aren't lost. Turbolinks, and Turbo, form submissions are
+// redirected so they follow.
+/*
+	return c.Redirect(302, "userPath", render.Data{"user_id": u.ID, "tab": "settings"})
+	return c.Redirect(302, "/users/%d", u.ID)
+	return c.Redirect(302, "/search?page=2", render.Data{"q": q})
+*/
 func (d *DefaultContext) Redirect(status int, url string, args ...interface{}) error {
-	http.Redirect(d.Response(), d.Request(), fmt.Sprintf(url, args...), status)
+	to, err := d.redirectURL(url, args)
+	if err != nil {
+		return err
+	}
+	if d.session != nil {
+		if err := d.session.Save(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	req := d.Request()
+	if req.Header.Get("Turbolinks-Referrer") != "" {
+		d.Response().Header().Set("Turbolinks-Location", to)
+	}
+	// Turbo only follows 303s for form submissions
+	if status == 302 && req.Method != "GET" && req.Method != "HEAD" &&
+		(req.Header.Get("Turbo-Frame") != "" || strings.Contains(req.Header.Get("Accept"), "turbo-stream")) {
+		status = 303
+	}
+	http.Redirect(d.Response(), req, to, status)
 	return nil
 }
 
+func (d *DefaultContext) redirectURL(to string, args []interface{}) (string, error) {
+	var data map[string]interface{}
+	if len(args) == 1 {
+		switch t := args[0].(type) {
+		case render.Data:
+			data = t
+		case map[string]interface{}:
+			data = t
+		}
+	}
+	if d.app != nil && d.app.hasRouteNamed(to) && (data != nil || len(args) == 0) {
+		params := make([]interface{}, 0, len(data)*2)
+		for k, v := range data {
+			params = append(params, k, v)
+		}
+		return d.app.URLFor(to, params...)
+	}
+	if data == nil {
+		if len(args) == 0 {
+			return to, nil
+		}
+		return fmt.Sprintf(to, args...), nil
+	}
+	u, err := url.Parse(to)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	q := u.Query()
+	for k, v := range data {
+		q.Set(k, fmt.Sprint(v))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 // Data contains all the values set through Get/Set.
 func (d *DefaultContext) Data() map[string]interface{} {
+	for _, k := range []string{"env", "routes", "current_route"} {
+		if _, ok := d.data[k]; !ok && d.app != nil {
+			d.Set(k, d.Get(k))
+		}
+	}
+	if d.data == nil {
+		d.data = map[string]interface{}{}
+	}
 	return d.data
 }
 
+// Fork returns a copy of the DefaultContext that uses the given request
+// and response. The copy gets its own data and logger, so it is safe to
+// hand off to another goroutine. Use Merge to bring any values set on the
+// copy back into the original.
+func (d *DefaultContext) Fork(req *http.Request, res http.ResponseWriter) *DefaultContext {
+	d.Session()
+	d.Params()
+	data := make(map[string]interface{}, len(d.data))
+	for k, v := range d.data {
+		data[k] = v
+	}
+	f := *d
+	f.request = req
+	f.response = res
+	f.data = data
+	return &f
+}
+
+// Detach returns a new DefaultContext, for the same App and route, that
+// uses the given request and response, for work that outlives the
+// request, such as rendering a response in the background. Unlike a
+// Fork it shares nothing with the original, which is reused once its
+// request has been handled: it gets a copy of the data and params, and
+// its own session, loaded from req, and hooks, which aren't run.
+/*
+	bg := c.(*buffalo.DefaultContext).Detach(req.WithContext(context.Background()), rec)
+	go handler(bg)
+*/
+func (d *DefaultContext) Detach(req *http.Request, res http.ResponseWriter) *DefaultContext {
+	data := make(map[string]interface{}, len(d.data))
+	for k, v := range d.data {
+		data[k] = v
+	}
+	d.Params()
+	params := make(url.Values, len(d.params))
+	for k, v := range d.params {
+		params[k] = append([]string(nil), v...)
+	}
+	n := &DefaultContext{
+		request: req,
+		params:  params,
+		logger:  d.logger,
+		data:    data,
+		app:     d.app,
+		route:   d.route,
+		hooks:   &requestHooks{},
+	}
+	br := &buffaloResponse{ResponseWriter: res}
+	br.beforeWrite = n.saveSession
+	n.response = br
+	return n
+}
+
+// Isolate is like Fork, but the new DefaultContext gets its own copy of
+// the session, and its own hooks, which are only kept if it is merged
+// back, see Merge. It is for handlers that may be given up on, and left
+// running, such as by middleware.Timeout. One that is given up on must
+// be Discarded once its handler returns.
+func (d *DefaultContext) Isolate(req *http.Request, res http.ResponseWriter) *DefaultContext {
+	f := d.Fork(req, res)
+	if d.session != nil {
+		f.session = d.session.copy(res)
+	}
+	f.hooks = &requestHooks{}
+	return f
+}
+
+// Merge the data, log fields, session, and hooks, of a Fork, or Isolate,
+// back into the DefaultContext.
+func (d *DefaultContext) Merge(f *DefaultContext) {
+	for k, v := range f.data {
+		d.Set(k, v)
+	}
+	d.logger = f.logger
+	if d.session != nil && f.session != nil && f.session != d.session {
+		d.session.merge(f.session)
+	}
+	if f.hooks != nil && f.hooks != d.hooks {
+		d.hookSet().merge(f.hooks)
+	}
+}
+
+// Discard an Isolate that won't be merged back, once its handler has
+// returned: its Cleanups are run, and the temp files of its multipart
+// form removed. Its AfterResponse hooks are dropped, as it has no
+// response.
+func (d *DefaultContext) Discard() {
+	d.runCleanups()
+	if d.request != nil && d.request.MultipartForm != nil {
+		d.request.MultipartForm.RemoveAll()
+	}
+}
+
 var defaultUpgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
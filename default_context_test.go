@@ -2,7 +2,9 @@ package buffalo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http/httptest"
 	"net/url"
 	"strings"
@@ -58,6 +60,80 @@ func Test_DefaultContext_GetSet(t *testing.T) {
 	r.Equal("Mark", c.Get("name").(string))
 }
 
+func Test_DefaultContext_Pooled(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.GET("/users/{id}", func(c Context) error {
+		if c.Get("name") != nil {
+			return errors.New("data from another request")
+		}
+		c.Set("name", c.Param("id"))
+		r.Equal("development", c.Get("env"))
+		r.Equal("/users/{id}", c.Get("current_route").(RouteInfo).Path)
+		r.Contains(c.Data(), "routes")
+		r.NotNil(c.Session())
+		return c.Render(200, render.String(c.Get("name").(string)))
+	})
+
+	w := willie.New(a)
+	for _, id := range []string{"1", "2", "3"} {
+		res := w.Request("/users/" + id).Get()
+		r.Equal(200, res.Code)
+		r.Equal(id, res.Body.String())
+	}
+}
+
+func Test_DefaultContext_Detach(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	done := make(chan string)
+	a.GET("/users/{id}", func(c Context) error {
+		c.Set("name", c.Param("id"))
+		req := c.Request().Clone(context.Background())
+		bg := c.(*DefaultContext).Detach(req, httptest.NewRecorder())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			done <- bg.Get("name").(string) + " " + bg.Param("id")
+		}()
+		return c.Render(200, render.String("ok"))
+	})
+
+	w := willie.New(a)
+	r.Equal("ok", w.Request("/users/1").Get().Body.String())
+	r.Equal("ok", w.Request("/users/2").Get().Body.String())
+	r.ElementsMatch([]string{"1 1", "2 2"}, []string{<-done, <-done})
+}
+
+type ctxKey struct{}
+
+func Test_DefaultContext_Context(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		c.Set("user", "Mark")
+		var ctx context.Context = c
+		r.Equal("Mark", ctx.Value("user"))
+		r.Equal("request", ctx.Value(ctxKey{}))
+		r.Nil(ctx.Value("missing"))
+
+		_, ok := ctx.Deadline()
+		r.True(ok)
+		r.Error(ctx.Err())
+		<-ctx.Done()
+		return nil
+	}).Timeout(time.Nanosecond)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	cancel()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	a.ServeHTTP(httptest.NewRecorder(), req)
+
+	c := &DefaultContext{}
+	r.NoError(c.Err())
+	r.Nil(c.Done())
+}
+
 func Test_DefaultContext_Render(t *testing.T) {
 	r := require.New(t)
 
@@ -220,3 +296,35 @@ func Test_DefaultContext_Websocket(t *testing.T) {
 		r.Equal(tt.Want, tt.Got)
 	}
 }
+
+func Test_DefaultContext_Render_Download(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/report", func(c Context) error {
+		return c.Render(200, render.Download("report.pdf", strings.NewReader("%PDF-1.4")))
+	})
+	a.GET("/broken", func(c Context) error {
+		return c.Render(200, render.Func("text/html", func(w io.Writer, d render.Data) error {
+			w.Write([]byte("half a page"))
+			return errors.New("boom")
+		}))
+	})
+	a.ErrorHandlers[500] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, werr := c.Response().Write([]byte("error page"))
+		return werr
+	}
+
+	w := willie.New(a)
+	res := w.Request("/report").Get()
+	r.Equal(200, res.Code)
+	r.Equal("application/pdf", res.Header().Get("Content-Type"))
+	r.Equal("attachment; filename=report.pdf", res.Header().Get("Content-Disposition"))
+	r.Equal("%PDF-1.4", res.Body.String())
+
+	// failed renders aren't written, and reach the ErrorHandlers
+	res = w.Request("/broken").Get()
+	r.Equal(500, res.Code)
+	r.Equal("error page", res.Body.String())
+}
@@ -0,0 +1,206 @@
+package buffalo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sendfile hands the sending of files off to the server in front of the
+// App, see the Sendfile option. The App only sets a header naming the
+// file, which the server then sends itself.
+/*
+	// apache mod_xsendfile, lighttpd
+	buffalo.Sendfile{Header: "X-Sendfile"}
+
+	// nginx, with "location /protected/ { internal; alias /var/app/files/; }"
+	buffalo.Sendfile{
+		Header:   "X-Accel-Redirect",
+		Mappings: map[string]string{"/var/app/files/": "/protected/"},
+	}
+*/
+type Sendfile struct {
+	// Header is "X-Sendfile" or "X-Accel-Redirect". Empty turns
+	// Sendfile off.
+	Header string
+	// Mappings turn the paths of files, by the directory they are in,
+	// into the internal locations the server serves them from. Files that
+	// aren't in a mapped directory are sent by the App.
+	Mappings map[string]string
+}
+
+// location returns the value of the header for the file, and whether
+// the file can be handed off.
+func (s Sendfile) location(path string) (string, bool) {
+	if s.Header == "" {
+		return "", false
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if len(s.Mappings) == 0 {
+		return path, true
+	}
+	// the longest prefix wins
+	prefixes := make([]string, 0, len(s.Mappings))
+	for p := range s.Mappings {
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, p := range prefixes {
+		// only match whole directories, so "/var/app/files" doesn't
+		// match "/var/app/files-private/key.pem"
+		dir := strings.TrimSuffix(p, "/")
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return strings.TrimSuffix(s.Mappings[p], "/") + strings.TrimPrefix(path, dir), true
+		}
+	}
+	return "", false
+}
+
+// SendFile sends the file at path, with its content type from its
+// extension, or sniffed from its contents. Range and conditional
+// requests are answered, and missing files return a 404. With the
+// Sendfile option the file is sent by the server in front of the App.
+// Any file the App can read is sent, so paths built from user input
+// must not be able to leave the directory they are meant for.
+/*
+	return c.SendFile(filepath.Join("uploads", filepath.Base(report.Path)))
+*/
+func (d *DefaultContext) SendFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d.Error(404, errors.WithStack(err))
+		}
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if fi.IsDir() {
+		return d.Error(404, errors.Errorf("%s is a directory", path))
+	}
+	return d.sendContent(fi.Name(), fi.ModTime(), f)
+}
+
+// Attachment sends r as a download, saved by the browser as filename.
+// Readers that are an io.ReadSeeker, such as an *os.File, answer range
+// requests. Files can be handed off with the Sendfile option.
+/*
+	f, err := os.Open(invoice.Path)
+	...
+	return c.Attachment(f, fmt.Sprintf("invoice-%d.pdf", invoice.ID))
+*/
+func (d *DefaultContext) Attachment(r io.Reader, filename string) error {
+	d.Response().Header().Set("Content-Disposition", ContentDisposition("attachment", filename))
+	modtime := time.Time{}
+	if f, ok := r.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil {
+			modtime = fi.ModTime()
+		}
+	}
+	return d.sendContent(filename, modtime, r)
+}
+
+func (d *DefaultContext) sendContent(name string, modtime time.Time, r io.Reader) error {
+	res := d.Response()
+	if res.Header().Get("Content-Type") == "" {
+		if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+			res.Header().Set("Content-Type", ct)
+		}
+	}
+
+	if f, ok := r.(*os.File); ok && d.app != nil {
+		if loc, ok := d.app.Sendfile.location(f.Name()); ok {
+			if res.Header().Get("Content-Type") == "" {
+				res.Header().Set("Content-Type", "application/octet-stream")
+			}
+			res.Header().Set(d.app.Sendfile.Header, loc)
+			res.WriteHeader(200)
+			return nil
+		}
+	}
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		http.ServeContent(res, d.Request(), name, modtime, rs)
+		return nil
+	}
+
+	if res.Header().Get("Content-Type") == "" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(r, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return errors.WithStack(err)
+		}
+		head = head[:n]
+		res.Header().Set("Content-Type", http.DetectContentType(head))
+		r = io.MultiReader(bytes.NewReader(head), r)
+	}
+	res.WriteHeader(200)
+	_, err := io.Copy(res, r)
+	return errors.WithStack(err)
+}
+
+// ContentDisposition returns a "Content-Disposition" header of the type,
+// "attachment" or "inline", for the filename. Names that aren't plain
+// ASCII are encoded as in RFC 5987, with an ASCII fallback for older
+// clients.
+func ContentDisposition(typ string, filename string) string {
+	if filename == "" {
+		return typ
+	}
+	fallback := &strings.Builder{}
+	plain := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\' || r == '%':
+			plain = false
+			fallback.WriteRune('_')
+		case r < 0x20 || r > 0x7e:
+			plain = false
+			fallback.WriteRune('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	s := fmt.Sprintf(`%s; filename="%s"`, typ, fallback)
+	if !plain {
+		s += "; filename*=UTF-8''" + rfc5987Escape(filename)
+	}
+	return s
+}
+
+func rfc5987Escape(s string) string {
+	b := &strings.Builder{}
+	for _, c := range []byte(s) {
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// isAttrChar reports whether c is an attr-char of RFC 5987, which can
+// be left unescaped.
+func isAttrChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
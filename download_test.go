@@ -0,0 +1,121 @@
+package buffalo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func downloadApp(t *testing.T, opts Options) (*App, string) {
+	dir, err := ioutil.TempDir("", "download")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "report.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello world"), 0644))
+
+	a := New(opts)
+	a.GET("/send", func(c Context) error {
+		return c.SendFile(path)
+	})
+	a.GET("/missing", func(c Context) error {
+		return c.SendFile(filepath.Join(dir, "missing.txt"))
+	})
+	a.GET("/attachment", func(c Context) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return c.Attachment(f, "résumé 2017.txt")
+	})
+	a.GET("/reader", func(c Context) error {
+		return c.Attachment(strings.NewReader("%PDF-1.4 ..."), "")
+	})
+	return a, dir
+}
+
+func Test_DefaultContext_SendFile(t *testing.T) {
+	r := require.New(t)
+	a, _ := downloadApp(t, Options{})
+	w := willie.New(a)
+
+	res := w.Request("/send").Get()
+	r.Equal(200, res.Code)
+	r.Equal("text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+	r.Equal("hello world", res.Body.String())
+	r.NotEmpty(res.Header().Get("Last-Modified"))
+
+	req := w.Request("/send")
+	req.Headers["Range"] = "bytes=6-"
+	res = req.Get()
+	r.Equal(206, res.Code)
+	r.Equal("world", res.Body.String())
+
+	r.Equal(404, w.Request("/missing").Get().Code)
+}
+
+func Test_DefaultContext_Attachment(t *testing.T) {
+	r := require.New(t)
+	a, _ := downloadApp(t, Options{})
+	w := willie.New(a)
+
+	res := w.Request("/attachment").Get()
+	r.Equal(200, res.Code)
+	r.Equal(`attachment; filename="r_sum_ 2017.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9%202017.txt`, res.Header().Get("Content-Disposition"))
+	r.Equal("hello world", res.Body.String())
+
+	// readers that can't seek are sniffed
+	res = w.Request("/reader").Get()
+	r.Equal("application/pdf", res.Header().Get("Content-Type"))
+	r.Equal("attachment", res.Header().Get("Content-Disposition"))
+	r.Equal("%PDF-1.4 ...", res.Body.String())
+}
+
+func Test_DefaultContext_SendFile_Sendfile(t *testing.T) {
+	r := require.New(t)
+	a, dir := downloadApp(t, Options{})
+	a.Sendfile = Sendfile{
+		Header:   "X-Accel-Redirect",
+		Mappings: map[string]string{dir + "/": "/protected/"},
+	}
+	w := willie.New(a)
+
+	res := w.Request("/send").Get()
+	r.Equal(200, res.Code)
+	r.Equal("/protected/report.txt", res.Header().Get("X-Accel-Redirect"))
+	r.Equal("", res.Body.String())
+
+	res = w.Request("/attachment").Get()
+	r.Equal("/protected/report.txt", res.Header().Get("X-Accel-Redirect"))
+	r.Contains(res.Header().Get("Content-Disposition"), "attachment")
+
+	a.Sendfile = Sendfile{Header: "X-Sendfile"}
+	res = w.Request("/send").Get()
+	r.Equal(filepath.Join(dir, "report.txt"), res.Header().Get("X-Sendfile"))
+}
+
+func Test_Sendfile_Location(t *testing.T) {
+	r := require.New(t)
+	s := Sendfile{
+		Header:   "X-Accel-Redirect",
+		Mappings: map[string]string{"/var/app/files": "/protected/"},
+	}
+
+	loc, ok := s.location("/var/app/files/report.pdf")
+	r.True(ok)
+	r.Equal("/protected/report.pdf", loc)
+
+	_, ok = s.location("/var/app/files-private/key.pem")
+	r.False(ok)
+}
+
+func Test_ContentDisposition(t *testing.T) {
+	r := require.New(t)
+	r.Equal(`inline; filename="report.pdf"`, ContentDisposition("inline", "report.pdf"))
+	r.Equal(`attachment; filename="say _hi_.txt"; filename*=UTF-8''say%20%22hi%22.txt`, ContentDisposition("attachment", `say "hi".txt`))
+}
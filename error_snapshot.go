@@ -0,0 +1,77 @@
+package buffalo
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ErrorSnapshot describes the state the App was in when a request
+// failed. It is attached to the Context, with ErrorSnapshotKey, before
+// the ErrorHandler is called so error reporters can add it to their
+// reports, and it is shown on the development error page. It makes
+// "it only breaks for some users" bugs much easier to track down.
+type ErrorSnapshot struct {
+	// Version of the App, from Options.
+	Version string `json:"version,omitempty"`
+	// Features that were turned on in the RuntimeConfig.
+	Features []string `json:"features"`
+	// Experiments the request was enrolled in, see SetExperiment.
+	Experiments map[string]string `json:"experiments,omitempty"`
+	// ConfigHash identifies the RuntimeConfig that was loaded.
+	ConfigHash string `json:"config_hash"`
+}
+
+// ErrorSnapshotKey is the ErrorSnapshot of a failed request, see
+// ErrorSnapshotFor.
+var ErrorSnapshotKey = NewKey[ErrorSnapshot]("error_snapshot")
+
+// experimentsKey holds the experiments set with SetExperiment.
+var experimentsKey = NewKey[map[string]string]("experiments")
+
+// SetExperiment records the variant of an experiment the request has
+// been enrolled in, so it can be included in the ErrorSnapshot.
+/*
+	variant := "control"
+	if rand.Intn(2) == 0 {
+		variant = "new_checkout"
+	}
+	buffalo.SetExperiment(c, "checkout", variant)
+*/
+func SetExperiment(c Context, name string, variant string) {
+	ex, ok := Get(c, experimentsKey)
+	if !ok {
+		ex = map[string]string{}
+		Set(c, experimentsKey, ex)
+	}
+	ex[name] = variant
+}
+
+// ErrorSnapshotFor returns the ErrorSnapshot attached to a failed
+// request. It is meant for use in ErrorHandlers.
+func ErrorSnapshotFor(c Context) (ErrorSnapshot, bool) {
+	return Get(c, ErrorSnapshotKey)
+}
+
+func (a *App) errorSnapshot(c Context) ErrorSnapshot {
+	rc := a.Config()
+	s := ErrorSnapshot{
+		Version:  a.Version,
+		Features: []string{},
+	}
+	for k, on := range rc.Features {
+		if on {
+			s.Features = append(s.Features, k)
+		}
+	}
+	sort.Strings(s.Features)
+	if ex, ok := Get(c, experimentsKey); ok {
+		s.Experiments = ex
+	}
+	// maps are marshaled with sorted keys, so the same config always
+	// has the same hash.
+	b, _ := json.Marshal(rc)
+	s.ConfigHash = fmt.Sprintf("%x", sha1.Sum(b))[:12]
+	return s
+}
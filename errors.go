@@ -3,6 +3,9 @@ package buffalo
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gobuffalo/velvet"
@@ -18,6 +21,17 @@ func (h httpError) Error() string {
 	return h.Cause.Error()
 }
 
+// StatusCode is the status the error will be handled with. It lets
+// middleware find out the status of a failed request.
+func (h httpError) StatusCode() int {
+	return h.Status
+}
+
+// Unwrap returns the underlying error.
+func (h httpError) Unwrap() error {
+	return h.Cause
+}
+
 // ErrorHandler interface for handling an error for a
 // specific status code.
 type ErrorHandler func(int, error, Context) error
@@ -45,31 +59,45 @@ func (e ErrorHandlers) Get(status int) ErrorHandler {
 }
 
 func defaultErrorHandler(status int, err error, c Context) error {
-	env := c.Get("env")
-	if env != nil && env.(string) == "production" {
+	refs := errorReferences(c)
+	ct := strings.ToLower(c.Request().Header.Get("Content-Type"))
+	if c.Env() == "production" {
 		c.Response().WriteHeader(status)
-		c.Response().Write([]byte(prodErrorTmpl))
+		if isJSON(ct) {
+			return json.NewEncoder(c.Response()).Encode(errorJSON(http.StatusText(status), status, refs))
+		}
+		c.Response().Write([]byte(prodErrorTmpl + prodErrorRefs(refs)))
 		return nil
 	}
 	err = errors.WithStack(err)
-	c.Logger().Error(err)
+	snap, hasSnap := ErrorSnapshotFor(c)
+	if hasSnap {
+		c.Logger().WithField("snapshot", snap).Error(err)
+	} else {
+		c.Logger().Error(err)
+	}
 	c.Response().WriteHeader(status)
 
 	msg := fmt.Sprintf("%+v", err)
-	ct := c.Request().Header.Get("Content-Type")
-	switch strings.ToLower(ct) {
-	case "application/json", "text/json", "json":
-		err = json.NewEncoder(c.Response()).Encode(map[string]interface{}{
-			"error": msg,
-			"code":  status,
-		})
-	case "application/xml", "text/xml", "xml":
+	switch {
+	case isJSON(ct):
+		m := errorJSON(msg, status, refs)
+		if hasSnap {
+			m["snapshot"] = snap
+		}
+		err = json.NewEncoder(c.Response()).Encode(m)
+	case ct == "application/xml", ct == "text/xml", ct == "xml":
 	default:
 		data := map[string]interface{}{
-			"routes": c.Get("routes"),
-			"error":  msg,
-			"status": status,
-			"data":   c.Data(),
+			"routes":     routesFor(c),
+			"error":      msg,
+			"status":     status,
+			"data":       DataSnapshot(c),
+			"headers":    Redaction.RedactHeader(c.Request().Header),
+			"references": refs,
+		}
+		if hasSnap {
+			data["snapshot"] = snap
 		}
 		ctx := velvet.NewContextWith(data)
 		t, err := velvet.Render(devErrorTmpl, ctx)
@@ -84,6 +112,50 @@ func defaultErrorHandler(status int, err error, c Context) error {
 	return err
 }
 
+// errorReferences returns the IDs, set in the Context by the request
+// logger and the tracing middleware, that tie a failed request to its
+// logs and trace. Users can quote them in bug reports.
+func errorReferences(c Context) map[string]string {
+	refs := map[string]string{}
+	for _, k := range []string{"request_id", "trace_id"} {
+		if v, ok := c.Get(k).(string); ok && v != "" {
+			refs[k] = v
+		}
+	}
+	return refs
+}
+
+func errorJSON(msg string, status int, refs map[string]string) map[string]interface{} {
+	m := map[string]interface{}{
+		"error": msg,
+		"code":  status,
+	}
+	for k, v := range refs {
+		m[k] = v
+	}
+	return m
+}
+
+func isJSON(ct string) bool {
+	return ct == "application/json" || ct == "text/json" || ct == "json"
+}
+
+func prodErrorRefs(refs map[string]string) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(refs))
+	for k := range refs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := "<p>\nIf you contact us about this problem, please include:\n</p>\n<ul>\n"
+	for _, k := range keys {
+		s += fmt.Sprintf("<li>%s: <code>%s</code></li>\n", html.EscapeString(k), html.EscapeString(refs[k]))
+	}
+	return s + "</ul>\n"
+}
+
 var devErrorTmpl = `
 <html>
 <head>
@@ -122,7 +194,29 @@ var devErrorTmpl = `
 </head>
 <body>
 <h1>{{status}} - ERROR!</h1>
+{{#if references}}
+<ul id="buffalo-error-references">
+	{{#each references as |k v|}}
+		<li>{{k}}: <code>{{v}}</code></li>
+	{{/each}}
+</ul>
+{{/if}}
 <pre>{{error}}</pre>
+{{#if snapshot}}
+<hr>
+<h3>Snapshot</h3>
+<table id="buffalo-error-snapshot">
+	<tr><th>Version</th><td>{{snapshot.Version}}</td></tr>
+	<tr><th>Config</th><td><code>{{snapshot.ConfigHash}}</code></td></tr>
+	<tr><th>Features</th><td>{{#each snapshot.Features as |f|}}<code>{{f}}</code> {{/each}}</td></tr>
+	<tr><th>Experiments</th><td>{{#each snapshot.Experiments as |k v|}}<code>{{k}}={{v}}</code> {{/each}}</td></tr>
+</table>
+{{/if}}
+<hr>
+<h3>Request Headers</h3>
+<pre>{{#each headers as |k v|}}
+{{k}}: {{inspect v}}
+{{/each}}</pre>
 <hr>
 <h3>Context</h3>
 <pre>{{#each data as |k v|}}
@@ -0,0 +1,96 @@
+package buffalo
+
+import (
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func errorRefsApp(env string) *App {
+	a := New(Options{Env: env})
+	a.Use(RequestLogger)
+	a.GET("/", func(c Context) error {
+		c.Set("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+		return c.Error(500, errors.New("boom"))
+	})
+	return a
+}
+
+func Test_defaultErrorHandler_References_JSON(t *testing.T) {
+	r := require.New(t)
+
+	for _, env := range []string{"development", "production"} {
+		w := willie.New(errorRefsApp(env))
+		res := w.JSON("/").Get()
+		r.Equal(500, res.Code)
+		body := map[string]interface{}{}
+		res.Bind(&body)
+		r.NotEmpty(body["request_id"], env)
+		r.Equal("4bf92f3577b34da6a3ce929d0e0e4736", body["trace_id"], env)
+	}
+}
+
+func Test_defaultErrorHandler_References_Production(t *testing.T) {
+	r := require.New(t)
+
+	w := willie.New(errorRefsApp("production"))
+	res := w.Request("/").Get()
+	r.Equal(500, res.Code)
+	r.Contains(res.Body.String(), "We're Sorry!")
+	r.Contains(res.Body.String(), "trace_id: <code>4bf92f3577b34da6a3ce929d0e0e4736</code>")
+	r.Contains(res.Body.String(), "request_id: <code>")
+	r.NotContains(res.Body.String(), "boom")
+}
+
+func Test_defaultErrorHandler_Snapshot(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Version: "v1.2.3"})
+	a.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{Features: map[string]bool{"search": true, "beta": false}}, nil
+	})
+	a.GET("/", func(c Context) error {
+		SetExperiment(c, "checkout", "new_flow")
+		return c.Error(500, errors.New("boom"))
+	})
+
+	w := willie.New(a)
+	res := w.JSON("/").Get()
+	r.Equal(500, res.Code)
+	body := struct {
+		Snapshot ErrorSnapshot `json:"snapshot"`
+	}{}
+	res.Bind(&body)
+	snap := body.Snapshot
+	r.Equal("v1.2.3", snap.Version)
+	r.Equal([]string{"search"}, snap.Features)
+	r.Equal(map[string]string{"checkout": "new_flow"}, snap.Experiments)
+	r.Len(snap.ConfigHash, 12)
+	r.Equal(a.errorSnapshot(&DefaultContext{data: map[string]interface{}{}}).ConfigHash, snap.ConfigHash)
+}
+
+func Test_ErrorSnapshotFor(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.ErrorHandlers[500] = func(status int, err error, c Context) error {
+		snap, ok := ErrorSnapshotFor(c)
+		r.True(ok)
+		r.Empty(snap.Features)
+		c.Response().WriteHeader(status)
+		return nil
+	}
+	a.GET("/", func(c Context) error {
+		return errors.New("boom")
+	})
+
+	w := willie.New(a)
+	r.Equal(500, w.Request("/").Get().Code)
+
+	// production responses don't give the snapshot away
+	w = willie.New(errorRefsApp("production"))
+	res := w.JSON("/").Get()
+	r.NotContains(res.Body.String(), "snapshot")
+}
@@ -0,0 +1,151 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventStream sends server-sent events to the client, see
+// Context.EventStream.
+type EventStream struct {
+	res     http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+	moot    *sync.Mutex
+}
+
+// EventStream starts a stream of server-sent events. Mark the route
+// as Streaming so it isn't cut off by the WriteTimeout option.
+/*
+	a.GET("/events", func(c buffalo.Context) error {
+		es, err := c.EventStream()
+		if err != nil {
+			return err
+		}
+		defer es.KeepAlive(15 * time.Second)()
+		for {
+			select {
+			case <-es.Done():
+				return nil
+			case o := <-orders:
+				if err := es.Send("order", o); err != nil {
+					return err
+				}
+			}
+		}
+	}).Streaming()
+*/
+func (d *DefaultContext) EventStream() (*EventStream, error) {
+	res := d.Response()
+	f, ok := res.(http.Flusher)
+	if !ok {
+		return nil, errors.New("the response can't be streamed")
+	}
+	h := res.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// stop proxies, such as nginx, from buffering the events
+	h.Set("X-Accel-Buffering", "no")
+	res.WriteHeader(200)
+	f.Flush()
+
+	es := &EventStream{
+		res:     res,
+		flusher: f,
+		done:    make(chan struct{}),
+		moot:    &sync.Mutex{},
+	}
+	var stopping <-chan struct{}
+	if d.app != nil {
+		stopping = d.app.stopping()
+	}
+	ctx := d.Request().Context()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopping:
+		}
+		close(es.done)
+	}()
+	return es, nil
+}
+
+// Done is closed when the client goes away, or the App is stopped.
+func (es *EventStream) Done() <-chan struct{} {
+	return es.done
+}
+
+// Send an event to the client. Strings are sent as they are, anything
+// else is sent as JSON. An empty event is sent without a name, so the
+// client gets it as a "message".
+func (es *EventStream) Send(event string, data interface{}) error {
+	s, ok := data.(string)
+	if !ok {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s = string(b)
+	}
+	msg := &strings.Builder{}
+	if event != "" {
+		fmt.Fprintf(msg, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Fprintf(msg, "data: %s\n", line)
+	}
+	msg.WriteString("\n")
+	return es.write(msg.String())
+}
+
+// KeepAlive sends a comment every interval, so proxies don't close the
+// connection while no events are being sent. Calling the returned
+// function stops it, and must be done before the Handler returns.
+func (es *EventStream) KeepAlive(interval time.Duration) func() {
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-es.done:
+				return
+			case <-t.C:
+				if err := es.write(": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-exited
+	}
+}
+
+func (es *EventStream) write(s string) error {
+	es.moot.Lock()
+	defer es.moot.Unlock()
+	select {
+	case <-es.done:
+		return errors.New("the event stream is closed")
+	default:
+	}
+	if _, err := es.res.Write([]byte(s)); err != nil {
+		return errors.WithStack(err)
+	}
+	es.flusher.Flush()
+	return nil
+}
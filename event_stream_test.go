@@ -0,0 +1,75 @@
+package buffalo
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventStream(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/events", func(c Context) error {
+		es, err := c.EventStream()
+		if err != nil {
+			return err
+		}
+		if err := es.Send("greeting", "hello\nworld"); err != nil {
+			return err
+		}
+		return es.Send("", map[string]int{"count": 1})
+	})
+
+	ts := httptest.NewServer(a)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/events")
+	r.NoError(err)
+	defer res.Body.Close()
+	r.Equal("text/event-stream", res.Header.Get("Content-Type"))
+	r.Equal("no-cache", res.Header.Get("Cache-Control"))
+	b, err := ioutil.ReadAll(res.Body)
+	r.NoError(err)
+	r.Equal("event: greeting\ndata: hello\ndata: world\n\ndata: {\"count\":1}\n\n", string(b))
+}
+
+func Test_EventStream_Shutdown(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/events", func(c Context) error {
+		es, err := c.EventStream()
+		if err != nil {
+			return err
+		}
+		defer es.KeepAlive(10 * time.Millisecond)()
+		<-es.Done()
+		return nil
+	}).Streaming()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+
+	res, err := http.Get("http://" + ln.Addr().String() + "/events")
+	r.NoError(err)
+	defer res.Body.Close()
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	r.NoError(err)
+	r.Equal(": keep-alive\n", line)
+
+	cancel()
+	r.NoError(<-served)
+}
@@ -0,0 +1,101 @@
+// Package events is a small, in process, event bus. Parts of Buffalo
+// emit events when interesting things happen, and applications and
+// plugins can listen for them without having to wrap middleware.
+/*
+	events.Listen("buffalo:config:reload", func(e events.Event) {
+		fmt.Println(e.Payload["changed"])
+	})
+
+	events.Emit(events.Event{Kind: "app:custom", Payload: events.Payload{"id": 1}})
+
+Events with a typed payload are emitted, and listened for, on a Topic,
+such as buffalo.RequestFinished, or worker.JobFailed.
+
+	buffalo.RequestFinished.Listen(func(e buffalo.RequestFinish) {
+		if e.Status >= 500 {
+			alerts.Notify(e.Route.Path, e.Error)
+		}
+	})
+*/
+package events
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Payload carries any extra information about an Event.
+type Payload map[string]interface{}
+
+// Event is emitted on the bus and delivered to every Listener
+// registered for its Kind.
+type Event struct {
+	// Kind of the event, for example "buffalo:config:reload".
+	Kind    string
+	Message string
+	Payload Payload
+	Error   error
+	// Data is the typed payload of events emitted on a Topic.
+	Data interface{}
+}
+
+// Listener is called with each Event of the kind it was registered for.
+type Listener func(Event)
+
+// DeleteFn removes a previously registered Listener.
+type DeleteFn func()
+
+var (
+	listeners = map[string]map[int]Listener{}
+	counter   int
+	moot      = &sync.RWMutex{}
+)
+
+// Listen for events of the given kind. Use "*" to listen to every event.
+// The returned DeleteFn will stop the Listener from receiving any more
+// events.
+func Listen(kind string, l Listener) DeleteFn {
+	moot.Lock()
+	defer moot.Unlock()
+	counter++
+	id := counter
+	if listeners[kind] == nil {
+		listeners[kind] = map[int]Listener{}
+	}
+	listeners[kind][id] = l
+	return func() {
+		moot.Lock()
+		defer moot.Unlock()
+		delete(listeners[kind], id)
+		// kinds can be made per request, such as by long polls, so
+		// they mustn't pile up
+		if len(listeners[kind]) == 0 {
+			delete(listeners, kind)
+		}
+	}
+}
+
+// Emit an Event to all of its listeners. Listeners are called
+// synchronously, in no particular order. An Event without a Kind
+// is an error.
+func Emit(e Event) error {
+	if e.Kind == "" {
+		return errors.New("events must have a Kind")
+	}
+	if e.Payload == nil {
+		e.Payload = Payload{}
+	}
+	moot.RLock()
+	ls := []Listener{}
+	for _, k := range []string{e.Kind, "*"} {
+		for _, l := range listeners[k] {
+			ls = append(ls, l)
+		}
+	}
+	moot.RUnlock()
+	for _, l := range ls {
+		l(e)
+	}
+	return nil
+}
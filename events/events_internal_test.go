@@ -0,0 +1,25 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Listen_Delete_Kind(t *testing.T) {
+	r := require.New(t)
+
+	del1 := Listen("test:poll:1", func(Event) {})
+	del2 := Listen("test:poll:1", func(Event) {})
+	del1()
+	moot.RLock()
+	r.Len(listeners["test:poll:1"], 1)
+	moot.RUnlock()
+
+	del2()
+	del2()
+	moot.RLock()
+	_, ok := listeners["test:poll:1"]
+	moot.RUnlock()
+	r.False(ok)
+}
@@ -0,0 +1,67 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Listen_Emit(t *testing.T) {
+	r := require.New(t)
+
+	var got []events.Event
+	del := events.Listen("test:foo", func(e events.Event) {
+		got = append(got, e)
+	})
+	delAll := events.Listen("*", func(e events.Event) {
+		got = append(got, e)
+	})
+	defer delAll()
+
+	r.NoError(events.Emit(events.Event{Kind: "test:foo", Payload: events.Payload{"a": 1}}))
+	r.Len(got, 2)
+	r.Equal(1, got[0].Payload["a"])
+
+	del()
+	got = nil
+	r.NoError(events.Emit(events.Event{Kind: "test:foo"}))
+	r.Len(got, 1)
+	r.NotNil(got[0].Payload)
+
+	r.Error(events.Emit(events.Event{}))
+}
+
+func Test_Topic(t *testing.T) {
+	r := require.New(t)
+
+	type order struct{ ID int }
+	shipped := events.NewTopic[*order]("test:order:shipped")
+	r.False(events.Listening(shipped.Kind))
+	// nobody is listening, so nothing is emitted
+	r.NoError(shipped.Emit(&order{ID: 1}))
+
+	var got []*order
+	del := shipped.Listen(func(o *order) {
+		got = append(got, o)
+	})
+	r.True(events.Listening(shipped.Kind))
+	r.NoError(shipped.Emit(&order{ID: 2}))
+	// untyped events of the kind are skipped
+	r.NoError(events.Emit(events.Event{Kind: shipped.Kind, Data: "2"}))
+	r.Len(got, 1)
+	r.Equal(2, got[0].ID)
+
+	var raw []events.Event
+	delAll := events.Listen("*", func(e events.Event) {
+		raw = append(raw, e)
+	})
+	defer delAll()
+	del()
+	r.NoError(shipped.Emit(&order{ID: 3}))
+	r.Len(got, 1)
+	r.Len(raw, 1)
+	r.Equal(&order{ID: 3}, raw[0].Data)
+
+	r.Error(events.NewTopic[int]("").Emit(1))
+}
@@ -0,0 +1,55 @@
+package events
+
+import "github.com/pkg/errors"
+
+// Topic is a Kind of Event with a typed payload, its Data. Parts of
+// Buffalo, such as the App and the workers, emit their lifecycle events
+// on Topics, and applications and plugins can add their own.
+/*
+	var OrderShipped = events.NewTopic[*models.Order]("order:shipped")
+
+	OrderShipped.Listen(func(o *models.Order) {
+		mailers.SendShipped(o)
+	})
+
+	OrderShipped.Emit(order)
+*/
+type Topic[T any] struct {
+	// Kind of the Events of the Topic.
+	Kind string
+}
+
+// NewTopic returns the Topic of the events of kind, whose Data is a T.
+func NewTopic[T any](kind string) Topic[T] {
+	return Topic[T]{Kind: kind}
+}
+
+// Emit an Event of the Topic with v as its Data. It does nothing if
+// there are no listeners, so it is cheap to call in hot paths.
+func (t Topic[T]) Emit(v T) error {
+	if t.Kind == "" {
+		return errors.New("events must have a Kind")
+	}
+	if !Listening(t.Kind) {
+		return nil
+	}
+	return Emit(Event{Kind: t.Kind, Data: v})
+}
+
+// Listen for the events of the Topic. Events of its Kind with Data of
+// another type, emitted by Emit rather than the Topic, are skipped.
+func (t Topic[T]) Listen(fn func(T)) DeleteFn {
+	return Listen(t.Kind, func(e Event) {
+		if v, ok := e.Data.(T); ok {
+			fn(v)
+		}
+	})
+}
+
+// Listening reports whether any Listener, including one for "*", is
+// registered for kind.
+func Listening(kind string) bool {
+	moot.RLock()
+	defer moot.RUnlock()
+	return len(listeners[kind]) > 0 || len(listeners["*"]) > 0
+}
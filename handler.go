@@ -1,9 +1,11 @@
 package buffalo
 
 import (
+	"context"
 	"net/http"
-
-	"github.com/gorilla/mux"
+	"runtime/pprof"
+	"sync"
+	"time"
 )
 
 // Handler is the basis for all of Buffalo. A Handler
@@ -27,39 +29,96 @@ import (
 */
 type Handler func(Context) error
 
+// contextPool holds the DefaultContexts of requests that have been
+// handled, so they can be reused by the next requests.
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return &DefaultContext{}
+	},
+}
+
 func (a *App) newContext(info RouteInfo, res http.ResponseWriter, req *http.Request) Context {
-	ws := res.(*buffaloResponse)
-	params := req.URL.Query()
-	vars := mux.Vars(req)
-	for k, v := range vars {
-		params.Set(k, v)
+	d := contextPool.Get().(*DefaultContext)
+	br := res.(*buffaloResponse)
+	br.beforeWrite = d.saveSession
+	d.response = br
+	d.request = req
+	d.logger = a.Logger
+	d.app = a
+	d.route = info
+	d.hooks = &requestHooks{}
+	if info.name != nil {
+		d.route.PathName = *info.name
+	}
+	if t := tenantFromRequest(req); t != nil {
+		for k, v := range t.Data {
+			d.Set(k, v)
+		}
+		Set(d, TenantKey, t)
 	}
+	return d
+}
 
-	return &DefaultContext{
-		response: ws,
-		request:  req,
-		params:   params,
-		logger:   a.Logger,
-		session:  a.getSession(req, ws),
-		data: map[string]interface{}{
-			"env":           a.Env,
-			"routes":        a.Routes(),
-			"current_route": info,
-		},
+// releaseContext runs the Cleanups of the request, and puts the Context
+// back in the pool, once its request has been handled. Its data map is
+// kept, emptied, unless it has grown large.
+func releaseContext(c Context) {
+	d, ok := c.(*DefaultContext)
+	if !ok {
+		return
+	}
+	d.runCleanups()
+	if d.request != nil && d.request.MultipartForm != nil {
+		d.request.MultipartForm.RemoveAll()
+	}
+	data := d.data
+	if len(data) > 32 {
+		data = nil
 	}
+	for k := range data {
+		delete(data, k)
+	}
+	*d = DefaultContext{data: data}
+	contextPool.Put(d)
 }
 
 func (a *App) handlerToHandler(info RouteInfo, h Handler) http.Handler {
 	hf := func(res http.ResponseWriter, req *http.Request) {
+		defer a.measure(info.Path, res, req)()
+		var herr error
+		defer func(start time.Time) {
+			requestFinished(info, res, req, start, herr)
+		}(time.Now())
+		if info.limits != nil {
+			var cancel func()
+			req, cancel = info.limits.apply(res, req)
+			defer cancel()
+		}
+		var br *BufferedResponse
+		if max := a.responseBufferSize(); max > 0 {
+			br = newBufferedResponse(res, max)
+			res = &buffaloResponse{ResponseWriter: br}
+		}
+		req, err := a.resolveTenant(req)
 		c := a.newContext(info, res, req)
-		err := a.Middleware.handler(h)(c)
+		defer releaseContext(c)
+		defer runAfterResponse(c, br)
+		if err == nil {
+			next := h
+			if info.schema != nil {
+				next = info.schema.handler(h)
+			}
+			err = a.Middleware.around(h, next)(c)
+		}
+		herr = err
 
 		if err != nil {
 			status := 500
 			if e, ok := err.(httpError); ok {
 				status = e.Status
 			}
-			eh := a.ErrorHandlers.Get(status)
+			Set(c, ErrorSnapshotKey, a.errorSnapshot(c))
+			eh := a.errorHandler(c, status)
 			err = eh(status, err, c)
 			if err != nil {
 				// things have really hit the fan if we're here!!
@@ -71,5 +130,30 @@ func (a *App) handlerToHandler(info RouteInfo, h Handler) http.Handler {
 			// a.Logger.Error(err)
 		}
 	}
-	return http.HandlerFunc(hf)
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !a.profileLabels() {
+			hf(res, req)
+			return
+		}
+		// label the goroutine so CPU and heap profiles can be broken
+		// down by route.
+		labels := pprof.Labels("route", info.Path, "method", info.Method)
+		pprof.Do(req.Context(), labels, func(ctx context.Context) {
+			hf(res, req.WithContext(ctx))
+		})
+	})
+}
+
+func (a *App) responseBufferSize() int {
+	if a.root != nil {
+		return a.root.ResponseBufferSize
+	}
+	return a.ResponseBufferSize
+}
+
+func (a *App) profileLabels() bool {
+	if a.root != nil {
+		return a.root.ProfileLabels
+	}
+	return a.ProfileLabels
 }
@@ -0,0 +1,225 @@
+package buffalo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HealthOptions configure the liveness and readiness endpoints of the
+// App, for Kubernetes probes and load balancers, see HealthChecks.
+type HealthOptions struct {
+	// LivenessPath answers with a 200 as long as the App is serving
+	// requests. Default is "/healthz".
+	LivenessPath string
+	// ReadinessPath runs the HealthChecks, and answers with a 200 if
+	// they all pass, and a 503 otherwise, or once the App is shutting
+	// down. Default is "/readyz".
+	ReadinessPath string
+	// Timeout of each check. Default is 5 seconds.
+	Timeout time.Duration
+	// CacheFor is how long the results of the checks are reused, so
+	// frequent probes don't overload what they check. Default is a
+	// second, a negative duration turns caching off.
+	CacheFor time.Duration
+	// Disable stops the App from serving the endpoints.
+	Disable bool
+}
+
+func healthOptionsWithDefaults(opts HealthOptions) HealthOptions {
+	if opts.LivenessPath == "" {
+		opts.LivenessPath = "/healthz"
+	}
+	if opts.ReadinessPath == "" {
+		opts.ReadinessPath = "/readyz"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.CacheFor == 0 {
+		opts.CacheFor = time.Second
+	}
+	return opts
+}
+
+// HealthCheck reports whether something the App needs, such as its
+// database, is working. It should give up when ctx is done.
+type HealthCheck func(ctx context.Context) error
+
+// HealthChecks are the checks run by the readiness endpoint of the App,
+// see HealthOptions. They are shared by the App and its Groups.
+/*
+	app.HealthChecks.Register("db", func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+
+	// GET /readyz
+	// {"status":"fail","checks":{"db":{"status":"fail","error":"connection refused","duration":"1.2ms"}}}
+*/
+type HealthChecks struct {
+	opts   HealthOptions
+	moot   sync.Mutex
+	checks map[string]HealthCheck
+	// running makes concurrent probes wait for the same run.
+	running sync.Mutex
+	report  HealthReport
+	ranAt   time.Time
+}
+
+func newHealthChecks(opts HealthOptions) *HealthChecks {
+	return &HealthChecks{opts: opts, checks: map[string]HealthCheck{}}
+}
+
+// Register the check under name, replacing any registered before.
+func (h *HealthChecks) Register(name string, fn HealthCheck) {
+	h.moot.Lock()
+	defer h.moot.Unlock()
+	h.checks[name] = fn
+	h.ranAt = time.Time{}
+}
+
+// HealthReport is the result of running the HealthChecks, it is what
+// the readiness endpoint responds with.
+type HealthReport struct {
+	// Status is "ok" if every check passed, and "fail" otherwise.
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// OK reports whether every check passed.
+func (r HealthReport) OK() bool {
+	return r.Status == "ok"
+}
+
+// CheckResult is the result of a HealthCheck.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Run the checks, concurrently, each with the Timeout. Results are
+// reused for CacheFor.
+func (h *HealthChecks) Run(ctx context.Context) HealthReport {
+	h.running.Lock()
+	defer h.running.Unlock()
+	h.moot.Lock()
+	if !h.ranAt.IsZero() && time.Since(h.ranAt) < h.opts.CacheFor {
+		defer h.moot.Unlock()
+		return h.report
+	}
+	checks := make(map[string]HealthCheck, len(h.checks))
+	for name, fn := range h.checks {
+		checks[name] = fn
+	}
+	h.moot.Unlock()
+
+	report := HealthReport{Status: "ok", Checks: map[string]CheckResult{}}
+	results := make(chan struct {
+		name string
+		res  CheckResult
+	}, len(checks))
+	for name, fn := range checks {
+		go func(name string, fn HealthCheck) {
+			res := h.check(ctx, fn)
+			results <- struct {
+				name string
+				res  CheckResult
+			}{name, res}
+		}(name, fn)
+	}
+	for range checks {
+		r := <-results
+		report.Checks[r.name] = r.res
+		if r.res.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+
+	h.moot.Lock()
+	h.report, h.ranAt = report, time.Now()
+	h.moot.Unlock()
+	return report
+}
+
+// check runs fn with the Timeout, a check that doesn't give up in time
+// is left to finish on its own.
+func (h *HealthChecks) check(ctx context.Context, fn HealthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs <- errors.Errorf("check panicked: %v", r)
+			}
+		}()
+		errs <- fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = errors.Errorf("timed out after %s", h.opts.Timeout)
+	}
+	res := CheckResult{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// serveHealth answers the liveness and readiness probes, outside of the
+// routes and their middleware, so they aren't logged, or held up by
+// sessions and auth.
+func (a *App) serveHealth(res http.ResponseWriter, req *http.Request) bool {
+	opts := a.Health
+	if opts.Disable || (req.Method != "GET" && req.Method != "HEAD") {
+		return false
+	}
+	var status int
+	var body interface{}
+	switch req.URL.Path {
+	case opts.LivenessPath:
+		status, body = 200, HealthReport{Status: "ok"}
+	case opts.ReadinessPath:
+		report := HealthReport{Status: "shutting down"}
+		if !a.shuttingDown() {
+			report = a.HealthChecks.Run(req.Context())
+		}
+		status, body = 200, report
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+	default:
+		return false
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(status)
+	if req.Method == "GET" {
+		json.NewEncoder(res).Encode(body)
+	}
+	return true
+}
+
+// shuttingDown reports whether the App being served has started to
+// shut down.
+func (a *App) shuttingDown() bool {
+	ch := a.stopping()
+	if ch == nil {
+		return false
+	}
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+package buffalo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getHealth(a *App, path string) (int, HealthReport) {
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+	var report HealthReport
+	json.NewDecoder(res.Body).Decode(&report)
+	return res.Code, report
+}
+
+func Test_HealthChecks(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Health: HealthOptions{Timeout: 20 * time.Millisecond, CacheFor: -1}})
+	// the middleware of the App doesn't run for the probes
+	a.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			return c.Error(401, errors.New("unauthorized"))
+		}
+	})
+
+	code, report := getHealth(a, "/healthz")
+	r.Equal(200, code)
+	r.Equal("ok", report.Status)
+
+	code, report = getHealth(a, "/readyz")
+	r.Equal(200, code)
+	r.True(report.OK())
+
+	var dbErr atomic.Value
+	dbErr.Store("")
+	a.Group("/admin").HealthChecks.Register("db", func(ctx context.Context) error {
+		if msg := dbErr.Load().(string); msg != "" {
+			return errors.New(msg)
+		}
+		return nil
+	})
+	a.HealthChecks.Register("cache", func(ctx context.Context) error {
+		return nil
+	})
+	code, report = getHealth(a, "/readyz")
+	r.Equal(200, code)
+	r.Equal("ok", report.Checks["db"].Status)
+	r.Equal("ok", report.Checks["cache"].Status)
+
+	dbErr.Store("connection refused")
+	code, report = getHealth(a, "/readyz")
+	r.Equal(503, code)
+	r.Equal("fail", report.Status)
+	r.Equal("connection refused", report.Checks["db"].Error)
+	r.Equal("ok", report.Checks["cache"].Status)
+
+	// liveness doesn't run the checks
+	code, _ = getHealth(a, "/healthz")
+	r.Equal(200, code)
+
+	a.HealthChecks.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	dbErr.Store("")
+	code, report = getHealth(a, "/readyz")
+	r.Equal(503, code)
+	r.Contains(report.Checks["slow"].Error, "timed out")
+}
+
+func Test_HealthChecks_Cache(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Health: HealthOptions{CacheFor: time.Hour}})
+	var runs int32
+	a.HealthChecks.Register("db", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		code, _ := getHealth(a, "/readyz")
+		r.Equal(200, code)
+	}
+	r.Equal(int32(1), atomic.LoadInt32(&runs))
+}
+
+func Test_HealthChecks_Options(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Health: HealthOptions{LivenessPath: "/live", ReadinessPath: "/ready"}})
+	code, _ := getHealth(a, "/live")
+	r.Equal(200, code)
+	code, _ = getHealth(a, "/ready")
+	r.Equal(200, code)
+	code, _ = getHealth(a, "/healthz")
+	r.Equal(404, code)
+
+	a = New(Options{Health: HealthOptions{Disable: true}})
+	code, _ = getHealth(a, "/healthz")
+	r.Equal(404, code)
+}
@@ -0,0 +1,70 @@
+package buffalo
+
+// Key is a typed key for a value on a Context, used with Get and Set
+// instead of asserting the type of Context.Get. The value is set on the
+// Context with the name of the Key, so it's still available in
+// templates. Names should be prefixed, such as "auth.user", to keep
+// clear of the keys of other packages.
+/*
+	var CurrentUser = buffalo.NewKey[*models.User]("auth.current_user")
+
+	buffalo.Set(c, CurrentUser, u)
+	u, ok := buffalo.Get(c, CurrentUser)
+*/
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a Key for values of type T, set with the name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name of the Key, that its values are set with.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+func (k Key[T]) String() string {
+	return k.name
+}
+
+// Set the value of the Key on the Context.
+func Set[T any](c Context, k Key[T], v T) {
+	c.Set(k.name, v)
+}
+
+// Get returns the value of the Key on the Context, and whether it was
+// set. Values of another type, set with the same name, are reported as
+// not set rather than panicking.
+func Get[T any](c Context, k Key[T]) (T, bool) {
+	v, ok := c.Get(k.name).(T)
+	return v, ok
+}
+
+// GetOr returns the value of the Key on the Context, or def if it
+// isn't set.
+func GetOr[T any](c Context, k Key[T], def T) T {
+	if v, ok := Get(c, k); ok {
+		return v
+	}
+	return def
+}
+
+// The Keys of the values buffalo sets on every Context. They are also
+// set as the strings "env", "routes", "current_route", and "session",
+// for templates and older code.
+var (
+	// EnvKey is the environment the App is running in.
+	EnvKey = NewKey[string]("env")
+	// RoutesKey is the RouteList of the App.
+	RoutesKey = NewKey[RouteList]("routes")
+	// CurrentRouteKey is the RouteInfo of the route being handled.
+	CurrentRouteKey = NewKey[RouteInfo]("current_route")
+	// SessionKey is the Session of the request.
+	SessionKey = NewKey[*Session]("session")
+	// TenantKey is the Tenant of the request, see TenantFrom.
+	TenantKey = NewKey[*Tenant]("tenant")
+	// TranslatorKey is the Translator that Context.T uses.
+	TranslatorKey = NewKey[Translator]("translator")
+)
@@ -0,0 +1,56 @@
+package buffalo
+
+import (
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Key_GetSet(t *testing.T) {
+	r := require.New(t)
+	count := NewKey[int]("test.count")
+	r.Equal("test.count", count.Name())
+
+	c := &DefaultContext{}
+	_, ok := Get(c, count)
+	r.False(ok)
+	r.Equal(3, GetOr(c, count, 3))
+
+	Set(c, count, 1)
+	n, ok := Get(c, count)
+	r.True(ok)
+	r.Equal(1, n)
+	r.Equal(1, c.Get("test.count"))
+
+	// a value of another type, set with the same name
+	c.Set("test.count", "one")
+	_, ok = Get(c, count)
+	r.False(ok)
+}
+
+func Test_Key_BuiltIn(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.Options.Env = "test"
+	a.GET("/users/{id}", func(c Context) error {
+		env, ok := Get(c, EnvKey)
+		r.True(ok)
+		r.Equal("test", env)
+
+		ri, _ := Get(c, CurrentRouteKey)
+		r.Equal("/users/{id}", ri.Path)
+
+		routes, _ := Get(c, RoutesKey)
+		r.Len(routes, 1)
+
+		s, ok := Get(c, SessionKey)
+		r.True(ok)
+		r.Equal(c.Session(), s)
+
+		_, ok = Get(c, TenantKey)
+		r.False(ok)
+		return nil
+	})
+	r.Equal(200, willie.New(a).Request("/users/1").Get().Code)
+}
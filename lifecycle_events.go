@@ -0,0 +1,51 @@
+package buffalo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/buffalo/events"
+)
+
+var (
+	// AppStarted is emitted once the App is being served, see Serve.
+	AppStarted = events.NewTopic[*App]("app:start")
+	// AppStopped is emitted once the App being served has shut down.
+	AppStopped = events.NewTopic[*App]("app:stop")
+	// RequestFinished is emitted once each request has been handled, on
+	// the request's goroutine, so listeners should be quick.
+	RequestFinished = events.NewTopic[RequestFinish]("request:finish")
+)
+
+// RequestFinish is the Data of RequestFinished events.
+type RequestFinish struct {
+	Request  *http.Request
+	Route    RouteInfo
+	Status   int
+	Size     int
+	Duration time.Duration
+	// Error returned by the Handler, before the error handlers handled
+	// it, if any.
+	Error error
+}
+
+// requestFinished emits the RequestFinished event of a request, res is
+// the response it was given.
+func requestFinished(info RouteInfo, res http.ResponseWriter, req *http.Request, start time.Time, err error) {
+	if !events.Listening(RequestFinished.Kind) {
+		return
+	}
+	if info.name != nil {
+		info.PathName = *info.name
+	}
+	e := RequestFinish{
+		Request:  req,
+		Route:    info,
+		Duration: time.Since(start),
+		Error:    err,
+	}
+	if rw, ok := res.(ResponseWriter); ok {
+		e.Status, e.Size = rw.Status(), rw.Size()
+	}
+	RequestFinished.Emit(e)
+}
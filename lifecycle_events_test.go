@@ -0,0 +1,80 @@
+package buffalo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestFinished(t *testing.T) {
+	r := require.New(t)
+
+	var got []RequestFinish
+	del := RequestFinished.Listen(func(e RequestFinish) {
+		got = append(got, e)
+	})
+	defer del()
+
+	a := New(Options{})
+	a.GET("/ok", func(c Context) error {
+		return c.Render(201, render.String("created"))
+	}).Name("okPath")
+	a.GET("/boom", func(c Context) error {
+		return c.Error(422, errors.New("boom"))
+	})
+
+	w := willie.New(a)
+	w.Request("/ok").Get()
+	w.Request("/boom").Get()
+	r.Len(got, 2)
+
+	r.Equal(201, got[0].Status)
+	r.Equal(7, got[0].Size)
+	r.Equal("okPath", got[0].Route.PathName)
+	r.Equal("/ok", got[0].Request.URL.Path)
+	r.NoError(got[0].Error)
+	r.True(got[0].Duration > 0)
+
+	r.Equal(422, got[1].Status)
+	r.Error(got[1].Error)
+}
+
+func Test_AppStarted_AppStopped(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	kinds := make(chan string, 2)
+	defer AppStarted.Listen(func(app *App) {
+		if app == a {
+			kinds <- "start"
+		}
+	})()
+	defer AppStopped.Listen(func(app *App) {
+		if app == a {
+			kinds <- "stop"
+		}
+	})()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+	select {
+	case k := <-kinds:
+		r.Equal("start", k)
+	case <-time.After(time.Second):
+		r.Fail("app:start wasn't emitted")
+	}
+	cancel()
+	r.NoError(<-served)
+	r.Equal("stop", <-kinds)
+}
@@ -0,0 +1,66 @@
+package buffalo
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// listen on an address, which is either a TCP address, such as ":3000",
+// "unix:" and the path of a unix socket, "fd:" and the number of an
+// open file descriptor, such as one passed by launchd, or "systemd"
+// for the first socket passed by systemd socket activation.
+func (a *App) listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		return listenUnix(strings.TrimPrefix(addr, "unix:"), a.SocketMode)
+	case strings.HasPrefix(addr, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(addr, "fd:"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file descriptor %q", addr)
+		}
+		return listenFD(fd)
+	case addr == "systemd":
+		if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+			return nil, errors.New("no sockets were passed by systemd")
+		}
+		if n, _ := strconv.Atoi(os.Getenv("LISTEN_FDS")); n < 1 {
+			return nil, errors.New("no sockets were passed by systemd")
+		}
+		// systemd passes sockets starting at file descriptor 3
+		return listenFD(3)
+	}
+	ln, err := net.Listen("tcp", addr)
+	return ln, errors.WithStack(err)
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// remove the socket left behind by a process that didn't exit cleanly
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			ln.Close()
+			return nil, errors.WithStack(err)
+		}
+	}
+	return ln, nil
+}
+
+func listenFD(fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), "fd:"+strconv.Itoa(fd))
+	if f == nil {
+		return nil, errors.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	return ln, errors.Wrapf(err, "could not listen on file descriptor %d", fd)
+}
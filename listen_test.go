@@ -0,0 +1,78 @@
+package buffalo
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Serve_Unix(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "buffalo-socket")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "app.sock")
+
+	a := New(Options{Addr: "unix:" + sock, SocketMode: 0600})
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("unix"))
+	})
+
+	ln, err := a.listen(a.Addr)
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+
+	fi, err := os.Stat(sock)
+	r.NoError(err)
+	r.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	c := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", sock)
+		},
+	}}
+	res, err := c.Get("http://unix/")
+	r.NoError(err)
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	r.Equal("unix", string(b))
+
+	cancel()
+	r.NoError(<-served)
+	_, err = os.Stat(sock)
+	r.True(os.IsNotExist(err))
+}
+
+func Test_App_Listen_FD(t *testing.T) {
+	r := require.New(t)
+
+	tl, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer tl.Close()
+	f, err := tl.(*net.TCPListener).File()
+	r.NoError(err)
+
+	a := New(Options{})
+	ln, err := a.listen("fd:" + strconv.Itoa(int(f.Fd())))
+	r.NoError(err)
+	r.Equal(tl.Addr().String(), ln.Addr().String())
+	ln.Close()
+
+	_, err = a.listen("fd:nope")
+	r.Error(err)
+	_, err = a.listen("systemd")
+	r.Error(err)
+}
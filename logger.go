@@ -1,6 +1,10 @@
 package buffalo
 
-import "github.com/Sirupsen/logrus"
+import (
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+)
 
 // Logger interface is used throughout Buffalo
 // apps to log a whole manner of things.
@@ -32,3 +36,19 @@ func NewLogger(level string) Logger {
 	l.Level, _ = logrus.ParseLevel(level)
 	return &multiLogger{Loggers: []logrus.FieldLogger{l}}
 }
+
+// DebugEnabled reports whether l logs at the debug level, so work that
+// only feeds debug logs can be skipped. Loggers not made by NewLogger
+// can say so with a DebugEnabled() bool method, otherwise they are
+// assumed to.
+func DebugEnabled(l Logger) bool {
+	if d, ok := l.(interface{ DebugEnabled() bool }); ok {
+		return d.DebugEnabled()
+	}
+	return true
+}
+
+// logrusLevel reads the level of l, which SetLevel may be changing.
+func logrusLevel(l *logrus.Logger) logrus.Level {
+	return logrus.Level(atomic.LoadUint32((*uint32)(&l.Level)))
+}
@@ -0,0 +1,50 @@
+package buffalo
+
+import (
+	"time"
+
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/gobuffalo/buffalo/render"
+)
+
+// LongPoll waits for the next event of the given kind, the topic, on
+// the events bus and renders it as JSON. If no event arrives within the
+// timeout a 204 is sent, and the client should simply poll again. If
+// the client goes away the wait is abandoned. Every request polling a
+// topic receives each event, so this is a simple alternative to
+// websockets for updates that don't happen very often.
+/*
+	a.GET("/orders/{id}/status", func(c buffalo.Context) error {
+		return c.LongPoll("order:"+c.Param("id"), 30*time.Second)
+	})
+
+	// elsewhere
+	events.Emit(events.Event{Kind: "order:42", Payload: events.Payload{"status": "shipped"}})
+*/
+func (d *DefaultContext) LongPoll(topic string, timeout time.Duration) error {
+	ch := make(chan events.Event, 1)
+	del := events.Listen(topic, func(e events.Event) {
+		select {
+		case ch <- e:
+		default:
+			// already got one, the rest will go to the next poll
+		}
+	})
+	defer del()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case e := <-ch:
+		return d.Render(200, render.JSON(map[string]interface{}{
+			"kind":    e.Kind,
+			"message": e.Message,
+			"payload": e.Payload,
+		}))
+	case <-t.C:
+		d.Response().WriteHeader(204)
+		return nil
+	case <-d.Request().Context().Done():
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package buffalo
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func longPollApp() *App {
+	a := New(Options{})
+	a.GET("/poll/{topic}", func(c Context) error {
+		return c.LongPoll("test:"+c.Param("topic"), 200*time.Millisecond)
+	})
+	return a
+}
+
+func Test_DefaultContext_LongPoll(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(longPollApp())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		events.Emit(events.Event{Kind: "test:a", Payload: events.Payload{"n": 1}})
+	}()
+	res := w.Request("/poll/a").Get()
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), `"kind":"test:a"`)
+	r.Contains(res.Body.String(), `"n":1`)
+}
+
+func Test_DefaultContext_LongPoll_FanOut(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(longPollApp())
+
+	codes := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			codes <- w.Request("/poll/b").Get().Code
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	events.Emit(events.Event{Kind: "test:b"})
+	for i := 0; i < 3; i++ {
+		r.Equal(200, <-codes)
+	}
+}
+
+func Test_DefaultContext_LongPoll_Timeout(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(longPollApp())
+
+	res := w.Request("/poll/c").Get()
+	r.Equal(204, res.Code)
+	r.Empty(res.Body.String())
+}
+
+func Test_DefaultContext_LongPoll_Disconnect(t *testing.T) {
+	r := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/poll/d", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	longPollApp().ServeHTTP(res, req)
+	r.True(time.Since(start) < 100*time.Millisecond)
+}
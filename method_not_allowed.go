@@ -0,0 +1,25 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// MethodNotAllowedHandler is the default ErrorHandler for 405
+// responses, which are sent when a route matches the path of a
+// request, but not its method. The Allow header has already been
+// set to the methods of the path when it runs.
+func MethodNotAllowedHandler(status int, err error, c Context) error {
+	res := c.Response()
+	ct := strings.ToLower(c.Request().Header.Get("Content-Type"))
+	if isJSON(ct) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(status)
+		return json.NewEncoder(res).Encode(errorJSON(err.Error(), status, errorReferences(c)))
+	}
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	res.WriteHeader(status)
+	_, err = res.Write([]byte(http.StatusText(status)))
+	return err
+}
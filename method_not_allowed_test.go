@@ -0,0 +1,45 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_MethodNotAllowed(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/users", func(c Context) error {
+		return c.Render(200, render.String("users"))
+	})
+	a.POST("/users", func(c Context) error {
+		return c.Render(201, render.String("created"))
+	})
+
+	res := serve(a, "DELETE", "/users")
+	r.Equal(405, res.Code)
+	r.Equal("GET, HEAD, POST, OPTIONS", res.Header().Get("Allow"))
+	r.Equal("Method Not Allowed", res.Body.String())
+
+	req, _ := http.NewRequest("DELETE", "/users", nil)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal(405, rec.Code)
+	r.Contains(rec.Body.String(), `"code":405`)
+
+	r.Equal(404, serve(a, "DELETE", "/nope").Code)
+
+	a.ErrorHandlers[405] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, err = c.Response().Write([]byte("custom " + c.Response().Header().Get("Allow")))
+		return err
+	}
+	res = serve(a, "PUT", "/users")
+	r.Equal(405, res.Code)
+	r.Equal("custom GET, HEAD, POST, OPTIONS", res.Body.String())
+}
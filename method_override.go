@@ -1,9 +1,9 @@
 package buffalo
 
 import (
+	"mime"
 	"net/http"
-
-	"github.com/markbates/going/defaults"
+	"strings"
 )
 
 // MethodOverride can be be overridden to a user specified
@@ -13,13 +13,51 @@ var MethodOverride http.HandlerFunc
 
 // MethodOverrideFunc is the default implementation for the
 // MethodOverride. By default it will look for a form value
-// name `_method` and change the request method if that is
-// present and the original request is of type "POST". This is
-// added automatically when using `Automatic` Buffalo, unless
-// an alternative is defined in the Options.
-func MethodOverrideFunc(res http.ResponseWriter, req *http.Request) {
-	if req.Method == "POST" {
-		req.Method = defaults.String(req.FormValue("_method"), "POST")
+// name `_method`, or an "X-HTTP-Method-Override" header, and
+// change the request method if that is present and the original
+// request is of type "POST". Only "PUT", "PATCH", and "DELETE"
+// are allowed. This is added automatically when using `Automatic`
+// Buffalo, unless an alternative is defined in the Options.
+var MethodOverrideFunc = NewMethodOverride("PUT", "PATCH", "DELETE")
+
+// NewMethodOverride returns a MethodOverride that lets "POST" requests
+// be changed into any of the allowed methods, so RESTful routes can be
+// used from plain HTML forms. The `_method` form value is checked first,
+// then the "X-HTTP-Method-Override" header. Anything that isn't on the
+// allowed list is ignored.
+//
+// It runs before routing, so `_method` is only read from the query, and
+// from "application/x-www-form-urlencoded" bodies. Multipart bodies are
+// left unread, so the route's limits, and StreamFile, still apply to
+// them: multipart forms need `_method` in their action's query instead,
+// such as action="/widgets/1?_method=PUT".
+/*
+	app := buffalo.Automatic(buffalo.Options{
+		MethodOverride: buffalo.NewMethodOverride("PUT", "DELETE"),
+	})
+*/
+func NewMethodOverride(allowed ...string) http.HandlerFunc {
+	methods := map[string]bool{}
+	for _, m := range allowed {
+		methods[strings.ToUpper(m)] = true
+	}
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			return
+		}
+		var m string
+		mt, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if mt == "application/x-www-form-urlencoded" {
+			m = req.FormValue("_method")
+		} else {
+			m = req.URL.Query().Get("_method")
+		}
+		if m == "" {
+			m = req.Header.Get("X-HTTP-Method-Override")
+		}
+		if m = strings.ToUpper(strings.TrimSpace(m)); methods[m] {
+			req.Method = m
+		}
 		req.Form.Del("_method")
 		req.PostForm.Del("_method")
 	}
@@ -1,7 +1,14 @@
 package buffalo
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gobuffalo/buffalo/render"
@@ -22,3 +29,88 @@ func Test_MethodOverride(t *testing.T) {
 	r.Equal(200, res.Code)
 	r.Equal("you put me!", res.Body.String())
 }
+
+func Test_MethodOverride_Header(t *testing.T) {
+	r := require.New(t)
+
+	a := Automatic(Options{})
+	a.DELETE("/", func(c Context) error {
+		return c.Render(200, render.String("you deleted me!"))
+	})
+
+	w := willie.New(a)
+	req := w.Request("/")
+	req.Headers["X-HTTP-Method-Override"] = "delete"
+	res := req.Post(nil)
+	r.Equal(200, res.Code)
+	r.Equal("you deleted me!", res.Body.String())
+}
+
+func Test_MethodOverride_Allowlist(t *testing.T) {
+	r := require.New(t)
+
+	a := Automatic(Options{
+		MethodOverride: NewMethodOverride("PUT"),
+	})
+	h := func(c Context) error {
+		return c.Render(200, render.String(c.Request().Method))
+	}
+	a.GET("/", h)
+	a.POST("/", h)
+	a.DELETE("/", func(c Context) error {
+		return c.Render(200, render.String("you deleted me!"))
+	})
+
+	w := willie.New(a)
+	res := w.Request("/").Post(url.Values{"_method": []string{"DELETE"}})
+	r.Equal(200, res.Code)
+	r.Equal("POST", res.Body.String())
+
+	// only POST requests can be overridden
+	req := w.Request("/")
+	req.Headers["X-HTTP-Method-Override"] = "DELETE"
+	r.Equal("GET", req.Get().Body.String())
+}
+
+// Test_MethodOverride_Multipart tests that multipart bodies aren't read
+// before routing, so the route's limits, and StreamFile, apply to them
+func Test_MethodOverride_Multipart(t *testing.T) {
+	r := require.New(t)
+
+	a := Automatic(Options{})
+	a.POST("/upload", func(c Context) error {
+		if _, err := c.File("avatar"); err != nil {
+			return c.Error(413, err)
+		}
+		return c.Render(200, render.String("ok"))
+	}).MaxBody(10)
+	r.Equal(413, serveUpload(a, nil, map[string]string{"avatar": strings.Repeat("x", 5000)}).Code)
+
+	a = Automatic(Options{})
+	a.POST("/upload", func(c Context) error {
+		f, err := c.StreamFile("video", func(f File) (io.Writer, error) {
+			return ioutil.Discard, nil
+		})
+		if err != nil {
+			return c.Error(422, err)
+		}
+		return c.Render(200, render.String(fmt.Sprintf("%d", f.Size)))
+	})
+	res := serveUpload(a, nil, map[string]string{"video": "meow"})
+	r.Equal(200, res.Code)
+	r.Equal("4", res.Body.String())
+
+	// the override can still be given in the query
+	a.PUT("/upload", func(c Context) error {
+		return c.Render(200, render.String(c.Request().Method))
+	})
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "mark")
+	mw.Close()
+	req := httptest.NewRequest("POST", "/upload?_method=PUT", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal("PUT", rec.Body.String())
+}
@@ -0,0 +1,232 @@
+package buffalo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	sizeBuckets    = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Metrics returns a Handler that serves the App's request metrics in the
+// Prometheus text format. Requests are counted from the moment Metrics is
+// first called, so call it while setting up the App.
+//
+// Requests are labeled with their method, their route pattern, such as
+// "/users/{user_id}", and the class of their status, such as "2xx".
+// Requests that don't match any route are labeled "NOT_FOUND", so
+// scanners can't blow up the number of series.
+//
+// The following metrics are exported:
+//	buffalo_http_requests_total
+//	buffalo_http_request_duration_seconds
+//	buffalo_http_response_size_bytes
+//	buffalo_http_requests_in_flight
+/*
+	a.GET("/metrics", a.Metrics())
+*/
+func (a *App) Metrics() Handler {
+	r := a
+	if a.root != nil {
+		r = a.root
+	}
+	r.moot.Lock()
+	m, _ := r.metrics.Load().(*metrics)
+	if m == nil {
+		m = newMetrics()
+		r.metrics.Store(m)
+	}
+	r.moot.Unlock()
+	return func(c Context) error {
+		res := c.Response()
+		res.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		res.WriteHeader(200)
+		_, err := m.WriteTo(res)
+		return err
+	}
+}
+
+// requestMetrics returns the metrics being collected for the App, if any.
+// It is called for every request, so it doesn't take any locks.
+func (a *App) requestMetrics() *metrics {
+	r := a
+	if a.root != nil {
+		r = a.root
+	}
+	if r.metrics == nil {
+		return nil
+	}
+	m, _ := r.metrics.Load().(*metrics)
+	return m
+}
+
+// measure starts measuring a request, if metrics are being collected.
+// The returned func must be called once the request has been served.
+func (a *App) measure(route string, res http.ResponseWriter, req *http.Request) func() {
+	m := a.requestMetrics()
+	if m == nil {
+		return func() {}
+	}
+	now := time.Now()
+	m.start()
+	return func() {
+		// the status and size are unknown for writers that aren't ours,
+		// so they count as a 200 of no size
+		status, size := 0, 0
+		if ws, ok := res.(*buffaloResponse); ok {
+			status, size = ws.status, ws.size
+		}
+		m.finish(req.Method, route, status, size, time.Now().Sub(now))
+	}
+}
+
+type metricKey struct {
+	method string
+	route  string
+	code   string
+}
+
+type routeMetrics struct {
+	count    uint64
+	duration *histogram
+	size     *histogram
+}
+
+type metrics struct {
+	inFlight int64
+	moot     *sync.Mutex
+	routes   map[metricKey]*routeMetrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		moot:   &sync.Mutex{},
+		routes: map[metricKey]*routeMetrics{},
+	}
+}
+
+func (m *metrics) start() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+func (m *metrics) finish(method, route string, status, size int, d time.Duration) {
+	atomic.AddInt64(&m.inFlight, -1)
+	if status == 0 {
+		status = 200
+	}
+	k := metricKey{
+		method: method,
+		route:  route,
+		code:   strconv.Itoa(status/100) + "xx",
+	}
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	rm, ok := m.routes[k]
+	if !ok {
+		rm = &routeMetrics{
+			duration: newHistogram(latencyBuckets),
+			size:     newHistogram(sizeBuckets),
+		}
+		m.routes[k] = rm
+	}
+	rm.count++
+	rm.duration.observe(d.Seconds())
+	rm.size.observe(float64(size))
+}
+
+// WriteTo writes the metrics out in the Prometheus text format.
+func (m *metrics) WriteTo(w io.Writer) (int64, error) {
+	m.moot.Lock()
+	keys := make([]metricKey, 0, len(m.routes))
+	for k := range m.routes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+
+	bb := &bytes.Buffer{}
+	bb.WriteString("# HELP buffalo_http_requests_total Total number of HTTP requests.\n")
+	bb.WriteString("# TYPE buffalo_http_requests_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(bb, "buffalo_http_requests_total{%s} %d\n", k.labels(), m.routes[k].count)
+	}
+	bb.WriteString("# HELP buffalo_http_request_duration_seconds HTTP request latencies in seconds.\n")
+	bb.WriteString("# TYPE buffalo_http_request_duration_seconds histogram\n")
+	for _, k := range keys {
+		m.routes[k].duration.write(bb, "buffalo_http_request_duration_seconds", k.labels())
+	}
+	bb.WriteString("# HELP buffalo_http_response_size_bytes HTTP response sizes in bytes.\n")
+	bb.WriteString("# TYPE buffalo_http_response_size_bytes histogram\n")
+	for _, k := range keys {
+		m.routes[k].size.write(bb, "buffalo_http_response_size_bytes", k.labels())
+	}
+	m.moot.Unlock()
+
+	bb.WriteString("# HELP buffalo_http_requests_in_flight Number of HTTP requests being served.\n")
+	bb.WriteString("# TYPE buffalo_http_requests_in_flight gauge\n")
+	fmt.Fprintf(bb, "buffalo_http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	n, err := w.Write(bb.Bytes())
+	return int64(n), err
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf(`method="%s",route="%s",code="%s"`, escapeLabel(k.method), escapeLabel(k.route), k.code)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(bb *bytes.Buffer, name, labels string) {
+	for i, b := range h.bounds {
+		fmt.Fprintf(bb, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(b, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(bb, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(bb, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(bb, "%s_count{%s} %d\n", name, labels, h.count)
+}
@@ -0,0 +1,54 @@
+package buffalo
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Metrics(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/metrics", a.Metrics())
+	g := a.Group("/users")
+	g.GET("/{id}", func(c Context) error {
+		return c.Render(200, render.String("hello"))
+	})
+	g.POST("/{id}", func(c Context) error {
+		return c.Error(422, errors.New("nope"))
+	})
+
+	w := willie.New(a)
+	w.Request("/users/1").Get()
+	w.Request("/users/2").Get()
+	w.Request("/users/1").Post(nil)
+	w.Request("/nothing/here").Get()
+
+	res := w.Request("/metrics").Get()
+	r.Equal(200, res.Code)
+	r.Contains(res.Header().Get("Content-Type"), "version=0.0.4")
+
+	body := res.Body.String()
+	r.Contains(body, `buffalo_http_requests_total{method="GET",route="/users/{id}",code="2xx"} 2`)
+	r.Contains(body, `buffalo_http_requests_total{method="POST",route="/users/{id}",code="4xx"} 1`)
+	r.Contains(body, `buffalo_http_requests_total{method="GET",route="NOT_FOUND",code="4xx"} 1`)
+	r.Contains(body, `buffalo_http_response_size_bytes_bucket{method="GET",route="/users/{id}",code="2xx",le="100"} 2`)
+	r.Contains(body, `buffalo_http_request_duration_seconds_count{method="GET",route="/users/{id}",code="2xx"} 2`)
+	r.Contains(body, "buffalo_http_requests_in_flight 1")
+	r.NotContains(body, "/users/1")
+}
+
+func Test_App_Metrics_OtherWriter(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.Metrics()
+	r.NotPanics(func() {
+		a.measure("/", httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))()
+	})
+}
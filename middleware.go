@@ -3,7 +3,10 @@ package buffalo
 import (
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 // MiddlewareFunc defines the interface for a piece of Buffalo
@@ -23,42 +26,375 @@ type MiddlewareFunc func(Handler) Handler
 // Use the specified Middleware for the App.
 // When defined on an `*App` the specified middleware will be
 // inherited by any `Group` calls that are made on that on
-// the App.
-func (a *App) Use(mw ...MiddlewareFunc) {
-	a.Middleware.Use(mw...)
+// the App. The middleware can be skipped for, or limited to,
+// some handlers straight away.
+/*
+	a.Use(CSRF).Skip(WebhookHandler)
+	a.Use(Authorize).Only(AdminHandler)
+*/
+func (a *App) Use(mw ...MiddlewareFunc) UsedMiddleware {
+	return a.Middleware.Use(mw...)
+}
+
+// UsedMiddleware is the middleware just added by a call to Use. It
+// can be kept to skip, limit, remove, or replace that middleware later
+// on, even when other middleware in the stack was made by the same
+// function.
+/*
+	long := a.Use(middleware.Timeout(time.Minute))
+	a.Use(middleware.Timeout(time.Second))
+	long.Only(ExportHandler)
+*/
+type UsedMiddleware struct {
+	ms      *MiddlewareStack
+	entries []*middlewareEntry
+}
+
+// Skip the middleware for the specified Handlers, see
+// MiddlewareStack.Skip.
+func (u UsedMiddleware) Skip(handlers ...Handler) UsedMiddleware {
+	for _, e := range u.entries {
+		u.ms.skip(e, handlers)
+	}
+	return u
+}
+
+// Only run the middleware for the specified Handlers, see
+// MiddlewareStack.Only.
+func (u UsedMiddleware) Only(handlers ...Handler) UsedMiddleware {
+	for _, e := range u.entries {
+		u.ms.onlyFor(e, handlers)
+	}
+	return u
+}
+
+// Remove the middleware from the stack it was added to.
+func (u UsedMiddleware) Remove() {
+	found := map[*middlewareEntry]bool{}
+	for _, e := range u.entries {
+		found[e] = true
+	}
+	u.ms.removeIf(func(e *middlewareEntry) bool {
+		return found[e]
+	})
+}
+
+// Replace the middleware with another piece of middleware.
+func (u UsedMiddleware) Replace(mw MiddlewareFunc) UsedMiddleware {
+	for _, e := range u.entries {
+		e.mw = mw
+	}
+	return u
+}
+
+// In returns the same middleware in the stack of a Group, which gets
+// its own copy of the middleware when it is created.
+/*
+	csrf := a.Use(CSRF)
+	api := a.Group("/api")
+	csrf.In(api.Middleware).Remove()
+*/
+func (u UsedMiddleware) In(ms *MiddlewareStack) UsedMiddleware {
+	origins := map[*middlewareEntry]bool{}
+	for _, e := range u.entries {
+		origins[e.origin] = true
+	}
+	n := UsedMiddleware{ms: ms}
+	for _, e := range ms.stack {
+		if origins[e.origin] {
+			n.entries = append(n.entries, e)
+		}
+	}
+	return n
+}
+
+// Priority sets the priority of the middleware. Middleware with a
+// lower priority runs first, middleware with the same priority runs in
+// the order it was added. The default priority is 0.
+/*
+	a.Middleware.UseNamed("recover", Recover).Priority(-100)
+*/
+func (u UsedMiddleware) Priority(p int) UsedMiddleware {
+	for _, e := range u.entries {
+		e.priority = p
+	}
+	u.ms.sort()
+	return u
+}
+
+// middlewareEntry is a piece of middleware in a MiddlewareStack.
+type middlewareEntry struct {
+	name     string
+	priority int
+	mw       MiddlewareFunc
+	// origin is the entry this one was copied from, or itself, so
+	// UsedMiddleware can find its entries in the stack of a Group.
+	origin *middlewareEntry
+}
+
+func newMiddlewareEntry(mw MiddlewareFunc, priority int) *middlewareEntry {
+	e := &middlewareEntry{mw: mw, priority: priority}
+	e.origin = e
+	return e
+}
+
+// Name is the name the middleware was registered with, or the name of
+// its function if it doesn't have one.
+func (e *middlewareEntry) Name() string {
+	if e.name != "" {
+		return e.name
+	}
+	return funcKey(e.mw)
 }
 
 // MiddlewareStack manages the middleware stack for an App/Group.
+// A Group gets a copy of its parent's stack when it is created, so
+// middleware added to, removed from, or skipped on the parent after
+// that doesn't change the Group, and changes made to the Group never
+// change the parent. Middleware runs in order of priority, and then in
+// the order it was added.
+//
+// Skip, Only, Remove, and Replace find the middleware given to them by
+// its function, so two pieces of middleware made by the same function,
+// such as two Timeouts, are both changed. Use the UsedMiddleware
+// returned by Use to change just one of them.
 type MiddlewareStack struct {
-	stack []MiddlewareFunc
-	skips map[string]bool
+	stack []*middlewareEntry
+	// sorted is the stack in the order it runs, sorted whenever the
+	// stack changes, rather than for every request.
+	sorted []*middlewareEntry
+	// skips and only hold the keys, see funcKey, of the Handlers each
+	// entry is skipped for, or only runs for.
+	skips map[*middlewareEntry]map[string]bool
+	only  map[*middlewareEntry]map[string]bool
+	app   *App
 }
 
 func (ms *MiddlewareStack) clone() *MiddlewareStack {
 	n := newMiddlewareStack()
-	for _, s := range ms.stack {
-		n.stack = append(n.stack, s)
-	}
-	for k, v := range ms.skips {
-		n.skips[k] = v
+	for _, e := range ms.stack {
+		ce := *e
+		n.stack = append(n.stack, &ce)
+		if hs, ok := ms.skips[e]; ok {
+			n.skips[&ce] = copyKeys(hs)
+		}
+		if hs, ok := ms.only[e]; ok {
+			n.only[&ce] = copyKeys(hs)
+		}
 	}
+	n.sort()
 	return n
 }
 
+func copyKeys(m map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// sort the stack into the order it runs.
+func (ms *MiddlewareStack) sort() {
+	sorted := append([]*middlewareEntry{}, ms.stack...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].priority < sorted[j].priority
+	})
+	ms.sorted = sorted
+}
+
 // Clear wipes out the current middleware stack for the App/Group,
 // any middleware previously defined will be removed leaving an empty
 // middleware stack.
 func (ms *MiddlewareStack) Clear() {
-	ms.stack = []MiddlewareFunc{}
-	ms.skips = map[string]bool{}
+	ms.stack = []*middlewareEntry{}
+	ms.sorted = []*middlewareEntry{}
+	ms.skips = map[*middlewareEntry]map[string]bool{}
+	ms.only = map[*middlewareEntry]map[string]bool{}
 }
 
 // Use the specified Middleware for the App.
 // When defined on an `*App` the specified middleware will be
 // inherited by any `Group` calls that are made on that on
 // the App.
-func (ms *MiddlewareStack) Use(mw ...MiddlewareFunc) {
-	ms.stack = append(ms.stack, mw...)
+func (ms *MiddlewareStack) Use(mw ...MiddlewareFunc) UsedMiddleware {
+	u := UsedMiddleware{ms: ms}
+	for _, m := range mw {
+		e := newMiddlewareEntry(m, 0)
+		ms.stack = append(ms.stack, e)
+		u.entries = append(u.entries, e)
+	}
+	ms.sort()
+	return u
+}
+
+// UseNamed adds a piece of middleware under a name, so other
+// middleware can be inserted around it, and so it shows up by that
+// name in Chain and List.
+/*
+	a.Middleware.UseNamed("csrf", CSRF)
+	a.Middleware.InsertBefore("csrf", ParseJSON)
+*/
+func (ms *MiddlewareStack) UseNamed(name string, mw MiddlewareFunc) UsedMiddleware {
+	u := ms.Use(mw)
+	u.entries[0].name = name
+	return u
+}
+
+// InsertBefore inserts middleware right before the named middleware.
+// The inserted middleware is given the same priority, so they stay
+// together. It is an error if there is no middleware with that name.
+func (ms *MiddlewareStack) InsertBefore(name string, mw ...MiddlewareFunc) error {
+	return ms.insert(name, 0, mw)
+}
+
+// InsertAfter inserts middleware right after the named middleware, see
+// InsertBefore.
+func (ms *MiddlewareStack) InsertAfter(name string, mw ...MiddlewareFunc) error {
+	return ms.insert(name, 1, mw)
+}
+
+func (ms *MiddlewareStack) insert(name string, offset int, mw []MiddlewareFunc) error {
+	for i, e := range ms.stack {
+		if e.name != name {
+			continue
+		}
+		entries := []*middlewareEntry{}
+		for _, m := range mw {
+			entries = append(entries, newMiddlewareEntry(m, e.priority))
+		}
+		i += offset
+		stack := append([]*middlewareEntry{}, ms.stack[:i]...)
+		stack = append(stack, entries...)
+		ms.stack = append(stack, ms.stack[i:]...)
+		ms.sort()
+		return nil
+	}
+	return errors.Errorf("could not find middleware named %q", name)
+}
+
+// Only runs a piece of middleware for the specified Handlers, and
+// skips it for every other Handler. Calling Only again adds more
+// Handlers.
+/*
+	a.Middleware.Only(Authorize, AdminHandler, ReportsHandler)
+*/
+func (ms *MiddlewareStack) Only(mw MiddlewareFunc, handlers ...Handler) {
+	for _, e := range ms.find(mw) {
+		ms.onlyFor(e, handlers)
+	}
+}
+
+func (ms *MiddlewareStack) onlyFor(e *middlewareEntry, handlers []Handler) {
+	if ms.only[e] == nil {
+		ms.only[e] = map[string]bool{}
+	}
+	for _, h := range handlers {
+		ms.only[e][funcKey(h)] = true
+	}
+}
+
+// Remove pieces of middleware from the stack.
+/*
+	api := a.Group("/api")
+	api.Middleware.Remove(CSRF)
+*/
+func (ms *MiddlewareStack) Remove(mws ...MiddlewareFunc) {
+	found := map[*middlewareEntry]bool{}
+	for _, mw := range mws {
+		for _, e := range ms.find(mw) {
+			found[e] = true
+		}
+	}
+	ms.removeIf(func(e *middlewareEntry) bool {
+		return found[e]
+	})
+}
+
+// RemoveNamed removes the named pieces of middleware from the stack.
+func (ms *MiddlewareStack) RemoveNamed(names ...string) {
+	ns := map[string]bool{}
+	for _, n := range names {
+		ns[n] = true
+	}
+	ms.removeIf(func(e *middlewareEntry) bool {
+		return e.name != "" && ns[e.name]
+	})
+}
+
+func (ms *MiddlewareStack) removeIf(fn func(*middlewareEntry) bool) {
+	stack := []*middlewareEntry{}
+	for _, e := range ms.stack {
+		if fn(e) {
+			delete(ms.skips, e)
+			delete(ms.only, e)
+			continue
+		}
+		stack = append(stack, e)
+	}
+	ms.stack = stack
+	ms.sort()
+}
+
+// Chain returns the names of the middleware that will run for the
+// Handler, in the order they will run, after any skips have been
+// applied.
+func (ms *MiddlewareStack) Chain(h Handler) []string {
+	names := []string{}
+	for _, e := range ms.effective(h) {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// RouteMiddleware is the middleware that runs for a route.
+type RouteMiddleware struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Middleware []string `json:"middleware"`
+}
+
+// List returns the middleware Chain of every route in the App, each
+// worked out from the stack of the App or Group the route was added to.
+/*
+	for _, rm := range a.Middleware.List() {
+		fmt.Println(rm.Method, rm.Path, strings.Join(rm.Middleware, " -> "))
+	}
+*/
+func (ms *MiddlewareStack) List() []RouteMiddleware {
+	list := []RouteMiddleware{}
+	if ms.app == nil {
+		return list
+	}
+	for _, r := range ms.app.Routes() {
+		stack := ms
+		if r.app != nil {
+			stack = r.app.Middleware
+		}
+		list = append(list, RouteMiddleware{
+			Method:     r.Method,
+			Path:       r.Path,
+			Middleware: stack.Chain(r.Handler),
+		})
+	}
+	return list
+}
+
+// effective returns the middleware that runs for the Handler, in the
+// order it runs.
+func (ms *MiddlewareStack) effective(h Handler) []*middlewareEntry {
+	hk := funcKey(h)
+	entries := []*middlewareEntry{}
+	for _, e := range ms.sorted {
+		if ms.skips[e][hk] {
+			continue
+		}
+		if only, ok := ms.only[e]; ok && !only[hk] {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
 }
 
 // Skip a specified piece of middleware the specified Handlers.
@@ -86,57 +422,50 @@ func (ms *MiddlewareStack) Use(mw ...MiddlewareFunc) {
 	g.Middleware.Skip(SomeMiddleware, cr.Show)
 */
 func (ms *MiddlewareStack) Skip(mw MiddlewareFunc, handlers ...Handler) {
+	for _, e := range ms.find(mw) {
+		ms.skip(e, handlers)
+	}
+}
+
+func (ms *MiddlewareStack) skip(e *middlewareEntry, handlers []Handler) {
+	if ms.skips[e] == nil {
+		ms.skips[e] = map[string]bool{}
+	}
 	for _, h := range handlers {
-		key := funcKey(mw, h)
-		ms.skips[key] = true
+		ms.skips[e][funcKey(h)] = true
 	}
 }
 
 // Replace a piece of middleware with another piece of middleware. Great for
 // testing.
 func (ms *MiddlewareStack) Replace(mw1 MiddlewareFunc, mw2 MiddlewareFunc) {
-	m1k := funcKey(mw1)
-	stack := []MiddlewareFunc{}
-	for _, mw := range ms.stack {
-		if funcKey(mw) == m1k {
-			stack = append(stack, mw2)
-		} else {
-			stack = append(stack, mw)
-		}
+	for _, e := range ms.find(mw1) {
+		e.mw = mw2
 	}
-	ms.stack = stack
 }
 
 func (ms *MiddlewareStack) handler(h Handler) Handler {
-	if len(ms.stack) > 0 {
-		mh := func(_ Handler) Handler {
-			return h
-		}
-
-		tstack := []MiddlewareFunc{mh}
-
-		sl := len(ms.stack) - 1
-		for i := sl; i >= 0; i-- {
-			mw := ms.stack[i]
-			key := funcKey(mw, h)
-			if !ms.skips[key] {
-				tstack = append(tstack, mw)
-			}
-		}
+	return ms.around(h, h)
+}
 
-		for _, mw := range tstack {
-			h = mw(h)
-		}
-		return h
+// around wraps next in the middleware that runs for h, so h can be
+// wrapped without changing which middleware is skipped for it.
+func (ms *MiddlewareStack) around(h, next Handler) Handler {
+	entries := ms.effective(h)
+	for i := len(entries) - 1; i >= 0; i-- {
+		next = entries[i].mw(next)
 	}
-	return h
+	return next
 }
 
 func newMiddlewareStack(mws ...MiddlewareFunc) *MiddlewareStack {
-	return &MiddlewareStack{
-		stack: mws,
-		skips: map[string]bool{},
+	ms := &MiddlewareStack{
+		stack: []*middlewareEntry{},
+		skips: map[*middlewareEntry]map[string]bool{},
+		only:  map[*middlewareEntry]map[string]bool{},
 	}
+	ms.Use(mws...)
+	return ms
 }
 
 func funcKey(funcs ...interface{}) string {
@@ -159,3 +488,15 @@ func funcKey(funcs ...interface{}) string {
 }
 
 var keyMap = map[uintptr]string{}
+
+// find the entries of mw, see MiddlewareStack.
+func (ms *MiddlewareStack) find(mw MiddlewareFunc) []*middlewareEntry {
+	key := funcKey(mw)
+	entries := []*middlewareEntry{}
+	for _, e := range ms.stack {
+		if funcKey(e.mw) == key {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// AllocReport is a piece of buffalo.Middleware, for use in development,
+// that records how much memory each request allocated and how much
+// garbage collection happened while it ran. The numbers are added to the
+// request log as "allocs", "alloc_bytes", "gc_runs", and "gc_pause", and
+// are sent back to the browser in a "Server-Timing" trailer so they show
+// up in the developer tools.
+//
+// The numbers come from runtime.ReadMemStats deltas, so they will include
+// work done by any other requests running at the same time, and reading
+// them briefly stops the world. Outside of the "development" env this
+// middleware does nothing.
+/*
+	if ENV == "development" {
+		app.Use(middleware.AllocReport)
+	}
+*/
+func AllocReport(next buffalo.Handler) buffalo.Handler {
+	return func(c buffalo.Context) error {
+		if c.Env() != "development" {
+			return next(c)
+		}
+		res := c.Response()
+		res.Header().Add("Trailer", "Server-Timing")
+
+		before := &runtime.MemStats{}
+		runtime.ReadMemStats(before)
+		err := next(c)
+		after := &runtime.MemStats{}
+		runtime.ReadMemStats(after)
+
+		allocs := after.Mallocs - before.Mallocs
+		bytes := after.TotalAlloc - before.TotalAlloc
+		gcs := after.NumGC - before.NumGC
+		pause := float64(after.PauseTotalNs-before.PauseTotalNs) / 1e6
+
+		c.LogFields(map[string]interface{}{
+			"allocs":      allocs,
+			"alloc_bytes": bytes,
+			"gc_runs":     gcs,
+			"gc_pause":    pause,
+		})
+		res.Header().Set("Server-Timing", fmt.Sprintf(
+			`alloc;desc="%d allocs, %d bytes", gc;desc="%d runs";dur=%.3f`,
+			allocs, bytes, gcs, pause,
+		))
+		return err
+	}
+}
@@ -0,0 +1,34 @@
+package middleware_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AllocReport(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{Env: "development"})
+	a.Use(middleware.AllocReport)
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String(strings.Repeat("x", 1024)))
+	})
+
+	ts := httptest.NewServer(a)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	r.NoError(err)
+	defer res.Body.Close()
+	_, err = ioutil.ReadAll(res.Body)
+	r.NoError(err)
+	r.Contains(res.Trailer.Get("Server-Timing"), "allocs")
+}
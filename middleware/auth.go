@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrUnauthorized is returned, with a 401 status, when a request fails
+// BasicAuth or TokenAuth.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// BasicAuthValidator checks a username and password. The returned value
+// is set onto the Context as "current_user". Returning an error, or a
+// nil user, will fail the request with a 401.
+type BasicAuthValidator func(c buffalo.Context, username, password string) (interface{}, error)
+
+// TokenLookup finds the user for a bearer token. The returned value is
+// set onto the Context as "current_user". Returning an error, or a nil
+// user, will fail the request with a 401.
+type TokenLookup func(c buffalo.Context, token string) (interface{}, error)
+
+// BasicAuth returns a piece of buffalo.Middleware that protects requests
+// with HTTP Basic authentication, using the realm "Restricted".
+/*
+	admin := app.Group("/admin")
+	admin.Use(middleware.BasicAuth(middleware.BasicAuthUsers(map[string]string{
+		"admin": os.Getenv("ADMIN_PASSWORD"),
+	})))
+*/
+func BasicAuth(v BasicAuthValidator) buffalo.MiddlewareFunc {
+	return BasicAuthRealm("Restricted", v)
+}
+
+// BasicAuthRealm is the same as BasicAuth, but uses the given realm.
+func BasicAuthRealm(realm string, v BasicAuthValidator) buffalo.MiddlewareFunc {
+	challenge := fmt.Sprintf("Basic realm=%q", realm)
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			username, password, ok := c.Request().BasicAuth()
+			if ok {
+				u, err := v(c, username, password)
+				if err == nil && u != nil {
+					c.Set("current_user", u)
+					return next(c)
+				}
+			}
+			c.Response().Header().Set("WWW-Authenticate", challenge)
+			return c.Error(401, ErrUnauthorized)
+		}
+	}
+}
+
+// BasicAuthUsers returns a BasicAuthValidator that checks against a map
+// of usernames to passwords, using constant time comparisons. The
+// username is used as the "current_user".
+func BasicAuthUsers(users map[string]string) BasicAuthValidator {
+	return func(c buffalo.Context, username, password string) (interface{}, error) {
+		var user string
+		found := 0
+		for u, p := range users {
+			un := subtle.ConstantTimeCompare([]byte(u), []byte(username))
+			pw := subtle.ConstantTimeCompare([]byte(p), []byte(password))
+			if un&pw == 1 {
+				user = u
+				found = 1
+			}
+		}
+		if found == 0 {
+			return nil, ErrUnauthorized
+		}
+		return user, nil
+	}
+}
+
+// TokenAuth returns a piece of buffalo.Middleware that protects requests
+// with a bearer token sent in the "Authorization" header, using the realm
+// "Restricted".
+/*
+	api.Use(middleware.TokenAuth(func(c buffalo.Context, token string) (interface{}, error) {
+		return models.FindUserByAPIToken(token)
+	}))
+*/
+func TokenAuth(lookup TokenLookup) buffalo.MiddlewareFunc {
+	return TokenAuthRealm("Restricted", lookup)
+}
+
+// TokenAuthRealm is the same as TokenAuth, but uses the given realm.
+func TokenAuthRealm(realm string, lookup TokenLookup) buffalo.MiddlewareFunc {
+	challenge := fmt.Sprintf("Bearer realm=%q", realm)
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			h := c.Request().Header.Get("Authorization")
+			if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+				u, err := lookup(c, strings.TrimSpace(h[7:]))
+				if err == nil && u != nil {
+					c.Set("current_user", u)
+					return next(c)
+				}
+			}
+			c.Response().Header().Set("WWW-Authenticate", challenge)
+			return c.Error(401, ErrUnauthorized)
+		}
+	}
+}
+
+// StaticTokens returns a TokenLookup that checks against a map of tokens
+// to users, using constant time comparisons.
+func StaticTokens(tokens map[string]interface{}) TokenLookup {
+	return func(c buffalo.Context, token string) (interface{}, error) {
+		var user interface{}
+		for t, u := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				user = u
+			}
+		}
+		if user == nil {
+			return nil, ErrUnauthorized
+		}
+		return user, nil
+	}
+}
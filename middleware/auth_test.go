@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func authApp() *buffalo.App {
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String(fmt.Sprint(c.Get("current_user"))))
+	}
+	a := buffalo.New(buffalo.Options{})
+	a.GET("/basic", middleware.BasicAuthRealm("admin", middleware.BasicAuthUsers(map[string]string{
+		"mark": "secret",
+	}))(h))
+	a.GET("/token", middleware.TokenAuth(middleware.StaticTokens(map[string]interface{}{
+		"abc123": "mark",
+	}))(h))
+	return a
+}
+
+func Test_BasicAuth(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(authApp())
+
+	res := w.Request("/basic").Get()
+	r.Equal(401, res.Code)
+	r.Equal(`Basic realm="admin"`, res.Header().Get("WWW-Authenticate"))
+
+	req := w.Request("/basic")
+	req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte("mark:wrong"))
+	r.Equal(401, req.Get().Code)
+
+	req = w.Request("/basic")
+	req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte("mark:secret"))
+	res = req.Get()
+	r.Equal(200, res.Code)
+	r.Equal("mark", res.Body.String())
+}
+
+func Test_TokenAuth(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(authApp())
+
+	res := w.Request("/token").Get()
+	r.Equal(401, res.Code)
+	r.Equal(`Bearer realm="Restricted"`, res.Header().Get("WWW-Authenticate"))
+
+	req := w.Request("/token")
+	req.Headers["Authorization"] = "Bearer nope"
+	r.Equal(401, req.Get().Code)
+
+	req = w.Request("/token")
+	req.Headers["Authorization"] = "Bearer abc123"
+	res = req.Get()
+	r.Equal(200, res.Code)
+	r.Equal("mark", res.Body.String())
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrBodyTooLarge is returned, with a 413 status, when a request body
+// is larger than the limit set by BodyLimit.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// ErrSlowClient is returned, with a 408 status, when a client sends the
+// request body slower than the rate set by ReadRate.
+var ErrSlowClient = errors.New("request body sent too slowly")
+
+// BodyLimit returns a piece of buffalo.Middleware that limits the size of
+// request bodies to max bytes. Requests that declare a larger
+// "Content-Length" fail as soon as the handler starts reading the body,
+// any others fail as soon as the handler reads past the limit. Either way
+// the request is sent to the 413 ErrorHandler.
+//
+// BodyLimit can be used again on a group, or a single route, to override
+// the limit set for the whole App, even to raise it:
+/*
+	app.Use(middleware.BodyLimit(1 << 20)) // 1MB
+
+	app.POST("/uploads", middleware.BodyLimit(100<<20)(UploadHandler)) // 100MB
+*/
+func BodyLimit(max int64) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			lb := limitBody(c)
+			lb.limit = max
+			return lb.handle(next, c)
+		}
+	}
+}
+
+// ReadRate returns a piece of buffalo.Middleware that protects against
+// "slowloris" style clients that trickle a request body in to tie up the
+// server. After the grace period, clients sending the body slower than
+// minRate bytes per second are cut off and the request is sent to the 408
+// ErrorHandler. Each individual read is also given a deadline of grace,
+// so a client that stops sending altogether doesn't hang the handler.
+/*
+	app.Use(middleware.ReadRate(1024, 5*time.Second))
+*/
+func ReadRate(minRate int64, grace time.Duration) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			lb := limitBody(c)
+			lb.minRate = minRate
+			lb.grace = grace
+			lb.rc = http.NewResponseController(c.Response())
+			return lb.handle(next, c)
+		}
+	}
+}
+
+// limitBody finds, or installs, the limitedBody for the request so that
+// inner BodyLimit and ReadRate calls update the outer ones.
+func limitBody(c buffalo.Context) *limitedBody {
+	if lb, ok := c.Get("body_limit").(*limitedBody); ok {
+		return lb
+	}
+	req := c.Request()
+	lb := &limitedBody{
+		body:   req.Body,
+		length: req.ContentLength,
+		limit:  -1,
+		start:  time.Now(),
+	}
+	if req.Body != nil {
+		req.Body = lb
+	}
+	c.Set("body_limit", lb)
+	return lb
+}
+
+type limitedBody struct {
+	body     io.ReadCloser
+	length   int64
+	limit    int64
+	minRate  int64
+	grace    time.Duration
+	read     int64
+	start    time.Time
+	rc       *http.ResponseController
+	tooLarge bool
+	tooSlow  bool
+}
+
+func (lb *limitedBody) handle(next buffalo.Handler, c buffalo.Context) error {
+	err := next(c)
+	switch {
+	case lb.tooLarge:
+		return c.Error(413, ErrBodyTooLarge)
+	case lb.tooSlow:
+		return c.Error(408, ErrSlowClient)
+	}
+	return err
+}
+
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	if lb.tooLarge {
+		return 0, ErrBodyTooLarge
+	}
+	if lb.tooSlow {
+		return 0, ErrSlowClient
+	}
+	if lb.limit >= 0 && lb.length > lb.limit {
+		lb.tooLarge = true
+		return 0, ErrBodyTooLarge
+	}
+	if lb.limit >= 0 && int64(len(p)) > lb.limit-lb.read+1 {
+		// read one byte past the limit so we know it was exceeded
+		p = p[:lb.limit-lb.read+1]
+	}
+	if lb.rc != nil && lb.grace > 0 {
+		lb.rc.SetReadDeadline(time.Now().Add(lb.grace))
+	}
+	n, err := lb.body.Read(p)
+	lb.read += int64(n)
+	if lb.limit >= 0 && lb.read > lb.limit {
+		lb.tooLarge = true
+		return n, ErrBodyTooLarge
+	}
+	if err != nil && err != io.EOF && lb.minRate > 0 {
+		if ne, ok := errors.Cause(err).(interface{ Timeout() bool }); ok && ne.Timeout() {
+			lb.tooSlow = true
+			return n, ErrSlowClient
+		}
+	}
+	if lb.minRate > 0 && err == nil {
+		elapsed := time.Since(lb.start)
+		if elapsed > lb.grace && float64(lb.read)/elapsed.Seconds() < float64(lb.minRate) {
+			lb.tooSlow = true
+			return n, ErrSlowClient
+		}
+	}
+	return n, err
+}
+
+func (lb *limitedBody) Close() error {
+	return lb.body.Close()
+}
@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func blApp() *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.BodyLimit(10))
+	h := func(c buffalo.Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.Render(200, render.String(string(b)))
+	}
+	a.POST("/", h)
+	a.POST("/upload", middleware.BodyLimit(100)(h))
+	return a
+}
+
+func Test_BodyLimit(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(blApp())
+
+	res := w.Request("/").Post(url.Values{"a": []string{"b"}})
+	r.Equal(200, res.Code)
+	r.Equal("a=b", res.Body.String())
+
+	big := url.Values{"a": []string{strings.Repeat("x", 50)}}
+	res = w.Request("/").Post(big)
+	r.Equal(413, res.Code)
+
+	res = w.Request("/upload").Post(big)
+	r.Equal(200, res.Code)
+	r.Equal(big.Encode(), res.Body.String())
+}
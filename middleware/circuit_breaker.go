@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned, with a 503 status, for requests to a route
+// whose circuit has been opened by the CircuitBreaker middleware.
+var ErrCircuitOpen = errors.New("service unavailable, circuit breaker is open")
+
+// CircuitBreakerOptions configures the CircuitBreaker middleware.
+type CircuitBreakerOptions struct {
+	// Threshold is the number of failures in a row that opens the
+	// circuit. Default is 5.
+	Threshold int
+	// Cooldown is how long the circuit stays open before probe requests
+	// are let through. Default is 30 seconds.
+	Cooldown time.Duration
+	// Probes is the number of requests let through while the circuit is
+	// half-open. If they all succeed the circuit is closed again. Default is 1.
+	Probes int
+	// IsFailure decides if an error returned by the handler counts as a
+	// failure. By default errors with a 5xx status do.
+	IsFailure func(error) bool
+}
+
+// CircuitBreaker returns a piece of buffalo.Middleware for routes that
+// depend on an upstream service. Every route gets its own circuit. Once a
+// route fails Threshold times in a row its circuit opens, and requests are
+// sent straight to the 503 ErrorHandler, with a "Retry-After" header,
+// instead of waiting on an upstream that is known to be down. After
+// Cooldown a few probe requests are let through, and if they succeed the
+// circuit closes again.
+/*
+	api := app.Group("/payments")
+	api.Use(middleware.CircuitBreaker(middleware.CircuitBreakerOptions{
+		Threshold: 3,
+		Cooldown:  10 * time.Second,
+	}))
+*/
+func CircuitBreaker(opts CircuitBreakerOptions) buffalo.MiddlewareFunc {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+	if opts.Cooldown == 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.Probes <= 0 {
+		opts.Probes = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(err error) bool {
+			return errorStatus(err) >= 500
+		}
+	}
+	moot := &sync.Mutex{}
+	circuits := map[string]*circuit{}
+
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			key := c.Request().Method + " " + c.Request().URL.Path
+			if ri, ok := buffalo.Get(c, buffalo.CurrentRouteKey); ok && ri.Path != "" {
+				key = ri.Method + " " + ri.Path
+			}
+			moot.Lock()
+			cb, ok := circuits[key]
+			if !ok {
+				cb = &circuit{opts: opts}
+				circuits[key] = cb
+			}
+			moot.Unlock()
+
+			if wait, ok := cb.allow(time.Now()); !ok {
+				secs := int(wait/time.Second) + 1
+				c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+				return c.Error(503, ErrCircuitOpen)
+			}
+			// a handler that panics has failed too, and has to give
+			// back its probe, or the circuit stays half-open for good
+			failed := true
+			defer func() {
+				cb.done(failed, time.Now())
+			}()
+			err := next(c)
+			failed = err != nil && opts.IsFailure(err)
+			return err
+		}
+	}
+}
+
+// errorStatus returns the status an error returned by a handler will
+// be handled with, looking through errors wrapped with errors.Wrap, so
+// a wrapped 404 isn't taken for a failure.
+func errorStatus(err error) int {
+	if se, ok := errors.Cause(err).(interface {
+		StatusCode() int
+	}); ok {
+		return se.StatusCode()
+	}
+	return 500
+}
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	opts      CircuitBreakerOptions
+	moot      sync.Mutex
+	state     int
+	failures  int
+	openedAt  time.Time
+	probing   int
+	successes int
+}
+
+// allow reports whether a request may go through. If not it also
+// returns how long until probes will be let through.
+func (cb *circuit) allow(now time.Time) (time.Duration, bool) {
+	cb.moot.Lock()
+	defer cb.moot.Unlock()
+	switch cb.state {
+	case circuitOpen:
+		wait := cb.openedAt.Add(cb.opts.Cooldown).Sub(now)
+		if wait > 0 {
+			return wait, false
+		}
+		cb.state = circuitHalfOpen
+		cb.probing = 0
+		cb.successes = 0
+		fallthrough
+	case circuitHalfOpen:
+		if cb.probing >= cb.opts.Probes {
+			return 0, false
+		}
+		cb.probing++
+	}
+	return 0, true
+}
+
+func (cb *circuit) done(failed bool, now time.Time) {
+	cb.moot.Lock()
+	defer cb.moot.Unlock()
+	switch cb.state {
+	case circuitClosed:
+		if !failed {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.opts.Threshold {
+			cb.state = circuitOpen
+			cb.openedAt = now
+		}
+	case circuitHalfOpen:
+		if failed {
+			cb.state = circuitOpen
+			cb.openedAt = now
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.opts.Probes {
+			cb.state = circuitClosed
+			cb.failures = 0
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CircuitBreaker(t *testing.T) {
+	r := require.New(t)
+
+	up := false
+	calls := 0
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.CircuitBreaker(middleware.CircuitBreakerOptions{
+		Threshold: 2,
+		Cooldown:  20 * time.Millisecond,
+	}))
+	a.GET("/upstream/{id}", func(c buffalo.Context) error {
+		calls++
+		if !up {
+			return c.Error(502, errors.New("upstream is down"))
+		}
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/other", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/missing", func(c buffalo.Context) error {
+		return c.Error(404, errors.New("nope"))
+	})
+
+	w := willie.New(a)
+	r.Equal(502, w.Request("/upstream/1").Get().Code)
+	r.Equal(502, w.Request("/upstream/2").Get().Code)
+
+	// the circuit is open, the handler isn't called
+	res := w.Request("/upstream/3").Get()
+	r.Equal(503, res.Code)
+	r.Equal("1", res.Header().Get("Retry-After"))
+	r.Equal(2, calls)
+
+	// other routes have their own circuit
+	r.Equal(200, w.Request("/other").Get().Code)
+	for i := 0; i < 3; i++ {
+		r.Equal(404, w.Request("/missing").Get().Code)
+	}
+
+	// a failed probe opens it again
+	time.Sleep(25 * time.Millisecond)
+	r.Equal(502, w.Request("/upstream/1").Get().Code)
+	r.Equal(503, w.Request("/upstream/1").Get().Code)
+	r.Equal(3, calls)
+
+	// a successful probe closes it
+	time.Sleep(25 * time.Millisecond)
+	up = true
+	r.Equal(200, w.Request("/upstream/1").Get().Code)
+	r.Equal(200, w.Request("/upstream/1").Get().Code)
+	r.Equal(5, calls)
+}
+
+func Test_CircuitBreaker_Panic(t *testing.T) {
+	r := require.New(t)
+
+	fail := "wrapped"
+	a := buffalo.New(buffalo.Options{})
+	a.Use(func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = c.Error(500, errors.Errorf("%v", p))
+				}
+			}()
+			return next(c)
+		}
+	})
+	a.Use(middleware.CircuitBreaker(middleware.CircuitBreakerOptions{
+		Threshold: 1,
+		Cooldown:  20 * time.Millisecond,
+	}))
+	a.GET("/", func(c buffalo.Context) error {
+		switch fail {
+		case "wrapped":
+			return errors.Wrap(c.Error(404, errors.New("not found")), "could not load")
+		case "panic":
+			panic("boom")
+		}
+		return c.Render(200, render.String("ok"))
+	})
+
+	// wrapped errors keep their status, so a 404 isn't a failure
+	w := willie.New(a)
+	r.NotEqual(503, w.Request("/").Get().Code)
+	r.NotEqual(503, w.Request("/").Get().Code)
+
+	fail = "panic"
+	r.Equal(500, w.Request("/").Get().Code)
+	r.Equal(503, w.Request("/").Get().Code)
+
+	// a probe that panics opens the circuit again
+	time.Sleep(25 * time.Millisecond)
+	r.Equal(500, w.Request("/").Get().Code)
+	r.Equal(503, w.Request("/").Get().Code)
+
+	time.Sleep(25 * time.Millisecond)
+	fail = ""
+	r.Equal(200, w.Request("/").Get().Code)
+}
@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrCORSDenied is returned, with a 403 status, for preflight requests
+// that aren't allowed by the CORS policy.
+var ErrCORSDenied = errors.New("cross-origin request denied")
+
+// CORSOptions is a Cross-Origin Resource Sharing policy.
+type CORSOptions struct {
+	// AllowedOrigins that may make cross-origin requests. "*" allows any
+	// origin, and a single "*" may be used as a subdomain wildcard, such as
+	// "https://*.example.com".
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD, POST, PUT, PATCH, and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders the client may send. Defaults to Accept,
+	// Content-Type, and Authorization. "*" allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders the client may read from the response.
+	ExposedHeaders []string
+	// AllowCredentials lets cookies and auth headers be sent. Allowing
+	// them for any site would let every site act as the user, so with
+	// AllowCredentials a "*" origin is ignored, and only the origins
+	// listed, or matched by a subdomain wildcard, are allowed.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the answer to a preflight.
+	MaxAge time.Duration
+	// Stats, if set, collects preflight statistics.
+	Stats *CORSStats
+}
+
+func (o CORSOptions) clone() *CORSOptions {
+	n := o
+	n.AllowedOrigins = append([]string{}, o.AllowedOrigins...)
+	n.AllowedMethods = append([]string{}, o.AllowedMethods...)
+	n.AllowedHeaders = append([]string{}, o.AllowedHeaders...)
+	n.ExposedHeaders = append([]string{}, o.ExposedHeaders...)
+	return &n
+}
+
+// CORS returns a piece of buffalo.Middleware that applies a CORS policy
+// to cross-origin requests. Use OverrideCORS to change the policy for a
+// route or group, for example to lock down credentialed admin routes
+// while leaving a public API open to everyone.
+//
+// Preflight requests are "OPTIONS" requests, and so only reach the
+// middleware if an "OPTIONS" route matches them. Map CORSPreflight to the
+// paths that should accept cross-origin requests.
+/*
+	app.Use(middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins: []string{"*"},
+		MaxAge:         time.Hour,
+	}))
+	app.OPTIONS("/{path:.*}", middleware.CORSPreflight)
+
+	admin := app.Group("/admin")
+	admin.Use(middleware.OverrideCORS(func(o *middleware.CORSOptions) {
+		o.AllowedOrigins = []string{"https://admin.example.com"}
+		o.AllowCredentials = true
+	}))
+	admin.OPTIONS("/{path:.*}", middleware.CORSPreflight)
+*/
+func CORS(opts CORSOptions) buffalo.MiddlewareFunc {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{"Accept", "Content-Type", "Authorization"}
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			p := opts.clone()
+			c.Set("cors", p)
+			applyCORS(c, p)
+			return next(c)
+		}
+	}
+}
+
+// OverrideCORS returns a piece of buffalo.Middleware that lets a route,
+// or a group, change the policy set up by CORS. The function is given a
+// copy of the policy for the current request.
+func OverrideCORS(fn func(*CORSOptions)) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			if p, ok := c.Get("cors").(*CORSOptions); ok {
+				fn(p)
+				applyCORS(c, p)
+			}
+			return next(c)
+		}
+	}
+}
+
+// CORSPreflight is a Handler that answers preflight requests using the
+// CORS policy for the current route.
+func CORSPreflight(c buffalo.Context) error {
+	p, ok := c.Get("cors").(*CORSOptions)
+	req := c.Request()
+	method := req.Header.Get("Access-Control-Request-Method")
+	origin := req.Header.Get("Origin")
+	if !ok || origin == "" || method == "" {
+		c.Response().WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	route := req.URL.Path
+	if ri, ok := buffalo.Get(c, buffalo.CurrentRouteKey); ok && ri.Path != "" {
+		route = ri.Path
+	}
+	h := c.Response().Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	headers := splitHeader(req.Header.Get("Access-Control-Request-Headers"))
+	allowed := p.originAllowed(origin) && contains(p.AllowedMethods, method) && p.headersAllowed(headers)
+	if p.Stats != nil {
+		p.Stats.record(route, origin, method, allowed, p.MaxAge)
+	}
+	if !allowed {
+		return c.Error(403, ErrCORSDenied)
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+	if len(headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if p.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+	}
+	c.Response().WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// applyCORS sets the headers shared by preflight and actual requests,
+// replacing any set by an earlier policy.
+func applyCORS(c buffalo.Context, p *CORSOptions) {
+	h := c.Response().Header()
+	for _, k := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Expose-Headers"} {
+		h.Del(k)
+	}
+	origin := c.Request().Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	if !contains(h["Vary"], "Origin") {
+		h.Add("Vary", "Origin")
+	}
+	if !p.originAllowed(origin) {
+		return
+	}
+	// with credentials "*" is ignored, see AllowCredentials, so the
+	// origin echoed back was listed
+	if contains(p.AllowedOrigins, "*") && !p.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(p.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+	}
+}
+
+func (o *CORSOptions) originAllowed(origin string) bool {
+	for _, a := range o.AllowedOrigins {
+		if a == "*" {
+			if o.AllowCredentials {
+				continue
+			}
+			return true
+		}
+		if a == origin {
+			return true
+		}
+		if i := strings.Index(a, "*"); i >= 0 {
+			pre, suf := a[:i], a[i+1:]
+			if len(origin) > len(pre)+len(suf) && strings.HasPrefix(origin, pre) && strings.HasSuffix(origin, suf) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (o *CORSOptions) headersAllowed(headers []string) bool {
+	if contains(o.AllowedHeaders, "*") {
+		return true
+	}
+	for _, h := range headers {
+		if !contains(o.AllowedHeaders, h) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitHeader(h string) []string {
+	parts := []string{}
+	for _, p := range strings.Split(h, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSRouteStats are the preflight statistics for a route.
+type CORSRouteStats struct {
+	// Preflights is the number of preflight requests answered.
+	Preflights uint64 `json:"preflights"`
+	// Allowed and Denied break Preflights down by outcome.
+	Allowed uint64 `json:"allowed"`
+	Denied  uint64 `json:"denied"`
+	// Repeats is the number of preflights from an origin for a method
+	// that had already been allowed within MaxAge. Browsers should have
+	// answered these from their cache, so a high number means MaxAge is
+	// being ignored or capped.
+	Repeats uint64 `json:"repeats"`
+}
+
+// CacheHitRate estimates how well browsers are caching preflights, as
+// the share of allowed preflights that weren't repeats.
+func (s CORSRouteStats) CacheHitRate() float64 {
+	if s.Allowed == 0 {
+		return 0
+	}
+	return 1 - float64(s.Repeats)/float64(s.Allowed)
+}
+
+// CORSStats collects preflight statistics, per route, for CORS.
+type CORSStats struct {
+	moot   *sync.Mutex
+	routes map[string]*CORSRouteStats
+	seen   map[string]time.Time
+}
+
+// NewCORSStats returns a new, empty, CORSStats.
+func NewCORSStats() *CORSStats {
+	return &CORSStats{
+		moot:   &sync.Mutex{},
+		routes: map[string]*CORSRouteStats{},
+		seen:   map[string]time.Time{},
+	}
+}
+
+// Routes returns a copy of the statistics for each route.
+func (s *CORSStats) Routes() map[string]CORSRouteStats {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	m := map[string]CORSRouteStats{}
+	for k, v := range s.routes {
+		m[k] = *v
+	}
+	return m
+}
+
+// Total returns the statistics for all routes combined.
+func (s *CORSStats) Total() CORSRouteStats {
+	t := CORSRouteStats{}
+	for _, r := range s.Routes() {
+		t.Preflights += r.Preflights
+		t.Allowed += r.Allowed
+		t.Denied += r.Denied
+		t.Repeats += r.Repeats
+	}
+	return t
+}
+
+// maxSeen caps the memory used to spot repeated preflights.
+const maxSeen = 10000
+
+func (s *CORSStats) record(route, origin, method string, allowed bool, maxAge time.Duration) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	rs, ok := s.routes[route]
+	if !ok {
+		rs = &CORSRouteStats{}
+		s.routes[route] = rs
+	}
+	rs.Preflights++
+	if !allowed {
+		rs.Denied++
+		return
+	}
+	rs.Allowed++
+	if maxAge <= 0 {
+		return
+	}
+	now := time.Now()
+	key := route + " " + origin + " " + method
+	if exp, ok := s.seen[key]; ok && now.Before(exp) {
+		rs.Repeats++
+		return
+	}
+	if len(s.seen) >= maxSeen {
+		s.seen = map[string]time.Time{}
+	}
+	s.seen[key] = now.Add(maxAge)
+}
@@ -0,0 +1,125 @@
+package middleware_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func corsApp(stats *middleware.CORSStats) *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Total"},
+		MaxAge:         time.Hour,
+		Stats:          stats,
+	}))
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	}
+	a.GET("/api/widgets", h)
+	a.OPTIONS("/api/{path:.*}", middleware.CORSPreflight)
+
+	g := a.Group("/admin")
+	g.Use(middleware.OverrideCORS(func(o *middleware.CORSOptions) {
+		o.AllowedOrigins = []string{"https://*.example.com"}
+		o.AllowCredentials = true
+	}))
+	g.GET("/users", h)
+	g.OPTIONS("/{path:.*}", middleware.CORSPreflight)
+	return a
+}
+
+func Test_CORS(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(corsApp(nil))
+
+	req := w.Request("/api/widgets")
+	req.Headers["Origin"] = "https://anywhere.com"
+	res := req.Get()
+	r.Equal(200, res.Code)
+	r.Equal("*", res.Header().Get("Access-Control-Allow-Origin"))
+	r.Equal("X-Total", res.Header().Get("Access-Control-Expose-Headers"))
+	r.Empty(res.Header().Get("Access-Control-Allow-Credentials"))
+
+	req = w.Request("/admin/users")
+	req.Headers["Origin"] = "https://anywhere.com"
+	res = req.Get()
+	r.Empty(res.Header().Get("Access-Control-Allow-Origin"))
+
+	req = w.Request("/admin/users")
+	req.Headers["Origin"] = "https://admin.example.com"
+	res = req.Get()
+	r.Equal("https://admin.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+	r.Equal("true", res.Header().Get("Access-Control-Allow-Credentials"))
+	r.Equal("Origin", res.Header().Get("Vary"))
+}
+
+func Test_CORS_Preflight(t *testing.T) {
+	r := require.New(t)
+	stats := middleware.NewCORSStats()
+	a := corsApp(stats)
+
+	preflight := func(path, origin, headers string) int {
+		req := httptest.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		req.Header.Set("Access-Control-Request-Headers", headers)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		if res.Code == 204 {
+			r.Equal("3600", res.Header().Get("Access-Control-Max-Age"))
+			r.Contains(res.Header().Get("Access-Control-Allow-Methods"), "PUT")
+		}
+		return res.Code
+	}
+
+	r.Equal(204, preflight("/api/widgets", "https://a.com", "Content-Type"))
+	r.Equal(204, preflight("/api/widgets", "https://a.com", "Content-Type"))
+	r.Equal(403, preflight("/api/widgets", "https://a.com", "X-Secret"))
+	r.Equal(403, preflight("/admin/users", "https://a.com", ""))
+	r.Equal(204, preflight("/admin/users", "https://admin.example.com", ""))
+
+	api := stats.Routes()["/api/{path:.*}"]
+	r.Equal(uint64(3), api.Preflights)
+	r.Equal(uint64(2), api.Allowed)
+	r.Equal(uint64(1), api.Denied)
+	r.Equal(uint64(1), api.Repeats)
+	r.Equal(0.5, api.CacheHitRate())
+
+	total := stats.Total()
+	r.Equal(uint64(5), total.Preflights)
+	r.Equal(uint64(2), total.Denied)
+}
+
+func Test_CORS_Credentials_Any(t *testing.T) {
+	r := require.New(t)
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   []string{"*", "https://app.example.com"},
+		AllowCredentials: true,
+	}))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+	w := willie.New(a)
+
+	// "*" doesn't let any site send credentials
+	req := w.Request("/")
+	req.Headers["Origin"] = "https://evil.com"
+	res := req.Get()
+	r.Empty(res.Header().Get("Access-Control-Allow-Origin"))
+	r.Empty(res.Header().Get("Access-Control-Allow-Credentials"))
+
+	req = w.Request("/")
+	req.Headers["Origin"] = "https://app.example.com"
+	res = req.Get()
+	r.Equal("https://app.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+	r.Equal("true", res.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// EarlyHints sends a 103 Early Hints response, preloading the links,
+// for every GET request for an HTML page, so the client can start
+// fetching assets while the page is rendered.
+/*
+	app.Use(middleware.EarlyHints("/assets/application.css", "/assets/application.js"))
+*/
+func EarlyHints(links ...string) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			req := c.Request()
+			if req.Method == "GET" && strings.Contains(req.Header.Get("Accept"), "text/html") {
+				if err := c.EarlyHints(links...); err != nil {
+					return err
+				}
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EarlyHints(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.EarlyHints("/assets/app.css", "/assets/app.js"))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String("page"))
+	})
+	ts := httptest.NewServer(a)
+	defer ts.Close()
+
+	get := func(accept string) (int, []string, string) {
+		hints := []string{}
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, h textproto.MIMEHeader) error {
+				if code == 103 {
+					hints = h["Link"]
+				}
+				return nil
+			},
+		}
+		ctx := httptrace.WithClientTrace(context.Background(), trace)
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+		req.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(req)
+		r.NoError(err)
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		return res.StatusCode, hints, string(b)
+	}
+
+	status, hints, body := get("text/html")
+	r.Equal(200, status)
+	r.Equal("page", body)
+	r.Equal([]string{
+		"</assets/app.css>; rel=preload; as=style",
+		"</assets/app.js>; rel=preload; as=script",
+	}, hints)
+
+	status, hints, _ = get("application/json")
+	r.Equal(200, status)
+	r.Empty(hints)
+}
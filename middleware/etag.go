@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ETag returns a piece of buffalo.Middleware that buffers successful
+// GET and HEAD responses, sets an "ETag" header based on a hash of the
+// body (a weak one if weak is true), and answers requests whose
+// "If-None-Match" or "If-Modified-Since" headers show the client already
+// has the current version with a 304 and no body. Handlers that set
+// their own "ETag" or "Last-Modified" headers have those used instead.
+//
+// If the App has Options.ResponseBufferSize set, ETag works on the
+// buffalo.BufferedResponse instead of buffering the response itself.
+//
+// Streaming handlers should call SkipETag before writing, or simply
+// Flush the response, to have it sent straight through unbuffered.
+/*
+	app.Use(middleware.ETag(false))
+*/
+func ETag(weak bool) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			req := c.Request()
+			if req.Method != "GET" && req.Method != "HEAD" {
+				return next(c)
+			}
+			if br, ok := buffalo.Buffered(c); ok {
+				err := next(c)
+				if err != nil || !br.Buffering() {
+					return err
+				}
+				if skip, _ := c.Get("etag_skip").(bool); skip {
+					return nil
+				}
+				etagBuffered(req, br, weak)
+				return nil
+			}
+			dc, ok := c.(*buffalo.DefaultContext)
+			if !ok {
+				return next(c)
+			}
+			ew := &etagWriter{ResponseWriter: c.Response(), header: http.Header{}}
+			fc := dc.Fork(req, ew)
+			ew.skip = func() bool {
+				b, _ := fc.Get("etag_skip").(bool)
+				return b
+			}
+			err := next(fc)
+			dc.Merge(fc)
+			if err != nil || ew.passthrough {
+				return err
+			}
+			return ew.finish(req, weak)
+		}
+	}
+}
+
+// SkipETag turns off ETag buffering for the current request. It must be
+// called before anything is written to the response.
+func SkipETag(c buffalo.Context) {
+	c.Set("etag_skip", true)
+}
+
+type etagWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	skip        func() bool
+	passthrough bool
+}
+
+func (ew *etagWriter) Header() http.Header {
+	if ew.passthrough {
+		return ew.ResponseWriter.Header()
+	}
+	return ew.header
+}
+
+func (ew *etagWriter) WriteHeader(status int) {
+	if ew.passthrough || ew.skip() {
+		ew.startPassthrough()
+		ew.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if ew.status == 0 {
+		ew.status = status
+	}
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.passthrough || ew.skip() {
+		ew.startPassthrough()
+		return ew.ResponseWriter.Write(b)
+	}
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	return ew.buf.Write(b)
+}
+
+// Flush means the handler is streaming, so switch to passing writes
+// straight through.
+func (ew *etagWriter) Flush() {
+	ew.startPassthrough()
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ew *etagWriter) startPassthrough() {
+	if ew.passthrough {
+		return
+	}
+	ew.passthrough = true
+	h := ew.ResponseWriter.Header()
+	for k, v := range ew.header {
+		h[k] = v
+	}
+	if ew.status != 0 {
+		ew.ResponseWriter.WriteHeader(ew.status)
+	}
+	if ew.buf.Len() > 0 {
+		ew.ResponseWriter.Write(ew.buf.Bytes())
+	}
+}
+
+func (ew *etagWriter) finish(req *http.Request, weak bool) error {
+	h := ew.ResponseWriter.Header()
+	for k, v := range ew.header {
+		h[k] = v
+	}
+	status := ew.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusOK && checkETag(req, h, ew.buf.Bytes(), weak) {
+		ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	ew.ResponseWriter.WriteHeader(status)
+	if req.Method == "HEAD" {
+		return nil
+	}
+	_, err := ew.ResponseWriter.Write(ew.buf.Bytes())
+	return errors.WithStack(err)
+}
+
+// etagBuffered does the work of ETag on a buffalo.BufferedResponse.
+func etagBuffered(req *http.Request, br *buffalo.BufferedResponse, weak bool) {
+	if br.Status() != 0 && br.Status() != http.StatusOK {
+		return
+	}
+	if checkETag(req, br.Header(), br.Body(), weak) {
+		br.Reset()
+		br.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if req.Method == "HEAD" {
+		br.SetBody(nil)
+	}
+}
+
+// checkETag sets the ETag header, unless the handler already has, and
+// reports whether the client's copy is current. If it is the headers
+// are readied for a 304.
+func checkETag(req *http.Request, h http.Header, body []byte, weak bool) bool {
+	if h.Get("ETag") == "" {
+		sum := sha1.Sum(body)
+		tag := `"` + hex.EncodeToString(sum[:]) + `"`
+		if weak {
+			tag = "W/" + tag
+		}
+		h.Set("ETag", tag)
+	}
+	if !notModified(req, h) {
+		return false
+	}
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	return true
+}
+
+// notModified checks the conditional request headers against the
+// response headers. If-None-Match takes precedence over
+// If-Modified-Since, as per RFC 7232.
+func notModified(req *http.Request, h http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, h.Get("ETag"))
+	}
+	ims := req.Header.Get("If-Modified-Since")
+	lm := h.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagMatch does a weak comparison of the If-None-Match header against
+// the current ETag.
+func etagMatch(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func etagApp(weak bool) *buffalo.App {
+	return etagAppWith(buffalo.Options{}, weak)
+}
+
+func etagAppWith(opts buffalo.Options, weak bool) *buffalo.App {
+	a := buffalo.New(opts)
+	a.Use(middleware.ETag(weak))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String("hello"))
+	})
+	a.GET("/modified", func(c buffalo.Context) error {
+		c.Response().Header().Set("Last-Modified", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		return c.Render(200, render.String("hello"))
+	})
+	a.GET("/stream", func(c buffalo.Context) error {
+		middleware.SkipETag(c)
+		return c.Render(200, render.String("streamed"))
+	})
+	return a
+}
+
+func Test_ETag(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(etagApp(false))
+
+	res := w.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal("hello", res.Body.String())
+	tag := res.Header().Get("ETag")
+	r.NotEmpty(tag)
+	r.NotContains(tag, "W/")
+
+	req := w.Request("/")
+	req.Headers["If-None-Match"] = tag
+	res = req.Get()
+	r.Equal(304, res.Code)
+	r.Empty(res.Body.String())
+
+	req = w.Request("/")
+	req.Headers["If-None-Match"] = `"nope"`
+	res = req.Get()
+	r.Equal(200, res.Code)
+}
+
+func Test_ETag_Buffered(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(etagAppWith(buffalo.Options{ResponseBufferSize: 1024}, false))
+
+	res := w.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal("hello", res.Body.String())
+	tag := res.Header().Get("ETag")
+	r.NotEmpty(tag)
+
+	req := w.Request("/")
+	req.Headers["If-None-Match"] = tag
+	res = req.Get()
+	r.Equal(304, res.Code)
+	r.Empty(res.Body.String())
+
+	res = w.Request("/stream").Get()
+	r.Equal("streamed", res.Body.String())
+	r.Empty(res.Header().Get("ETag"))
+}
+
+func Test_ETag_Weak(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(etagApp(true))
+
+	res := w.Request("/").Get()
+	r.Contains(res.Header().Get("ETag"), "W/")
+}
+
+func Test_ETag_IfModifiedSince(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(etagApp(false))
+
+	req := w.Request("/modified")
+	req.Headers["If-Modified-Since"] = time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	r.Equal(304, req.Get().Code)
+
+	req = w.Request("/modified")
+	req.Headers["If-Modified-Since"] = time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	r.Equal(200, req.Get().Code)
+}
+
+func Test_ETag_Skip(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(etagApp(false))
+
+	res := w.Request("/stream").Get()
+	r.Equal(200, res.Code)
+	r.Equal("streamed", res.Body.String())
+	r.Empty(res.Header().Get("ETag"))
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/pkg/errors"
+)
+
+// ErrBotDetected is returned, with a 403 status, for submissions the
+// Honeypot middleware thinks came from a bot.
+var ErrBotDetected = errors.New("your submission looks automated")
+
+// HoneypotOptions configures the Honeypot middleware.
+type HoneypotOptions struct {
+	// Secret signs the form timestamps. A random one is used if it is
+	// empty, which means forms rendered before a restart will be rejected.
+	Secret []byte
+	// FieldName of the hidden field bots are tempted into filling in.
+	// Defaults to "website".
+	FieldName string
+	// MinSubmitTime is the shortest time a human could take to fill in
+	// the form. Defaults to 2 seconds.
+	MinSubmitTime time.Duration
+	// MaxAge is how long a rendered form can be submitted for, so a bot
+	// can't fetch a timestamp once and reuse it forever. Defaults to one
+	// day.
+	MaxAge time.Duration
+	// BlockAfter is how many rejected submissions an IP can make before
+	// all of its submissions are rejected. Defaults to 5.
+	BlockAfter int
+	// BlockFor is how long an IP stays blocked. Defaults to one hour.
+	BlockFor time.Duration
+	// TrustedProxies are used to find the client's IP, see ClientIP.
+	// Default is the TrustedProxies option of the App.
+	TrustedProxies *IPList
+	// Reject is called for submissions from bots. It defaults to sending
+	// ErrBotDetected to the 403 ErrorHandler. Some prefer to pretend
+	// everything went fine, so bots don't learn from their mistakes.
+	Reject buffalo.Handler
+}
+
+// Honeypot returns a piece of buffalo.Middleware that rejects form
+// submissions from obvious bots, for use in front of signup, contact, and
+// other forms open to the public. A submission is rejected if:
+//
+// * the hidden honeypot field has been filled in
+// * it arrives sooner than MinSubmitTime, or later than MaxAge, after
+// the form was rendered
+// * the signed render timestamp is missing or tampered with
+// * there is no "User-Agent" header
+// * its IP has already had BlockAfter submissions rejected
+//
+// The hidden fields must be added to the form, they are available to
+// templates as "honeypot". Each rejection emits a "buffalo:bot:rejected"
+// event with the "reason" and "ip" in its payload, so they can be counted.
+// JSON requests aren't checked. Honeypot panics if it needs a random
+// Secret, and none can be read from crypto/rand.
+/*
+	signup := app.Group("/signup")
+	signup.Use(middleware.Honeypot(middleware.HoneypotOptions{
+		Secret: []byte(os.Getenv("HONEYPOT_SECRET")),
+	}))
+
+	<form method="POST" action="/signup">
+		{{honeypot}}
+		...
+	</form>
+*/
+func Honeypot(opts HoneypotOptions) buffalo.MiddlewareFunc {
+	if len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		if _, err := rand.Read(opts.Secret); err != nil {
+			panic(errors.Wrap(err, "could not make a honeypot secret"))
+		}
+	}
+	if opts.FieldName == "" {
+		opts.FieldName = "website"
+	}
+	if opts.MinSubmitTime == 0 {
+		opts.MinSubmitTime = 2 * time.Second
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.BlockAfter == 0 {
+		opts.BlockAfter = 5
+	}
+	if opts.BlockFor == 0 {
+		opts.BlockFor = time.Hour
+	}
+	if opts.Reject == nil {
+		opts.Reject = func(c buffalo.Context) error {
+			return c.Error(403, ErrBotDetected)
+		}
+	}
+	hp := &honeypot{
+		HoneypotOptions: opts,
+		offenders:       map[string]*offender{},
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			c.Set("honeypot", hp.fields(time.Now()))
+			req := c.Request()
+			switch req.Method {
+			case "POST", "PUT", "PATCH":
+			default:
+				return next(c)
+			}
+			if strings.Contains(strings.ToLower(req.Header.Get("Content-Type")), "json") {
+				return next(c)
+			}
+			ip := ""
+			if cip := clientIP(c, opts.TrustedProxies); cip != nil {
+				ip = cip.String()
+			}
+			if reason := hp.check(c, ip, time.Now()); reason != "" {
+				hp.reject(ip, time.Now())
+				c.LogField("bot", reason)
+				events.Emit(events.Event{
+					Kind:    "buffalo:bot:rejected",
+					Message: reason,
+					Payload: events.Payload{
+						"reason": reason,
+						"ip":     ip,
+						"path":   req.URL.Path,
+					},
+				})
+				return opts.Reject(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// HoneypotTimestampField is the name of the hidden field holding the
+// signed time the form was rendered at.
+const HoneypotTimestampField = "_hp_ts"
+
+type offender struct {
+	rejections int
+	last       time.Time
+	until      time.Time
+}
+
+type honeypot struct {
+	HoneypotOptions
+	offenders map[string]*offender
+	moot      sync.Mutex
+}
+
+func (hp *honeypot) sign(ts string) string {
+	m := hmac.New(sha256.New, hp.Secret)
+	m.Write([]byte(ts))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// fields returns the hidden form fields. The honeypot field is hidden
+// with CSS, rather than being a hidden input, as bots skip those.
+func (hp *honeypot) fields(now time.Time) template.HTML {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	return template.HTML(fmt.Sprintf(
+		`<div style="position:absolute;left:-10000px" aria-hidden="true"><input type="text" name="%s" value="" tabindex="-1" autocomplete="off"></div><input type="hidden" name="%s" value="%s.%s">`,
+		template.HTMLEscapeString(hp.FieldName), HoneypotTimestampField, ts, hp.sign(ts),
+	))
+}
+
+// check returns why the submission looks like it came from a bot, or
+// "" if it looks fine.
+func (hp *honeypot) check(c buffalo.Context, ip string, now time.Time) string {
+	hp.moot.Lock()
+	o, ok := hp.offenders[ip]
+	blocked := ok && now.Before(o.until)
+	hp.moot.Unlock()
+	if blocked {
+		return "blocked"
+	}
+	req := c.Request()
+	if req.Header.Get("User-Agent") == "" {
+		return "no user agent"
+	}
+	if req.FormValue(hp.FieldName) != "" {
+		return "honeypot"
+	}
+	parts := strings.SplitN(req.FormValue(HoneypotTimestampField), ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(hp.sign(parts[0]))) {
+		return "invalid timestamp"
+	}
+	secs, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "invalid timestamp"
+	}
+	age := now.Sub(time.Unix(secs, 0))
+	if age < hp.MinSubmitTime {
+		return "too fast"
+	}
+	if age > hp.MaxAge {
+		return "expired"
+	}
+	return ""
+}
+
+func (hp *honeypot) reject(ip string, now time.Time) {
+	hp.moot.Lock()
+	defer hp.moot.Unlock()
+	for k, o := range hp.offenders {
+		if now.After(o.until) && now.Sub(o.last) > hp.BlockFor {
+			delete(hp.offenders, k)
+		}
+	}
+	o, ok := hp.offenders[ip]
+	if !ok {
+		o = &offender{}
+		hp.offenders[ip] = o
+	}
+	o.rejections++
+	o.last = now
+	if o.rejections >= hp.BlockAfter {
+		o.until = now.Add(hp.BlockFor)
+	}
+}
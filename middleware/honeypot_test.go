@@ -0,0 +1,139 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+var honeypotTS = regexp.MustCompile(`name="_hp_ts" value="([^"]+)"`)
+
+func honeypotApp(opts middleware.HoneypotOptions) *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Honeypot(opts))
+	a.GET("/signup", func(c buffalo.Context) error {
+		return c.Render(200, render.String(fmt.Sprint(c.Get("honeypot"))))
+	})
+	a.POST("/signup", func(c buffalo.Context) error {
+		return c.Render(201, render.String("welcome"))
+	})
+	return a
+}
+
+func honeypotForm(r *require.Assertions, w *willie.Willie) url.Values {
+	res := w.Request("/signup").Get()
+	r.Equal(200, res.Code)
+	m := honeypotTS.FindStringSubmatch(res.Body.String())
+	r.Len(m, 2)
+	return url.Values{"email": []string{"mark@example.com"}, "_hp_ts": []string{m[1]}}
+}
+
+func honeypotPost(w *willie.Willie, form url.Values) *willie.Response {
+	req := w.Request("/signup")
+	req.Headers["User-Agent"] = "Mozilla/5.0"
+	return req.Post(form)
+}
+
+func Test_Honeypot(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{MinSubmitTime: time.Nanosecond}))
+
+	form := honeypotForm(r, w)
+	res := w.Request("/signup").Get()
+	r.Contains(res.Body.String(), `name="website"`)
+
+	res = honeypotPost(w, form)
+	r.Equal(201, res.Code)
+	r.Equal("welcome", res.Body.String())
+}
+
+func Test_Honeypot_Rejections(t *testing.T) {
+	r := require.New(t)
+
+	reasons := []string{}
+	del := events.Listen("buffalo:bot:rejected", func(e events.Event) {
+		reasons = append(reasons, e.Payload["reason"].(string))
+	})
+	defer del()
+
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{}))
+
+	form := honeypotForm(r, w)
+	r.Equal(403, honeypotPost(w, form).Code)
+
+	form.Set("website", "http://spam.example.com")
+	r.Equal(403, honeypotPost(w, form).Code)
+
+	form.Del("website")
+	form.Set("_hp_ts", "1.forged")
+	r.Equal(403, honeypotPost(w, form).Code)
+
+	r.Equal(403, w.Request("/signup").Post(form).Code)
+
+	r.Equal([]string{"too fast", "honeypot", "invalid timestamp", "no user agent"}, reasons)
+}
+
+func Test_Honeypot_MaxAge(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{
+		MinSubmitTime: time.Nanosecond,
+		MaxAge:        time.Nanosecond,
+	}))
+
+	reasons := []string{}
+	del := events.Listen("buffalo:bot:rejected", func(e events.Event) {
+		reasons = append(reasons, e.Payload["reason"].(string))
+	})
+	defer del()
+
+	form := honeypotForm(r, w)
+	r.Equal(403, honeypotPost(w, form).Code)
+	r.Equal([]string{"expired"}, reasons)
+}
+
+func Test_Honeypot_BlockAfter(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{
+		MinSubmitTime: time.Nanosecond,
+		BlockAfter:    2,
+	}))
+
+	form := honeypotForm(r, w)
+	bad := url.Values{"website": []string{"spam"}}
+	r.Equal(403, honeypotPost(w, bad).Code)
+	r.Equal(201, honeypotPost(w, form).Code)
+	r.Equal(403, honeypotPost(w, bad).Code)
+
+	// the IP is now blocked, even for good submissions
+	r.Equal(403, honeypotPost(w, form).Code)
+}
+
+func Test_Honeypot_Reject(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{
+		Reject: func(c buffalo.Context) error {
+			return c.Render(200, render.String("thanks!"))
+		},
+	}))
+
+	res := honeypotPost(w, url.Values{"website": []string{"spam"}})
+	r.Equal(200, res.Code)
+	r.Equal("thanks!", res.Body.String())
+}
+
+func Test_Honeypot_SkipsJSON(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(honeypotApp(middleware.HoneypotOptions{}))
+
+	res := w.JSON("/signup").Post(map[string]string{"email": "mark@example.com"})
+	r.Equal(201, res.Code)
+}
@@ -0,0 +1,505 @@
+/*
+Package i18n adds translations to a Buffalo application. Translations are
+loaded from YAML or JSON locale files, the best language for each request
+is negotiated from the "lang" param, the "lang" cookie, and the
+"Accept-Language" header, and translated strings are available to handlers
+through c.T, and to templates through the "t" helper.
+
+Locale files are named after their language, such as "en.yaml" or
+"pt-BR.json", or "users.fr.yml" to split a language over several files.
+Nested keys are joined with dots:
+
+	# en.yaml
+	welcome: "Welcome to %{app}!"
+	users:
+	  count:
+	    zero: "No users"
+	    one: "One user"
+	    other: "%{count} users"
+
+	T, err := i18n.New(os.DirFS("locales"), "en")
+	app.Use(T.Middleware())
+
+	c.T("welcome", i18n.Args{"app": "Buffalo"})
+	c.T("users.count", 3)
+*/
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/velvet"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Args are named arguments, interpolated into "%{name}" placeholders.
+// A "count" argument also picks the plural form.
+type Args map[string]interface{}
+
+// PluralRule returns the plural form, "zero", "one", "two", "few",
+// "many", or "other", to use for n in a language.
+type PluralRule func(n int) string
+
+// LanguageExtractor returns the languages a request asks for, in order
+// of preference.
+type LanguageExtractor func(c buffalo.Context) []string
+
+// Translator holds the translations for every language.
+type Translator struct {
+	// DefaultLanguage is used when nothing better matches the request,
+	// and is the last language in every fallback chain.
+	DefaultLanguage string
+	// Fallbacks are tried, in order, before DefaultLanguage when a key is
+	// missing for a language. "pt-BR" always falls back to "pt" first.
+	Fallbacks map[string][]string
+	// PluralRules for languages that don't follow the English one/other
+	// rule. A few common ones are set up by New.
+	PluralRules map[string]PluralRule
+	// LanguageExtractors are asked, in order, for the languages a request
+	// wants. Defaults to the "lang" param, the "lang" cookie, and the
+	// "Accept-Language" header.
+	LanguageExtractors []LanguageExtractor
+
+	translations map[string]map[string]interface{}
+	moot         *sync.RWMutex
+}
+
+// New returns a Translator with the locale files found in fsys, which can
+// be an os.DirFS, an embed.FS, or any other fs.FS.
+func New(fsys fs.FS, defaultLanguage string) (*Translator, error) {
+	t := &Translator{
+		DefaultLanguage: defaultLanguage,
+		Fallbacks:       map[string][]string{},
+		PluralRules: map[string]PluralRule{
+			"fr": pluralFrench,
+			"ja": pluralNone,
+			"ko": pluralNone,
+			"zh": pluralNone,
+			"ru": pluralSlavic,
+			"uk": pluralSlavic,
+		},
+		LanguageExtractors: []LanguageExtractor{
+			ParamLanguage("lang"),
+			CookieLanguage("lang"),
+			HeaderLanguage,
+		},
+		translations: map[string]map[string]interface{}{},
+		moot:         &sync.RWMutex{},
+	}
+	if fsys == nil {
+		return t, nil
+	}
+	return t, t.Load(fsys)
+}
+
+// Load the ".yaml", ".yml", and ".json" locale files found in fsys,
+// adding to, or replacing, the translations already loaded.
+func (t *Translator) Load(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := path.Ext(p)
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		m := map[string]interface{}{}
+		if ext == ".json" {
+			err = json.Unmarshal(b, &m)
+		} else {
+			err = yaml.Unmarshal(b, &m)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not parse %s", p)
+		}
+		base := strings.TrimSuffix(path.Base(p), ext)
+		lang := base[strings.LastIndex(base, ".")+1:]
+		t.AddTranslations(lang, m)
+		return nil
+	})
+}
+
+// AddTranslations adds translations for a language. Nested maps are
+// flattened into dotted keys, except for maps of plural forms.
+func (t *Translator) AddTranslations(lang string, m map[string]interface{}) {
+	t.moot.Lock()
+	defer t.moot.Unlock()
+	lang = normalize(lang)
+	if t.translations[lang] == nil {
+		t.translations[lang] = map[string]interface{}{}
+	}
+	flatten(t.translations[lang], "", m)
+}
+
+// Languages returns the languages translations have been loaded for.
+func (t *Translator) Languages() []string {
+	t.moot.RLock()
+	defer t.moot.RUnlock()
+	langs := []string{}
+	for l := range t.translations {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Middleware returns a piece of buffalo.Middleware that negotiates the
+// language for each request. The fallback chain is stored in the Context
+// as "languages", and the Translator as "translator", which makes c.T and
+// the "t" template helper work.
+func (t *Translator) Middleware() buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			requested := []string{}
+			for _, le := range t.LanguageExtractors {
+				requested = append(requested, le(c)...)
+			}
+			chain := t.chain(requested)
+			c.Set("languages", chain)
+			buffalo.Set[buffalo.Translator](c, buffalo.TranslatorKey, t)
+			if len(chain) > 0 {
+				c.Set("current_language", chain[0])
+			}
+			return next(c)
+		}
+	}
+}
+
+// Translate the key into the language of the request. This is what c.T
+// calls. See TranslateIn for the arguments.
+func (t *Translator) Translate(c buffalo.Context, key string, args ...interface{}) string {
+	langs, ok := c.Get("languages").([]string)
+	if !ok {
+		langs = t.chain(nil)
+	}
+	return t.TranslateIn(langs, key, args...)
+}
+
+// TranslateIn translates the key using the first of langs, or their
+// fallbacks, that has it. If no language has the key, the key itself is
+// returned. The arguments can be:
+//
+//	c.T("welcome")                              // no arguments
+//	c.T("welcome", i18n.Args{"app": "Buffalo"}) // "%{app}" placeholders
+//	c.T("users.count", 3)                       // a count, for plurals and "%{count}"
+//	c.T("total", "%.2f", 9.5)                   // anything else goes to fmt.Sprintf
+func (t *Translator) TranslateIn(langs []string, key string, args ...interface{}) string {
+	named := Args{}
+	var rest []interface{}
+	switch {
+	case len(args) == 1:
+		switch a := args[0].(type) {
+		case Args:
+			named = a
+		case map[string]interface{}:
+			named = a
+		case int:
+			named["count"] = a
+		default:
+			rest = args
+		}
+	default:
+		rest = args
+	}
+
+	t.moot.RLock()
+	var msg interface{}
+	lang := ""
+	for _, l := range langs {
+		if m, ok := t.translations[l][key]; ok {
+			msg, lang = m, l
+			break
+		}
+	}
+	rule := t.PluralRules[base(lang)]
+	t.moot.RUnlock()
+
+	if msg == nil {
+		return key
+	}
+	s := pluralize(msg, named, rule)
+	for k, v := range named {
+		s = strings.Replace(s, "%{"+k+"}", fmt.Sprint(v), -1)
+	}
+	if len(rest) > 0 {
+		s = fmt.Sprintf(s, rest...)
+	}
+	return s
+}
+
+// Helper is the "t" template helper. It translates a key into the
+// language of the request, filling in placeholders from the template's
+// data, so {{t "welcome"}} can use "%{app}" if "app" has been set.
+/*
+	r := render.New(render.Options{
+		Helpers: map[string]interface{}{
+			"t": T.Helper,
+		},
+	})
+*/
+func (t *Translator) Helper(key string, help velvet.HelperContext) string {
+	langs, ok := help.Context.Get("languages").([]string)
+	if !ok {
+		langs = t.chain(nil)
+	}
+	named := Args{}
+	t.moot.RLock()
+	placeholders := []string{}
+	for _, l := range langs {
+		if m, ok := t.translations[l][key]; ok {
+			placeholders = findPlaceholders(m)
+			break
+		}
+	}
+	t.moot.RUnlock()
+	for _, p := range placeholders {
+		if v := help.Context.Get(p); v != nil {
+			named[p] = v
+		}
+	}
+	return t.TranslateIn(langs, key, named)
+}
+
+// chain builds the fallback chain for the requested languages, keeping
+// only languages that have translations.
+func (t *Translator) chain(requested []string) []string {
+	t.moot.RLock()
+	defer t.moot.RUnlock()
+	seen := map[string]bool{}
+	chain := []string{}
+	add := func(l string) {
+		l = normalize(l)
+		if _, ok := t.translations[l]; ok && !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+	for _, r := range requested {
+		add(r)
+		add(base(r))
+		for _, f := range t.Fallbacks[normalize(r)] {
+			add(f)
+		}
+	}
+	add(t.DefaultLanguage)
+	return chain
+}
+
+// ParamLanguage reads the language from a request param, such as "?lang=fr".
+func ParamLanguage(name string) LanguageExtractor {
+	return func(c buffalo.Context) []string {
+		if l := c.Param(name); l != "" {
+			return []string{l}
+		}
+		return nil
+	}
+}
+
+// CookieLanguage reads the language from a cookie.
+func CookieLanguage(name string) LanguageExtractor {
+	return func(c buffalo.Context) []string {
+		if ck, err := c.Request().Cookie(name); err == nil && ck.Value != "" {
+			return []string{ck.Value}
+		}
+		return nil
+	}
+}
+
+// HeaderLanguage reads the languages from the "Accept-Language" header,
+// ordered by their quality values.
+func HeaderLanguage(c buffalo.Context) []string {
+	return parseAcceptLanguage(c.Request().Header)
+}
+
+func parseAcceptLanguage(h http.Header) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	ws := []weighted{}
+	for _, part := range strings.Split(h.Get("Accept-Language"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w := weighted{lang: part, q: 1}
+		if i := strings.Index(part, ";"); i >= 0 {
+			w.lang = strings.TrimSpace(part[:i])
+			if q := strings.TrimSpace(part[i+1:]); strings.HasPrefix(q, "q=") {
+				w.q, _ = strconv.ParseFloat(q[2:], 64)
+			}
+		}
+		if w.lang != "*" && w.q > 0 {
+			ws = append(ws, w)
+		}
+	}
+	sort.SliceStable(ws, func(i, j int) bool {
+		return ws[i].q > ws[j].q
+	})
+	langs := make([]string, len(ws))
+	for i, w := range ws {
+		langs[i] = w.lang
+	}
+	return langs
+}
+
+// normalize turns "pt_br" and "PT-br" into "pt-BR".
+func normalize(l string) string {
+	l = strings.Replace(strings.TrimSpace(l), "_", "-", -1)
+	parts := strings.SplitN(l, "-", 2)
+	parts[0] = strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		parts[1] = strings.ToUpper(parts[1])
+	}
+	return strings.Join(parts, "-")
+}
+
+func base(l string) string {
+	if i := strings.Index(l, "-"); i > 0 {
+		return l[:i]
+	}
+	return l
+}
+
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+func isPlural(m map[string]interface{}) bool {
+	if _, ok := m["other"]; !ok {
+		return false
+	}
+	for k := range m {
+		if !pluralForms[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func flatten(dst map[string]interface{}, prefix string, src map[string]interface{}) {
+	for k, v := range src {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		m, ok := toStringMap(v)
+		switch {
+		case ok && isPlural(m):
+			forms := map[string]string{}
+			for f, s := range m {
+				forms[f] = fmt.Sprint(s)
+			}
+			dst[key] = forms
+		case ok:
+			flatten(dst, key, m)
+		default:
+			dst[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// toStringMap handles both JSON maps and the map[interface{}]interface{}
+// maps YAML produces.
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		sm := map[string]interface{}{}
+		for k, v := range m {
+			sm[fmt.Sprint(k)] = v
+		}
+		return sm, true
+	}
+	return nil, false
+}
+
+func pluralize(msg interface{}, named Args, rule PluralRule) string {
+	forms, ok := msg.(map[string]string)
+	if !ok {
+		return msg.(string)
+	}
+	count, ok := named["count"].(int)
+	if !ok {
+		return forms["other"]
+	}
+	if s, ok := forms["zero"]; ok && count == 0 {
+		return s
+	}
+	if rule == nil {
+		rule = pluralEnglish
+	}
+	if s, ok := forms[rule(count)]; ok {
+		return s
+	}
+	return forms["other"]
+}
+
+func findPlaceholders(msg interface{}) []string {
+	texts := []string{}
+	switch m := msg.(type) {
+	case string:
+		texts = append(texts, m)
+	case map[string]string:
+		for _, s := range m {
+			texts = append(texts, s)
+		}
+	}
+	ps := []string{}
+	for _, s := range texts {
+		for {
+			i := strings.Index(s, "%{")
+			if i < 0 {
+				break
+			}
+			j := strings.Index(s[i:], "}")
+			if j < 0 {
+				break
+			}
+			ps = append(ps, s[i+2:i+j])
+			s = s[i+j:]
+		}
+	}
+	return ps
+}
+
+func pluralEnglish(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func pluralFrench(n int) string {
+	if n == 0 || n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func pluralNone(n int) string {
+	return "other"
+}
+
+func pluralSlavic(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "one"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "few"
+	}
+	return "many"
+}
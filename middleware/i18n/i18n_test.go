@@ -0,0 +1,106 @@
+package i18n_test
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware/i18n"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/velvet"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+var locales = fstest.MapFS{
+	"en.yaml": {Data: []byte(`
+welcome: "Welcome to %{app}!"
+total: "Total: %.2f"
+users:
+  count:
+    zero: "No users"
+    one: "One user"
+    other: "%{count} users"
+`)},
+	"fr.json": {Data: []byte(`{
+	"welcome": "Bienvenue sur %{app} !",
+	"users": {"count": {"one": "%{count} utilisateur", "other": "%{count} utilisateurs"}}
+}`)},
+	"admin/pt-BR.yml": {Data: []byte(`welcome: "Bem-vindo ao %{app}!"`)},
+	"README.md":       {Data: []byte(`not a locale`)},
+}
+
+func translator(r *require.Assertions) *i18n.Translator {
+	T, err := i18n.New(locales, "en")
+	r.NoError(err)
+	return T
+}
+
+func Test_Translator_Load(t *testing.T) {
+	r := require.New(t)
+	T := translator(r)
+	r.Equal([]string{"en", "fr", "pt-BR"}, T.Languages())
+}
+
+func Test_Translator_TranslateIn(t *testing.T) {
+	r := require.New(t)
+	T := translator(r)
+
+	en := []string{"en"}
+	r.Equal("Welcome to Buffalo!", T.TranslateIn(en, "welcome", i18n.Args{"app": "Buffalo"}))
+	r.Equal("No users", T.TranslateIn(en, "users.count", 0))
+	r.Equal("One user", T.TranslateIn(en, "users.count", 1))
+	r.Equal("5 users", T.TranslateIn(en, "users.count", 5))
+	r.Equal("Total: 9.50", T.TranslateIn(en, "total", 9.5))
+	r.Equal("missing.key", T.TranslateIn(en, "missing.key"))
+
+	// French counts 0 as singular, and has no "zero" form
+	fr := []string{"fr", "en"}
+	r.Equal("0 utilisateur", T.TranslateIn(fr, "users.count", 0))
+	r.Equal("2 utilisateurs", T.TranslateIn(fr, "users.count", 2))
+	// falls back to English
+	r.Equal("Total: 1.00", T.TranslateIn(fr, "total", 1.0))
+}
+
+func Test_Translator_Middleware(t *testing.T) {
+	r := require.New(t)
+	T := translator(r)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(T.Middleware())
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String(c.T("welcome", i18n.Args{"app": "Buffalo"})))
+	})
+
+	w := willie.New(a)
+	r.Equal("Welcome to Buffalo!", w.Request("/").Get().Body.String())
+
+	req := w.Request("/")
+	req.Headers["Accept-Language"] = "de;q=0.9, fr;q=0.8, en;q=0.5"
+	r.Equal("Bienvenue sur Buffalo !", req.Get().Body.String())
+
+	req = w.Request("/")
+	req.Headers["Accept-Language"] = "pt-br"
+	r.Equal("Bem-vindo ao Buffalo!", req.Get().Body.String())
+
+	req = w.Request("/")
+	req.Headers["Cookie"] = (&http.Cookie{Name: "lang", Value: "fr"}).String()
+	r.Equal("Bienvenue sur Buffalo !", req.Get().Body.String())
+
+	req = w.Request("/?lang=pt_BR")
+	req.Headers["Accept-Language"] = "fr"
+	r.Equal("Bem-vindo ao Buffalo!", req.Get().Body.String())
+}
+
+func Test_Translator_Helper(t *testing.T) {
+	r := require.New(t)
+	T := translator(r)
+
+	ctx := velvet.NewContextWith(map[string]interface{}{
+		"languages": []string{"fr", "en"},
+		"app":       "Buffalo",
+	})
+	r.Equal("Bienvenue sur Buffalo !", T.Helper("welcome", velvet.HelperContext{Context: ctx}))
+	r.Equal("Total: %.2f", T.Helper("total", velvet.HelperContext{Context: ctx}))
+}
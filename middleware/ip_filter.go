@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrIPForbidden is returned, with a 403 status, for requests rejected
+// by the IPFilter middleware.
+var ErrIPForbidden = errors.New("access from your IP address is not allowed")
+
+// IPList is a list of IP addresses and CIDR ranges, see buffalo.IPList.
+type IPList = buffalo.IPList
+
+// NewIPList returns an IPList of the given addresses and CIDR ranges,
+// see buffalo.NewIPList.
+func NewIPList(entries ...string) (*IPList, error) {
+	return buffalo.NewIPList(entries...)
+}
+
+// ClientIP returns the address of the client that made the request,
+// see buffalo.ClientIP.
+func ClientIP(req *http.Request, trusted *IPList) net.IP {
+	return buffalo.ClientIP(req, trusted)
+}
+
+// IPFilterOptions configures the IPFilter middleware.
+type IPFilterOptions struct {
+	// Allow, if set, is the only addresses that may make requests.
+	Allow *IPList
+	// Deny is addresses that may not make requests. It is checked after
+	// Allow, so it can carve holes out of allowed ranges.
+	Deny *IPList
+	// TrustedProxies are the load balancers and proxies in front of the
+	// App. The "Forwarded" and "X-Forwarded-For" headers are only believed
+	// when the request comes from one of them. Default is the
+	// TrustedProxies option of the App, see Context.ClientIP.
+	TrustedProxies *IPList
+}
+
+// IPFilter returns a piece of buffalo.Middleware that restricts a route,
+// or group, to clients whose IP addresses are allowed. Rejected requests
+// are sent to the 403 ErrorHandler. The client's address is stored in the
+// Context as "client_ip".
+/*
+	office, _ := middleware.NewIPList("203.0.113.0/24")
+	proxies, _ := middleware.NewIPList("10.0.0.0/8")
+
+	admin := app.Group("/admin")
+	admin.Use(middleware.IPFilter(middleware.IPFilterOptions{
+		Allow:          office,
+		TrustedProxies: proxies,
+	}))
+
+	// later, after the office moves
+	office.Set("198.51.100.0/24")
+*/
+func IPFilter(opts IPFilterOptions) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			ip := clientIP(c, opts.TrustedProxies)
+			if ip != nil {
+				c.Set("client_ip", ip.String())
+			}
+			if opts.Allow != nil && !opts.Allow.Contains(ip) {
+				return c.Error(403, ErrIPForbidden)
+			}
+			if opts.Deny.Contains(ip) {
+				return c.Error(403, ErrIPForbidden)
+			}
+			return next(c)
+		}
+	}
+}
+
+// clientIP returns the address of the client, using the trusted proxies
+// if they are set, or else those of the App.
+func clientIP(c buffalo.Context, trusted *IPList) net.IP {
+	if trusted != nil {
+		return ClientIP(c.Request(), trusted)
+	}
+	return net.ParseIP(c.ClientIP())
+}
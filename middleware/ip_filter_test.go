@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClientIP(t *testing.T) {
+	r := require.New(t)
+	proxies, err := middleware.NewIPList("10.0.0.0/8")
+	r.NoError(err)
+
+	table := []struct {
+		remote  string
+		headers map[string]string
+		ip      string
+	}{
+		{"203.0.113.9:1234", nil, "203.0.113.9"},
+		// untrusted clients can't spoof their address
+		{"203.0.113.9:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7, 10.0.0.2"}, "198.51.100.7"},
+		{"10.0.0.1:1234", map[string]string{"Forwarded": `for=198.51.100.7;proto=https, for="[2001:db8::1]:4711"`}, "2001:db8::1"},
+		{"10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, tt := range table {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		r.Equal(tt.ip, middleware.ClientIP(req, proxies).String())
+	}
+}
+
+func Test_IPFilter(t *testing.T) {
+	r := require.New(t)
+
+	allow, err := middleware.NewIPList("203.0.113.0/24", "2001:db8::/32")
+	r.NoError(err)
+	deny, err := middleware.NewIPList("203.0.113.66")
+	r.NoError(err)
+	proxies, err := middleware.NewIPList("10.0.0.1")
+	r.NoError(err)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.IPFilter(middleware.IPFilterOptions{
+		Allow:          allow,
+		Deny:           deny,
+		TrustedProxies: proxies,
+	}))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String(c.Get("client_ip").(string)))
+	})
+
+	get := func(remote, xff string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	r.Equal(200, get("203.0.113.5:80", ""))
+	r.Equal(200, get("[2001:db8::5]:80", ""))
+	r.Equal(403, get("198.51.100.1:80", ""))
+	r.Equal(403, get("203.0.113.66:80", ""))
+	r.Equal(200, get("10.0.0.1:80", "203.0.113.5"))
+	r.Equal(403, get("10.0.0.1:80", "198.51.100.1"))
+
+	// hot reload
+	r.NoError(allow.Set("198.51.100.0/24"))
+	r.Equal(200, get("198.51.100.1:80", ""))
+	r.Equal(403, get("203.0.113.5:80", ""))
+
+	r.Error(allow.Set("nope"))
+	r.Equal(1, allow.Len())
+}
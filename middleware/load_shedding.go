@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// Priority is the class a request is placed in by a PriorityClassifier.
+// When the server is under pressure the LoadShedder middleware will
+// start rejecting the lowest classes first.
+type Priority int
+
+const (
+	// PriorityBackground is for work that can safely be retried later,
+	// such as batch jobs, prefetches, or crawlers. These requests are
+	// the first to be shed.
+	PriorityBackground Priority = iota
+	// PriorityInteractive is for requests a user is actively waiting on.
+	PriorityInteractive
+	// PriorityProbe is for health checks and other probes. These requests
+	// are never shed.
+	PriorityProbe
+)
+
+// PriorityClassifier places a request into a Priority class.
+type PriorityClassifier func(buffalo.Context) Priority
+
+// ErrLoadShed is returned, with a 503 status, for any request that
+// is rejected by the LoadShedder middleware.
+var ErrLoadShed = errors.New("server is overloaded, please try again later")
+
+// LoadShedder returns a piece of buffalo.Middleware that limits the
+// number of requests being handled at the same time to max. Requests
+// are classified using the PriorityClassifier, and lower classes are
+// shed first:
+//
+// * PriorityBackground requests are rejected once half of max (but
+//   at least one) is in use.
+// * PriorityInteractive requests are rejected once max is in use.
+// * PriorityProbe requests are always let through, and don't count
+//   towards max.
+//
+// Rejected requests are sent to the 503 ErrorHandler with a "Retry-After"
+// header set. If classifier is nil every request is considered to be
+// PriorityInteractive.
+/*
+	app.Use(middleware.LoadShedder(100, func(c buffalo.Context) middleware.Priority {
+		switch {
+		case c.Request().URL.Path == "/healthz":
+			return middleware.PriorityProbe
+		case strings.HasPrefix(c.Request().URL.Path, "/api/reports"):
+			return middleware.PriorityBackground
+		}
+		return middleware.PriorityInteractive
+	}))
+*/
+func LoadShedder(max int, classifier PriorityClassifier) buffalo.MiddlewareFunc {
+	if classifier == nil {
+		classifier = func(buffalo.Context) Priority {
+			return PriorityInteractive
+		}
+	}
+	background := max / 2
+	if background < 1 && max > 0 {
+		background = 1
+	}
+	limits := map[Priority]int64{
+		PriorityBackground:  int64(background),
+		PriorityInteractive: int64(max),
+	}
+	var inFlight int64
+	// acquire takes a slot, unless limit of them are already in use.
+	acquire := func(limit int64) bool {
+		for {
+			n := atomic.LoadInt64(&inFlight)
+			if n >= limit {
+				return false
+			}
+			if atomic.CompareAndSwapInt64(&inFlight, n, n+1) {
+				return true
+			}
+		}
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			limit, ok := limits[classifier(c)]
+			if !ok {
+				return next(c)
+			}
+			if !acquire(limit) {
+				c.Response().Header().Set("Retry-After", "1")
+				return c.Error(503, ErrLoadShed)
+			}
+			defer atomic.AddInt64(&inFlight, -1)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func lsApp(started chan bool, release chan bool) *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.LoadShedder(2, func(c buffalo.Context) middleware.Priority {
+		switch c.Request().URL.Path {
+		case "/probe":
+			return middleware.PriorityProbe
+		case "/background":
+			return middleware.PriorityBackground
+		}
+		return middleware.PriorityInteractive
+	}))
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	}
+	a.GET("/slow", func(c buffalo.Context) error {
+		started <- true
+		<-release
+		return c.Render(200, render.String("slow"))
+	})
+	a.GET("/probe", h)
+	a.GET("/background", h)
+	a.GET("/interactive", h)
+	return a
+}
+
+func Test_LoadShedder(t *testing.T) {
+	r := require.New(t)
+
+	started := make(chan bool)
+	release := make(chan bool)
+	w := willie.New(lsApp(started, release))
+
+	res := w.Request("/background").Get()
+	r.Equal(200, res.Code)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.Request("/slow").Get()
+	}()
+	<-started
+
+	// one slot in use: background work is shed first
+	res = w.Request("/background").Get()
+	r.Equal(503, res.Code)
+	r.Equal("1", res.Header().Get("Retry-After"))
+
+	res = w.Request("/interactive").Get()
+	r.Equal(200, res.Code)
+
+	res = w.Request("/probe").Get()
+	r.Equal(200, res.Code)
+
+	close(release)
+	wg.Wait()
+}
+
+func Test_LoadShedder_Small(t *testing.T) {
+	r := require.New(t)
+
+	started := make(chan bool)
+	release := make(chan bool)
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.LoadShedder(1, func(c buffalo.Context) middleware.Priority {
+		switch c.Request().URL.Path {
+		case "/probe":
+			return middleware.PriorityProbe
+		case "/background":
+			return middleware.PriorityBackground
+		}
+		return middleware.PriorityInteractive
+	}))
+	a.GET("/background", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/probe", func(c buffalo.Context) error {
+		started <- true
+		<-release
+		return c.Render(200, render.String("probe"))
+	})
+	w := willie.New(a)
+
+	// a max of one still lets background work through
+	res := w.Request("/background").Get()
+	r.Equal(200, res.Code)
+
+	// in flight probes don't take up any of the slots
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.Request("/probe").Get()
+	}()
+	<-started
+
+	res = w.Request("/background").Get()
+	r.Equal(200, res.Code)
+
+	close(release)
+	wg.Wait()
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// FilteredValue replaces the values of filtered parameters.
+const FilteredValue = buffalo.RedactedValue
+
+// maxParamBody is the most of a JSON body ParameterLogger will read.
+const maxParamBody = 64 * 1024
+
+// ParameterLogger returns a piece of buffalo.Middleware that logs the
+// query, form, and JSON parameters of every request at the debug level,
+// which makes reproducing bug reports much easier. Values of parameters
+// redacted by buffalo.Redaction, or whose names contain any of the extra
+// filters given, are replaced with FilteredValue, as are any values that
+// look like credit card numbers.
+//
+// Bodies are only read when the logger logs at the debug level, see
+// buffalo.DebugEnabled. Multipart bodies are never read, so uploads are
+// left to the handler, and the limits of its route.
+/*
+	app.Use(middleware.ParameterLogger("pin", "otp"))
+*/
+func ParameterLogger(filters ...string) buffalo.MiddlewareFunc {
+	pf := paramFilter(filters)
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			if !buffalo.DebugEnabled(c.Logger()) {
+				return next(c)
+			}
+			params := pf.values(c.Request().URL.Query())
+			req := c.Request()
+			ct := strings.ToLower(req.Header.Get("Content-Type"))
+			switch {
+			case strings.Contains(ct, "json"):
+				if req.Body == nil {
+					break
+				}
+				b, err := ioutil.ReadAll(io.LimitReader(req.Body, maxParamBody))
+				if err != nil {
+					return err
+				}
+				// put back what was read, so the handler can still Bind
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+				var body interface{}
+				dec := json.NewDecoder(bytes.NewReader(b))
+				// keep numbers as written, so long ones can be filtered
+				dec.UseNumber()
+				if dec.Decode(&body) == nil {
+					if m, ok := pf.filter("", body).(map[string]interface{}); ok {
+						for k, v := range m {
+							params[k] = v
+						}
+					} else {
+						params["_json"] = pf.filter("", body)
+					}
+				}
+			case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+				if err := req.ParseForm(); err == nil {
+					for k, v := range pf.values(req.PostForm) {
+						params[k] = v
+					}
+				}
+			}
+			if len(params) > 0 {
+				c.Logger().WithField("params", params).Debug("request parameters")
+			}
+			return next(c)
+		}
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// creditCardPattern matches runs of 13 to 19 digits, with optional
+// spaces or dashes, that are then checked with the Luhn algorithm.
+var creditCardPattern = regexp.MustCompile(`\d(?:[ -]?\d){12,18}`)
+
+// paramFilter holds the extra filters given to ParameterLogger.
+type paramFilter []string
+
+func (pf paramFilter) filtered(key string) bool {
+	if buffalo.Redaction.FieldRedacted(key) {
+		return true
+	}
+	key = strings.ToLower(key)
+	for _, f := range pf {
+		if strings.Contains(key, strings.ToLower(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pf paramFilter) values(vals url.Values) map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, vs := range vals {
+		out := make([]interface{}, len(vs))
+		for i, v := range vs {
+			out[i] = pf.filter(k, v)
+		}
+		if len(out) == 1 {
+			m[k] = out[0]
+		} else {
+			m[k] = out
+		}
+	}
+	return m
+}
+
+// filter returns v with any filtered keys, or credit card numbers,
+// replaced.
+func (pf paramFilter) filter(key string, v interface{}) interface{} {
+	if key != "" && pf.filtered(key) {
+		return FilteredValue
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = pf.filter(k, v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = pf.filter("", v)
+		}
+		return s
+	case json.Number:
+		if s := pf.filter("", string(t)); s != string(t) {
+			return FilteredValue
+		}
+	case string:
+		return creditCardPattern.ReplaceAllStringFunc(t, func(s string) string {
+			if luhn(s) {
+				return FilteredValue
+			}
+			return s
+		})
+	}
+	return v
+}
+
+func luhn(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
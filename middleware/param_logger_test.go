@@ -0,0 +1,116 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParameterLogger(t *testing.T) {
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	l := logrus.New()
+	l.Out = bb
+	l.Level = logrus.DebugLevel
+	l.Formatter = &logrus.JSONFormatter{}
+
+	a := buffalo.New(buffalo.Options{Logger: testLogger{l}})
+	a.Use(middleware.ParameterLogger("pin"))
+	h := func(c buffalo.Context) error {
+		m := map[string]interface{}{}
+		if err := c.Bind(&m); err != nil {
+			return err
+		}
+		return c.Render(200, render.JSON(m))
+	}
+	a.POST("/json", h)
+	a.POST("/form", func(c buffalo.Context) error {
+		return c.Render(200, render.String(c.Request().FormValue("name")))
+	})
+
+	w := willie.New(a)
+	res := w.JSON("/json?page=2&access_token=abc").Post(map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":    "mark@example.com",
+			"password": "hunter2",
+		},
+		"card":   "4111 1111 1111 1111",
+		"number": 5500000000000004,
+		"PIN":    "1234",
+	})
+	r.Equal(200, res.Code)
+	// the handler can still read the body
+	r.Contains(res.Body.String(), "hunter2")
+
+	out := bb.String()
+	r.Contains(out, "request parameters")
+	r.Contains(out, "mark@example.com")
+	r.Contains(out, `"page":"2"`)
+	r.NotContains(out, "hunter2")
+	r.NotContains(out, "abc")
+	r.NotContains(out, "4111")
+	r.NotContains(out, "5500")
+	r.NotContains(out, "1234")
+	r.Contains(out, middleware.FilteredValue)
+
+	bb.Reset()
+	fres := w.Request("/form").Post(url.Values{"name": []string{"mark"}, "password": []string{"hunter2"}, "order": []string{"1234567890123"}})
+	r.Equal("mark", fres.Body.String())
+	out = bb.String()
+	r.Contains(out, "mark")
+	r.NotContains(out, "hunter2")
+	// not a valid card number
+	r.Contains(out, "1234567890123")
+}
+
+func Test_ParameterLogger_NotDebugging(t *testing.T) {
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	l := logrus.New()
+	l.Out = bb
+	l.Level = logrus.InfoLevel
+
+	a := buffalo.New(buffalo.Options{Logger: testLogger{l}})
+	a.Use(middleware.ParameterLogger())
+	a.POST("/", func(c buffalo.Context) error {
+		r.Nil(c.Request().PostForm)
+		return c.Render(200, render.String(c.Request().FormValue("name")))
+	})
+
+	w := willie.New(a)
+	res := w.Request("/").Post(url.Values{"name": []string{"mark"}})
+	r.Equal("mark", res.Body.String())
+	r.NotContains(bb.String(), "request parameters")
+}
+
+// testLogger adapts a logrus.FieldLogger to the buffalo.Logger interface.
+type testLogger struct {
+	logrus.FieldLogger
+}
+
+func (l testLogger) WithField(k string, v interface{}) buffalo.Logger {
+	return testLogger{l.FieldLogger.WithField(k, v)}
+}
+
+func (l testLogger) WithFields(m map[string]interface{}) buffalo.Logger {
+	return testLogger{l.FieldLogger.WithFields(m)}
+}
+
+func (l testLogger) DebugEnabled() bool {
+	switch t := l.FieldLogger.(type) {
+	case *logrus.Logger:
+		return t.Level >= logrus.DebugLevel
+	case *logrus.Entry:
+		return t.Logger.Level >= logrus.DebugLevel
+	}
+	return true
+}
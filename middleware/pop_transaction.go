@@ -11,13 +11,15 @@ import (
 // request in a transaction that will automatically get committed or
 // rolledback. It will also add a field to the log, "db", that
 // shows the total duration spent during the reques making database
-// calls.
+// calls. Work that must wait for the commit can be added with
+// AfterCommit.
 var PopTransaction = func(db *pop.Connection) buffalo.MiddlewareFunc {
 	return func(h buffalo.Handler) buffalo.Handler {
 		return func(c buffalo.Context) error {
 			// wrap all requests in a transaction and set the length
 			// of time doing things in the db to the log.
-			return db.Transaction(func(tx *pop.Connection) error {
+			ac := &afterCommit{}
+			err := db.Transaction(func(tx *pop.Connection) error {
 				start := tx.Elapsed
 				defer func() {
 					finished := tx.Elapsed
@@ -25,8 +27,14 @@ var PopTransaction = func(db *pop.Connection) buffalo.MiddlewareFunc {
 					c.LogField("db", elapsed)
 				}()
 				c.Set("tx", tx)
+				buffalo.Set(c, afterCommitKey, ac)
 				return h(c)
 			})
+			if err != nil {
+				return err
+			}
+			ac.run()
+			return nil
 		}
 	}
 }
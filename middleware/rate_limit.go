@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrRateLimited is returned, with a 429 status, for any request that
+// has exceeded its rate limit.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitKeyer returns the key a request should be counted against.
+// Returning an empty string will exempt the request from rate limiting.
+type RateLimitKeyer func(buffalo.Context) string
+
+// RateLimitStore keeps track of the number of hits a key has received
+// during the current window.
+type RateLimitStore interface {
+	// Increment the hit counter for key. It returns the number of hits
+	// in the current window, and how long until that window resets.
+	Increment(key string, window time.Duration) (int, time.Duration, error)
+}
+
+// RateLimitByIP keys requests on the IP address of the client, see
+// Context.ClientIP.
+func RateLimitByIP(c buffalo.Context) string {
+	if ip := c.ClientIP(); ip != "" {
+		return "ip:" + ip
+	}
+	return "ip:" + c.Request().RemoteAddr
+}
+
+// RateLimitByRoute keys requests on the route they matched, so each
+// route gets its own limit that is shared by all clients.
+func RateLimitByRoute(c buffalo.Context) string {
+	if ri, ok := buffalo.Get(c, buffalo.CurrentRouteKey); ok && ri.Path != "" {
+		return "route:" + ri.Method + " " + ri.Path
+	}
+	return "route:" + c.Request().Method + " " + c.Request().URL.Path
+}
+
+// RateLimitByUser keys requests on the value stored in the session
+// under the name key, usually the ID of the current user. Requests
+// without a user fall back to being limited by IP.
+func RateLimitByUser(key string) RateLimitKeyer {
+	return func(c buffalo.Context) string {
+		if id := c.Session().Get(key); id != nil {
+			return fmt.Sprintf("user:%v", id)
+		}
+		return RateLimitByIP(c)
+	}
+}
+
+// RateLimit returns a piece of buffalo.Middleware that allows limit
+// requests per window for each key returned by the keyer. Every response
+// carries the "RateLimit-Limit", "RateLimit-Remaining", and
+// "RateLimit-Reset" headers. Requests over the limit also get a
+// "Retry-After" header and are sent to the 429 ErrorHandler.
+//
+// If keyer is nil requests are limited by IP, and if store is nil an
+// in memory store is used.
+/*
+	app.Use(middleware.RateLimit(100, time.Minute, middleware.RateLimitByIP, nil))
+
+	api := app.Group("/api")
+	api.Use(middleware.RateLimit(1000, time.Hour, middleware.RateLimitByUser("current_user_id"), redisStore))
+*/
+func RateLimit(limit int, window time.Duration, keyer RateLimitKeyer, store RateLimitStore) buffalo.MiddlewareFunc {
+	if keyer == nil {
+		keyer = RateLimitByIP
+	}
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			key := keyer(c)
+			if key == "" {
+				return next(c)
+			}
+			count, reset, err := store.Increment(key, window)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			remaining := limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			secs := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			h.Set("RateLimit-Reset", secs)
+			if count > limit {
+				h.Set("Retry-After", secs)
+				return c.Error(429, ErrRateLimited)
+			}
+			return next(c)
+		}
+	}
+}
+
+type rateLimitWindow struct {
+	count int
+	reset time.Time
+}
+
+// MemoryRateLimitStore is a RateLimitStore that keeps its counters in
+// memory. It is only suitable for applications running a single
+// instance.
+type MemoryRateLimitStore struct {
+	windows map[string]*rateLimitWindow
+	moot    *sync.Mutex
+	sweep   time.Time
+}
+
+// NewMemoryRateLimitStore returns a new, empty, MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: map[string]*rateLimitWindow{},
+		moot:    &sync.Mutex{},
+	}
+}
+
+// Increment the hit counter for key.
+func (s *MemoryRateLimitStore) Increment(key string, window time.Duration) (int, time.Duration, error) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+
+	now := time.Now()
+	if now.After(s.sweep) {
+		// drop expired windows so the map doesn't grow forever
+		for k, w := range s.windows {
+			if now.After(w.reset) {
+				delete(s.windows, k)
+			}
+		}
+		s.sweep = now.Add(window)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || now.After(w.reset) {
+		w = &rateLimitWindow{reset: now.Add(window)}
+		s.windows[key] = w
+	}
+	w.count++
+	return w.count, w.reset.Sub(now), nil
+}
+
+// the counter and its expiry have to be set atomically, otherwise
+// a crash between the two calls would leave a key that never expires.
+const redisRateLimitScript = `
+local c = redis.call("INCR", KEYS[1])
+if c == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {c, redis.call("PTTL", KEYS[1])}
+`
+
+// RedisRateLimitStore is a RateLimitStore backed by Redis, allowing
+// the limits to be shared across multiple instances of an application.
+type RedisRateLimitStore struct {
+	Conn RedisConn
+	// Prefix is prepended to every key. Defaults to "buffalo:ratelimit:".
+	Prefix string
+}
+
+// NewRedisRateLimitStore returns a RedisRateLimitStore using conn.
+func NewRedisRateLimitStore(conn RedisConn) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		Conn:   conn,
+		Prefix: "buffalo:ratelimit:",
+	}
+}
+
+// Increment the hit counter for key.
+func (s *RedisRateLimitStore) Increment(key string, window time.Duration) (int, time.Duration, error) {
+	res, err := s.Conn.Do("EVAL", redisRateLimitScript, 1, s.Prefix+key, int64(window/time.Millisecond))
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 2 {
+		return 0, 0, errors.Errorf("unexpected reply from redis: %v", res)
+	}
+	count, ok := vals[0].(int64)
+	if !ok {
+		return 0, 0, errors.Errorf("unexpected reply from redis: %v", res)
+	}
+	ttl, ok := vals[1].(int64)
+	if !ok || ttl < 0 {
+		ttl = int64(window / time.Millisecond)
+	}
+	return int(count), time.Duration(ttl) * time.Millisecond, nil
+}
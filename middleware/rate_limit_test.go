@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RateLimit(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.RateLimit(2, time.Minute, middleware.RateLimitByRoute, nil))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/other", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+
+	w := willie.New(a)
+	res := w.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal("2", res.Header().Get("RateLimit-Limit"))
+	r.Equal("1", res.Header().Get("RateLimit-Remaining"))
+	r.Equal("60", res.Header().Get("RateLimit-Reset"))
+
+	res = w.Request("/").Get()
+	r.Equal(200, res.Code)
+	r.Equal("0", res.Header().Get("RateLimit-Remaining"))
+
+	res = w.Request("/").Get()
+	r.Equal(429, res.Code)
+	r.NotEmpty(res.Header().Get("Retry-After"))
+
+	res = w.Request("/other").Get()
+	r.Equal(200, res.Code)
+}
+
+func Test_MemoryRateLimitStore(t *testing.T) {
+	r := require.New(t)
+
+	s := middleware.NewMemoryRateLimitStore()
+	c, _, err := s.Increment("a", time.Millisecond)
+	r.NoError(err)
+	r.Equal(1, c)
+	c, _, _ = s.Increment("a", time.Millisecond)
+	r.Equal(2, c)
+
+	time.Sleep(2 * time.Millisecond)
+	c, reset, _ := s.Increment("a", time.Millisecond)
+	r.Equal(1, c)
+	r.True(reset <= time.Millisecond)
+}
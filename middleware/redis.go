@@ -0,0 +1,11 @@
+package middleware
+
+import "github.com/gobuffalo/buffalo/redisconn"
+
+// RedisConn is the small slice of a Redis client that the Redis backed
+// stores in this package need, see redisconn.Conn.
+type RedisConn = redisconn.Conn
+
+// RedisFunc adapts a function to the RedisConn interface, see
+// redisconn.Func.
+type RedisFunc = redisconn.Func
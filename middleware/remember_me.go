@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// RememberToken is a remember me token, as kept by a RememberStore. The
+// token is made of a series, that finds it, and a validator, that only
+// the client has, the store keeps a hash of it, so a leaked store can't
+// be used to log in.
+type RememberToken struct {
+	Series  string
+	Hash    string
+	UserID  string
+	Expires time.Time
+	// PreviousHash is the hash of the validator before the token was
+	// last rotated, at RotatedAt, see RememberMe.Grace.
+	PreviousHash string
+	RotatedAt    time.Time
+}
+
+// RememberStore keeps the remember me tokens of a RememberMe.
+type RememberStore interface {
+	// Find the token of the series, false if there is none.
+	Find(series string) (RememberToken, bool, error)
+	// Save the token, replacing the one of the same series.
+	Save(t RememberToken) error
+	// Delete the token of the series.
+	Delete(series string) error
+	// DeleteUser deletes all the tokens of the user.
+	DeleteUser(userID string) error
+}
+
+// RememberSwapper is a RememberStore that can save a token only if it
+// is still the same as old, so when parallel requests with the same
+// token rotate it, only one of them does.
+type RememberSwapper interface {
+	// Swap saves t if the token of its series is old, and reports
+	// whether it did.
+	Swap(old, t RememberToken) (bool, error)
+}
+
+// RememberMe keeps users logged in, across sessions, with long lived
+// tokens in a cookie. When a request has no logged in user, but a valid
+// token, the user is logged in, the session is regenerated, and the
+// token is rotated, so each token can only be used once. A token that is
+// used again, after it has been rotated, means it was stolen, and all of
+// the user's tokens are revoked. Browsers send parallel requests with the
+// same token though, so the previous one is still accepted for a Grace
+// period, and stores that are a RememberSwapper make sure it is only
+// rotated once.
+/*
+	rm := &middleware.RememberMe{Store: tokens}
+	app.Use(rm.Middleware)
+
+	func Login(c buffalo.Context) error {
+		...
+		c.Session().Regenerate()
+		c.Session().Set("current_user_id", u.ID)
+		if c.Param("remember_me") == "on" {
+			if err := rm.Remember(c, u.ID); err != nil {
+				return err
+			}
+		}
+		return c.Redirect(303, "/")
+	}
+*/
+type RememberMe struct {
+	Store RememberStore
+	// Cookie is the name of the cookie, default is "remember_me".
+	Cookie string
+	// TTL of the tokens, default is 30 days.
+	TTL time.Duration
+	// SessionKey the user ID is set in the session under, default is
+	// "current_user_id".
+	SessionKey string
+	// Grace is how long the previous validator of a rotated token is
+	// still accepted, default is 30 seconds. A negative Grace accepts
+	// none.
+	Grace time.Duration
+}
+
+func (rm *RememberMe) cookie() string {
+	if rm.Cookie == "" {
+		return "remember_me"
+	}
+	return rm.Cookie
+}
+
+func (rm *RememberMe) ttl() time.Duration {
+	if rm.TTL == 0 {
+		return 30 * 24 * time.Hour
+	}
+	return rm.TTL
+}
+
+func (rm *RememberMe) grace() time.Duration {
+	if rm.Grace == 0 {
+		return 30 * time.Second
+	}
+	return rm.Grace
+}
+
+func (rm *RememberMe) sessionKey() string {
+	if rm.SessionKey == "" {
+		return "current_user_id"
+	}
+	return rm.SessionKey
+}
+
+// Remember issues a new token for the user, and sets it in the cookie.
+// Call it when a user logs in with "remember me" checked.
+func (rm *RememberMe) Remember(c buffalo.Context, userID string) error {
+	series, err := randomToken()
+	if err != nil {
+		return err
+	}
+	return rm.issue(c, series, userID)
+}
+
+// issue a new validator for the series, and set it in the cookie.
+func (rm *RememberMe) issue(c buffalo.Context, series string, userID string) error {
+	validator, err := randomToken()
+	if err != nil {
+		return err
+	}
+	t := RememberToken{
+		Series:  series,
+		Hash:    hashValidator(validator),
+		UserID:  userID,
+		Expires: time.Now().Add(rm.ttl()),
+	}
+	if err := rm.Store.Save(t); err != nil {
+		return errors.WithStack(err)
+	}
+	c.Cookies().Set(rm.cookie(), series+":"+validator, rm.ttl())
+	return nil
+}
+
+// rotate gives the token a new validator, and sets it in the cookie,
+// unless a parallel request has just done so.
+func (rm *RememberMe) rotate(c buffalo.Context, old RememberToken) error {
+	validator, err := randomToken()
+	if err != nil {
+		return err
+	}
+	t := old
+	t.Hash = hashValidator(validator)
+	t.PreviousHash = old.Hash
+	t.RotatedAt = time.Now()
+	t.Expires = t.RotatedAt.Add(rm.ttl())
+	if sw, ok := rm.Store.(RememberSwapper); ok {
+		swapped, err := sw.Swap(old, t)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !swapped {
+			return nil
+		}
+	} else if err := rm.Store.Save(t); err != nil {
+		return errors.WithStack(err)
+	}
+	c.Cookies().Set(rm.cookie(), t.Series+":"+validator, rm.ttl())
+	return nil
+}
+
+// Forget revokes the token of the request, and deletes the cookie. Call
+// it when the user logs out.
+func (rm *RememberMe) Forget(c buffalo.Context) error {
+	defer c.Cookies().Delete(rm.cookie())
+	series, _, ok := rm.read(c)
+	if !ok {
+		return nil
+	}
+	return errors.WithStack(rm.Store.Delete(series))
+}
+
+// ForgetUser revokes all the tokens of the user, such as when their
+// password changes.
+func (rm *RememberMe) ForgetUser(userID string) error {
+	return errors.WithStack(rm.Store.DeleteUser(userID))
+}
+
+func (rm *RememberMe) read(c buffalo.Context) (string, string, bool) {
+	v, err := c.Cookies().Get(rm.cookie())
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(v, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Middleware logs in the user of a valid token, if the session has no
+// user, see RememberMe.
+func (rm *RememberMe) Middleware(next buffalo.Handler) buffalo.Handler {
+	return func(c buffalo.Context) error {
+		if c.Session().Get(rm.sessionKey()) != nil {
+			return next(c)
+		}
+		series, validator, ok := rm.read(c)
+		if !ok {
+			return next(c)
+		}
+		t, found, err := rm.Store.Find(series)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		hash := []byte(hashValidator(validator))
+		switch {
+		case !found:
+			c.Cookies().Delete(rm.cookie())
+		case time.Now().After(t.Expires):
+			c.Cookies().Delete(rm.cookie())
+			if err := rm.Store.Delete(series); err != nil {
+				return errors.WithStack(err)
+			}
+		case subtle.ConstantTimeCompare([]byte(t.Hash), hash) == 1:
+			if err := rm.rotate(c, t); err != nil {
+				return err
+			}
+			c.Session().Regenerate()
+			c.Session().Set(rm.sessionKey(), t.UserID)
+		case subtle.ConstantTimeCompare([]byte(t.PreviousHash), hash) == 1 && time.Since(t.RotatedAt) <= rm.grace():
+			// sent in parallel with the request that rotated it, which
+			// gives the client the new one
+			c.Session().Regenerate()
+			c.Session().Set(rm.sessionKey(), t.UserID)
+		default:
+			// the series is right, but the validator has already been
+			// rotated, so someone else has used this token.
+			c.Logger().WithField("user_id", t.UserID).Warn("remember me token reused, revoking the user's tokens")
+			c.Cookies().Delete(rm.cookie())
+			if err := rm.Store.DeleteUser(t.UserID); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return next(c)
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashValidator(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+type memoryRememberStore struct {
+	moot   sync.Mutex
+	tokens map[string]RememberToken
+}
+
+// MemoryRememberStore returns a RememberStore that keeps tokens in the
+// memory of the process, for development and tests.
+func MemoryRememberStore() RememberStore {
+	return &memoryRememberStore{tokens: map[string]RememberToken{}}
+}
+
+func (m *memoryRememberStore) Find(series string) (RememberToken, bool, error) {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	t, ok := m.tokens[series]
+	return t, ok, nil
+}
+
+func (m *memoryRememberStore) Save(t RememberToken) error {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	m.tokens[t.Series] = t
+	return nil
+}
+
+func (m *memoryRememberStore) Swap(old, t RememberToken) (bool, error) {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	if cur, ok := m.tokens[old.Series]; !ok || cur.Hash != old.Hash {
+		return false, nil
+	}
+	m.tokens[t.Series] = t
+	return true, nil
+}
+
+func (m *memoryRememberStore) Delete(series string) error {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	delete(m.tokens, series)
+	return nil
+}
+
+func (m *memoryRememberStore) DeleteUser(userID string) error {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	for k, t := range m.tokens {
+		if t.UserID == userID {
+			delete(m.tokens, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func rememberCookie(res *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range res.Result().Cookies() {
+		if c.Name == "remember_me" {
+			return c
+		}
+	}
+	return nil
+}
+
+func rememberApp(rm *middleware.RememberMe) *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(rm.Middleware)
+	a.GET("/login", func(c buffalo.Context) error {
+		c.Session().Set("current_user_id", "u1")
+		if err := rm.Remember(c, "u1"); err != nil {
+			return err
+		}
+		return c.String(200, "ok")
+	})
+	a.GET("/whoami", func(c buffalo.Context) error {
+		id, _ := c.Session().GetString("current_user_id")
+		return c.String(200, id)
+	})
+	a.GET("/logout", func(c buffalo.Context) error {
+		c.Session().Clear()
+		return rm.Forget(c)
+	})
+	return a
+}
+
+func rememberGet(a *buffalo.App, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_RememberMe(t *testing.T) {
+	r := require.New(t)
+
+	rm := &middleware.RememberMe{
+		Store: middleware.MemoryRememberStore(),
+		Grace: 20 * time.Millisecond,
+	}
+	a := rememberApp(rm)
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		return rememberGet(a, path, cookies...)
+	}
+
+	first := rememberCookie(get("/login"))
+	r.NotNil(first)
+
+	// a new session is logged in by the token, which is rotated
+	res := get("/whoami", first)
+	r.Equal("u1", res.Body.String())
+	second := rememberCookie(res)
+	r.NotNil(second)
+	r.NotEqual(first.Value, second.Value)
+
+	// the old token is still good, for requests sent at the same time
+	res = get("/whoami", first)
+	r.Equal("u1", res.Body.String())
+	r.Nil(rememberCookie(res))
+
+	// but reusing it later revokes them all
+	time.Sleep(30 * time.Millisecond)
+	res = get("/whoami", first)
+	r.Equal("", res.Body.String())
+	r.Equal("", get("/whoami", second).Body.String())
+
+	// logging out revokes the token
+	third := rememberCookie(get("/login"))
+	get("/logout", third)
+	r.Equal("", get("/whoami", third).Body.String())
+}
+
+func Test_RememberMe_Parallel(t *testing.T) {
+	r := require.New(t)
+
+	rm := &middleware.RememberMe{Store: middleware.MemoryRememberStore()}
+	a := rememberApp(rm)
+	first := rememberCookie(rememberGet(a, "/login"))
+	r.NotNil(first)
+
+	// a browser opening several tabs at once
+	var wg sync.WaitGroup
+	ids := make([]string, 10)
+	rotated := make([]*http.Cookie, 10)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res := rememberGet(a, "/whoami", first)
+			ids[i] = res.Body.String()
+			rotated[i] = rememberCookie(res)
+		}(i)
+	}
+	wg.Wait()
+
+	var second *http.Cookie
+	for i, id := range ids {
+		r.Equal("u1", id)
+		if rotated[i] != nil {
+			r.Nil(second, "rotated more than once")
+			second = rotated[i]
+		}
+	}
+	r.NotNil(second)
+	r.Equal("u1", rememberGet(a, "/whoami", second).Body.String())
+}
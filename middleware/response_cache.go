@@ -0,0 +1,572 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// CachedResponse is a response stored by the ResponseCache middleware.
+type CachedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Expires    time.Time   `json:"expires"`
+	StaleUntil time.Time   `json:"stale_until"`
+}
+
+// CacheEntry holds all of the variants, by query string and the headers
+// in ResponseCacheOptions.Vary, of the response for a single method and
+// path.
+type CacheEntry struct {
+	Variants map[string]*CachedResponse `json:"variants"`
+}
+
+// CacheStore is a backend for the ResponseCache middleware. Get should
+// return nil, and no error, on a miss.
+type CacheStore interface {
+	Get(key string) (*CacheEntry, error)
+	Set(key string, e *CacheEntry, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// ResponseCacheOptions configures the ResponseCache middleware.
+type ResponseCacheOptions struct {
+	// Store to keep responses in. Defaults to a MemoryCacheStore.
+	Store CacheStore
+	// TTL is how long a response is fresh for, unless the response sets
+	// its own "Cache-Control: max-age" or "s-maxage". Defaults to 1 minute.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long an expired response may still be
+	// served, while a fresh one is rendered in the background. Responses
+	// can set their own with "Cache-Control: stale-while-revalidate".
+	StaleWhileRevalidate time.Duration
+	// Vary lists the request headers that produce a different response,
+	// such as "Accept" or "Accept-Language".
+	Vary []string
+}
+
+// ResponseCache returns a piece of buffalo.Middleware that caches 200
+// responses to GET requests. Responses are keyed on the method, the path
+// and query, and the request headers listed in Vary. Handlers can control
+// caching with the "Cache-Control" response header: "no-store" and
+// "private" responses are never cached, while "max-age", "s-maxage", and
+// "stale-while-revalidate" override the options. Requests sent with
+// "Cache-Control: no-cache" skip the cache and refresh it.
+//
+// Requests with an "Authorization" or "Cookie" header, whose responses
+// may be personal, skip the cache, and responses that set a cookie, such
+// as a new session, are never cached.
+//
+// Use CacheBust to remove a path from the cache, for example after it
+// has been updated.
+/*
+	app.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{
+		TTL:  5 * time.Minute,
+		Vary: []string{"Accept"},
+	}))
+*/
+func ResponseCache(opts ResponseCacheOptions) buffalo.MiddlewareFunc {
+	if opts.Store == nil {
+		opts.Store = NewMemoryCacheStore()
+	}
+	if opts.TTL == 0 {
+		opts.TTL = time.Minute
+	}
+	rc := &responseCache{
+		ResponseCacheOptions: opts,
+		revalidating:         map[string]bool{},
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			c.Set("response_cache", opts.Store)
+			req := c.Request()
+			dc, ok := c.(*buffalo.DefaultContext)
+			if !ok || req.Method != "GET" || req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "" {
+				return next(c)
+			}
+			key := cacheKey(c, req.Method, req.URL.EscapedPath())
+			variant := rc.variant(req)
+
+			if !strings.Contains(req.Header.Get("Cache-Control"), "no-cache") {
+				entry, err := opts.Store.Get(key)
+				if err != nil {
+					c.Logger().Error(err)
+				}
+				if entry != nil {
+					if cr := entry.Variants[variant]; cr != nil {
+						now := time.Now()
+						switch {
+						case now.Before(cr.Expires):
+							return writeCached(c.Response(), cr, "HIT")
+						case now.Before(cr.StaleUntil):
+							rc.revalidate(dc, next, key, variant)
+							return writeCached(c.Response(), cr, "STALE")
+						}
+					}
+				}
+			}
+
+			cw := &cacheWriter{ResponseWriter: c.Response(), header: http.Header{}}
+			fc := dc.Fork(req, cw)
+			err := next(fc)
+			dc.Merge(fc)
+			if err != nil || cw.passthrough {
+				return err
+			}
+			// the session, if it changed, is saved as the response is
+			// written, so store it afterwards, to see its cookie.
+			cw.Header().Set("X-Cache", "MISS")
+			err = cw.flush()
+			rc.store(c, key, variant, cw)
+			return err
+		}
+	}
+}
+
+// CacheBust removes all cached responses for the given path, on the
+// host, and for the tenant, of the request, whatever their query string,
+// or Vary headers. Stores that are a
+// CachePrefixDeleter also remove the paths under it, so busting "/users"
+// removes "/users/42" too. It can be used by handlers running behind the
+// ResponseCache middleware.
+/*
+	func UsersUpdate(c buffalo.Context) error {
+		// ...
+		middleware.CacheBust(c, "/users/"+id)
+		return c.Redirect(302, "/users/%s", id)
+	}
+*/
+func CacheBust(c buffalo.Context, path string) error {
+	store, ok := c.Get("response_cache").(CacheStore)
+	if !ok {
+		return nil
+	}
+	if err := store.Delete(cacheKey(c, "GET", path)); err != nil {
+		return err
+	}
+	if pd, ok := store.(CachePrefixDeleter); ok {
+		return pd.DeletePrefix(cacheKey(c, "GET", strings.TrimSuffix(path, "/")+"/"))
+	}
+	return nil
+}
+
+// CachePrefixDeleter is a CacheStore that can delete every key with a
+// prefix, see CacheBust.
+type CachePrefixDeleter interface {
+	DeletePrefix(prefix string) error
+}
+
+// cacheKey is the key of the responses to a path, which are kept apart
+// for each host, and tenant, as host routes, and tenants, can answer the
+// same path differently. The host has to be in the key, rather than in
+// Vary, as it isn't one of the headers of the request.
+func cacheKey(c buffalo.Context, method, path string) string {
+	key := method + " " + strings.ToLower(c.Request().Host)
+	if t := buffalo.TenantFrom(c); t != nil {
+		key += " tenant=" + t.ID
+	}
+	return key + " " + path
+}
+
+type responseCache struct {
+	ResponseCacheOptions
+	revalidating map[string]bool
+	moot         sync.Mutex
+}
+
+func (rc *responseCache) variant(req *http.Request) string {
+	parts := []string{"?" + req.URL.RawQuery}
+	for _, h := range rc.Vary {
+		parts = append(parts, h+"="+req.Header.Get(h))
+	}
+	return strings.Join(parts, "&")
+}
+
+// revalidate renders a fresh copy of the response in the background.
+// Only one revalidation per key, and variant, is run at a time.
+func (rc *responseCache) revalidate(dc *buffalo.DefaultContext, next buffalo.Handler, key, variant string) {
+	id := key + "#" + variant
+	rc.moot.Lock()
+	if rc.revalidating[id] {
+		rc.moot.Unlock()
+		return
+	}
+	rc.revalidating[id] = true
+	rc.moot.Unlock()
+
+	// the original request's context is canceled, and the Context
+	// reused, as soon as the stale response has been sent, so the
+	// refresh gets its own of both.
+	req := dc.Request().Clone(context.Background())
+	cw := &cacheWriter{ResponseWriter: discardWriter{}, header: http.Header{}}
+	fc := dc.Detach(req, cw)
+	go func() {
+		defer func() {
+			rc.moot.Lock()
+			delete(rc.revalidating, id)
+			rc.moot.Unlock()
+		}()
+		if err := next(fc); err != nil {
+			fc.Logger().Error(err)
+			return
+		}
+		rc.store(fc, key, variant, cw)
+	}()
+}
+
+func (rc *responseCache) store(c buffalo.Context, key, variant string, cw *cacheWriter) {
+	if cw.status != 0 && cw.status != http.StatusOK {
+		return
+	}
+	// a cookie set for one visitor must not be handed to the next
+	if len(cw.header["Set-Cookie"]) > 0 || len(cw.ResponseWriter.Header()["Set-Cookie"]) > 0 {
+		return
+	}
+	ttl, stale, ok := cacheControl(cw.header.Get("Cache-Control"), rc.TTL, rc.StaleWhileRevalidate)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	header := cw.header.Clone()
+	header.Del("Set-Cookie")
+	header.Del("X-Cache")
+	cr := &CachedResponse{
+		Status:     http.StatusOK,
+		Header:     header,
+		Body:       cw.buf.Bytes(),
+		Expires:    now.Add(ttl),
+		StaleUntil: now.Add(ttl + stale),
+	}
+	entry, err := rc.Store.Get(key)
+	if err != nil || entry == nil {
+		entry = &CacheEntry{}
+	}
+	if entry.Variants == nil {
+		entry.Variants = map[string]*CachedResponse{}
+	}
+	entry.Variants[variant] = cr
+	if err := rc.Store.Set(key, entry, ttl+stale); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// cacheControl parses the response "Cache-Control" header. It returns
+// false if the response must not be cached.
+func cacheControl(header string, ttl, stale time.Duration) (time.Duration, time.Duration, bool) {
+	sharedMax := false
+	for _, d := range strings.Split(header, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		name, value := d, ""
+		if i := strings.Index(d, "="); i > 0 {
+			name, value = d[:i], strings.Trim(d[i+1:], `"`)
+		}
+		secs, _ := strconv.Atoi(value)
+		switch name {
+		case "no-store", "private", "no-cache":
+			return 0, 0, false
+		case "s-maxage":
+			ttl = time.Duration(secs) * time.Second
+			sharedMax = true
+		case "max-age":
+			if !sharedMax {
+				ttl = time.Duration(secs) * time.Second
+			}
+		case "stale-while-revalidate":
+			stale = time.Duration(secs) * time.Second
+		}
+	}
+	return ttl, stale, ttl > 0
+}
+
+func writeCached(w http.ResponseWriter, cr *CachedResponse, state string) error {
+	h := w.Header()
+	for k, v := range cr.Header {
+		h[k] = v
+	}
+	h.Set("X-Cache", state)
+	w.WriteHeader(cr.Status)
+	_, err := w.Write(cr.Body)
+	return errors.WithStack(err)
+}
+
+type cacheWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	passthrough bool
+}
+
+func (cw *cacheWriter) Header() http.Header {
+	if cw.passthrough {
+		return cw.ResponseWriter.Header()
+	}
+	return cw.header
+}
+
+func (cw *cacheWriter) WriteHeader(status int) {
+	if cw.passthrough {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if cw.passthrough {
+		return cw.ResponseWriter.Write(b)
+	}
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	return cw.buf.Write(b)
+}
+
+// Flush means the handler is streaming, which can't be cached, so
+// send everything so far and pass the rest straight through.
+func (cw *cacheWriter) Flush() {
+	if !cw.passthrough {
+		cw.flush()
+		cw.passthrough = true
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *cacheWriter) flush() error {
+	h := cw.ResponseWriter.Header()
+	for k, v := range cw.header {
+		h[k] = v
+	}
+	if cw.status == 0 && cw.buf.Len() == 0 {
+		return nil
+	}
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	return errors.WithStack(err)
+}
+
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header         { return http.Header{} }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
+
+type memoryCacheItem struct {
+	entry   *CacheEntry
+	expires time.Time
+}
+
+// MemoryCacheStore is a CacheStore that keeps responses in memory.
+type MemoryCacheStore struct {
+	items map[string]memoryCacheItem
+	moot  *sync.RWMutex
+}
+
+// NewMemoryCacheStore returns a new, empty, MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		items: map[string]memoryCacheItem{},
+		moot:  &sync.RWMutex{},
+	}
+}
+
+// Get the CacheEntry for key.
+func (s *MemoryCacheStore) Get(key string) (*CacheEntry, error) {
+	s.moot.RLock()
+	defer s.moot.RUnlock()
+	it, ok := s.items[key]
+	if !ok || time.Now().After(it.expires) {
+		return nil, nil
+	}
+	// hand back a copy so callers can't change the stored entry
+	e := &CacheEntry{Variants: map[string]*CachedResponse{}}
+	for k, v := range it.entry.Variants {
+		e.Variants[k] = v
+	}
+	return e, nil
+}
+
+// Set the CacheEntry for key.
+func (s *MemoryCacheStore) Set(key string, e *CacheEntry, ttl time.Duration) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	now := time.Now()
+	for k, it := range s.items {
+		if now.After(it.expires) {
+			delete(s.items, k)
+		}
+	}
+	s.items[key] = memoryCacheItem{entry: e, expires: now.Add(ttl)}
+	return nil
+}
+
+// Delete the CacheEntry for key.
+func (s *MemoryCacheStore) Delete(key string) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// DeletePrefix deletes every CacheEntry whose key starts with prefix.
+func (s *MemoryCacheStore) DeletePrefix(prefix string) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	for k := range s.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.items, k)
+		}
+	}
+	return nil
+}
+
+// RedisCacheStore is a CacheStore backed by Redis.
+type RedisCacheStore struct {
+	Conn RedisConn
+	// Prefix is prepended to every key. Defaults to "buffalo:cache:".
+	Prefix string
+}
+
+// NewRedisCacheStore returns a RedisCacheStore using conn.
+func NewRedisCacheStore(conn RedisConn) *RedisCacheStore {
+	return &RedisCacheStore{Conn: conn, Prefix: "buffalo:cache:"}
+}
+
+// Get the CacheEntry for key.
+func (s *RedisCacheStore) Get(key string) (*CacheEntry, error) {
+	res, err := s.Conn.Do("GET", s.Prefix+key)
+	if err != nil || res == nil {
+		return nil, errors.WithStack(err)
+	}
+	b, ok := res.([]byte)
+	if !ok {
+		return nil, errors.Errorf("unexpected reply from redis: %v", res)
+	}
+	e := &CacheEntry{}
+	return e, errors.WithStack(json.Unmarshal(b, e))
+}
+
+// Set the CacheEntry for key.
+func (s *RedisCacheStore) Set(key string, e *CacheEntry, ttl time.Duration) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = s.Conn.Do("SET", s.Prefix+key, b, "PX", int64(ttl/time.Millisecond))
+	return errors.WithStack(err)
+}
+
+// Delete the CacheEntry for key.
+func (s *RedisCacheStore) Delete(key string) error {
+	_, err := s.Conn.Do("DEL", s.Prefix+key)
+	return errors.WithStack(err)
+}
+
+// DeletePrefix deletes every CacheEntry whose key starts with prefix,
+// using SCAN, so it doesn't block Redis.
+func (s *RedisCacheStore) DeletePrefix(prefix string) error {
+	cursor := "0"
+	for {
+		res, err := s.Conn.Do("SCAN", cursor, "MATCH", redisGlobEscape(s.Prefix+prefix)+"*", "COUNT", 100)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		reply, ok := res.([]interface{})
+		if !ok || len(reply) != 2 {
+			return errors.Errorf("unexpected reply from redis: %v", res)
+		}
+		keys, _ := reply[1].([]interface{})
+		if len(keys) > 0 {
+			if _, err := s.Conn.Do("DEL", keys...); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		cursor = redisString(reply[0])
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func redisString(v interface{}) string {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case string:
+		return t
+	}
+	return ""
+}
+
+// redisGlobEscape escapes the characters SCAN MATCH treats specially.
+func redisGlobEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+	return r.Replace(s)
+}
+
+// MemcacheClient is the small slice of a memcached client that the
+// MemcacheCacheStore needs. A thin wrapper around a
+// github.com/bradfitz/gomemcache/memcache.Client will do. Get should
+// return nil, and no error, on a miss.
+type MemcacheClient interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// MemcacheCacheStore is a CacheStore backed by memcached.
+type MemcacheCacheStore struct {
+	Client MemcacheClient
+	// Prefix is prepended to every key. Defaults to "buffalo:cache:".
+	Prefix string
+}
+
+// NewMemcacheCacheStore returns a MemcacheCacheStore using client.
+func NewMemcacheCacheStore(client MemcacheClient) *MemcacheCacheStore {
+	return &MemcacheCacheStore{Client: client, Prefix: "buffalo:cache:"}
+}
+
+// Get the CacheEntry for key.
+func (s *MemcacheCacheStore) Get(key string) (*CacheEntry, error) {
+	b, err := s.Client.Get(s.memcacheKey(key))
+	if err != nil || b == nil {
+		return nil, errors.WithStack(err)
+	}
+	e := &CacheEntry{}
+	return e, errors.WithStack(json.Unmarshal(b, e))
+}
+
+// Set the CacheEntry for key.
+func (s *MemcacheCacheStore) Set(key string, e *CacheEntry, ttl time.Duration) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(s.Client.Set(s.memcacheKey(key), b, ttl))
+}
+
+// Delete the CacheEntry for key.
+func (s *MemcacheCacheStore) Delete(key string) error {
+	return errors.WithStack(s.Client.Delete(s.memcacheKey(key)))
+}
+
+// memcached keys can't contain spaces or control characters.
+func (s *MemcacheCacheStore) memcacheKey(key string) string {
+	return s.Prefix + strings.Replace(key, " ", "_", -1)
+}
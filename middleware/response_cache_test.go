@@ -0,0 +1,204 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResponseCache(t *testing.T) {
+	r := require.New(t)
+
+	hits := 0
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{
+		TTL:  time.Minute,
+		Vary: []string{"Accept-Language"},
+	}))
+	a.GET("/", func(c buffalo.Context) error {
+		hits++
+		return c.Render(200, render.String(fmt.Sprintf("%d %s", hits, c.Request().Header.Get("Accept-Language"))))
+	})
+	a.GET("/private", func(c buffalo.Context) error {
+		hits++
+		c.Response().Header().Set("Cache-Control", "private")
+		return c.Render(200, render.String(fmt.Sprint(hits)))
+	})
+	a.POST("/bust", func(c buffalo.Context) error {
+		return middleware.CacheBust(c, "/")
+	})
+
+	w := willie.New(a)
+	res := w.Request("/").Get()
+	r.Equal("1 ", res.Body.String())
+	r.Equal("MISS", res.Header().Get("X-Cache"))
+
+	res = w.Request("/").Get()
+	r.Equal("1 ", res.Body.String())
+	r.Equal("HIT", res.Header().Get("X-Cache"))
+
+	req := w.Request("/")
+	req.Headers["Accept-Language"] = "fr"
+	r.Equal("2 fr", req.Get().Body.String())
+
+	req = w.Request("/")
+	req.Headers["Cache-Control"] = "no-cache"
+	r.Equal("3 ", req.Get().Body.String())
+	r.Equal("3 ", w.Request("/").Get().Body.String())
+
+	w.Request("/bust").Post(nil)
+	r.Equal("4 ", w.Request("/").Get().Body.String())
+
+	r.Equal("5", w.Request("/private").Get().Body.String())
+	r.Equal("6", w.Request("/private").Get().Body.String())
+}
+
+func Test_ResponseCache_StaleWhileRevalidate(t *testing.T) {
+	r := require.New(t)
+
+	hits := make(chan int, 10)
+	count := 0
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{
+		TTL:                  10 * time.Millisecond,
+		StaleWhileRevalidate: time.Minute,
+	}))
+	a.GET("/", func(c buffalo.Context) error {
+		count++
+		hits <- count
+		return c.Render(200, render.String(fmt.Sprint(count)))
+	})
+
+	w := willie.New(a)
+	r.Equal("1", w.Request("/").Get().Body.String())
+	<-hits
+
+	time.Sleep(20 * time.Millisecond)
+	res := w.Request("/").Get()
+	r.Equal("1", res.Body.String())
+	r.Equal("STALE", res.Header().Get("X-Cache"))
+
+	r.Equal(2, <-hits)
+	r.Eventually(func() bool {
+		return w.Request("/").Get().Body.String() == "2"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func Test_ResponseCache_Personal(t *testing.T) {
+	r := require.New(t)
+
+	hits := 0
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{}))
+	a.GET("/", func(c buffalo.Context) error {
+		hits++
+		return c.Render(200, render.String(fmt.Sprint(hits)))
+	})
+	a.GET("/login", func(c buffalo.Context) error {
+		hits++
+		c.Session().Set("user", hits)
+		return c.Render(200, render.String(fmt.Sprint(hits)))
+	})
+
+	w := willie.New(a)
+	req := w.Request("/")
+	req.Headers["Authorization"] = "Bearer secret"
+	r.Equal("1", req.Get().Body.String())
+	req = w.Request("/")
+	req.Headers["Cookie"] = "_buffalo_session=abc"
+	r.Equal("2", req.Get().Body.String())
+	r.Equal("3", w.Request("/").Get().Body.String())
+	r.Equal("3", w.Request("/").Get().Body.String())
+
+	res := w.Request("/login").Get()
+	r.Equal("4", res.Body.String())
+	r.NotEmpty(res.Header().Get("Set-Cookie"))
+	res = w.Request("/login").Get()
+	r.Equal("5", res.Body.String())
+	r.NotEmpty(res.Header().Get("Set-Cookie"))
+}
+
+func Test_CacheBust_Variants(t *testing.T) {
+	r := require.New(t)
+
+	hits := 0
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{
+		Vary: []string{"Accept-Language"},
+	}))
+	a.GET("/users", func(c buffalo.Context) error {
+		hits++
+		return c.Render(200, render.String(fmt.Sprint(hits)))
+	})
+	a.GET("/users/{id}", func(c buffalo.Context) error {
+		hits++
+		return c.Render(200, render.String(fmt.Sprint(hits)))
+	})
+	a.POST("/bust", func(c buffalo.Context) error {
+		return middleware.CacheBust(c, "/users")
+	})
+
+	w := willie.New(a)
+	fr := func(u string) string {
+		req := w.Request(u)
+		req.Headers["Accept-Language"] = "fr"
+		return req.Get().Body.String()
+	}
+	r.Equal("1", w.Request("/users?page=2").Get().Body.String())
+	r.Equal("2", fr("/users"))
+	r.Equal("3", w.Request("/users/42").Get().Body.String())
+	r.Equal("1", w.Request("/users?page=2").Get().Body.String())
+	r.Equal("2", fr("/users"))
+	r.Equal("3", w.Request("/users/42").Get().Body.String())
+
+	w.Request("/bust").Post(nil)
+	r.Equal("4", w.Request("/users?page=2").Get().Body.String())
+	r.Equal("5", fr("/users"))
+	r.Equal("6", w.Request("/users/42").Get().Body.String())
+}
+
+func Test_ResponseCache_Hosts(t *testing.T) {
+	r := require.New(t)
+
+	hits := 0
+	a := buffalo.New(buffalo.Options{
+		TenantResolver: buffalo.TenantByHeader("X-Tenant", func(id string) (*buffalo.Tenant, error) {
+			return &buffalo.Tenant{ID: id}, nil
+		}),
+	})
+	a.Use(middleware.ResponseCache(middleware.ResponseCacheOptions{}))
+	a.GET("/", func(c buffalo.Context) error {
+		hits++
+		return c.Render(200, render.String(fmt.Sprintf("%d %s", hits, c.Request().Host)))
+	})
+	a.POST("/bust", func(c buffalo.Context) error {
+		return middleware.CacheBust(c, "/")
+	})
+
+	get := func(host, tenant string) string {
+		req := httptest.NewRequest("GET", "http://"+host+"/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+	r.Equal("1 a.example.com", get("a.example.com", "a"))
+	r.Equal("2 b.example.com", get("b.example.com", "a"))
+	r.Equal("3 a.example.com", get("a.example.com", "b"))
+	r.Equal("1 a.example.com", get("a.example.com", "a"))
+	r.Equal("2 b.example.com", get("b.example.com", "a"))
+
+	// busting only removes the responses of the host, and tenant
+	req := httptest.NewRequest("POST", "http://b.example.com/bust", nil)
+	req.Header.Set("X-Tenant", "a")
+	a.ServeHTTP(httptest.NewRecorder(), req)
+	r.Equal("1 a.example.com", get("a.example.com", "a"))
+	r.Equal("4 b.example.com", get("b.example.com", "a"))
+}
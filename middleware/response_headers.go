@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ResponseHeaders is a composable builder of changes to make to the
+// response headers. The changes are made after the handler has run, but
+// before the first byte of the response is written, so they win over
+// anything the handler set, unless SetDefault is used.
+/*
+	api := app.Group("/api")
+	api.Use(middleware.NewResponseHeaders().
+		SetDefault("Cache-Control", "no-store").
+		Set("X-API-Version", "2").
+		Remove("Server", "X-Powered-By").
+		Middleware())
+*/
+type ResponseHeaders struct {
+	ops []headerOp
+}
+
+type headerOp func(http.Header)
+
+// NewResponseHeaders returns a new, empty, ResponseHeaders.
+func NewResponseHeaders() *ResponseHeaders {
+	return &ResponseHeaders{}
+}
+
+// Set the header, replacing any values it already has.
+func (rh *ResponseHeaders) Set(name string, value string) *ResponseHeaders {
+	rh.ops = append(rh.ops, func(h http.Header) {
+		h.Set(name, value)
+	})
+	return rh
+}
+
+// SetDefault sets the header only if the handler hasn't set it.
+func (rh *ResponseHeaders) SetDefault(name string, value string) *ResponseHeaders {
+	rh.ops = append(rh.ops, func(h http.Header) {
+		if h.Get(name) == "" {
+			h.Set(name, value)
+		}
+	})
+	return rh
+}
+
+// Add a value to the header, keeping any values it already has.
+func (rh *ResponseHeaders) Add(name string, value string) *ResponseHeaders {
+	rh.ops = append(rh.ops, func(h http.Header) {
+		h.Add(name, value)
+	})
+	return rh
+}
+
+// Remove the headers.
+func (rh *ResponseHeaders) Remove(names ...string) *ResponseHeaders {
+	rh.ops = append(rh.ops, func(h http.Header) {
+		for _, n := range names {
+			h.Del(n)
+		}
+	})
+	return rh
+}
+
+// Clone returns a copy of the ResponseHeaders, so a group can build on
+// the changes of its parent without changing them.
+func (rh *ResponseHeaders) Clone() *ResponseHeaders {
+	return &ResponseHeaders{ops: append([]headerOp{}, rh.ops...)}
+}
+
+// Middleware returns a piece of buffalo.Middleware that makes the
+// changes to every response.
+func (rh *ResponseHeaders) Middleware() buffalo.MiddlewareFunc {
+	ops := append([]headerOp{}, rh.ops...)
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			dc, ok := c.(*buffalo.DefaultContext)
+			if !ok {
+				return next(c)
+			}
+			hw := &headerWriter{ResponseWriter: c.Response(), ops: ops}
+			fc := dc.Fork(c.Request(), hw)
+			defer dc.Merge(fc)
+			err := next(fc)
+			// the handler might not have written anything, for example
+			// when it only sets a status with c.Render(200, nil)
+			hw.apply()
+			return err
+		}
+	}
+}
+
+// headerWriter makes the header changes right before the response
+// is written.
+type headerWriter struct {
+	http.ResponseWriter
+	ops     []headerOp
+	applied bool
+}
+
+func (w *headerWriter) apply() {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	h := w.ResponseWriter.Header()
+	for _, op := range w.ops {
+		op(h)
+	}
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	w.apply()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerWriter) Flush() {
+	w.apply()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *headerWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("does not implement http.Hijack")
+}
+
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResponseHeaders(t *testing.T) {
+	r := require.New(t)
+
+	base := middleware.NewResponseHeaders().
+		SetDefault("Cache-Control", "no-store").
+		Set("X-API-Version", "2").
+		Add("Vary", "Accept").
+		Remove("X-Powered-By")
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(base.Middleware())
+	a.GET("/", func(c buffalo.Context) error {
+		h := c.Response().Header()
+		h.Set("X-Powered-By", "buffalo")
+		h.Set("X-API-Version", "1")
+		h.Set("Vary", "Origin")
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/cached", func(c buffalo.Context) error {
+		c.Response().Header().Set("Cache-Control", "max-age=60")
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/error", func(c buffalo.Context) error {
+		return c.Error(422, errors.New("invalid"))
+	})
+
+	g := a.Group("/admin")
+	g.Middleware.Clear()
+	g.Use(base.Clone().Set("X-Admin", "true").Middleware())
+	g.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, nil)
+	})
+
+	w := willie.New(a)
+	res := w.Request("/").Get()
+	r.Equal("no-store", res.Header().Get("Cache-Control"))
+	r.Equal("2", res.Header().Get("X-API-Version"))
+	r.Equal([]string{"Origin", "Accept"}, res.Header()["Vary"])
+	r.Empty(res.Header().Get("X-Powered-By"))
+
+	res = w.Request("/cached").Get()
+	r.Equal("max-age=60", res.Header().Get("Cache-Control"))
+
+	// error responses get the headers too
+	res = w.Request("/error").Get()
+	r.Equal(422, res.Code)
+	r.Equal("2", res.Header().Get("X-API-Version"))
+
+	res = w.Request("/admin").Get()
+	r.Equal(200, res.Code)
+	r.Equal("true", res.Header().Get("X-Admin"))
+	r.Equal("2", res.Header().Get("X-API-Version"))
+
+	res = w.Request("/").Get()
+	r.Empty(res.Header().Get("X-Admin"))
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/markbates/going/defaults"
+	"github.com/pkg/errors"
+)
+
+// CSPNonce can be used as a source in a CSP directive. It will be
+// replaced with the nonce generated for the current request, which
+// is also available in templates as "{{csp_nonce}}".
+/*
+	csp := middleware.NewCSP().
+		Add("default-src", "'self'").
+		Add("script-src", "'self'", middleware.CSPNonce)
+
+	// in a template
+	<script nonce="{{csp_nonce}}">...</script>
+*/
+const CSPNonce = "'nonce'"
+
+// CSP is a composable builder for a Content-Security-Policy header.
+type CSP struct {
+	names      []string
+	directives map[string][]string
+}
+
+// NewCSP returns a new, empty, CSP.
+func NewCSP() *CSP {
+	return &CSP{directives: map[string][]string{}}
+}
+
+// Add sources to a directive, creating the directive if needed.
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	if _, ok := p.directives[directive]; !ok {
+		p.names = append(p.names, directive)
+	}
+	p.directives[directive] = append(p.directives[directive], sources...)
+	return p
+}
+
+// Set the sources for a directive, replacing any existing sources.
+func (p *CSP) Set(directive string, sources ...string) *CSP {
+	p.Remove(directive)
+	return p.Add(directive, sources...)
+}
+
+// Remove a directive from the policy.
+func (p *CSP) Remove(directive string) *CSP {
+	if _, ok := p.directives[directive]; !ok {
+		return p
+	}
+	delete(p.directives, directive)
+	names := []string{}
+	for _, n := range p.names {
+		if n != directive {
+			names = append(names, n)
+		}
+	}
+	p.names = names
+	return p
+}
+
+// Clone returns a deep copy of the policy.
+func (p *CSP) Clone() *CSP {
+	n := NewCSP()
+	for _, d := range p.names {
+		n.Add(d, p.directives[d]...)
+	}
+	return n
+}
+
+// Header builds the value of the Content-Security-Policy header,
+// replacing any CSPNonce sources with the given nonce.
+func (p *CSP) Header(nonce string) string {
+	parts := []string{}
+	for _, d := range p.names {
+		srcs := []string{d}
+		for _, s := range p.directives[d] {
+			if s == CSPNonce {
+				s = fmt.Sprintf("'nonce-%s'", nonce)
+			}
+			srcs = append(srcs, s)
+		}
+		parts = append(parts, strings.Join(srcs, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SecureHeadersOptions configures the SecureHeaders middleware.
+type SecureHeadersOptions struct {
+	// HSTSMaxAge sets the "Strict-Transport-Security" header. It is only
+	// sent if greater than zero.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// FrameOptions for the "X-Frame-Options" header. Defaults to "SAMEORIGIN".
+	FrameOptions string
+	// ReferrerPolicy for the "Referrer-Policy" header. Defaults to
+	// "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// CSP is the default Content-Security-Policy. If nil no policy is sent.
+	CSP *CSP
+}
+
+// SecureHeaders returns a piece of buffalo.Middleware that sets a
+// standard set of security related headers on every response:
+// "Strict-Transport-Security", "X-Frame-Options",
+// "X-Content-Type-Options", "Referrer-Policy", and
+// "Content-Security-Policy". A fresh nonce is generated for each request
+// and made available to templates as "{{csp_nonce}}". Use OverrideCSP to
+// change the policy for a route or a group.
+func SecureHeaders(opts SecureHeadersOptions) buffalo.MiddlewareFunc {
+	opts.FrameOptions = defaults.String(opts.FrameOptions, "SAMEORIGIN")
+	opts.ReferrerPolicy = defaults.String(opts.ReferrerPolicy, "strict-origin-when-cross-origin")
+	hsts := ""
+	if opts.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int64(opts.HSTSMaxAge/time.Second))
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if opts.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			h := c.Response().Header()
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			h.Set("X-Frame-Options", opts.FrameOptions)
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", opts.ReferrerPolicy)
+			if opts.CSP != nil {
+				nonce, err := cspNonce()
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				p := opts.CSP.Clone()
+				c.Set("csp_nonce", nonce)
+				c.Set("csp", p)
+				h.Set("Content-Security-Policy", p.Header(nonce))
+			}
+			return next(c)
+		}
+	}
+}
+
+// OverrideCSP returns a piece of buffalo.Middleware that lets a route, or
+// a group, change the Content-Security-Policy set up by SecureHeaders.
+// The function is given a copy of the policy for the current request.
+/*
+	g := app.Group("/embed")
+	g.Use(middleware.OverrideCSP(func(p *middleware.CSP) {
+		p.Set("frame-ancestors", "https://partner.example.com")
+	}))
+*/
+func OverrideCSP(fn func(*CSP)) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			if p, ok := c.Get("csp").(*CSP); ok {
+				fn(p)
+				nonce, _ := c.Get("csp_nonce").(string)
+				c.Response().Header().Set("Content-Security-Policy", p.Header(nonce))
+			}
+			return next(c)
+		}
+	}
+}
+
+func cspNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
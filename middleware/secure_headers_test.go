@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func shApp() *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.SecureHeaders(middleware.SecureHeadersOptions{
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubdomains: true,
+		CSP: middleware.NewCSP().
+			Add("default-src", "'self'").
+			Add("script-src", "'self'", middleware.CSPNonce),
+	}))
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String("{{csp_nonce}}"))
+	}
+	a.GET("/", h)
+	a.GET("/embed", middleware.OverrideCSP(func(p *middleware.CSP) {
+		p.Set("frame-ancestors", "https://example.com")
+	})(h))
+	return a
+}
+
+func Test_SecureHeaders(t *testing.T) {
+	r := require.New(t)
+
+	w := willie.New(shApp())
+	res := w.Request("/").Get()
+	r.Equal(200, res.Code)
+
+	h := res.Header()
+	r.Equal("max-age=3600; includeSubDomains", h.Get("Strict-Transport-Security"))
+	r.Equal("SAMEORIGIN", h.Get("X-Frame-Options"))
+	r.Equal("nosniff", h.Get("X-Content-Type-Options"))
+	r.Equal("strict-origin-when-cross-origin", h.Get("Referrer-Policy"))
+
+	nonce := res.Body.String()
+	r.NotEmpty(nonce)
+	r.Equal("default-src 'self'; script-src 'self' 'nonce-"+nonce+"'", h.Get("Content-Security-Policy"))
+
+	res2 := w.Request("/").Get()
+	r.NotEqual(nonce, res2.Body.String())
+}
+
+func Test_OverrideCSP(t *testing.T) {
+	r := require.New(t)
+
+	w := willie.New(shApp())
+	res := w.Request("/embed").Get()
+	csp := res.Header().Get("Content-Security-Policy")
+	r.True(strings.HasSuffix(csp, "; frame-ancestors https://example.com"), csp)
+
+	res = w.Request("/").Get()
+	r.NotContains(res.Header().Get("Content-Security-Policy"), "frame-ancestors")
+}
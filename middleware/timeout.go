@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// ErrTimeout is returned, with a 503 status, when a request runs longer
+// than the duration given to the Timeout middleware.
+var ErrTimeout = errors.New("request timed out")
+
+// Timeout returns a piece of buffalo.Middleware that gives each request
+// d to finish. The request's context is canceled once the deadline passes,
+// so anything watching c.Request().Context(), database queries, outgoing
+// HTTP calls, etc..., can stop early. A request that runs out of time is
+// sent to the 503 ErrorHandler, one whose client has gone away is just
+// given up on.
+//
+// The handler is run in its own goroutine, writing into a buffer, and the
+// buffer is only copied to the real response if the handler finishes in
+// time. This guarantees that a handler that keeps going after the deadline
+// can't write over the error response, but it also means responses can't
+// be streamed. Don't use Timeout on streaming or websocket routes. The
+// handler has its own copy of the session, and its own hooks, too, see
+// buffalo.DefaultContext.Isolate, so the changes it makes once it has
+// been given up on are dropped, and its Cleanups run when it returns,
+// rather than when the request is over.
+func Timeout(d time.Duration) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			dc, ok := c.(*buffalo.DefaultContext)
+			if !ok {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{header: http.Header{}}
+			fc := dc.Isolate(c.Request().WithContext(ctx), tw)
+
+			done := make(chan error, 1)
+			panics := make(chan interface{}, 1)
+			go func() {
+				var err error
+				defer func() {
+					p := recover()
+					tw.moot.Lock()
+					late := tw.timedOut
+					tw.finished = true
+					tw.moot.Unlock()
+					if late {
+						// nobody is waiting on the handler any more, so
+						// its hooks, and files, are cleaned up here
+						fc.Discard()
+						return
+					}
+					if p != nil {
+						panics <- p
+						return
+					}
+					done <- err
+				}()
+				err = next(fc)
+			}()
+
+			select {
+			case p := <-panics:
+				panic(p)
+			case err := <-done:
+				tw.moot.Lock()
+				defer tw.moot.Unlock()
+				dc.Merge(fc)
+				res := c.Response()
+				for k, v := range tw.header {
+					res.Header()[k] = v
+				}
+				if tw.status != 0 {
+					res.WriteHeader(tw.status)
+				}
+				if tw.buf.Len() > 0 {
+					if _, werr := res.Write(tw.buf.Bytes()); werr != nil && err == nil {
+						err = errors.WithStack(werr)
+					}
+				}
+				return err
+			case <-ctx.Done():
+			}
+
+			tw.moot.Lock()
+			tw.timedOut = true
+			finished := tw.finished
+			if !finished {
+				// the handler may still be reading the multipart form, it
+				// is removed by Discard rather than when this request is
+				// over
+				c.Request().MultipartForm = nil
+			}
+			tw.moot.Unlock()
+			if finished {
+				// the handler returned too late, but before it could
+				// see it had, so it is discarded here
+				fc.Discard()
+			}
+			if ctx.Err() != context.DeadlineExceeded {
+				// the client went away, there is nobody to answer
+				return nil
+			}
+			return c.Error(503, ErrTimeout)
+		}
+	}
+}
+
+type timeoutWriter struct {
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+	finished bool
+	moot     sync.Mutex
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.moot.Lock()
+	defer tw.moot.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.moot.Lock()
+	defer tw.moot.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
@@ -0,0 +1,150 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Timeout(t *testing.T) {
+	r := require.New(t)
+
+	late := make(chan error, 1)
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Timeout(20 * time.Millisecond))
+	a.GET("/fast", func(c buffalo.Context) error {
+		c.Set("fast", true)
+		c.Response().Header().Set("X-Fast", "yes")
+		return c.Render(201, render.String("fast"))
+	})
+	a.GET("/slow", func(c buffalo.Context) error {
+		<-c.Request().Context().Done()
+		// let the 503 be sent first
+		time.Sleep(10 * time.Millisecond)
+		late <- c.Render(200, render.String("too late"))
+		return nil
+	})
+
+	w := willie.New(a)
+	res := w.Request("/fast").Get()
+	r.Equal(201, res.Code)
+	r.Equal("fast", res.Body.String())
+	r.Equal("yes", res.Header().Get("X-Fast"))
+
+	res = w.Request("/slow").Get()
+	r.Equal(503, res.Code)
+	r.NotContains(res.Body.String(), "too late")
+	r.Error(<-late)
+}
+
+func Test_Timeout_Session(t *testing.T) {
+	r := require.New(t)
+
+	late := make(chan struct{})
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Timeout(20 * time.Millisecond))
+	a.GET("/set", func(c buffalo.Context) error {
+		c.Session().Set("name", c.Param("name"))
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/slow", func(c buffalo.Context) error {
+		<-c.Request().Context().Done()
+		c.Session().Set("name", "too late")
+		close(late)
+		return nil
+	})
+	a.GET("/get", func(c buffalo.Context) error {
+		return c.Render(200, render.String(c.Session().Get("name").(string)))
+	})
+
+	get := func(path string, cookies string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Cookie", cookies)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+	res := get("/set?name=mark", "")
+	cookie := res.Header().Get("Set-Cookie")
+	r.NotEmpty(cookie)
+	r.Equal("mark", get("/get", cookie).Body.String())
+
+	res = get("/slow", cookie)
+	r.Equal(503, res.Code)
+	<-late
+	r.Empty(res.Header().Get("Set-Cookie"))
+}
+
+func Test_Timeout_Cleanup(t *testing.T) {
+	r := require.New(t)
+
+	var moot sync.Mutex
+	ran := []string{}
+	cleanup := func(name string) func() error {
+		return func() error {
+			moot.Lock()
+			defer moot.Unlock()
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	returned := make(chan struct{})
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Timeout(20 * time.Millisecond))
+	a.GET("/fast", func(c buffalo.Context) error {
+		c.Cleanup(cleanup("fast"))
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/slow", func(c buffalo.Context) error {
+		c.Cleanup(cleanup("before"))
+		<-c.Request().Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		moot.Lock()
+		r.Empty(ran, "cleanups ran while the handler was still running")
+		moot.Unlock()
+		c.Cleanup(cleanup("after"))
+		close(returned)
+		return nil
+	})
+
+	w := willie.New(a)
+	r.Equal(200, w.Request("/fast").Get().Code)
+	r.Equal([]string{"fast"}, ran)
+
+	ran = []string{}
+	r.Equal(503, w.Request("/slow").Get().Code)
+	<-returned
+	r.Eventually(func() bool {
+		moot.Lock()
+		defer moot.Unlock()
+		return len(ran) == 2
+	}, time.Second, time.Millisecond)
+	r.Equal([]string{"after", "before"}, ran)
+}
+
+func Test_Timeout_ClientGone(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Timeout(time.Second))
+	a.GET("/", func(c buffalo.Context) error {
+		<-c.Request().Context().Done()
+		return c.Request().Context().Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	time.AfterFunc(10*time.Millisecond, cancel)
+	a.ServeHTTP(res, req)
+	r.NotEqual(503, res.Code)
+	r.NotContains(res.Body.String(), middleware.ErrTimeout.Error())
+}
@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// SpanContext identifies a span within a trace. It is what is carried
+// between services in the W3C "traceparent" header.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether the SpanContext has a trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats the SpanContext as a W3C "traceparent" header.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceparent parses a W3C "traceparent" header. It returns false
+// if the header is missing or malformed.
+func ParseTraceparent(h string) (SpanContext, bool) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	tid, err := hex.DecodeString(parts[1])
+	if err != nil || len(tid) != 16 {
+		return sc, false
+	}
+	sid, err := hex.DecodeString(parts[2])
+	if err != nil || len(sid) != 8 {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return sc, false
+	}
+	copy(sc.TraceID[:], tid)
+	copy(sc.SpanID[:], sid)
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// Span is a single, timed, operation within a trace.
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value interface{})
+	AddEvent(name string, attrs map[string]interface{})
+	// RecordError adds an "exception" event for err.
+	RecordError(err error)
+	// SetError marks the span as failed.
+	SetError(msg string)
+	End()
+}
+
+// Tracer starts spans. Start should create a child of parent, if it is
+// valid, or else a new trace. The returned context.Context carries the
+// new span. An OpenTelemetry trace.Tracer can be adapted to Tracer in a
+// few lines, or NewTracer can be used to collect spans directly.
+type Tracer interface {
+	Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the Span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) Span {
+	s, _ := ctx.Value(spanKey{}).(Span)
+	return s
+}
+
+// Tracing returns a piece of buffalo.Middleware that starts a span for
+// every request, named after the matched route, such as
+// "GET /users/{user_id}". An incoming "traceparent" header makes the span
+// part of the caller's trace. Errors returned by the handler, and every
+// error they wrap, are recorded on the span as "exception" events.
+//
+// The span is stored in the Context as "span", and in the request's
+// context.Context, so handlers and other middleware can start child spans
+// with StartSpan, or pass it on to other services with InjectTraceparent.
+// Its trace ID is stored as "trace_id", so it is shown on error pages.
+/*
+	app.Use(middleware.Tracing(middleware.NewTracer(func(s middleware.SpanData) {
+		// export s
+	})))
+*/
+func Tracing(tracer Tracer) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			req := c.Request()
+			name := req.Method + " " + req.URL.Path
+			if ri, ok := buffalo.Get(c, buffalo.CurrentRouteKey); ok && ri.Path != "" {
+				name = ri.Method + " " + ri.Path
+			}
+			parent, _ := ParseTraceparent(req.Header.Get("traceparent"))
+			ctx, span := tracer.Start(req.Context(), name, parent)
+			defer span.End()
+			span.SetAttribute("http.method", req.Method)
+			span.SetAttribute("http.target", req.URL.RequestURI())
+			span.SetAttribute("http.route", strings.TrimPrefix(name, req.Method+" "))
+
+			c.Set("span", span)
+			c.Set("tracer", tracer)
+			sc := span.SpanContext()
+			c.Set("trace_id", hex.EncodeToString(sc.TraceID[:]))
+			sw := buffalo.NewResponseWriter(c.Response())
+			req = req.WithContext(ContextWithSpan(ctx, span))
+			if dc, ok := c.(*buffalo.DefaultContext); ok {
+				fc := dc.Fork(req, sw)
+				defer dc.Merge(fc)
+				c = fc
+			}
+
+			err := next(c)
+			status := sw.Status()
+			if err != nil {
+				status = errorStatus(err)
+				for e := err; e != nil; e = unwrapError(e) {
+					span.RecordError(e)
+				}
+			}
+			if status == 0 {
+				status = 200
+			}
+			span.SetAttribute("http.status_code", status)
+			if status >= 500 {
+				span.SetError(http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
+
+// StartSpan starts a child of the request's span. The child should be
+// ended by the caller.
+/*
+	span := middleware.StartSpan(c, "render")
+	defer span.End()
+*/
+func StartSpan(c buffalo.Context, name string) Span {
+	parent, _ := c.Get("span").(Span)
+	tracer, ok := c.Get("tracer").(Tracer)
+	if parent == nil || !ok {
+		return noopSpan{}
+	}
+	_, span := tracer.Start(c.Request().Context(), name, parent.SpanContext())
+	return span
+}
+
+// InjectTraceparent sets the "traceparent" header on req, an outgoing
+// request, so the service it calls can join the current trace.
+func InjectTraceparent(c buffalo.Context, req *http.Request) {
+	if span, ok := c.Get("span").(Span); ok {
+		req.Header.Set("traceparent", span.SpanContext().Traceparent())
+	}
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+// SpanEvent is something that happened during a span.
+type SpanEvent struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]interface{}
+}
+
+// SpanData is a finished span, as handed to the func given to NewTracer.
+type SpanData struct {
+	Name         string
+	SpanContext  SpanContext
+	ParentSpanID [8]byte
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	Events       []SpanEvent
+	Error        string
+}
+
+// NewTracer returns a simple Tracer that calls export with every span
+// once it has ended.
+func NewTracer(export func(SpanData)) Tracer {
+	return &tracer{export: export}
+}
+
+type tracer struct {
+	export func(SpanData)
+}
+
+func (t *tracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: map[string]interface{}{},
+		},
+	}
+	if parent.IsValid() {
+		s.data.SpanContext.TraceID = parent.TraceID
+		s.data.SpanContext.Sampled = parent.Sampled
+		s.data.ParentSpanID = parent.SpanID
+	} else {
+		rand.Read(s.data.SpanContext.TraceID[:])
+		s.data.SpanContext.Sampled = true
+	}
+	rand.Read(s.data.SpanContext.SpanID[:])
+	return ContextWithSpan(ctx, s), s
+}
+
+type span struct {
+	tracer *tracer
+	data   SpanData
+	ended  bool
+	moot   sync.Mutex
+}
+
+func (s *span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	s.data.Attributes[key] = value
+}
+
+func (s *span) AddEvent(name string, attrs map[string]interface{}) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	s.data.Events = append(s.data.Events, SpanEvent{Name: name, Time: time.Now(), Attributes: attrs})
+}
+
+func (s *span) RecordError(err error) {
+	s.AddEvent("exception", map[string]interface{}{
+		"exception.type":    fmt.Sprintf("%T", err),
+		"exception.message": err.Error(),
+	})
+}
+
+func (s *span) SetError(msg string) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	s.data.Error = msg
+}
+
+func (s *span) End() {
+	s.moot.Lock()
+	if s.ended {
+		s.moot.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.moot.Unlock()
+	s.tracer.export(s.data)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SpanContext() SpanContext                { return SpanContext{} }
+func (noopSpan) SetAttribute(string, interface{})        {}
+func (noopSpan) AddEvent(string, map[string]interface{}) {}
+func (noopSpan) RecordError(error)                       {}
+func (noopSpan) SetError(string)                         {}
+func (noopSpan) End()                                    {}
@@ -0,0 +1,74 @@
+package middleware_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseTraceparent(t *testing.T) {
+	r := require.New(t)
+
+	h := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := middleware.ParseTraceparent(h)
+	r.True(ok)
+	r.True(sc.Sampled)
+	r.Equal(h, sc.Traceparent())
+
+	for _, bad := range []string{"", "nope", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"} {
+		_, ok = middleware.ParseTraceparent(bad)
+		r.False(ok, bad)
+	}
+}
+
+func Test_Tracing(t *testing.T) {
+	r := require.New(t)
+
+	moot := &sync.Mutex{}
+	spans := map[string]middleware.SpanData{}
+	tracer := middleware.NewTracer(func(s middleware.SpanData) {
+		moot.Lock()
+		defer moot.Unlock()
+		spans[s.Name] = s
+	})
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Tracing(tracer))
+	a.GET("/users/{id}", func(c buffalo.Context) error {
+		span := middleware.StartSpan(c, "render")
+		defer span.End()
+		r.NotNil(middleware.SpanFromContext(c.Request().Context()))
+		return c.Render(201, render.String("ok"))
+	})
+	a.GET("/fail", func(c buffalo.Context) error {
+		return c.Error(502, errors.Wrap(errors.New("upstream down"), "calling api"))
+	})
+
+	w := willie.New(a)
+	req := w.Request("/users/42")
+	req.Headers["traceparent"] = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r.Equal(201, req.Get().Code)
+
+	root := spans["GET /users/{id}"]
+	r.Equal("4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext.Traceparent()[3:35])
+	r.Equal(201, root.Attributes["http.status_code"])
+	r.Equal("/users/{id}", root.Attributes["http.route"])
+
+	child := spans["render"]
+	r.Equal(root.SpanContext.TraceID, child.SpanContext.TraceID)
+	r.Equal(root.SpanContext.SpanID, child.ParentSpanID)
+
+	r.Equal(502, w.Request("/fail").Get().Code)
+	fail := spans["GET /fail"]
+	r.Equal(502, fail.Attributes["http.status_code"])
+	r.Equal("Bad Gateway", fail.Error)
+	r.True(len(fail.Events) >= 2)
+	r.Equal("exception", fail.Events[0].Name)
+	r.Equal("upstream down", fail.Events[len(fail.Events)-1].Attributes["exception.message"])
+}
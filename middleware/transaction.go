@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/markbates/pop"
+	"github.com/pkg/errors"
+)
+
+// TxKey is the Context key the Transaction middleware stores the
+// current transaction under. It is set as "tx" too, for templates and
+// older code.
+var TxKey = buffalo.NewKey[Tx]("tx")
+
+// afterCommitKey is the Context key of the AfterCommit hooks of the
+// current transaction.
+var afterCommitKey = buffalo.NewKey[*afterCommit]("tx_after_commit")
+
+type afterCommit struct {
+	hooks []func()
+}
+
+// run the hooks, in the order they were added.
+func (a *afterCommit) run() {
+	for _, fn := range a.hooks {
+		fn()
+	}
+}
+
+// AfterCommit adds a function to run once the transaction of the
+// Transaction, or PopTransaction, middleware has been committed, for
+// work that must only happen if the request's writes did, such as
+// enqueueing a job that reads them, see TxWorker, or sending an email.
+// The hooks are dropped if the transaction is rolled back. Without a
+// transaction fn runs right away.
+/*
+	func UsersCreate(c buffalo.Context) error {
+		...
+		middleware.AfterCommit(c, func() {
+			mailers.SendWelcome(u)
+		})
+		return c.Redirect(303, "/users/%s", u.ID)
+	}
+*/
+func AfterCommit(c buffalo.Context, fn func()) {
+	ac, ok := buffalo.Get(c, afterCommitKey)
+	if !ok || ac == nil {
+		fn()
+		return
+	}
+	ac.hooks = append(ac.hooks, fn)
+}
+
+// TxWorker returns w for the request of c, with jobs it is given held
+// back until the transaction of the Transaction, or PopTransaction,
+// middleware has been committed, so workers don't look for rows that
+// aren't there yet, and dropped if it is rolled back. Held back jobs
+// are only checked when they are enqueued, and errors then are logged.
+// Without a transaction jobs are enqueued right away.
+/*
+	func UsersCreate(c buffalo.Context) error {
+		...
+		return middleware.TxWorker(c, app.Worker).Perform(worker.Job{
+			Handler: "welcome_email",
+			Args:    worker.Args{"user_id": u.ID},
+		})
+	}
+*/
+func TxWorker(c buffalo.Context, w worker.Worker) worker.Worker {
+	return txWorker{Worker: w, c: c}
+}
+
+type txWorker struct {
+	worker.Worker
+	c buffalo.Context
+}
+
+func (t txWorker) Perform(job worker.Job) error {
+	return t.afterCommit(job, func() error { return t.Worker.Perform(job) })
+}
+
+func (t txWorker) PerformAt(job worker.Job, at time.Time) error {
+	return t.afterCommit(job, func() error { return t.Worker.PerformAt(job, at) })
+}
+
+func (t txWorker) PerformIn(job worker.Job, d time.Duration) error {
+	return t.afterCommit(job, func() error { return t.Worker.PerformIn(job, d) })
+}
+
+func (t txWorker) afterCommit(job worker.Job, enqueue func() error) error {
+	ac, ok := buffalo.Get(t.c, afterCommitKey)
+	if !ok || ac == nil {
+		return enqueue()
+	}
+	logger := t.c.Logger()
+	ac.hooks = append(ac.hooks, func() {
+		if err := enqueue(); err != nil {
+			logger.Errorf("could not enqueue job %s: %v", job.Handler, err)
+		}
+	})
+	return nil
+}
+
+// Tx is a database transaction. *sql.Tx and *sqlx.Tx are both a Tx.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxBeginner begins a new transaction.
+type TxBeginner interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// TxBeginnerFunc turns a function into a TxBeginner. It makes adapting
+// any database library a one liner, for example sqlx:
+/*
+	begin := middleware.TxBeginnerFunc(func(ctx context.Context) (middleware.Tx, error) {
+		return db.BeginTxx(ctx, nil)
+	})
+	app.Use(middleware.Transaction(begin))
+*/
+type TxBeginnerFunc func(ctx context.Context) (Tx, error)
+
+// Begin calls f(ctx).
+func (f TxBeginnerFunc) Begin(ctx context.Context) (Tx, error) {
+	return f(ctx)
+}
+
+// SQLTx adapts a database/sql DB to the Transaction middleware. The
+// transaction is stored on the Context as a *sql.Tx.
+func SQLTx(db *sql.DB) TxBeginner {
+	return TxBeginnerFunc(func(ctx context.Context) (Tx, error) {
+		return db.BeginTx(ctx, nil)
+	})
+}
+
+// PopTx is the transaction stored on the Context when the Transaction
+// middleware is used with a pop connection, see PopBeginner.
+type PopTx struct {
+	*pop.Connection
+	ctx context.Context
+	// stop keeps the transaction from being rolled back when ctx is
+	// done, false if it already has been.
+	stop func() bool
+}
+
+// Commit the transaction, unless it has been rolled back because its
+// context is done.
+func (p PopTx) Commit() error {
+	if p.stop != nil && !p.stop() {
+		return errors.Wrap(p.ctx.Err(), "transaction was rolled back")
+	}
+	return p.TX.Commit()
+}
+
+// Rollback the transaction.
+func (p PopTx) Rollback() error {
+	if p.stop != nil && !p.stop() {
+		return nil
+	}
+	return p.TX.Rollback()
+}
+
+// PopBeginner adapts a pop connection to the Transaction middleware.
+// The transaction is stored on the Context as a PopTx. pop can't begin
+// a transaction with a context, so, as sql.DB.BeginTx does, it is rolled
+// back when ctx is done, and can't be committed after.
+func PopBeginner(db *pop.Connection) TxBeginner {
+	return TxBeginnerFunc(func(ctx context.Context) (Tx, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		tx, err := db.NewTransaction()
+		if err != nil {
+			return nil, err
+		}
+		stop := context.AfterFunc(ctx, func() {
+			tx.TX.Rollback()
+		})
+		return PopTx{Connection: tx, ctx: ctx, stop: stop}, nil
+	})
+}
+
+// Transaction returns a piece of buffalo.Middleware that wraps each
+// request in a transaction, stored on the Context under TxKey. The
+// transaction is committed if the handler succeeds, and rolled back if
+// it returns an error, panics, or responds with a 4xx or 5xx status.
+// Redirects count as success, so the usual create and redirect flow
+// works. The response may already have been sent when the transaction
+// is committed, use Options.ResponseBufferSize if a failed commit has to
+// turn into an error response. Work that must wait for the commit can be
+// added with AfterCommit.
+/*
+	app.Use(middleware.Transaction(middleware.SQLTx(db)))
+
+	func UsersCreate(c buffalo.Context) error {
+		tx := middleware.TxFrom(c).(*sql.Tx)
+		...
+	}
+*/
+func Transaction(b TxBeginner) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) (err error) {
+			tx, err := b.Begin(c.Request().Context())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			sw := buffalo.NewResponseWriter(c.Response())
+			if dc, ok := c.(*buffalo.DefaultContext); ok {
+				fc := dc.Fork(c.Request(), sw)
+				defer dc.Merge(fc)
+				c = fc
+			}
+			buffalo.Set(c, TxKey, tx)
+			ac := &afterCommit{}
+			buffalo.Set(c, afterCommitKey, ac)
+
+			defer func() {
+				if r := recover(); r != nil {
+					tx.Rollback()
+					panic(r)
+				}
+			}()
+
+			err = next(c)
+			if err != nil || sw.Status() >= 400 {
+				if rerr := tx.Rollback(); rerr != nil {
+					c.Logger().Error(errors.WithStack(rerr))
+				}
+				return err
+			}
+			if err := tx.Commit(); err != nil {
+				return errors.WithStack(err)
+			}
+			ac.run()
+			return nil
+		}
+	}
+}
+
+// TxFrom returns the transaction the Transaction middleware stored on
+// the Context, or nil if there isn't one.
+func TxFrom(c buffalo.Context) Tx {
+	tx, _ := buffalo.Get(c, TxKey)
+	return tx
+}
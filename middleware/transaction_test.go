@@ -0,0 +1,206 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/markbates/pop"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testTx struct {
+	committed  bool
+	rolledback bool
+}
+
+func (t *testTx) Commit() error {
+	t.committed = true
+	return nil
+}
+
+func (t *testTx) Rollback() error {
+	t.rolledback = true
+	return nil
+}
+
+func Test_Transaction(t *testing.T) {
+	r := require.New(t)
+
+	var tx *testTx
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Transaction(middleware.TxBeginnerFunc(func(ctx context.Context) (middleware.Tx, error) {
+		tx = &testTx{}
+		return tx, nil
+	})))
+	a.GET("/ok", func(c buffalo.Context) error {
+		r.Equal(tx, middleware.TxFrom(c))
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/redirect", func(c buffalo.Context) error {
+		return c.Redirect(302, "/ok")
+	})
+	a.GET("/error", func(c buffalo.Context) error {
+		return errors.New("boom")
+	})
+	a.GET("/status", func(c buffalo.Context) error {
+		return c.Render(422, render.String("invalid"))
+	})
+	a.GET("/panic", func(c buffalo.Context) error {
+		panic("boom")
+	})
+
+	w := willie.New(a)
+	table := []struct {
+		path     string
+		code     int
+		commited bool
+	}{
+		{"/ok", 200, true},
+		{"/redirect", 302, true},
+		{"/error", 500, false},
+		{"/status", 422, false},
+	}
+	for _, tt := range table {
+		res := w.Request(tt.path).Get()
+		r.Equal(tt.code, res.Code, tt.path)
+		r.Equal(tt.commited, tx.committed, tt.path)
+		r.Equal(!tt.commited, tx.rolledback, tt.path)
+	}
+
+	r.Panics(func() {
+		w.Request("/panic").Get()
+	})
+	r.True(tx.rolledback)
+	r.False(tx.committed)
+}
+
+func Test_Transaction_BeginError(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Transaction(middleware.TxBeginnerFunc(func(ctx context.Context) (middleware.Tx, error) {
+		return nil, errors.New("db is down")
+	})))
+	a.GET("/", func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+
+	w := willie.New(a)
+	r.Equal(500, w.Request("/").Get().Code)
+}
+
+func Test_Transaction_AfterCommit(t *testing.T) {
+	r := require.New(t)
+
+	var tx *testTx
+	var ran []string
+	a := buffalo.New(buffalo.Options{})
+	a.GET("/none", func(c buffalo.Context) error {
+		middleware.AfterCommit(c, func() {
+			ran = append(ran, "none")
+		})
+		return c.Render(200, render.String("ok"))
+	})
+	g := a.Group("/tx")
+	g.Use(middleware.Transaction(middleware.TxBeginnerFunc(func(ctx context.Context) (middleware.Tx, error) {
+		tx = &testTx{}
+		return tx, nil
+	})))
+	g.GET("/ok", func(c buffalo.Context) error {
+		middleware.AfterCommit(c, func() {
+			r.True(tx.committed)
+			ran = append(ran, "ok")
+		})
+		return c.Render(200, render.String("ok"))
+	})
+	g.GET("/error", func(c buffalo.Context) error {
+		middleware.AfterCommit(c, func() {
+			ran = append(ran, "error")
+		})
+		return errors.New("boom")
+	})
+
+	w := willie.New(a)
+	w.Request("/none").Get()
+	w.Request("/tx/ok").Get()
+	w.Request("/tx/error").Get()
+	r.Equal([]string{"none", "ok"}, ran)
+}
+
+// recordWorker records the jobs it is given, and whether the
+// transaction was committed by then.
+type recordWorker struct {
+	worker.Worker
+	tx   **testTx
+	jobs []string
+}
+
+func (w *recordWorker) Perform(job worker.Job) error {
+	if *w.tx != nil && !(*w.tx).committed {
+		return errors.New("enqueued before the commit")
+	}
+	w.jobs = append(w.jobs, job.Handler)
+	return nil
+}
+
+func (w *recordWorker) PerformIn(job worker.Job, d time.Duration) error {
+	return w.Perform(job)
+}
+
+func Test_TxWorker(t *testing.T) {
+	r := require.New(t)
+
+	var tx *testTx
+	w := &recordWorker{tx: &tx}
+	a := buffalo.New(buffalo.Options{})
+	a.GET("/none", func(c buffalo.Context) error {
+		return middleware.TxWorker(c, w).Perform(worker.Job{Handler: "none"})
+	})
+	g := a.Group("/tx")
+	g.Use(middleware.Transaction(middleware.TxBeginnerFunc(func(ctx context.Context) (middleware.Tx, error) {
+		tx = &testTx{}
+		return tx, nil
+	})))
+	g.GET("/ok", func(c buffalo.Context) error {
+		if err := middleware.TxWorker(c, w).PerformIn(worker.Job{Handler: "ok"}, time.Minute); err != nil {
+			return err
+		}
+		r.Empty(w.jobs[1:])
+		return c.Render(200, render.String("ok"))
+	})
+	g.GET("/error", func(c buffalo.Context) error {
+		middleware.TxWorker(c, w).Perform(worker.Job{Handler: "error"})
+		return c.Render(422, render.String("invalid"))
+	})
+
+	wl := willie.New(a)
+	r.Equal(200, wl.Request("/none").Get().Code)
+	r.Equal(200, wl.Request("/tx/ok").Get().Code)
+	r.Equal(422, wl.Request("/tx/error").Get().Code)
+	r.Equal([]string{"none", "ok"}, w.jobs)
+}
+
+func Test_PopBeginner_Context(t *testing.T) {
+	r := require.New(t)
+
+	b := middleware.PopBeginner(&pop.Connection{})
+	tx, err := b.Begin(context.Background())
+	r.NoError(err)
+	r.NoError(tx.Commit())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err = b.Begin(ctx)
+	r.NoError(err)
+	cancel()
+	r.Error(tx.Commit())
+
+	_, err = b.Begin(ctx)
+	r.Error(err)
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// Predicate decides whether a piece of conditional middleware runs for
+// a request. Predicates are checked before the handler runs, so they
+// can only look at the request, not the response.
+type Predicate func(buffalo.Context) bool
+
+// When returns a piece of buffalo.Middleware that runs mw only when the
+// predicate is true, otherwise the request goes straight on.
+/*
+	app.Use(middleware.When(middleware.HeaderPresent("Authorization"), TokenAuth))
+	app.Use(middleware.When(
+		middleware.And(middleware.Method("GET"), middleware.HeaderContains("Accept", "text/html")),
+		Gzip,
+	))
+*/
+func When(p Predicate, mw buffalo.MiddlewareFunc) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		wrapped := mw(next)
+		return func(c buffalo.Context) error {
+			if p(c) {
+				return wrapped(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Unless runs mw only when the predicate is false.
+/*
+	app.Use(middleware.Unless(middleware.PathPrefix("/webhooks"), CSRF))
+*/
+func Unless(p Predicate, mw buffalo.MiddlewareFunc) buffalo.MiddlewareFunc {
+	return When(Not(p), mw)
+}
+
+// And is true when all of the predicates are true.
+func And(ps ...Predicate) Predicate {
+	return func(c buffalo.Context) bool {
+		for _, p := range ps {
+			if !p(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or is true when any of the predicates are true.
+func Or(ps ...Predicate) Predicate {
+	return func(c buffalo.Context) bool {
+		for _, p := range ps {
+			if p(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not is true when the predicate is false.
+func Not(p Predicate) Predicate {
+	return func(c buffalo.Context) bool {
+		return !p(c)
+	}
+}
+
+// Method is true when the request uses any of the methods.
+func Method(methods ...string) Predicate {
+	return func(c buffalo.Context) bool {
+		m := c.Request().Method
+		for _, x := range methods {
+			if strings.EqualFold(m, x) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PathPrefix is true when the request path starts with any of the
+// prefixes.
+func PathPrefix(prefixes ...string) Predicate {
+	return func(c buffalo.Context) bool {
+		p := c.Request().URL.Path
+		for _, x := range prefixes {
+			if strings.HasPrefix(p, x) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PathMatches is true when the request path matches any of the glob
+// patterns, see path.Match. A "*" doesn't match across a "/".
+/*
+	middleware.PathMatches("/*.json", "/api/v?/status")
+*/
+func PathMatches(patterns ...string) Predicate {
+	return func(c buffalo.Context) bool {
+		p := c.Request().URL.Path
+		for _, x := range patterns {
+			if ok, _ := path.Match(x, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HeaderPresent is true when the request has the header, with any value.
+func HeaderPresent(name string) Predicate {
+	return func(c buffalo.Context) bool {
+		return c.Request().Header.Get(name) != ""
+	}
+}
+
+// HeaderEquals is true when the request header has the value, ignoring
+// case.
+func HeaderEquals(name string, value string) Predicate {
+	return func(c buffalo.Context) bool {
+		return strings.EqualFold(c.Request().Header.Get(name), value)
+	}
+}
+
+// HeaderContains is true when the request header contains s, ignoring
+// case. It is useful for headers that are lists, like "Accept".
+func HeaderContains(name string, s string) Predicate {
+	return func(c buffalo.Context) bool {
+		return strings.Contains(strings.ToLower(c.Request().Header.Get(name)), strings.ToLower(s))
+	}
+}
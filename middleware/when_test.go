@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/middleware"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func whenApp(p middleware.Predicate) *buffalo.App {
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.When(p, func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			c.Response().Header().Set("X-Ran", "yes")
+			return next(c)
+		}
+	}))
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	}
+	a.GET("/", h)
+	a.GET("/users/{id}/avatar", h)
+	a.POST("/webhooks/stripe", h)
+	return a
+}
+
+func Test_When(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		p       middleware.Predicate
+		method  string
+		path    string
+		headers map[string]string
+		ran     bool
+	}{
+		{middleware.PathPrefix("/webhooks"), "POST", "/webhooks/stripe", nil, true},
+		{middleware.PathPrefix("/webhooks"), "GET", "/", nil, false},
+		{middleware.PathMatches("/users/*/avatar"), "GET", "/users/1/avatar", nil, true},
+		{middleware.Method("post"), "GET", "/", nil, false},
+		{middleware.HeaderPresent("Authorization"), "GET", "/", map[string]string{"Authorization": "Bearer x"}, true},
+		{middleware.HeaderPresent("Authorization"), "GET", "/", nil, false},
+		{middleware.HeaderEquals("X-Env", "STAGING"), "GET", "/", map[string]string{"X-Env": "staging"}, true},
+		{middleware.HeaderContains("Accept", "text/html"), "GET", "/", map[string]string{"Accept": "text/html,*/*"}, true},
+		{middleware.And(middleware.Method("GET"), middleware.PathPrefix("/users")), "GET", "/", nil, false},
+		{middleware.Or(middleware.Method("POST"), middleware.PathPrefix("/users")), "GET", "/users/1/avatar", nil, true},
+		{middleware.Not(middleware.Method("GET")), "GET", "/", nil, false},
+	}
+
+	for _, tt := range table {
+		w := willie.New(whenApp(tt.p))
+		req := w.Request(tt.path)
+		for k, v := range tt.headers {
+			req.Headers[k] = v
+		}
+		var res *willie.Response
+		if tt.method == "POST" {
+			res = req.Post(nil)
+		} else {
+			res = req.Get()
+		}
+		r.Equal(200, res.Code, tt.path)
+		r.Equal(tt.ran, res.Header().Get("X-Ran") == "yes", tt.path)
+	}
+}
+
+func Test_Unless(t *testing.T) {
+	r := require.New(t)
+
+	a := buffalo.New(buffalo.Options{})
+	a.Use(middleware.Unless(middleware.PathPrefix("/public"), func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			return c.Error(401, errors.New("not allowed"))
+		}
+	}))
+	h := func(c buffalo.Context) error {
+		return c.Render(200, render.String("ok"))
+	}
+	a.GET("/public", h)
+	a.GET("/private", h)
+
+	w := willie.New(a)
+	r.Equal(200, w.Request("/public").Get().Code)
+	r.Equal(401, w.Request("/private").Get().Code)
+}
@@ -200,3 +200,174 @@ func Test_Middleware_Clear(t *testing.T) {
 	r.Len(mws.stack, 0)
 	r.Len(mws.skips, 0)
 }
+
+func logMiddleware(log *[]string, name string) MiddlewareFunc {
+	return func(h Handler) Handler {
+		return func(c Context) error {
+			*log = append(*log, name)
+			return h(c)
+		}
+	}
+}
+
+// Test_Middleware_Use_Skip_Only tests skipping and limiting middleware
+// straight from Use
+func Test_Middleware_Use_Skip_Only(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	csrf := logMiddleware(&log, "csrf")
+	auth := func(h Handler) Handler {
+		return func(c Context) error {
+			log = append(log, "auth")
+			return h(c)
+		}
+	}
+	home := func(c Context) error { return nil }
+	webhook := func(c Context) error { return nil }
+	admin := func(c Context) error { return nil }
+
+	a := New(Options{})
+	a.Use(csrf).Skip(webhook)
+	a.Use(auth).Only(admin)
+	a.GET("/", home)
+	a.POST("/webhook", webhook)
+	a.GET("/admin", admin)
+
+	w := willie.New(a)
+	w.Request("/").Get()
+	r.Equal([]string{"csrf"}, log)
+
+	log = []string{}
+	w.Request("/webhook").Post(nil)
+	r.Empty(log)
+
+	log = []string{}
+	w.Request("/admin").Get()
+	r.Equal([]string{"csrf", "auth"}, log)
+
+	r.Len(a.Middleware.Chain(admin), 2)
+	r.Len(a.Middleware.Chain(webhook), 0)
+}
+
+// Test_Middleware_Remove tests that a group can remove middleware
+// without changing its parent
+func Test_Middleware_Remove(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	mw1 := logMiddleware(&log, "mw1")
+	mw2 := func(h Handler) Handler {
+		return func(c Context) error {
+			log = append(log, "mw2")
+			return h(c)
+		}
+	}
+
+	a := New(Options{})
+	a.Use(mw1, mw2)
+	a.GET("/", voidHandler)
+
+	g := a.Group("/api")
+	g.Middleware.Remove(mw1)
+	g.GET("/", voidHandler)
+
+	w := willie.New(a)
+	w.Request("/").Get()
+	r.Equal([]string{"mw1", "mw2"}, log)
+
+	log = []string{}
+	w.Request("/api").Get()
+	r.Equal([]string{"mw2"}, log)
+}
+
+// Test_Middleware_Same_Factory tests that middleware made by the same
+// function can be skipped, removed, and replaced on its own
+func Test_Middleware_Same_Factory(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	mw1 := logMiddleware(&log, "mw1")
+	mw2 := logMiddleware(&log, "mw2")
+	mw3 := logMiddleware(&log, "mw3")
+	mw4 := logMiddleware(&log, "mw4")
+	home := func(c Context) error { return nil }
+
+	a := New(Options{})
+	a.Use(mw1).Skip(home)
+	u2 := a.Use(mw2)
+	u3 := a.Use(mw3)
+	a.GET("/", home)
+
+	g := a.Group("/api")
+	u2.In(g.Middleware).Remove()
+	g.GET("/", voidHandler)
+	u3.Replace(mw4)
+
+	w := willie.New(a)
+	w.Request("/").Get()
+	r.Equal([]string{"mw2", "mw4"}, log)
+
+	log = []string{}
+	w.Request("/api").Get()
+	r.Equal([]string{"mw1", "mw3"}, log)
+
+	// by function, every piece of middleware made by it is found
+	log = []string{}
+	a.Middleware.Skip(mw1, home)
+	w.Request("/").Get()
+	r.Equal([]string{}, log)
+}
+
+// Test_Middleware_Named tests inserting around named middleware, and
+// ordering by priority
+func Test_Middleware_Named(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	csrf := logMiddleware(&log, "csrf")
+	parse := func(h Handler) Handler {
+		return func(c Context) error {
+			log = append(log, "parse")
+			return h(c)
+		}
+	}
+	audit := func(h Handler) Handler {
+		return func(c Context) error {
+			log = append(log, "audit")
+			return h(c)
+		}
+	}
+	rescue := func(h Handler) Handler {
+		return func(c Context) error {
+			log = append(log, "recover")
+			return h(c)
+		}
+	}
+
+	a := New(Options{})
+	a.Middleware.UseNamed("csrf", csrf)
+	a.Middleware.UseNamed("recover", rescue).Priority(-10)
+	r.NoError(a.Middleware.InsertBefore("csrf", parse))
+	r.NoError(a.Middleware.InsertAfter("csrf", audit))
+	r.Error(a.Middleware.InsertBefore("nope", audit))
+	a.GET("/", voidHandler)
+
+	g := a.Group("/admin")
+	g.Middleware.RemoveNamed("csrf")
+	g.GET("/", voidHandler)
+
+	w := willie.New(a)
+	w.Request("/").Get()
+	r.Equal([]string{"recover", "parse", "csrf", "audit"}, log)
+
+	list := a.Middleware.List()
+	r.Len(list, 2)
+	r.Equal("/", list[0].Path)
+	r.Len(list[0].Middleware, 4)
+	r.Equal("recover", list[0].Middleware[0])
+	r.Equal("csrf", list[0].Middleware[2])
+	r.Equal("/admin", list[1].Path)
+	r.Len(list[1].Middleware, 3)
+	r.NotContains(list[1].Middleware, "csrf")
+}
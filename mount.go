@@ -0,0 +1,132 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Mount an http.Handler, such as another router, net/http/pprof, or a
+// gRPC-web gateway, at a path. Every request to the path, or below it,
+// for any method, is sent to the handler with the path stripped, so
+// the handler sees "/" for the path itself. The handler is wrapped in
+// the App's middleware, just like any other route.
+/*
+	a.Mount("/admin", adminRouter)
+	// GET /admin/users => adminRouter sees GET /users
+*/
+func (a *App) Mount(p string, h http.Handler) RouteInfo {
+	a.moot.Lock()
+	defer a.moot.Unlock()
+
+	prefix := path.Join(a.prefix, p)
+	mh := WrapHandler(stripPrefix(prefix, h))
+	r := RouteInfo{
+		Method:      "ANY",
+		Path:        path.Join(prefix, "*"),
+		HandlerName: fmt.Sprintf("%T", h),
+		Handler:     mh,
+		Host:        a.host,
+		app:         a,
+	}
+	limits := a.RouteLimits
+	r.limits = &limits
+	r.name = new(string)
+
+	hh := a.handlerToHandler(r, mh)
+	if prefix == "/" {
+		r.MuxRoute = a.router.PathPrefix(prefix).Handler(hh)
+	} else {
+		r.MuxRoute = a.router.Handle(prefix, hh)
+		a.router.PathPrefix(prefix + "/").Handler(hh)
+	}
+	a.routesChanged()
+
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	routes := append(root.routes, r)
+	sort.Sort(routes)
+	root.routes = routes
+	return r
+}
+
+// stripPrefix is like http.StripPrefix, but it always leaves the
+// request with a path starting with "/".
+func stripPrefix(prefix string, h http.Handler) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		p := "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+		r := new(http.Request)
+		*r = *req
+		r.URL = new(url.URL)
+		*r.URL = *req.URL
+		r.URL.Path = p
+		r.URL.RawPath = ""
+		h.ServeHTTP(res, r)
+	})
+}
+
+// mountedApp is an App mounted with MountApp.
+type mountedApp struct {
+	prefix string
+	host   string
+	app    *App
+}
+
+// MountApp composes another App, with its own middleware and
+// ErrorHandlers, into this one at a path, and for the host if this is
+// a Host group. Requests to the path, or below it, are sent to the App
+// with the path stripped, and without running this App's middleware.
+// The mounted App logs with this App's Logger, is stopped along with
+// this App, its ShutdownHooks are run with this App's, and its routes
+// are listed in this App's Routes.
+/*
+	a.MountApp("/api", api)
+	a.Host("admin.example.com").MountApp("/", admin)
+*/
+func (a *App) MountApp(p string, sub *App) {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	prefix := path.Join(a.prefix, p)
+	sub.Logger = a.Logger
+	sub.parent = root
+
+	h := stripPrefix(prefix, sub)
+	if prefix == "/" {
+		a.router.PathPrefix(prefix).Handler(h)
+	} else {
+		a.router.Handle(prefix, h)
+		a.router.PathPrefix(prefix + "/").Handler(h)
+	}
+	a.routesChanged()
+
+	root.moot.Lock()
+	defer root.moot.Unlock()
+	root.mounted = append(root.mounted, mountedApp{prefix: prefix, host: a.host, app: sub})
+}
+
+// mountedRoutes returns the routes of the mounted Apps, with the paths
+// they are mounted at.
+func (a *App) mountedRoutes() RouteList {
+	a.moot.Lock()
+	mounted := append([]mountedApp{}, a.mounted...)
+	a.moot.Unlock()
+	routes := RouteList{}
+	for _, m := range mounted {
+		for _, r := range m.app.Routes() {
+			r.Path = path.Join(m.prefix, r.Path)
+			if r.Host == "" {
+				r.Host = m.host
+			}
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,89 @@
+package buffalo
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Mount(t *testing.T) {
+	r := require.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.Method + " " + req.URL.Path))
+	})
+
+	log := []string{}
+	a := New(Options{})
+	a.Use(logMiddleware(&log, "mw"))
+	a.GET("/debugger", func(c Context) error {
+		return c.Render(200, nil)
+	})
+	a.Group("/api").Mount("/legacy", mux)
+
+	w := willie.New(a)
+	r.Equal("GET /users/1", w.Request("/api/legacy/users/1").Get().Body.String())
+	r.Equal("POST /", w.Request("/api/legacy").Post(nil).Body.String())
+	r.Equal([]string{"mw", "mw"}, log)
+	r.Equal(404, w.Request("/api/legacyx").Get().Code)
+
+	found := false
+	for _, rt := range a.Routes() {
+		if rt.Path == "/api/legacy/*" {
+			found = true
+			r.Equal("ANY", rt.Method)
+			r.Equal("*http.ServeMux", rt.HandlerName)
+		}
+	}
+	r.True(found)
+}
+
+func Test_App_MountApp(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	a := New(Options{})
+	a.Use(logMiddleware(&log, "main"))
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("main"))
+	})
+
+	api := New(Options{})
+	api.Use(logMiddleware(&log, "api"))
+	api.ErrorHandlers[404] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, err = c.Response().Write([]byte(`{"error":"not found"}`))
+		return err
+	}
+	api.GET("/users/{id}", func(c Context) error {
+		return c.Render(200, render.String("user {{params.id}}"))
+	})
+	a.MountApp("/api", api)
+
+	w := willie.New(a)
+	r.Equal("main", w.Request("/").Get().Body.String())
+	r.Equal([]string{"main"}, log)
+
+	log = []string{}
+	r.Equal("user 1", w.Request("/api/users/1").Get().Body.String())
+	r.Equal([]string{"api"}, log)
+
+	res := w.Request("/api/nope").Get()
+	r.Equal(404, res.Code)
+	r.Equal(`{"error":"not found"}`, res.Body.String())
+
+	r.Equal(a.Logger, api.Logger)
+	r.Equal(a.server(), api.server())
+
+	found := false
+	for _, rt := range a.Routes() {
+		if rt.Path == "/api/users/{id}" {
+			found = true
+		}
+	}
+	r.True(found)
+}
@@ -10,6 +10,26 @@ type multiLogger struct {
 	Loggers []logrus.FieldLogger
 }
 
+// DebugEnabled reports whether any of the Loggers log at the debug
+// level, see buffalo.DebugEnabled.
+func (m *multiLogger) DebugEnabled() bool {
+	for _, l := range m.Loggers {
+		switch t := l.(type) {
+		case *logrus.Logger:
+			if logrusLevel(t) >= logrus.DebugLevel {
+				return true
+			}
+		case *logrus.Entry:
+			if logrusLevel(t.Logger) >= logrus.DebugLevel {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
 func (m *multiLogger) WithField(key string, value interface{}) Logger {
 	lgs := []logrus.FieldLogger{}
 	for _, l := range m.Loggers {
@@ -0,0 +1,189 @@
+package buffalo
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotAcceptable is returned, with a 406 status, by RespondTo when the
+// client accepts none of the formats.
+var ErrNotAcceptable = errors.New("none of the formats the client accepts are available")
+
+// mediaNames are the media types of the short names given to Accepts,
+// other names are looked up by their extension.
+var mediaNames = map[string][]string{
+	"json": {"application/json"},
+	"xml":  {"application/xml", "text/xml"},
+	"html": {"text/html", "application/xhtml+xml"},
+	"text": {"text/plain"},
+	"js":   {"application/javascript", "text/javascript"},
+}
+
+// Accepts returns the offer the client prefers, by the q-values of the
+// "Accept" header. Offers are media types, such as "application/json",
+// or short names, such as "json", "html", or "xml". Ties go to the
+// first offer, and it is returned if there is no header. It returns ""
+// if the client accepts none of them.
+/*
+	switch c.Accepts("html", "json") {
+	case "json":
+		...
+	}
+*/
+func (d *DefaultContext) Accepts(offers ...string) string {
+	return negotiateHeader(d.Request().Header.Get("Accept"), offers, matchMedia)
+}
+
+// AcceptsEncoding returns the encoding, such as "gzip" or "br", that the
+// client prefers, by the "Accept-Encoding" header, see Accepts.
+func (d *DefaultContext) AcceptsEncoding(offers ...string) string {
+	return negotiateHeader(d.Request().Header.Get("Accept-Encoding"), offers, matchToken)
+}
+
+// AcceptsLanguage returns the language, such as "en" or "pt-BR", that
+// the client prefers, by the "Accept-Language" header, see Accepts. A
+// range such as "en" matches the offer "en-US", and the other way
+// around, less closely.
+func (d *DefaultContext) AcceptsLanguage(offers ...string) string {
+	return negotiateHeader(d.Request().Header.Get("Accept-Language"), offers, matchLanguage)
+}
+
+// RespondTo calls the Handler of the format the client prefers, see
+// Accepts. Clients that accept none of them get a 406.
+/*
+	return c.RespondTo(map[string]buffalo.Handler{
+		"html": func(c buffalo.Context) error {
+			return c.Render(200, r.HTML("users/show.html"))
+		},
+		"json": func(c buffalo.Context) error {
+			return c.Render(200, r.JSON(user))
+		},
+	})
+*/
+func (d *DefaultContext) RespondTo(handlers map[string]Handler) error {
+	offers := make([]string, 0, len(handlers))
+	for k := range handlers {
+		offers = append(offers, k)
+	}
+	sort.Strings(offers)
+	h, ok := handlers[d.Accepts(offers...)]
+	if !ok {
+		return d.Error(406, ErrNotAcceptable)
+	}
+	return h(d)
+}
+
+type acceptRange struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses the ranges, and their q-values, of an "Accept" like
+// header.
+func parseAccept(header string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(fields[0]))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if len(p) > 2 && (p[0] == 'q' || p[0] == 'Q') && p[1] == '=' {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{value: value, q: q})
+	}
+	return ranges
+}
+
+// negotiateHeader returns the offer with the highest q-value. An offer
+// gets the q-value of the range that matches it most closely, so
+// "text/html;q=0" rules out HTML even with "*/*".
+func negotiateHeader(header string, offers []string, match func(rng, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		closest, q := 0, 0.0
+		for _, r := range ranges {
+			if m := match(r.value, offer); m > closest {
+				closest, q = m, r.q
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// matchMedia returns how closely the media range matches the offer.
+func matchMedia(rng, offer string) int {
+	types := mediaNames[strings.ToLower(offer)]
+	if types == nil {
+		t := offer
+		if !strings.Contains(offer, "/") {
+			t = mime.TypeByExtension("." + offer)
+		}
+		if mt, _, err := mime.ParseMediaType(t); err == nil {
+			t = mt
+		}
+		types = []string{strings.ToLower(t)}
+	}
+	closest := 0
+	for _, t := range types {
+		switch {
+		case t == "":
+		case rng == t:
+			return 3
+		case rng == "*/*":
+			closest = max(closest, 1)
+		case strings.HasSuffix(rng, "/*") && strings.HasPrefix(t, rng[:len(rng)-1]):
+			closest = max(closest, 2)
+		}
+	}
+	return closest
+}
+
+// matchToken matches tokens, such as encodings, and the "*" wildcard.
+func matchToken(rng, offer string) int {
+	switch {
+	case rng == strings.ToLower(offer):
+		return 2
+	case rng == "*":
+		return 1
+	}
+	return 0
+}
+
+// matchLanguage matches language tags, a range matches the tags it is a
+// prefix of, and less closely, the tags that are a prefix of it.
+func matchLanguage(rng, offer string) int {
+	offer = strings.ToLower(offer)
+	switch {
+	case rng == offer:
+		return 4
+	case strings.HasPrefix(offer, rng+"-"):
+		return 3
+	case strings.HasPrefix(rng, offer+"-"):
+		return 2
+	case rng == "*":
+		return 1
+	}
+	return 0
+}
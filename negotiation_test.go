@@ -0,0 +1,87 @@
+package buffalo
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DefaultContext_Accepts(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		accept string
+		offers []string
+		best   string
+	}{
+		{"", []string{"html", "json"}, "html"},
+		{"application/json", []string{"html", "json"}, "json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []string{"json", "xml", "html"}, "html"},
+		{"text/html,application/xml;q=0.9,*/*;q=0.8", []string{"json", "xml"}, "xml"},
+		{"*/*;q=0.5, text/html;q=0", []string{"html", "json"}, "json"},
+		{"text/*", []string{"json", "text/csv"}, "text/csv"},
+		{"image/png", []string{"html", "json"}, ""},
+		{"application/vnd.api+json; q=0.9, application/json; q=0.4", []string{"application/json", "application/vnd.api+json"}, "application/vnd.api+json"},
+	}
+	for _, tt := range table {
+		c := &DefaultContext{request: httptest.NewRequest("GET", "/", nil)}
+		c.request.Header.Set("Accept", tt.accept)
+		r.Equal(tt.best, c.Accepts(tt.offers...), tt.accept)
+	}
+}
+
+func Test_DefaultContext_AcceptsEncoding(t *testing.T) {
+	r := require.New(t)
+	c := &DefaultContext{request: httptest.NewRequest("GET", "/", nil)}
+	c.request.Header.Set("Accept-Encoding", "gzip;q=0.8, br, *;q=0.1")
+	r.Equal("br", c.AcceptsEncoding("gzip", "br"))
+	r.Equal("gzip", c.AcceptsEncoding("gzip", "deflate"))
+	r.Equal("deflate", c.AcceptsEncoding("deflate"))
+
+	c.request.Header.Set("Accept-Encoding", "gzip, identity;q=0")
+	r.Equal("", c.AcceptsEncoding("identity"))
+}
+
+func Test_DefaultContext_AcceptsLanguage(t *testing.T) {
+	r := require.New(t)
+	c := &DefaultContext{request: httptest.NewRequest("GET", "/", nil)}
+	c.request.Header.Set("Accept-Language", "fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5")
+	r.Equal("fr", c.AcceptsLanguage("en", "fr"))
+	r.Equal("fr-CH", c.AcceptsLanguage("fr", "fr-CH"))
+	r.Equal("en-US", c.AcceptsLanguage("en-US", "de"))
+	r.Equal("de", c.AcceptsLanguage("de"))
+
+	c.request.Header.Set("Accept-Language", "pt-BR")
+	r.Equal("pt", c.AcceptsLanguage("en", "pt"))
+}
+
+func Test_DefaultContext_RespondTo(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		return c.RespondTo(map[string]Handler{
+			"html": func(c Context) error {
+				return c.Render(200, render.String("<p>hi</p>"))
+			},
+			"json": func(c Context) error {
+				return c.JSON(200, map[string]string{"msg": "hi"})
+			},
+		})
+	})
+	w := willie.New(a)
+
+	req := w.Request("/")
+	req.Headers["Accept"] = "application/json"
+	r.Equal(`{"msg":"hi"}`+"\n", req.Get().Body.String())
+
+	req = w.Request("/")
+	req.Headers["Accept"] = "text/html,*/*;q=0.8"
+	r.Equal("<p>hi</p>", req.Get().Body.String())
+
+	req = w.Request("/")
+	req.Headers["Accept"] = "image/png"
+	r.Equal(406, req.Get().Code)
+}
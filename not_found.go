@@ -13,15 +13,14 @@ import (
 // information. In production it defaults to use the http.NotFound
 // handler.
 func NotFoundHandler(status int, err error, c Context) error {
-	env := c.Get("env")
 	req := c.Request()
 	res := c.Response()
-	if env != nil && env.(string) == "production" {
+	if c.Env() == "production" {
 		http.NotFound(res, req)
 		return nil
 	}
 	data := map[string]interface{}{
-		"routes": c.Get("routes"),
+		"routes": routesFor(c),
 		"method": req.Method,
 		"path":   req.URL.String(),
 		"error":  err.Error(),
@@ -12,7 +12,7 @@ func Test_App_Dev_NotFound(t *testing.T) {
 	r := require.New(t)
 
 	a := Automatic(Options{})
-	a.Env = "development"
+	a.Options.Env = "development"
 	a.GET("/foo", func(c Context) error { return nil })
 
 	w := willie.New(a)
@@ -28,7 +28,7 @@ func Test_App_Dev_NotFound_JSON(t *testing.T) {
 	r := require.New(t)
 
 	a := Automatic(Options{})
-	a.Env = "development"
+	a.Options.Env = "development"
 	a.GET("/foo", func(c Context) error { return nil })
 
 	w := willie.New(a)
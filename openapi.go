@@ -0,0 +1,266 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Doc sets the documentation of the route for OpenAPI. The first line
+// is the summary, the rest is the description.
+/*
+	a.POST("/users", UsersCreate).
+		Doc("Create a user\nThe user is sent a confirmation email.").
+		Body(NewUser{}).
+		Returns(201, User{})
+*/
+func (ri RouteInfo) Doc(doc string) RouteInfo {
+	if ri.schema != nil {
+		ri.schema.Doc = doc
+	}
+	return ri
+}
+
+// Returns documents a response of the route for OpenAPI. The body can
+// be a JSONSchema, a struct, see SchemaFor, or nil for an empty body.
+func (ri RouteInfo) Returns(status int, body interface{}) RouteInfo {
+	if ri.schema != nil {
+		if ri.schema.Responses == nil {
+			ri.schema.Responses = map[int]interface{}{}
+		}
+		ri.schema.Responses[status] = body
+	}
+	return ri
+}
+
+// OpenAPIDocument is an OpenAPI 3 document describing the routes of an
+// App, see App.OpenAPI.
+type OpenAPIDocument struct {
+	OpenAPI string                                  `json:"openapi"`
+	Info    OpenAPIInfo                             `json:"info"`
+	Paths   map[string]map[string]*OpenAPIOperation `json:"paths"`
+}
+
+// OpenAPIInfo is the "info" of an OpenAPIDocument.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIOperation describes a single route.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a path, or query, param of a route.
+type OpenAPIParameter struct {
+	Name     string     `json:"name"`
+	In       string     `json:"in"`
+	Required bool       `json:"required,omitempty"`
+	Schema   JSONSchema `json:"schema"`
+}
+
+// OpenAPIBody describes the request body of a route.
+type OpenAPIBody struct {
+	Required bool                    `json:"required,omitempty"`
+	Content  map[string]OpenAPIMedia `json:"content"`
+}
+
+// OpenAPIResponse describes a response of a route.
+type OpenAPIResponse struct {
+	Description string                  `json:"description"`
+	Content     map[string]OpenAPIMedia `json:"content,omitempty"`
+}
+
+// OpenAPIMedia is the schema of a request, or response, body.
+type OpenAPIMedia struct {
+	Schema JSONSchema `json:"schema"`
+}
+
+// OpenAPI returns an OpenAPI 3 document of the App's routes. Routes are
+// documented with their path params, and the Body and Query schemas,
+// Doc, and Returns given to them. Mounted http.Handlers are left out.
+func (a *App) OpenAPI() *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:   "Buffalo",
+			Version: a.Version,
+		},
+		Paths: map[string]map[string]*OpenAPIOperation{},
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	for _, ri := range a.Routes() {
+		if ri.Method == "ANY" {
+			continue
+		}
+		p, params := openAPIPath(ri.Path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[p][strings.ToLower(ri.Method)] = openAPIOperation(ri, params)
+	}
+	return doc
+}
+
+func openAPIOperation(ri RouteInfo, params []OpenAPIParameter) *OpenAPIOperation {
+	rs := ri.Schema()
+	op := &OpenAPIOperation{
+		OperationID: path.Base(ri.HandlerName),
+		Parameters:  params,
+		Responses:   map[string]OpenAPIResponse{},
+	}
+	lines := strings.SplitN(strings.TrimSpace(rs.Doc), "\n", 2)
+	op.Summary = lines[0]
+	if len(lines) > 1 {
+		op.Description = strings.TrimSpace(lines[1])
+	}
+	if rs.Query != nil {
+		s := SchemaFor(rs.Query)
+		required := map[string]bool{}
+		for _, r := range asSlice(s["required"]) {
+			required[fmt.Sprint(r)] = true
+		}
+		names := []string{}
+		for name := range asSchema(s["properties"]) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     name,
+				In:       "query",
+				Required: required[name],
+				Schema:   asSchema(asSchema(s["properties"])[name]),
+			})
+		}
+	}
+	if rs.Body != nil {
+		s := SchemaFor(rs.Body)
+		op.RequestBody = &OpenAPIBody{
+			Required: true,
+			Content: map[string]OpenAPIMedia{
+				"application/json":                  {Schema: s},
+				"application/x-www-form-urlencoded": {Schema: s},
+			},
+		}
+	}
+	for status, body := range rs.Responses {
+		res := OpenAPIResponse{Description: http.StatusText(status)}
+		if body != nil {
+			res.Content = map[string]OpenAPIMedia{
+				"application/json": {Schema: SchemaFor(body)},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = res
+	}
+	if rs.Body != nil || rs.Query != nil {
+		op.Responses["422"] = OpenAPIResponse{Description: http.StatusText(422)}
+	}
+	if len(rs.Responses) == 0 {
+		op.Responses["200"] = OpenAPIResponse{Description: http.StatusText(200)}
+	}
+	return op
+}
+
+// openAPIPath converts the path of a route to an OpenAPI path, without
+// the patterns of its variables, and returns the variables as params.
+func openAPIPath(p string) (string, []OpenAPIParameter) {
+	b := &strings.Builder{}
+	params := []OpenAPIParameter{}
+	level := 0
+	start := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '{':
+			if level == 0 {
+				b.WriteString(p[start:i])
+				start = i
+			}
+			level++
+		case '}':
+			level--
+			if level != 0 {
+				continue
+			}
+			parts := strings.SplitN(p[start+1:i], ":", 2)
+			start = i + 1
+			s := JSONSchema{"type": "string"}
+			if len(parts) == 2 {
+				switch parts[1] {
+				case "int", "uint":
+					s = JSONSchema{"type": "integer"}
+				case "uuid":
+					s["format"] = "uuid"
+				case "*", "alpha", "slug":
+				default:
+					s["pattern"] = "^" + parts[1] + "$"
+				}
+			}
+			params = append(params, OpenAPIParameter{
+				Name:     parts[0],
+				In:       "path",
+				Required: true,
+				Schema:   s,
+			})
+			b.WriteString("{" + parts[0] + "}")
+		}
+	}
+	b.WriteString(p[start:])
+	return b.String(), params
+}
+
+// ServeOpenAPI serves the OpenAPI document of the App as JSON at
+// p + "/openapi.json", and, in development, a Swagger UI for it at p.
+/*
+	a.ServeOpenAPI("/docs")
+*/
+func (a *App) ServeOpenAPI(p string) {
+	spec := path.Join(p, "openapi.json")
+	a.GET(spec, func(c Context) error {
+		res := c.Response()
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(200)
+		return json.NewEncoder(res).Encode(a.OpenAPI())
+	})
+	a.GET(p, func(c Context) error {
+		if a.Env() != "development" {
+			return c.Error(404, errors.Errorf("path not found: %s", c.Request().URL.Path))
+		}
+		res := c.Response()
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		res.WriteHeader(200)
+		_, err := fmt.Fprintf(res, swaggerUITmpl, path.Join(a.prefix, spec))
+		return err
+	})
+}
+
+var swaggerUITmpl = `<!DOCTYPE html>
+<html>
+<head>
+	<title>API Docs</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+	SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
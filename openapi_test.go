@@ -0,0 +1,79 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+type userJSON struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func Test_App_OpenAPI(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{Version: "1.2.3"})
+	a.GET("/users", voidHandler).
+		Doc("List users\nUsers are sorted by name.").
+		Query(JSONSchema{
+			"type":     "object",
+			"required": []string{"page"},
+			"properties": JSONSchema{
+				"page": JSONSchema{"type": "integer"},
+			},
+		}).
+		Returns(200, []userJSON{})
+	a.POST("/users", voidHandler).Body(newUser{}).Returns(201, userJSON{})
+	a.GET("/users/{user_id:int}/posts/{slug:slug}", voidHandler)
+	a.Mount("/legacy", http.NotFoundHandler())
+
+	doc := a.OpenAPI()
+	r.Equal("3.0.3", doc.OpenAPI)
+	r.Equal("1.2.3", doc.Info.Version)
+	r.Len(doc.Paths, 2)
+
+	list := doc.Paths["/users"]["get"]
+	r.Equal("List users", list.Summary)
+	r.Equal("Users are sorted by name.", list.Description)
+	r.Equal([]OpenAPIParameter{{Name: "page", In: "query", Required: true, Schema: JSONSchema{"type": "integer"}}}, list.Parameters)
+	r.Equal(JSONSchema{"type": "array", "items": SchemaFor(userJSON{})}, list.Responses["200"].Content["application/json"].Schema)
+	r.Contains(list.Responses, "422")
+
+	create := doc.Paths["/users"]["post"]
+	r.Equal(SchemaFor(newUser{}), create.RequestBody.Content["application/json"].Schema)
+	r.Equal("Created", create.Responses["201"].Description)
+
+	show := doc.Paths["/users/{user_id}/posts/{slug}"]["get"]
+	r.Len(show.Parameters, 2)
+	r.Equal(OpenAPIParameter{Name: "user_id", In: "path", Required: true, Schema: JSONSchema{"type": "integer"}}, show.Parameters[0])
+	r.Equal("slug", show.Parameters[1].Name)
+	r.Contains(show.Responses, "200")
+}
+
+func Test_App_ServeOpenAPI(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.GET("/users", voidHandler).Doc("List users")
+	a.Group("/api").ServeOpenAPI("/docs")
+
+	w := willie.New(a)
+	res := w.Request("/api/docs/openapi.json").Get()
+	r.Equal(200, res.Code)
+	doc := &OpenAPIDocument{}
+	r.NoError(json.NewDecoder(res.Body).Decode(doc))
+	r.Equal("List users", doc.Paths["/users"]["get"].Summary)
+
+	res = w.Request("/api/docs").Get()
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), `"/api/docs/openapi.json"`)
+
+	a = New(Options{Env: "production"})
+	a.ServeOpenAPI("/docs")
+	w = willie.New(a)
+	r.Equal(200, w.Request("/docs/openapi.json").Get().Code)
+	r.Equal(404, w.Request("/docs").Get().Code)
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/gobuffalo/buffalo/worker"
 	"github.com/gobuffalo/envy"
 	"github.com/gorilla/sessions"
 	"github.com/markbates/going/defaults"
@@ -22,18 +24,188 @@ type Options struct {
 	Logger Logger
 	// LogDir is the path to the directory for storing the JSON log files from the
 	// default Logger
-	LogDir         string
+	LogDir string
+	// LogRotation rotates the JSON log file of the default Logger, see
+	// RotatingFile. By default the file is truncated when the App starts
+	// and never rotated.
+	LogRotation    RotateOptions
 	MethodOverride http.HandlerFunc
 	// SessionStore is the `github.com/gorilla/sessions` store used to back
 	// the session. It defaults to use a cookie store and the ENV variable
-	// `SESSION_SECRET`.
+	// `SESSION_SECRET`. See github.com/gobuffalo/buffalo/sessionstore for
+	// encrypted cookie, Redis, memcached, and SQL stores.
 	SessionStore sessions.Store
 	// SessionName is the name of the session cookie that is set. This defaults
 	// to "_buffalo_session".
 	SessionName string
+	// Worker performs background jobs. It is started when the App is
+	// served, and stopped, after the requests in flight, when it shuts
+	// down. Default is a worker.Simple, performing jobs in goroutines.
+	Worker worker.Worker
+	// Sessions harden sessions, with timeouts, binding them to the client,
+	// and secret rotation, see SessionOptions.
+	Sessions SessionOptions
+	// Health configures the liveness and readiness endpoints, see
+	// HealthChecks.
+	Health HealthOptions
+	// Addr is the address Serve listens on. Default is $ADDR, or
+	// ":[$PORT|3000]".
+	// It can also be "unix:" and the path of a unix socket, "fd:" and the
+	// number of a file descriptor passed by launchd or another process
+	// manager, or "systemd" for a socket passed by systemd.
+	Addr string
+	// SocketMode is the permissions of the unix socket that Serve
+	// creates. Default is to leave them to the umask.
+	SocketMode os.FileMode
+	// ReadTimeout, WriteTimeout, and IdleTimeout are the timeouts of the
+	// http.Server used by Serve. Routes can change the read and write
+	// timeouts with RouteLimits. Default is no timeouts.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// RedirectAddr is the address, such as ":80", that ServeTLS and
+	// ServeAutoCert redirect requests to HTTPS from. Default is not to.
+	RedirectAddr string
+	// ShutdownTimeout is how long Serve waits for requests in flight to
+	// finish, and for the ShutdownHooks to run, when the App is stopped.
+	// Default is 30 seconds.
+	ShutdownTimeout time.Duration
 	// Host that this application will be available at. Default is "http://127.0.0.1:[$PORT|3000]".
-	Host   string
-	prefix string
+	Host string
+	// ProfileLabels attaches "route" and "method" pprof labels to the
+	// goroutine handling each request, so CPU and heap profiles can be
+	// broken down by route. App.Debug turns it on.
+	ProfileLabels bool
+	// ResponseBufferSize turns on buffered responses, see BufferedResponse.
+	// Responses up to this many bytes are held in memory until the request
+	// has been handled, larger ones are streamed. Default is 0, no buffering.
+	ResponseBufferSize int
+	// Version of the application, such as a git SHA or release tag. It
+	// is included in the ErrorSnapshot of failed requests.
+	Version string
+	// DisableAutoHead stops HEAD requests, for paths without a HEAD
+	// route, from being answered by the GET route without a body.
+	DisableAutoHead bool
+	// DisableAutoOptions stops OPTIONS requests, for paths without an
+	// OPTIONS route, from being answered with an Allow header listing the
+	// methods of the path.
+	DisableAutoOptions bool
+	// OptionsHandler answers the automatic OPTIONS requests, after the
+	// Allow header has been set. The default responds with a 204.
+	OptionsHandler Handler
+	// TrailingSlash is what happens to requests whose path only differs
+	// from a route by a trailing slash, see SlashPolicy. The default is
+	// SlashStrict.
+	TrailingSlash SlashPolicy
+	// CaseInsensitive matches paths to routes without regard to case.
+	// Params keep the case they were requested with.
+	CaseInsensitive bool
+	// TenantResolver finds the Tenant of each request, for Apps serving
+	// many tenants. The Tenant can override the session, error pages, and
+	// templates of the App, see Tenant. Default is no tenants.
+	TenantResolver TenantResolver
+	// JSONEncoder encodes the values given to Context.JSON, and to
+	// Context.Respond, such as with jsoniter. Default is encoding/json.
+	JSONEncoder JSONEncoder
+	// Sendfile hands the files sent with SendFile and Attachment off to
+	// the server in front of the App. Default is to send them from the
+	// App.
+	Sendfile Sendfile
+	// Cookies are the attributes of the cookies set through
+	// Context.Cookies, see CookieOptions.
+	Cookies CookieOptions
+	// CookieSecret signs, and encrypts, the signed and encrypted cookies
+	// of Context.Cookies. Default is $COOKIE_SECRET, or $SESSION_SECRET.
+	CookieSecret string
+	// TrustedProxies are the load balancers and proxies in front of the
+	// App, whose forwarding headers are believed by Context.ClientIP.
+	// Default is to trust none.
+	TrustedProxies *IPList
+	prefix         string
+}
+
+// Option configures an App, see New. Options is itself an Option, which
+// replaces all of the options set before it.
+type Option interface {
+	applyOption(*Options)
+}
+
+func (o Options) applyOption(opts *Options) {
+	*opts = o
+}
+
+type optionFunc func(*Options)
+
+func (f optionFunc) applyOption(opts *Options) {
+	f(opts)
+}
+
+// WithEnv sets the Env option.
+func WithEnv(env string) Option {
+	return optionFunc(func(o *Options) { o.Env = env })
+}
+
+// WithAddr sets the Addr option.
+func WithAddr(addr string) Option {
+	return optionFunc(func(o *Options) { o.Addr = addr })
+}
+
+// WithHost sets the Host option.
+func WithHost(host string) Option {
+	return optionFunc(func(o *Options) { o.Host = host })
+}
+
+// WithLogger sets the Logger option.
+func WithLogger(l Logger) Option {
+	return optionFunc(func(o *Options) { o.Logger = l })
+}
+
+// WithLogLevel sets the LogLevel option.
+func WithLogLevel(lvl string) Option {
+	return optionFunc(func(o *Options) { o.LogLevel = lvl })
+}
+
+// WithSessionStore sets the SessionStore option.
+func WithSessionStore(s sessions.Store) Option {
+	return optionFunc(func(o *Options) { o.SessionStore = s })
+}
+
+// WithWorker sets the Worker option.
+func WithWorker(w worker.Worker) Option {
+	return optionFunc(func(o *Options) { o.Worker = w })
+}
+
+// WithSessionName sets the SessionName option.
+func WithSessionName(name string) Option {
+	return optionFunc(func(o *Options) { o.SessionName = name })
+}
+
+// WithPrefix puts all of the routes of the App under the path.
+func WithPrefix(p string) Option {
+	return optionFunc(func(o *Options) { o.prefix = p })
+}
+
+// WithVersion sets the Version option.
+func WithVersion(v string) Option {
+	return optionFunc(func(o *Options) { o.Version = v })
+}
+
+// WithShutdownTimeout sets the ShutdownTimeout option.
+func WithShutdownTimeout(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.ShutdownTimeout = d })
+}
+
+// WithJSONEncoder sets the JSONEncoder option.
+func WithJSONEncoder(e JSONEncoder) Option {
+	return optionFunc(func(o *Options) { o.JSONEncoder = e })
+}
+
+func buildOptions(opts []Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt.applyOption(&o)
+	}
+	return optionsWithDefaults(o)
 }
 
 // NewOptions returns a new Options instance with sensible defaults
@@ -52,15 +224,26 @@ func optionsWithDefaults(opts Options) Options {
 		opts.LogDir = os.TempDir()
 	}
 
+	opts.Sessions = sessionOptionsWithDefaults(opts.Sessions, opts.Env)
 	if opts.SessionStore == nil {
-		secret := envy.Get("SESSION_SECRET", "")
 		// In production a SESSION_SECRET must be set!
-		if opts.Env == "production" && secret == "" {
+		if opts.Env == "production" && opts.Sessions.Secrets[0] == "" {
 			log.Println("WARNING! Unless you set SESSION_SECRET env variable, your session storage is not protected!")
 		}
-		opts.SessionStore = sessions.NewCookieStore([]byte(secret))
+		opts.SessionStore = newCookieStore(opts.Sessions.Secrets)
 	}
 	opts.SessionName = defaults.String(opts.SessionName, "_buffalo_session")
+	if opts.Worker == nil {
+		opts.Worker = worker.NewSimple()
+	}
+	opts.Health = healthOptionsWithDefaults(opts.Health)
+	opts.Cookies = cookieOptionsWithDefaults(opts.Cookies, opts.Env)
+	opts.CookieSecret = defaults.String(opts.CookieSecret, envy.Get("COOKIE_SECRET", envy.Get("SESSION_SECRET", "")))
+	addr := defaults.String(envy.Get("ADDR", ""), ":"+envy.Get("PORT", "3000"))
+	opts.Addr = defaults.String(opts.Addr, addr)
+	if opts.ShutdownTimeout == 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
 	opts.Host = defaults.String(opts.Host, fmt.Sprintf("http://127.0.0.1:%s", envy.Get("PORT", "3000")))
 	return opts
 }
@@ -0,0 +1,71 @@
+package buffalo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/gorilla/sessions"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New_Options(t *testing.T) {
+	r := require.New(t)
+
+	store := sessions.NewCookieStore([]byte("secret"))
+	a := New(
+		WithEnv("production"),
+		WithAddr(":8080"),
+		WithSessionStore(store),
+		WithSessionName("_app_session"),
+		WithPrefix("/api"),
+		WithVersion("1.0.0"),
+		WithShutdownTimeout(time.Second),
+	)
+	r.Equal("production", a.Env())
+	r.Equal(":8080", a.Addr)
+	r.Equal(store, a.SessionStore)
+	r.Equal("_app_session", a.SessionName)
+	r.Equal("1.0.0", a.Version)
+	r.Equal(time.Second, a.ShutdownTimeout)
+	r.Equal("debug", a.LogLevel)
+
+	a.GET("/users", voidHandler)
+	r.Equal(200, willie.New(a).Request("/api/users").Get().Code)
+
+	// Options replace the options before them
+	a = New(WithEnv("production"), Options{Addr: ":9000"}, WithLogLevel("info"))
+	r.Equal(":9000", a.Addr)
+	r.Equal("info", a.LogLevel)
+	r.NotEqual("production", a.Env())
+}
+
+func Test_New_Options_Env(t *testing.T) {
+	r := require.New(t)
+
+	defer envy.Set("ADDR", envy.Get("ADDR", ""))
+	defer envy.Set("PORT", envy.Get("PORT", "3000"))
+
+	envy.Set("ADDR", "")
+	envy.Set("PORT", "4000")
+	r.Equal(":4000", New().Addr)
+
+	envy.Set("ADDR", "127.0.0.1:5000")
+	r.Equal("127.0.0.1:5000", New().Addr)
+	r.Equal(":6000", New(WithAddr(":6000")).Addr)
+}
+
+func Test_DefaultContext_Env(t *testing.T) {
+	r := require.New(t)
+	a := New(WithEnv("staging"))
+	a.GET("/", func(c Context) error {
+		r.Equal("staging", c.Env())
+		return nil
+	})
+	r.Equal(200, willie.New(a).Request("/").Get().Code)
+
+	c := &DefaultContext{}
+	c.Set("env", "test")
+	r.Equal("test", c.Env())
+}
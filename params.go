@@ -0,0 +1,115 @@
+package buffalo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParamInt tries to convert the requested parameter to
+// an int. It will return an error if there is a problem.
+func (d *DefaultContext) ParamInt(key string) (int, error) {
+	k := d.Params().Get(key)
+	i, err := strconv.Atoi(k)
+	return i, errors.WithMessage(err, fmt.Sprintf("could not convert %s to an int", k))
+}
+
+// ParamIntOr returns the requested parameter as an int, or
+// def if it is missing or isn't an int.
+func (d *DefaultContext) ParamIntOr(key string, def int) int {
+	if i, err := d.ParamInt(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// ParamInt64 tries to convert the requested parameter to
+// an int64. It will return an error if there is a problem.
+func (d *DefaultContext) ParamInt64(key string) (int64, error) {
+	k := d.Params().Get(key)
+	i, err := strconv.ParseInt(k, 10, 64)
+	return i, errors.WithMessage(err, fmt.Sprintf("could not convert %s to an int64", k))
+}
+
+// ParamInt64Or returns the requested parameter as an int64, or
+// def if it is missing or isn't an int64.
+func (d *DefaultContext) ParamInt64Or(key string, def int64) int64 {
+	if i, err := d.ParamInt64(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// ParamBool tries to convert the requested parameter to a bool.
+// Besides the values strconv.ParseBool accepts, "on" and "off",
+// as sent by checkboxes, are understood.
+func (d *DefaultContext) ParamBool(key string) (bool, error) {
+	k := d.Params().Get(key)
+	switch strings.ToLower(k) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(k)
+	return b, errors.WithMessage(err, fmt.Sprintf("could not convert %s to a bool", k))
+}
+
+// ParamBoolOr returns the requested parameter as a bool, or
+// def if it is missing or isn't a bool.
+func (d *DefaultContext) ParamBoolOr(key string, def bool) bool {
+	if b, err := d.ParamBool(key); err == nil {
+		return b
+	}
+	return def
+}
+
+var uuidRx = regexp.MustCompile(`^` + ParamTypes["uuid"] + `$`)
+
+// ParamUUID returns the requested parameter, in lower case, if it
+// is a UUID. It will return an error if it isn't.
+func (d *DefaultContext) ParamUUID(key string) (string, error) {
+	k := d.Params().Get(key)
+	if !uuidRx.MatchString(k) {
+		return "", errors.Errorf("could not convert %s to a uuid", k)
+	}
+	return strings.ToLower(k), nil
+}
+
+// ParamUUIDOr returns the requested parameter as a UUID, or
+// def if it is missing or isn't a UUID.
+func (d *DefaultContext) ParamUUIDOr(key string, def string) string {
+	if u, err := d.ParamUUID(key); err == nil {
+		return u
+	}
+	return def
+}
+
+// ParamTime tries to parse the requested parameter as a time
+// with the layout. An empty layout tries each of the TimeFormats.
+func (d *DefaultContext) ParamTime(key string, layout string) (time.Time, error) {
+	k := d.Params().Get(key)
+	layouts := TimeFormats
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, k); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("could not convert %s to a time", k)
+}
+
+// ParamTimeOr returns the requested parameter as a time, or
+// def if it is missing or can't be parsed with the layout.
+func (d *DefaultContext) ParamTimeOr(key string, layout string, def time.Time) time.Time {
+	if t, err := d.ParamTime(key, layout); err == nil {
+		return t
+	}
+	return def
+}
@@ -0,0 +1,86 @@
+package buffalo
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func paramsContext() *DefaultContext {
+	return &DefaultContext{
+		params: url.Values{
+			"name":    []string{"Mark"},
+			"id":      []string{"9000000000"},
+			"admin":   []string{"on"},
+			"active":  []string{"false"},
+			"file_id": []string{"0F8FAD5B-D9CB-469F-A165-70867728950E"},
+			"born":    []string{"1976-04-01"},
+			"at":      []string{"01 Apr 76 10:30 UTC"},
+		},
+	}
+}
+
+func Test_DefaultContext_ParamInt64(t *testing.T) {
+	r := require.New(t)
+	c := paramsContext()
+
+	id, err := c.ParamInt64("id")
+	r.NoError(err)
+	r.Equal(int64(9000000000), id)
+
+	_, err = c.ParamInt64("name")
+	r.Error(err)
+	r.Equal(int64(7), c.ParamInt64Or("missing", 7))
+	r.Equal(7, c.ParamIntOr("name", 7))
+}
+
+func Test_DefaultContext_ParamBool(t *testing.T) {
+	r := require.New(t)
+	c := paramsContext()
+
+	b, err := c.ParamBool("admin")
+	r.NoError(err)
+	r.True(b)
+
+	b, err = c.ParamBool("active")
+	r.NoError(err)
+	r.False(b)
+
+	_, err = c.ParamBool("name")
+	r.Error(err)
+	r.True(c.ParamBoolOr("missing", true))
+}
+
+func Test_DefaultContext_ParamUUID(t *testing.T) {
+	r := require.New(t)
+	c := paramsContext()
+
+	u, err := c.ParamUUID("file_id")
+	r.NoError(err)
+	r.Equal("0f8fad5b-d9cb-469f-a165-70867728950e", u)
+
+	_, err = c.ParamUUID("id")
+	r.Error(err)
+	r.Equal("none", c.ParamUUIDOr("name", "none"))
+}
+
+func Test_DefaultContext_ParamTime(t *testing.T) {
+	r := require.New(t)
+	c := paramsContext()
+
+	tm, err := c.ParamTime("born", "")
+	r.NoError(err)
+	r.Equal(time.Date(1976, 4, 1, 0, 0, 0, 0, time.UTC), tm)
+
+	tm, err = c.ParamTime("at", time.RFC822)
+	r.NoError(err)
+	r.Equal(time.Date(1976, 4, 1, 10, 30, 0, 0, time.UTC), tm)
+
+	_, err = c.ParamTime("born", time.RFC822)
+	r.Error(err)
+
+	def := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.Equal(def, c.ParamTimeOr("missing", "", def))
+}
@@ -0,0 +1,154 @@
+package buffalo
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// SlashPolicy is what happens to requests whose path only differs from
+// the path of a route by a trailing slash.
+type SlashPolicy int
+
+const (
+	// SlashStrict doesn't match them to the route, so they are a 404.
+	// It is the default.
+	SlashStrict SlashPolicy = iota
+	// SlashRedirect redirects them to the path of the route, with a 301
+	// for GET and HEAD requests, and a 308 for everything else, so the
+	// method and body are kept.
+	SlashRedirect
+	// SlashRewrite serves them as if the path of the route had been
+	// requested.
+	SlashRewrite
+)
+
+// servePathPolicy serves requests that don't match a route, but would
+// if the TrailingSlash and CaseInsensitive options were applied to
+// them. It returns false if the request wasn't answered.
+func (a *App) servePathPolicy(res http.ResponseWriter, req *http.Request) bool {
+	if a.TrailingSlash == SlashStrict && !a.CaseInsensitive {
+		return false
+	}
+	if a.matchPath(req) {
+		return false
+	}
+	p := req.URL.Path
+	paths := []string{p}
+	if a.TrailingSlash != SlashStrict && p != "/" {
+		alt := p + "/"
+		if strings.HasSuffix(p, "/") {
+			alt = strings.TrimSuffix(p, "/")
+		}
+		if a.matchPath(withPath(req, alt)) {
+			a.serveSlash(res, req, alt)
+			return true
+		}
+		paths = append(paths, alt)
+	}
+	if !a.CaseInsensitive {
+		return false
+	}
+	for _, pp := range paths {
+		ri, vars, ok := a.matchFold(req.Method, pp)
+		if !ok {
+			continue
+		}
+		if pp != p {
+			a.serveSlash(res, req, pp)
+			return true
+		}
+		if req.Method != ri.Method && req.Method == "HEAD" {
+			res = &buffaloResponse{ResponseWriter: headResponse{res}}
+		}
+		ri.MuxRoute.GetHandler().ServeHTTP(res, mux.SetURLVars(req, vars))
+		return true
+	}
+	return false
+}
+
+func (a *App) serveSlash(res http.ResponseWriter, req *http.Request, p string) {
+	if a.TrailingSlash == SlashRewrite {
+		a.ServeHTTP(res, withPath(req, p))
+		return
+	}
+	u := *req.URL
+	u.Path = p
+	u.RawPath = ""
+	status := http.StatusPermanentRedirect
+	if req.Method == "GET" || req.Method == "HEAD" {
+		status = http.StatusMovedPermanently
+	}
+	http.Redirect(res, req, u.String(), status)
+}
+
+// matchPath returns true if a route matches the path of the request,
+// even if it is for another method.
+func (a *App) matchPath(req *http.Request) bool {
+	m := &mux.RouteMatch{}
+	return a.router.Match(req, m) && m.MatchErr != mux.ErrNotFound
+}
+
+// foldedPaths caches the case-insensitive versions of the regular
+// expressions of route paths.
+var foldedPaths = &sync.Map{}
+
+// matchFold finds the route for the method that matches the path
+// without regard to case, and returns the params of the path with the
+// case they were requested with.
+func (a *App) matchFold(method string, p string) (RouteInfo, map[string]string, bool) {
+	for _, ri := range a.Routes() {
+		if ri.MuxRoute == nil || ri.Host != "" {
+			continue
+		}
+		if ri.Method != method && ri.Method != "ANY" && !(method == "HEAD" && ri.Method == "GET" && !a.DisableAutoHead) {
+			continue
+		}
+		rx, err := ri.MuxRoute.GetPathRegexp()
+		if err != nil {
+			continue
+		}
+		var re *regexp.Regexp
+		if v, ok := foldedPaths.Load(rx); ok {
+			re = v.(*regexp.Regexp)
+		} else {
+			re, err = regexp.Compile("(?i)" + rx)
+			if err != nil {
+				continue
+			}
+			foldedPaths.Store(rx, re)
+		}
+		match := re.FindStringSubmatch(p)
+		if match == nil {
+			continue
+		}
+		// mux names the group of each variable "v" and its index
+		groups := map[string]string{}
+		tmpl, _ := ri.MuxRoute.GetPathTemplate()
+		for i, m := range routeVars.FindAllStringSubmatch(tmpl, -1) {
+			groups["v"+strconv.Itoa(i)] = m[1]
+		}
+		vars := map[string]string{}
+		for i, n := range re.SubexpNames() {
+			if name, ok := groups[n]; ok {
+				vars[name] = match[i]
+			}
+		}
+		return ri, vars, true
+	}
+	return RouteInfo{}, nil, false
+}
+
+func withPath(req *http.Request, p string) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	u := *req.URL
+	u.Path = p
+	u.RawPath = ""
+	r.URL = &u
+	return r
+}
@@ -0,0 +1,59 @@
+package buffalo
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func pathPolicyApp(opts Options) *App {
+	a := New(opts)
+	a.GET("/users", func(c Context) error {
+		return c.Render(200, render.String("users"))
+	})
+	a.POST("/users", func(c Context) error {
+		return c.Render(201, render.String("created"))
+	})
+	a.GET("/users/{name}/posts", func(c Context) error {
+		return c.Render(200, render.String("posts {{params.name}}"))
+	})
+	return a
+}
+
+func Test_App_TrailingSlash(t *testing.T) {
+	r := require.New(t)
+
+	a := pathPolicyApp(Options{})
+	r.Equal(404, serve(a, "GET", "/users/").Code)
+
+	a = pathPolicyApp(Options{TrailingSlash: SlashRedirect})
+	res := serve(a, "GET", "/users/?page=2")
+	r.Equal(301, res.Code)
+	r.Equal("/users?page=2", res.Header().Get("Location"))
+	res = serve(a, "POST", "/users/")
+	r.Equal(308, res.Code)
+	r.Equal("/users", res.Header().Get("Location"))
+	r.Equal(404, serve(a, "GET", "/nope/").Code)
+
+	a = pathPolicyApp(Options{TrailingSlash: SlashRewrite})
+	r.Equal("users", serve(a, "GET", "/users/").Body.String())
+	r.Equal("created", serve(a, "POST", "/users/").Body.String())
+}
+
+func Test_App_CaseInsensitive(t *testing.T) {
+	r := require.New(t)
+
+	a := pathPolicyApp(Options{})
+	r.Equal(404, serve(a, "GET", "/Users").Code)
+
+	a = pathPolicyApp(Options{CaseInsensitive: true})
+	r.Equal("users", serve(a, "GET", "/Users").Body.String())
+	r.Equal("posts MarkBates", serve(a, "GET", "/USERS/MarkBates/Posts").Body.String())
+	r.Equal(404, serve(a, "GET", "/Users/").Code)
+
+	a = pathPolicyApp(Options{CaseInsensitive: true, TrailingSlash: SlashRedirect})
+	res := serve(a, "GET", "/Users/")
+	r.Equal(301, res.Code)
+	r.Equal("/Users", res.Header().Get("Location"))
+}
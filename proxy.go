@@ -0,0 +1,140 @@
+package buffalo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// ProxyOptions configure a reverse proxy, see App.Proxy.
+type ProxyOptions struct {
+	// Rewrite changes the path sent upstream, which is the "path" param
+	// of the route, or the path of the request if there isn't one.
+	Rewrite func(path string) string
+	// SetHeaders are set on the request sent upstream.
+	SetHeaders map[string]string
+	// RemoveHeaders are removed from the request sent upstream.
+	RemoveHeaders []string
+	// PreserveHost sends the Host of the request upstream, in place of
+	// the host of the target.
+	PreserveHost bool
+	// ModifyResponse can change the response from upstream.
+	ModifyResponse func(*http.Response) error
+	// Retries is how many times a GET, HEAD, or OPTIONS request, that
+	// couldn't reach upstream, is tried again. The first retry waits for
+	// Backoff, and every retry after that waits twice as long.
+	Retries int
+	Backoff time.Duration
+	// FlushInterval is how often the response is flushed to the client
+	// while it is copied. Streamed responses, such as server-sent
+	// events, are always flushed straight away.
+	FlushInterval time.Duration
+	// Transport used for requests upstream. Default is
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// Proxy requests for the path, for any method, to the target. Requests
+// are proxied with X-Forwarded headers, websockets are passed through,
+// and if upstream can't be reached the request is sent to the 502
+// ErrorHandler.
+/*
+	api, _ := url.Parse("http://localhost:8080/v2")
+	a.Proxy("/api/{path:*}", api, buffalo.ProxyOptions{Retries: 2})
+	// GET /api/users/1 => GET http://localhost:8080/v2/users/1
+*/
+func (a *App) Proxy(p string, target *url.URL, opts ProxyOptions) {
+	a.ANY(p, ProxyHandler(target, opts))
+}
+
+type proxyErrorKey struct{}
+
+// ProxyHandler returns a Handler that proxies requests to the target,
+// see App.Proxy.
+func ProxyHandler(target *url.URL, opts ProxyOptions) Handler {
+	transport := opts.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			if opts.PreserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+			for k, v := range opts.SetHeaders {
+				pr.Out.Header.Set(k, v)
+			}
+			for _, k := range opts.RemoveHeaders {
+				pr.Out.Header.Del(k)
+			}
+		},
+		Transport: &retryTransport{
+			transport: transport,
+			retries:   opts.Retries,
+			backoff:   opts.Backoff,
+		},
+		FlushInterval:  opts.FlushInterval,
+		ModifyResponse: opts.ModifyResponse,
+		ErrorHandler: func(res http.ResponseWriter, req *http.Request, err error) {
+			if perr, ok := req.Context().Value(proxyErrorKey{}).(*error); ok {
+				*perr = err
+			}
+		},
+	}
+	return func(c Context) error {
+		req := c.Request()
+		p := req.URL.Path
+		if pp, ok := mux.Vars(req)["path"]; ok {
+			p = "/" + strings.TrimPrefix(pp, "/")
+		}
+		if opts.Rewrite != nil {
+			p = opts.Rewrite(p)
+		}
+		var perr error
+		out := req.WithContext(context.WithValue(req.Context(), proxyErrorKey{}, &perr))
+		u := *req.URL
+		u.Path = p
+		u.RawPath = ""
+		out.URL = &u
+		rp.ServeHTTP(c.Response(), out)
+		if perr != nil {
+			return c.Error(http.StatusBadGateway, errors.Wrapf(perr, "could not proxy to %s", target.Host))
+		}
+		return nil
+	}
+}
+
+// retryTransport retries requests, that are safe to retry, when they
+// can't reach upstream.
+type retryTransport struct {
+	transport http.RoundTripper
+	retries   int
+	backoff   time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.transport.RoundTrip(req)
+	retriable := req.Method == "GET" || req.Method == "HEAD" || req.Method == "OPTIONS"
+	if !retriable || (req.Body != nil && req.Body != http.NoBody) {
+		return res, err
+	}
+	wait := t.backoff
+	for i := 0; i < t.retries && err != nil; i++ {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+		res, err = t.transport.RoundTrip(req)
+	}
+	return res, err
+}
@@ -0,0 +1,94 @@
+package buffalo
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Proxy(t *testing.T) {
+	r := require.New(t)
+
+	up := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Path", req.URL.RequestURI())
+		res.Header().Set("X-Token", req.Header.Get("X-Token"))
+		res.Header().Set("X-Cookie", req.Header.Get("Cookie"))
+		res.Header().Set("X-Forwarded", req.Header.Get("X-Forwarded-Proto"))
+		res.WriteHeader(201)
+		res.Write([]byte(req.Method))
+	}))
+	defer up.Close()
+	target, _ := url.Parse(up.URL + "/v2")
+
+	a := New(Options{})
+	a.Proxy("/api/{path:*}", target, ProxyOptions{
+		SetHeaders:    map[string]string{"X-Token": "secret"},
+		RemoveHeaders: []string{"Cookie"},
+	})
+	a.Proxy("/legacy/{path:*}", target, ProxyOptions{
+		Rewrite: func(p string) string {
+			return "/old" + p
+		},
+	})
+
+	w := willie.New(a)
+	req := w.Request("/api/users/1?page=2")
+	req.Headers["Cookie"] = "session=1"
+	res := req.Post(nil)
+	r.Equal(201, res.Code)
+	r.Equal("POST", res.Body.String())
+	r.Equal("/v2/users/1?page=2", res.Header().Get("X-Path"))
+	r.Equal("secret", res.Header().Get("X-Token"))
+	r.Empty(res.Header().Get("X-Cookie"))
+	r.Equal("http", res.Header().Get("X-Forwarded"))
+
+	res = w.Request("/legacy/users").Get()
+	r.Equal("/v2/old/users", res.Header().Get("X-Path"))
+}
+
+func Test_App_Proxy_Down(t *testing.T) {
+	r := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	target, _ := url.Parse("http://" + ln.Addr().String())
+	ln.Close()
+
+	tries := 0
+	a := New(Options{})
+	a.ErrorHandlers[502] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, err = c.Response().Write([]byte("upstream is down"))
+		return err
+	}
+	a.Proxy("/{path:*}", target, ProxyOptions{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			tries++
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	})
+
+	w := willie.New(a)
+	res := w.Request("/users").Get()
+	r.Equal(502, res.Code)
+	r.Equal("upstream is down", res.Body.String())
+	r.Equal(3, tries)
+
+	tries = 0
+	w.Request("/users").Post(map[string]string{"a": "b"})
+	r.Equal(1, tries)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,70 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Push the target, such as a stylesheet the page will need, to the
+// client with HTTP/2 server push. It returns http.ErrNotSupported if
+// the connection doesn't support push, which callers can usually
+// ignore.
+/*
+	c.Push("/assets/application.css", nil)
+	return c.Render(200, r.HTML("index.html"))
+*/
+func (d *DefaultContext) Push(target string, opts *http.PushOptions) error {
+	return push(d.Response(), target, opts)
+}
+
+// push the target with the first http.Pusher w is, or wraps.
+func push(w http.ResponseWriter, target string, opts *http.PushOptions) error {
+	for w != nil {
+		if p, ok := w.(http.Pusher); ok {
+			return p.Push(target, opts)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return http.ErrNotSupported
+}
+
+// EarlyHints sends a 103 Early Hints response, asking the client to
+// preload the links while the real response is being prepared. It must
+// be called before anything else is written.
+/*
+	c.EarlyHints("/assets/application.css", "/assets/application.js")
+*/
+func (d *DefaultContext) EarlyHints(links ...string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	h := d.Response().Header()
+	for _, l := range links {
+		h.Add("Link", PreloadLink(l))
+	}
+	d.Response().WriteHeader(http.StatusEarlyHints)
+	return nil
+}
+
+// PreloadLink returns the value of a Link header to preload the target,
+// with the type of content guessed from its extension.
+func PreloadLink(target string) string {
+	ext := strings.ToLower(path.Ext(strings.SplitN(target, "?", 2)[0]))
+	switch ext {
+	case ".css":
+		return fmt.Sprintf("<%s>; rel=preload; as=style", target)
+	case ".js", ".mjs":
+		return fmt.Sprintf("<%s>; rel=preload; as=script", target)
+	case ".woff", ".woff2", ".ttf", ".otf":
+		return fmt.Sprintf("<%s>; rel=preload; as=font; crossorigin", target)
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico":
+		return fmt.Sprintf("<%s>; rel=preload; as=image", target)
+	}
+	return fmt.Sprintf("<%s>; rel=preload", target)
+}
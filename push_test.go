@@ -0,0 +1,34 @@
+package buffalo
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PreloadLink(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("</app.css>; rel=preload; as=style", PreloadLink("/app.css"))
+	r.Equal("</app.js?v=1>; rel=preload; as=script", PreloadLink("/app.js?v=1"))
+	r.Equal("</font.woff2>; rel=preload; as=font; crossorigin", PreloadLink("/font.woff2"))
+	r.Equal("</logo.PNG>; rel=preload; as=image", PreloadLink("/logo.PNG"))
+	r.Equal("</data>; rel=preload", PreloadLink("/data"))
+}
+
+func Test_DefaultContext_Push_NotSupported(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	var err error
+	a.GET("/", func(c Context) error {
+		err = c.Push("/app.css", nil)
+		return nil
+	})
+
+	w := willie.New(a)
+	w.Request("/").Get()
+	r.Equal(http.ErrNotSupported, err)
+}
@@ -0,0 +1,251 @@
+package buffalo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RecordedRequest is a request saved by a RequestRecorder.
+type RecordedRequest struct {
+	ID     string      `json:"id"`
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+	// Truncated is true if the body was larger than MaxBody, such a
+	// request can't be replayed faithfully.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// RequestRecorder saves the last few requests made to the App, in
+// development, to disk so they can be replayed later, see Replay. It
+// makes reproducing a bug that happened a moment ago, but is hard to
+// trigger again by hand, much easier. As it is only meant for
+// development, requests are saved as they are, cookies, passwords, and
+// all.
+type RequestRecorder struct {
+	// Dir the requests are saved in.
+	Dir string
+	// Max is the number of requests kept. Defaults to 50.
+	Max int
+	// MaxBody is the most of each request body that is saved. Defaults
+	// to 1MB.
+	MaxBody int64
+
+	app  *App
+	moot sync.Mutex
+	last int64
+}
+
+// replayHeader marks replayed requests, so they aren't recorded again.
+const replayHeader = "X-Buffalo-Replay"
+
+// RequestRecorder returns a RequestRecorder for the App that saves
+// requests in dir. Use its Middleware to start recording, it does
+// nothing outside of the "development" environment.
+/*
+	rec := a.RequestRecorder(filepath.Join(os.TempDir(), "myapp-requests"))
+	a.Use(rec.Middleware)
+	a.GET("/__replay", rec.ReplayHandler)
+	a.POST("/__replay/{id}", rec.ReplayHandler)
+*/
+func (a *App) RequestRecorder(dir string) *RequestRecorder {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	return &RequestRecorder{
+		Dir:     dir,
+		Max:     50,
+		MaxBody: 1 << 20,
+		app:     root,
+	}
+}
+
+// Middleware records each request before handing it on. Replayed
+// requests, and requests to the ReplayHandler, aren't recorded.
+func (rr *RequestRecorder) Middleware(next Handler) Handler {
+	return func(c Context) error {
+		req := c.Request()
+		if rr.app.Env() != "development" || req.Header.Get(replayHeader) != "" {
+			return next(c)
+		}
+		if ri, ok := Get(c, CurrentRouteKey); ok && funcKey(ri.Handler) == funcKey(rr.ReplayHandler) {
+			return next(c)
+		}
+		if err := rr.record(req); err != nil {
+			c.Logger().Warn(errors.Wrap(err, "could not record request"))
+		}
+		return next(c)
+	}
+}
+
+func (rr *RequestRecorder) record(req *http.Request) error {
+	now := time.Now()
+	rr.moot.Lock()
+	// IDs are the time the request was made, kept unique so requests
+	// made in the same nanosecond aren't lost.
+	id := now.UnixNano()
+	if id <= rr.last {
+		id = rr.last + 1
+	}
+	rr.last = id
+	rr.moot.Unlock()
+
+	rec := RecordedRequest{
+		ID:     strconv.FormatInt(id, 10),
+		Time:   now,
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: req.Header,
+	}
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(io.LimitReader(req.Body, rr.MaxBody+1))
+		if err != nil {
+			return err
+		}
+		// put back what was read, so the handler can still read the body
+		req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(b), req.Body))
+		if int64(len(b)) > rr.MaxBody {
+			b = b[:rr.MaxBody]
+			rec.Truncated = true
+		}
+		rec.Body = b
+	}
+
+	if err := os.MkdirAll(rr.Dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(rr.Dir, rec.ID+".json"), b, 0644); err != nil {
+		return err
+	}
+	return rr.prune()
+}
+
+// prune removes all but the newest Max requests.
+func (rr *RequestRecorder) prune() error {
+	rr.moot.Lock()
+	defer rr.moot.Unlock()
+	ids, err := rr.ids()
+	if err != nil {
+		return err
+	}
+	for len(ids) > rr.Max {
+		os.Remove(filepath.Join(rr.Dir, ids[0]+".json"))
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// ids returns the IDs of the saved requests, oldest first.
+func (rr *RequestRecorder) ids() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(rr.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, f := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(f), ".json"))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
+
+// Requests returns the saved requests, newest first.
+func (rr *RequestRecorder) Requests() ([]RecordedRequest, error) {
+	ids, err := rr.ids()
+	if err != nil {
+		return nil, err
+	}
+	recs := []RecordedRequest{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		rec, err := rr.Get(ids[i])
+		if err != nil {
+			// it was pruned while we were reading
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// Get a saved request by its ID.
+func (rr *RequestRecorder) Get(id string) (RecordedRequest, error) {
+	rec := RecordedRequest{}
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return rec, errors.Errorf("invalid request id %q", id)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(rr.Dir, id+".json"))
+	if err != nil {
+		return rec, errors.WithStack(err)
+	}
+	err = json.Unmarshal(b, &rec)
+	return rec, errors.WithStack(err)
+}
+
+// Replay a saved request against the App, and return its response.
+func (rr *RequestRecorder) Replay(id string) (BatchResponse, error) {
+	rec, err := rr.Get(id)
+	if err != nil {
+		return BatchResponse{}, err
+	}
+	req, err := http.NewRequest(rec.Method, rec.URL, bytes.NewReader(rec.Body))
+	if err != nil {
+		return BatchResponse{}, errors.WithStack(err)
+	}
+	for k, vs := range rec.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set(replayHeader, rec.ID)
+	bw := &batchWriter{header: http.Header{}}
+	rr.app.ServeHTTP(bw, req)
+	return bw.response(), nil
+}
+
+// ReplayHandler lists the saved requests, as JSON, for GET requests,
+// and replays the request with the "id" param for any other request,
+// rendering its BatchResponse.
+func (rr *RequestRecorder) ReplayHandler(c Context) error {
+	var v interface{}
+	var err error
+	if c.Request().Method == "GET" {
+		v, err = rr.Requests()
+	} else {
+		v, err = rr.Replay(c.Param("id"))
+		if os.IsNotExist(errors.Cause(err)) {
+			return c.Error(404, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	res := c.Response()
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(200)
+	return json.NewEncoder(res).Encode(v)
+}
@@ -0,0 +1,86 @@
+package buffalo
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func recorderApp(env string, dir string) (*App, *RequestRecorder, *[]string) {
+	bodies := []string{}
+	a := New(Options{Env: env})
+	rec := a.RequestRecorder(dir)
+	rec.Max = 2
+	a.Use(rec.Middleware)
+	a.POST("/widgets", func(c Context) error {
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		bodies = append(bodies, string(b))
+		return c.Render(201, render.String("created "+string(b)))
+	})
+	a.GET("/__replay", rec.ReplayHandler)
+	a.POST("/__replay/{id}", rec.ReplayHandler)
+	return a, rec, &bodies
+}
+
+func Test_RequestRecorder(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "buffalo-recorder")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	a, rec, bodies := recorderApp("development", dir)
+	w := willie.New(a)
+	for _, name := range []string{"one", "two", "three"} {
+		req := w.JSON("/widgets")
+		req.Headers["X-Widget"] = name
+		r.Equal(201, req.Post(map[string]string{"name": name}).Code)
+	}
+	r.Len(*bodies, 3)
+	// the handler still gets the body
+	r.Equal(`{"name":"three"}`, (*bodies)[2])
+
+	recs, err := rec.Requests()
+	r.NoError(err)
+	r.Len(recs, 2)
+	r.Equal("POST", recs[0].Method)
+	r.Equal("/widgets", recs[0].URL)
+	r.Equal("three", recs[0].Header.Get("X-Widget"))
+	r.Equal(`{"name":"three"}`, string(recs[0].Body))
+	r.Equal(`{"name":"two"}`, string(recs[1].Body))
+
+	res := w.JSON("/__replay/" + recs[1].ID).Post(nil)
+	r.Equal(200, res.Code)
+	br := BatchResponse{}
+	res.Bind(&br)
+	r.Equal(201, br.Status)
+	r.Equal(`"created {\"name\":\"two\"}"`, string(br.Body))
+	r.Equal(`{"name":"two"}`, (*bodies)[3])
+
+	// replays aren't recorded
+	recs, err = rec.Requests()
+	r.NoError(err)
+	r.Equal(`{"name":"three"}`, string(recs[0].Body))
+
+	r.Equal(404, w.Request("/__replay/1").Post(nil).Code)
+}
+
+func Test_RequestRecorder_NotDevelopment(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "buffalo-recorder")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	a, rec, _ := recorderApp("production", dir)
+	w := willie.New(a)
+	r.Equal(201, w.JSON("/widgets").Post(map[string]string{"name": "one"}).Code)
+
+	recs, err := rec.Requests()
+	r.NoError(err)
+	r.Empty(recs)
+}
@@ -0,0 +1,235 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces the values of redacted headers and fields.
+const RedactedValue = "[FILTERED]"
+
+// Redactor decides which headers and fields hold sensitive data that
+// must never be logged, shown, or reported. Patterns are matched case
+// insensitively anywhere in the name, so "token" matches both
+// "access_token" and "X-CSRF-Token".
+type Redactor struct {
+	// Headers are the request and response header name patterns.
+	Headers []string
+	// Fields are the query, form, and JSON parameter, and Context
+	// data, name patterns. They also match the map keys, and struct
+	// fields, of the values being redacted.
+	Fields []string
+	// Values handle the types of values that must be redacted, or
+	// summarized, whatever their name, such as credentials and
+	// connections. A handler returns the value to show instead, and
+	// true, for the values it handles. The first that does wins.
+	Values []func(v interface{}) (interface{}, bool)
+}
+
+// Redactable values decide how they are shown once redacted, see
+// Redactor.Redact. Types that hold secrets can implement it to keep
+// them out of error pages, error reports, and logs.
+/*
+	func (k APIKey) Redacted() interface{} {
+		return k.Name
+	}
+*/
+type Redactable interface {
+	Redacted() interface{}
+}
+
+// maxRedactDepth is how deep Redact descends into values, deeper ones,
+// and cycles, are shown as their type.
+const maxRedactDepth = 8
+
+// Redaction is the one place sensitive data policy is defined. The
+// RequestLogger, the ParameterLogger middleware, and the error pages all
+// use it, and error reporters and debugging tools should too. Add to it
+// when the App boots.
+/*
+	buffalo.Redaction.Fields = append(buffalo.Redaction.Fields, "pin", "otp")
+	buffalo.Redaction.Headers = append(buffalo.Redaction.Headers, "X-Upstream-Key")
+	buffalo.Redaction.Values = append(buffalo.Redaction.Values, func(v interface{}) (interface{}, bool) {
+		if c, ok := v.(*stripe.Client); ok {
+			return fmt.Sprintf("stripe client %s", c.Account), true
+		}
+		return nil, false
+	})
+*/
+var Redaction = &Redactor{
+	Headers: []string{
+		"authorization",
+		"cookie",
+		"token",
+		"secret",
+		"api-key",
+		"apikey",
+	},
+	Fields: []string{
+		"password",
+		"passwd",
+		"secret",
+		"token",
+		"api_key",
+		"apikey",
+		"authorization",
+		"credit_card",
+		"card_number",
+		"cvv",
+		"ssn",
+	},
+}
+
+// HeaderRedacted returns true if the value of the named header must be
+// redacted.
+func (r *Redactor) HeaderRedacted(name string) bool {
+	return matchesAny(name, r.Headers)
+}
+
+// FieldRedacted returns true if the value of the named field must be
+// redacted.
+func (r *Redactor) FieldRedacted(name string) bool {
+	return matchesAny(name, r.Fields)
+}
+
+// RedactHeader returns a copy of h with the redacted values replaced.
+func (r *Redactor) RedactHeader(h http.Header) http.Header {
+	out := http.Header{}
+	for k, vs := range h {
+		if r.HeaderRedacted(k) {
+			out[k] = []string{RedactedValue}
+			continue
+		}
+		out[k] = append([]string{}, vs...)
+	}
+	return out
+}
+
+// RedactValues returns a copy of vals with the redacted values replaced.
+func (r *Redactor) RedactValues(vals url.Values) url.Values {
+	out := url.Values{}
+	for k, vs := range vals {
+		if r.FieldRedacted(k) {
+			out[k] = []string{RedactedValue}
+			continue
+		}
+		out[k] = append([]string{}, vs...)
+	}
+	return out
+}
+
+// RedactURL returns u as a string with the redacted query parameters
+// replaced.
+func (r *Redactor) RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	cu := *u
+	cu.RawQuery = r.RedactValues(u.Query()).Encode()
+	return cu.String()
+}
+
+// Redact returns a copy of v with the values of any redacted fields
+// replaced, descending into maps, slices, and structs. If key is itself
+// redacted RedactedValue is returned. Redactable values, and those the
+// Values handlers handle, are replaced by what they return. Structs are
+// turned into maps of their exported fields, named by their json tags,
+// unless they are a fmt.Stringer or an error.
+func (r *Redactor) Redact(key string, v interface{}) interface{} {
+	return r.redact(key, v, 0)
+}
+
+func (r *Redactor) redact(key string, v interface{}, depth int) interface{} {
+	if key != "" && r.FieldRedacted(key) {
+		return RedactedValue
+	}
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	if rv, ok := v.(Redactable); ok {
+		return rv.Redacted()
+	}
+	for _, fn := range r.Values {
+		if out, ok := fn(v); ok {
+			return out
+		}
+	}
+	switch t := v.(type) {
+	case url.Values:
+		return r.RedactValues(t)
+	case http.Header:
+		return r.RedactHeader(t)
+	case fmt.Stringer, error, []byte:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if depth >= maxRedactDepth {
+			return fmt.Sprintf("[%T]", v)
+		}
+	default:
+		return v
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			m[k] = r.redact(k, iter.Value().Interface(), depth+1)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = r.redact("", rv.Index(i).Interface(), depth+1)
+		}
+		return s
+	}
+	m := map[string]interface{}{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		m[name] = r.redact(name, rv.Field(i).Interface(), depth+1)
+	}
+	return m
+}
+
+// DataSnapshot returns a copy of the data of the Context, see
+// Context.Data, redacted by Redaction. It is what the development error
+// page shows, and what error reporters and debugging tools should use
+// instead of the raw data, which can hold session tokens, credentials,
+// and connections.
+func DataSnapshot(c Context) map[string]interface{} {
+	m, _ := Redaction.Redact("", c.Data()).(map[string]interface{})
+	return m
+}
+
+func matchesAny(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if p != "" && strings.Contains(name, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Redactor(t *testing.T) {
+	r := require.New(t)
+
+	rd := &Redactor{
+		Headers: []string{"authorization", "cookie"},
+		Fields:  []string{"password", "Token"},
+	}
+	r.True(rd.HeaderRedacted("Proxy-Authorization"))
+	r.True(rd.HeaderRedacted("Set-Cookie"))
+	r.False(rd.HeaderRedacted("Accept"))
+	r.True(rd.FieldRedacted("access_token"))
+	r.False(rd.FieldRedacted("email"))
+
+	h := http.Header{"Authorization": {"Bearer abc"}, "Accept": {"text/html"}}
+	rh := rd.RedactHeader(h)
+	r.Equal(RedactedValue, rh.Get("Authorization"))
+	r.Equal("text/html", rh.Get("Accept"))
+	// the original is left alone
+	r.Equal("Bearer abc", h.Get("Authorization"))
+
+	u, _ := url.Parse("/users?page=2&access_token=abc")
+	r.Equal("/users?access_token=%5BFILTERED%5D&page=2", rd.RedactURL(u))
+	u, _ = url.Parse("/users")
+	r.Equal("/users", rd.RedactURL(u))
+
+	v := rd.Redact("", map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":    "mark@example.com",
+			"password": "hunter2",
+		},
+		"tokens": []interface{}{"a", "b"},
+		"list":   []interface{}{map[string]interface{}{"password": "hunter2"}},
+	})
+	r.Equal(map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":    "mark@example.com",
+			"password": RedactedValue,
+		},
+		"tokens": RedactedValue,
+		"list":   []interface{}{map[string]interface{}{"password": RedactedValue}},
+	}, v)
+}
+
+type testCredentials struct {
+	User     string
+	Password string
+	APIToken string `json:"api_token"`
+	Skipped  string `json:"-"`
+	secret   string
+}
+
+type testKey string
+
+func (k testKey) Redacted() interface{} {
+	return "key " + string(k[:2])
+}
+
+type testConn struct {
+	Credentials *testCredentials
+	Next        *testConn
+}
+
+func Test_Redactor_Values(t *testing.T) {
+	r := require.New(t)
+
+	rd := &Redactor{
+		Fields: []string{"password", "token"},
+		Values: []func(v interface{}) (interface{}, bool){
+			func(v interface{}) (interface{}, bool) {
+				if _, ok := v.(chan int); ok {
+					return "[chan]", true
+				}
+				return nil, false
+			},
+		},
+	}
+
+	conn := &testConn{Credentials: &testCredentials{
+		User:     "mark",
+		Password: "hunter2",
+		APIToken: "abc",
+		Skipped:  "skipped",
+		secret:   "shh",
+	}}
+	conn.Next = conn
+
+	v := rd.Redact("", map[string]interface{}{
+		"conn":  conn,
+		"key":   testKey("abcdef"),
+		"ch":    make(chan int),
+		"ids":   []int{1, 2},
+		"codes": map[interface{}]interface{}{1: "one", "refresh_token": "xyz"},
+		"nil":   (*testConn)(nil),
+	}).(map[string]interface{})
+
+	creds := v["conn"].(map[string]interface{})["Credentials"]
+	r.Equal(map[string]interface{}{
+		"User":      "mark",
+		"Password":  RedactedValue,
+		"api_token": RedactedValue,
+	}, creds)
+	r.Equal("key ab", v["key"])
+	r.Equal("[chan]", v["ch"])
+	r.Equal([]interface{}{1, 2}, v["ids"])
+	r.Equal(map[string]interface{}{"1": "one", "refresh_token": RedactedValue}, v["codes"])
+	r.Nil(v["nil"])
+
+	// cycles stop at maxRedactDepth
+	next := v["conn"].(map[string]interface{})
+	for i := 0; i < maxRedactDepth-2; i++ {
+		next = next["Next"].(map[string]interface{})
+	}
+	r.Equal("[*buffalo.testConn]", next["Next"])
+}
+
+func Test_DataSnapshot(t *testing.T) {
+	r := require.New(t)
+
+	c := &DefaultContext{data: map[string]interface{}{
+		"user":  &testCredentials{User: "mark", Password: "hunter2"},
+		"token": "abc",
+	}}
+	snap := DataSnapshot(c)
+	r.Equal(RedactedValue, snap["token"])
+	r.Equal(RedactedValue, snap["user"].(map[string]interface{})["Password"])
+	// the data is left alone
+	r.Equal("abc", c.Get("token"))
+}
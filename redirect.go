@@ -0,0 +1,92 @@
+package buffalo
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redirect requests for a path, with any method, to another path or
+// URL. Variables in the target are filled in with the params of the
+// request, and the query string of the request is kept.
+/*
+	a.Redirect(301, "/about-us", "/about")
+	a.Redirect(301, "/blog/{year:int}/{slug}", "/posts/{slug}")
+	a.Redirect(308, "/api/v1/{path:*}", "https://api.example.com/v2/{path}")
+*/
+func (a *App) Redirect(status int, from, to string) RouteInfo {
+	return a.redirect(status, from, to, func(req *http.Request, target string) string {
+		return target
+	})
+}
+
+// RedirectHost redirects every request for a host to the same path on
+// another host, such as from "www.example.com" to "example.com". The
+// scheme, port, and query string of the request are kept.
+/*
+	a.RedirectHost(301, "www.example.com", "example.com")
+*/
+func (a *App) RedirectHost(status int, from, to string) RouteInfo {
+	return a.Host(from).redirect(status, "/{path:*}", "/{path}", func(req *http.Request, target string) string {
+		scheme := "http"
+		if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		host := to
+		if _, port, err := net.SplitHostPort(req.Host); err == nil && !strings.Contains(to, ":") {
+			host = net.JoinHostPort(to, port)
+		}
+		return scheme + "://" + host + target
+	})
+}
+
+var redirectVarRx = regexp.MustCompile(`\{([^{}:]+)\}`)
+
+func (a *App) redirect(status int, from, to string, finish func(*http.Request, string) string) RouteInfo {
+	h := func(c Context) error {
+		req := c.Request()
+		target := redirectVarRx.ReplaceAllStringFunc(to, func(v string) string {
+			return c.Param(v[1 : len(v)-1])
+		})
+		target = finish(req, target)
+		if req.URL.RawQuery != "" {
+			sep := "?"
+			if strings.Contains(target, "?") {
+				sep = "&"
+			}
+			target += sep + req.URL.RawQuery
+		}
+		http.Redirect(c.Response(), req, target, status)
+		return nil
+	}
+
+	a.moot.Lock()
+	defer a.moot.Unlock()
+
+	url := path.Join(a.prefix, from)
+	r := RouteInfo{
+		Method:      "ANY",
+		Path:        url,
+		HandlerName: "redirect " + to,
+		Handler:     h,
+		Host:        a.host,
+		app:         a,
+	}
+	limits := a.RouteLimits
+	r.limits = &limits
+	r.name = new(string)
+	r.MuxRoute = a.router.Handle(expandParamTypes(url), a.handlerToHandler(r, h))
+	a.routesChanged()
+
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	routes := append(root.routes, r)
+	sort.Sort(routes)
+	root.routes = routes
+	return r
+}
@@ -0,0 +1,120 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Redirect(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.Redirect(301, "/about-us", "/about")
+	a.Redirect(308, "/blog/{year:int}/{slug}", "/posts/{slug}?year={year}")
+	a.Group("/api").Redirect(302, "/v1/{path:*}", "https://api.example.com/v2/{path}")
+
+	w := willie.New(a)
+	res := w.Request("/about-us?ref=home").Get()
+	r.Equal(301, res.Code)
+	r.Equal("/about?ref=home", res.Header().Get("Location"))
+
+	pres := w.Request("/blog/2017/hello-world?x=1").Post(nil)
+	r.Equal(308, pres.Code)
+	r.Equal("/posts/hello-world?year=2017&x=1", pres.Header().Get("Location"))
+	r.Equal(404, w.Request("/blog/latest/hello-world").Get().Code)
+
+	res = w.Request("/api/v1/users/1").Get()
+	r.Equal(302, res.Code)
+	r.Equal("https://api.example.com/v2/users/1", res.Header().Get("Location"))
+
+	found := false
+	for _, rt := range a.Routes() {
+		if rt.Path == "/about-us" {
+			found = true
+			r.Equal("ANY", rt.Method)
+			r.Equal("redirect /about", rt.HandlerName)
+		}
+	}
+	r.True(found)
+}
+
+func Test_App_RedirectHost(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.RedirectHost(301, "www.example.com", "example.com")
+	a.GET("/users", voidHandler)
+
+	req := httptest.NewRequest("GET", "/users?page=2", nil)
+	req.Host = "www.example.com:3000"
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal(301, res.Code)
+	r.Equal("http://example.com:3000/users?page=2", res.Header().Get("Location"))
+
+	req = httptest.NewRequest("GET", "https://www.example.com/", nil)
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal(301, res.Code)
+	r.Equal("https://example.com/", res.Header().Get("Location"))
+
+	req = httptest.NewRequest("GET", "/users", nil)
+	req.Host = "example.com"
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal(200, res.Code)
+}
+
+func Test_DefaultContext_Redirect(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/users/{user_id}", voidHandler).Name("userPath")
+	a.GET("/to/route", func(c Context) error {
+		return c.Redirect(302, "userPath", render.Data{"user_id": 1, "tab": "settings"})
+	})
+	a.GET("/to/format", func(c Context) error {
+		return c.Redirect(302, "/users/%d", 2)
+	})
+	a.GET("/to/query", func(c Context) error {
+		return c.Redirect(302, "/search?page=2", render.Data{"q": "cats"})
+	})
+	a.GET("/to/missing", func(c Context) error {
+		return c.Redirect(302, "userPath", render.Data{})
+	})
+	a.POST("/flash", func(c Context) error {
+		c.Session().AddFlash("success", "saved!")
+		return c.Redirect(302, "/flashes")
+	})
+	a.GET("/flashes", func(c Context) error {
+		return c.Render(200, render.String(fmt.Sprint(c.Session().Flashes("success"))))
+	})
+	w := willie.New(a)
+
+	res := w.Request("/to/route").Get()
+	r.Equal(302, res.Code)
+	r.Equal("/users/1?tab=settings", res.Header().Get("Location"))
+	r.Equal("/users/2", w.Request("/to/format").Get().Header().Get("Location"))
+	r.Equal("/search?page=2&q=cats", w.Request("/to/query").Get().Header().Get("Location"))
+	r.Equal(500, w.Request("/to/missing").Get().Code)
+
+	req := httptest.NewRequest("POST", "/flash", nil)
+	req.Header.Set("Turbolinks-Referrer", "/form")
+	req.Header.Set("Accept", "text/vnd.turbo-stream.html, text/html")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal(303, rec.Code)
+	r.Equal("/flashes", rec.Header().Get("Turbolinks-Location"))
+
+	req = httptest.NewRequest("GET", "/flashes", nil)
+	for _, ck := range (&http.Response{Header: rec.Header()}).Cookies() {
+		req.AddCookie(ck)
+	}
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal("[saved!]", rec.Body.String())
+}
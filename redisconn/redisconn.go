@@ -0,0 +1,28 @@
+// Package redisconn holds the small slice of a Redis client that the
+// Redis backed parts of Buffalo need, so one connection can be shared
+// by the middleware stores, the session store, the worker, and cron.
+package redisconn
+
+// Conn is satisfied by a github.com/garyburd/redigo/redis.Conn, but
+// since those connections are not safe for concurrent use, and the
+// worker uses blocking commands, you will most likely want to use Func
+// to check a connection out of a pool for each command.
+type Conn interface {
+	Do(cmd string, args ...interface{}) (interface{}, error)
+}
+
+// Func adapts a function to the Conn interface.
+/*
+	pool := &redis.Pool{...}
+	conn := redisconn.Func(func(cmd string, args ...interface{}) (interface{}, error) {
+		c := pool.Get()
+		defer c.Close()
+		return c.Do(cmd, args...)
+	})
+*/
+type Func func(cmd string, args ...interface{}) (interface{}, error)
+
+// Do calls f(cmd, args...).
+func (f Func) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return f(cmd, args...)
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+)
+
+// HeaderRenderer is a Renderer that sets headers of the response, other
+// than the Content-Type, such as Download. buffalo.Context.Render sets
+// them before writing the status.
+type HeaderRenderer interface {
+	Renderer
+	Headers() http.Header
+}
+
+// StreamRenderer is a Renderer that, when Stream returns true, is
+// rendered straight to the response, rather than into a buffer first,
+// so large bodies aren't held in memory. An error part way through
+// can't be turned into an error page, as the status has been sent.
+type StreamRenderer interface {
+	Renderer
+	Stream() bool
+}
+
+type downloadRenderer struct {
+	name   string
+	reader io.Reader
+}
+
+func (d downloadRenderer) ContentType() string {
+	if ct := mime.TypeByExtension(filepath.Ext(d.name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+func (d downloadRenderer) Headers() http.Header {
+	h := http.Header{}
+	if cd := mime.FormatMediaType("attachment", map[string]string{"filename": d.name}); cd != "" {
+		h.Set("Content-Disposition", cd)
+	} else {
+		h.Set("Content-Disposition", "attachment")
+	}
+	return h
+}
+
+func (d downloadRenderer) Stream() bool {
+	return true
+}
+
+func (d downloadRenderer) Render(w io.Writer, data Data) error {
+	if c, ok := d.reader.(io.Closer); ok {
+		defer c.Close()
+	}
+	_, err := io.Copy(w, d.reader)
+	return err
+}
+
+// Download renders the contents of r as a download, saved by the
+// browser as name, with the content type of its extension. It is
+// streamed, and r is closed afterwards if it is an io.Closer.
+/*
+	f, err := os.Open(report.Path)
+	...
+	return c.Render(200, render.Download("report.pdf", f))
+*/
+func Download(name string, r io.Reader) Renderer {
+	return downloadRenderer{name: name, reader: r}
+}
+
+// Download renders the contents of r as a download, saved by the
+// browser as name, see Download.
+func (e *Engine) Download(name string, r io.Reader) Renderer {
+	return Download(name, r)
+}
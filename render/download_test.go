@@ -0,0 +1,44 @@
+package render_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+type closeReader struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeReader) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_Download(t *testing.T) {
+	r := require.New(t)
+
+	cr := &closeReader{Reader: strings.NewReader("a,b\n1,2\n")}
+	re := render.New(render.Options{}).Download("report 2017.pdf", cr)
+	r.Equal("application/pdf", re.ContentType())
+
+	hr, ok := re.(render.HeaderRenderer)
+	r.True(ok)
+	r.Equal(`attachment; filename="report 2017.pdf"`, hr.Headers().Get("Content-Disposition"))
+	sr, ok := re.(render.StreamRenderer)
+	r.True(ok)
+	r.True(sr.Stream())
+
+	bb := &bytes.Buffer{}
+	r.NoError(re.Render(bb, render.Data{}))
+	r.Equal("a,b\n1,2\n", bb.String())
+	r.True(cr.closed)
+
+	re = render.Download("blob", strings.NewReader(""))
+	r.Equal("application/octet-stream", re.ContentType())
+}
@@ -0,0 +1,158 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Fragment is an independent section of a page, such as a sidebar of
+// recommendations, that can be rendered concurrently with the other
+// sections, see Fragments.
+type Fragment struct {
+	// Name the rendered HTML is returned under.
+	Name string
+	// Timeout for loading and rendering the Fragment. Defaults to
+	// one second.
+	Timeout time.Duration
+	// Load the data the Fragment needs, and return the Renderer for it.
+	// The context is cancelled when the Timeout is reached.
+	Load func(ctx context.Context) (Renderer, error)
+	// Fallback is rendered instead if Load fails, or the Timeout is
+	// reached. If it is nil the Fragment is left empty.
+	Fallback Renderer
+}
+
+// Fragments loads and renders each Fragment concurrently, and returns
+// their HTML by name, along with the errors of any that failed or timed
+// out. A slow or broken data source only degrades its own section to
+// its Fallback, instead of taking the whole page down with it, as does
+// one that panics. Each Renderer is given a copy of data, taken before
+// any of them start, so data can be changed once Fragments returns, even
+// while a Fragment that timed out is still running.
+/*
+	func Dashboard(c buffalo.Context) error {
+		html, errs := render.Fragments(c.Request().Context(), c.Data(),
+			render.Fragment{
+				Name:    "recommendations",
+				Timeout: 200 * time.Millisecond,
+				Load: func(ctx context.Context) (render.Renderer, error) {
+					recs, err := recommender.For(ctx, user)
+					if err != nil {
+						return nil, err
+					}
+					return r.Func("text/html", ...), nil
+				},
+				Fallback: r.String("No recommendations right now."),
+			},
+			...
+		)
+		for name, err := range errs {
+			c.Logger().WithField("fragment", name).Warn(err)
+		}
+		for name, h := range html {
+			c.Set(name, h)
+		}
+		return c.Render(200, r.HTML("dashboard.html"))
+	}
+*/
+func Fragments(ctx context.Context, data Data, frags ...Fragment) (map[string]template.HTML, map[string]error) {
+	data = copyData(data)
+	html := make(map[string]template.HTML, len(frags))
+	errs := map[string]error{}
+	moot := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, f := range frags {
+		wg.Add(1)
+		go func(f Fragment) {
+			defer wg.Done()
+			h, err := f.render(ctx, data)
+			if err != nil {
+				h = f.fallback(data)
+			}
+			moot.Lock()
+			defer moot.Unlock()
+			html[f.Name] = h
+			if err != nil {
+				errs[f.Name] = err
+			}
+		}(f)
+	}
+	wg.Wait()
+	return html, errs
+}
+
+func (f Fragment) render(ctx context.Context, data Data) (template.HTML, error) {
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		html template.HTML
+		err  error
+	}
+	// buffered, so the goroutine can finish even if nobody is waiting
+	// for it any more.
+	ch := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- result{err: errors.Errorf("fragment %s panicked: %v", f.Name, r)}
+			}
+		}()
+		if f.Load == nil {
+			ch <- result{err: errors.Errorf("fragment %s has no Load function", f.Name)}
+			return
+		}
+		r, err := f.Load(ctx)
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+		h, err := renderHTML(r, data)
+		ch <- result{html: h, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.html, res.err
+	case <-ctx.Done():
+		return "", errors.Wrapf(ctx.Err(), "fragment %s", f.Name)
+	}
+}
+
+func (f Fragment) fallback(data Data) (h template.HTML) {
+	if f.Fallback == nil {
+		return ""
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			h = ""
+		}
+	}()
+	h, _ = renderHTML(f.Fallback, data)
+	return h
+}
+
+func renderHTML(r Renderer, data Data) (template.HTML, error) {
+	bb := &bytes.Buffer{}
+	if err := r.Render(bb, copyData(data)); err != nil {
+		return "", err
+	}
+	return template.HTML(bb.String()), nil
+}
+
+func copyData(data Data) Data {
+	d := make(Data, len(data))
+	for k, v := range data {
+		d[k] = v
+	}
+	return d
+}
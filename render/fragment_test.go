@@ -0,0 +1,110 @@
+package render_test
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func htmlFunc(s string) render.Renderer {
+	return render.Func("text/html", func(w io.Writer, d render.Data) error {
+		_, err := fmt.Fprintf(w, s, d["name"])
+		return err
+	})
+}
+
+func Test_Fragments(t *testing.T) {
+	r := require.New(t)
+
+	start := time.Now()
+	html, errs := render.Fragments(context.Background(), render.Data{"name": "mark"},
+		render.Fragment{
+			Name: "greeting",
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				return htmlFunc("<b>hi %s</b>"), nil
+			},
+		},
+		render.Fragment{
+			Name:    "slow",
+			Timeout: 50 * time.Millisecond,
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			Fallback: htmlFunc("sorry %s, try later"),
+		},
+		render.Fragment{
+			Name: "broken",
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				return nil, errors.New("db is down")
+			},
+		},
+	)
+	r.True(time.Since(start) < time.Second)
+
+	r.Equal(template.HTML("<b>hi mark</b>"), html["greeting"])
+	r.Equal(template.HTML("sorry mark, try later"), html["slow"])
+	r.Equal(template.HTML(""), html["broken"])
+
+	r.Len(errs, 2)
+	r.Contains(errs["slow"].Error(), "deadline exceeded")
+	r.EqualError(errs["broken"], "db is down")
+}
+
+func Test_Fragments_IgnoresLoad(t *testing.T) {
+	r := require.New(t)
+
+	// Load ignores the context, but the fragment still times out
+	html, errs := render.Fragments(context.Background(), render.Data{},
+		render.Fragment{
+			Name:    "stuck",
+			Timeout: 20 * time.Millisecond,
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				time.Sleep(200 * time.Millisecond)
+				return htmlFunc("done"), nil
+			},
+			Fallback: render.String("fallback"),
+		},
+	)
+	r.Equal(template.HTML("fallback"), html["stuck"])
+	r.Error(errs["stuck"])
+}
+
+func Test_Fragments_Panics(t *testing.T) {
+	r := require.New(t)
+
+	data := render.Data{"name": "mark"}
+	html, errs := render.Fragments(context.Background(), data,
+		render.Fragment{
+			Name: "broken",
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				panic("boom")
+			},
+			Fallback: render.String("fallback"),
+		},
+		render.Fragment{
+			Name:    "slow",
+			Timeout: 10 * time.Millisecond,
+			Load: func(ctx context.Context) (render.Renderer, error) {
+				time.Sleep(50 * time.Millisecond)
+				return htmlFunc("%s"), nil
+			},
+		},
+	)
+	r.Equal(template.HTML("fallback"), html["broken"])
+	r.Contains(errs["broken"].Error(), "boom")
+	r.Error(errs["slow"])
+
+	// the slow fragment is still rendering with its own copy
+	for i := 0; i < 100; i++ {
+		data[fmt.Sprint(i)] = i
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -19,7 +19,13 @@ func HTML(names ...string) Renderer {
 // be placed into the "layout" using "{{yield}}". If no
 // second file is provided and an `HTMLLayout` is specified
 // in the options, then that layout file will be used
-// automatically.
+// automatically. Otherwise the HTMLLayout is put around the
+// layouts given, nesting them, see Template.
+/*
+	// admin.html yields the page, application.html yields admin.html
+	r := render.New(render.Options{HTMLLayout: "application.html"})
+	return c.Render(200, r.HTML("admin/users.html", "admin.html"))
+*/
 func (e *Engine) HTML(names ...string) Renderer {
 	if e.HTMLLayout != "" {
 		names = append(names, e.HTMLLayout)
@@ -58,4 +58,26 @@ func Test_HTML(t *testing.T) {
 		r.NoError(err)
 		r.Equal("<body>Mark</body>", strings.TrimSpace(bb.String()))
 	})
+
+	t.Run("with nested layouts", func(st *testing.T) {
+		r := require.New(st)
+
+		layout, err := ioutil.TempFile("", "test")
+		r.NoError(err)
+		defer os.Remove(layout.Name())
+		_, err = layout.Write([]byte("<body>{{yield}}</body>"))
+		r.NoError(err)
+
+		admin, err := ioutil.TempFile("", "test")
+		r.NoError(err)
+		defer os.Remove(admin.Name())
+		_, err = admin.Write([]byte("<nav>{{name}}</nav>{{yield}}"))
+		r.NoError(err)
+
+		re := render.New(render.Options{HTMLLayout: layout.Name()}).HTML(tmpFile.Name(), admin.Name())
+		bb := &bytes.Buffer{}
+		err = re.Render(bb, map[string]interface{}{"name": "Mark"})
+		r.NoError(err)
+		r.Equal("<body><nav>Mark</nav>Mark</body>", strings.TrimSpace(bb.String()))
+	})
 }
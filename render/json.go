@@ -14,11 +14,17 @@ func (s jsonRenderer) ContentType() string {
 }
 
 func (s jsonRenderer) Render(w io.Writer, data Data) error {
-	return json.NewEncoder(w).Encode(s.value)
+	v, err := Serialize(s.value)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(v)
 }
 
 // JSON renders the value using the "application/json"
-// content type.
+// content type. The value is run through Serialize first,
+// so registered Serializers are used, and fields tagged
+// `render:"-"` are left out.
 func JSON(v interface{}) Renderer {
 	return jsonRenderer{value: v}
 }
@@ -14,7 +14,9 @@ type Options struct {
 	// Helpers to be rendered with the templates
 	Helpers map[string]interface{}
 	// CacheTemplates reduced overheads, but won't reload changed templates.
-	// This should only be set to true in production environments.
+	// This should only be set to true in production environments, along
+	// with Precompile. Without it templates are read, and so reloaded,
+	// on every render.
 	CacheTemplates bool
 }
 
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/buffalo/render/resolvers"
+	"github.com/gobuffalo/velvet"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/github_flavored_markdown"
+)
+
+// TemplateExtensions are the file extensions Precompile will look for
+// in the TemplatesPath.
+var TemplateExtensions = []string{".html", ".md", ".js", ".txt"}
+
+// PrecompileError lists every template that failed to parse.
+type PrecompileError struct {
+	Errors map[string]error
+}
+
+func (p PrecompileError) Error() string {
+	names := []string{}
+	for n := range p.Errors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	lines := []string{fmt.Sprintf("%d template(s) failed to parse:", len(names))}
+	for _, n := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", n, p.Errors[n]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Precompile walks the TemplatesPath, using the FileResolver if it
+// implements resolvers.Walker, and parses every template it finds,
+// so syntax errors show up when the application boots instead of the
+// first time a page is rendered. If CacheTemplates is turned on the parsed
+// templates are also stored in the cache; in development, with caching
+// off, the templates are only checked and will still be reloaded from
+// disk on every render.
+/*
+	r := render.New(render.Options{
+		TemplatesPath:  "templates",
+		CacheTemplates: ENV == "production",
+	})
+	if err := r.Precompile(); err != nil {
+		log.Fatal(err)
+	}
+*/
+func (e *Engine) Precompile() error {
+	res := e.Resolver()
+	walk := walkDisk
+	if w, ok := res.(resolvers.Walker); ok {
+		walk = w.Walk
+	}
+	perr := PrecompileError{Errors: map[string]error{}}
+	err := walk(e.TemplatesPath, func(name string) error {
+		if !isTemplate(name) {
+			return nil
+		}
+		b, err := res.Read(filepath.Join(e.TemplatesPath, name))
+		if err != nil {
+			return err
+		}
+		t, err := e.parse(name, b)
+		if err != nil {
+			perr.Errors[name] = err
+			return nil
+		}
+		if e.CacheTemplates {
+			e.moot.Lock()
+			e.templateCache[name] = t
+			e.moot.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(perr.Errors) > 0 {
+		return perr
+	}
+	return nil
+}
+
+// walkDisk is used for FileResolvers that don't implement
+// resolvers.Walker.
+func walkDisk(root string, fn func(string) error) error {
+	if root == "" {
+		root = "."
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return fn(name)
+	})
+}
+
+// parse the template source, converting markdown templates to HTML
+// first, and add the Engine's helpers to it.
+func (e *Engine) parse(name string, b []byte) (*velvet.Template, error) {
+	if strings.ToLower(filepath.Ext(name)) == ".md" {
+		b = github_flavored_markdown.Markdown(b)
+		// unescape quotes so raymond can parse the file correctly.
+		b = bytes.Replace(b, []byte("&#34;"), []byte("\""), -1)
+	}
+	t, err := velvet.Parse(string(b))
+	if err != nil {
+		return t, errors.Errorf("Error parsing %s: %+v", name, errors.WithStack(err))
+	}
+	err = t.Helpers.AddMany(e.Helpers)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func isTemplate(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, x := range TemplateExtensions {
+		if ext == x {
+			return true
+		}
+	}
+	return false
+}
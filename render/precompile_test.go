@@ -0,0 +1,52 @@
+package render_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Precompile(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "precompile")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	r.NoError(os.MkdirAll(filepath.Join(dir, "users"), 0755))
+	index := filepath.Join(dir, "users", "index.html")
+	r.NoError(ioutil.WriteFile(index, []byte("Hi {{name}}"), 0644))
+
+	e := render.New(render.Options{TemplatesPath: dir, CacheTemplates: true})
+	r.NoError(e.Precompile())
+
+	// the cached template is used even after the file is gone
+	r.NoError(os.Remove(index))
+	bb := &bytes.Buffer{}
+	r.NoError(e.HTML("users/index.html").Render(bb, render.Data{"name": "Mark"}))
+	r.Equal("Hi Mark", bb.String())
+}
+
+func Test_Precompile_Errors(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "precompile")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	r.NoError(ioutil.WriteFile(filepath.Join(dir, "good.html"), []byte("{{name}}"), 0644))
+	r.NoError(ioutil.WriteFile(filepath.Join(dir, "bad.html"), []byte("{{name"), 0644))
+
+	e := render.New(render.Options{TemplatesPath: dir})
+	err = e.Precompile()
+	r.Error(err)
+	perr, ok := err.(render.PrecompileError)
+	r.True(ok)
+	r.Len(perr.Errors, 1)
+	r.Contains(perr.Errors, "bad.html")
+}
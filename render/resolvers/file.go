@@ -9,3 +9,10 @@ type FileResolver interface {
 	// Resolve the location of the given file
 	Resolve(string) (string, error)
 }
+
+// Walker is implemented by FileResolvers that can list all of the
+// files they are able to resolve. The names passed to the function are
+// relative to root.
+type Walker interface {
+	Walk(root string, fn func(name string) error) error
+}
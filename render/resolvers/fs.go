@@ -0,0 +1,64 @@
+package resolvers
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FSResolver resolves files from an fs.FS, such as an embed.FS, so
+// templates can be compiled right into the application binary.
+/*
+	//go:embed templates
+	var templates embed.FS
+
+	r = render.New(render.Options{
+		TemplatesPath: "templates",
+		FileResolverFunc: func() resolvers.FileResolver {
+			return &resolvers.FSResolver{FS: templates}
+		},
+	})
+*/
+type FSResolver struct {
+	FS fs.FS
+}
+
+// Read the named file from the fs.FS.
+func (r *FSResolver) Read(name string) ([]byte, error) {
+	return fs.ReadFile(r.FS, fsName(name))
+}
+
+// Resolve the named file, returning its path in the fs.FS.
+func (r *FSResolver) Resolve(name string) (string, error) {
+	name = fsName(name)
+	if _, err := fs.Stat(r.FS, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Walk all of the files in the fs.FS under root.
+func (r *FSResolver) Walk(root string, fn func(name string) error) error {
+	root = fsName(root)
+	return fs.WalkDir(r.FS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if root != "." {
+			p = strings.TrimPrefix(p, root+"/")
+		}
+		return fn(p)
+	})
+}
+
+// fsName converts an OS path into the unrooted, slash separated, form
+// that fs.FS expects.
+func fsName(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
@@ -0,0 +1,36 @@
+package resolvers
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FSResolver(t *testing.T) {
+	r := require.New(t)
+
+	res := &FSResolver{FS: fstest.MapFS{
+		"templates/index.html":       {Data: []byte("index")},
+		"templates/users/_form.html": {Data: []byte("form")},
+	}}
+
+	b, err := res.Read("templates/index.html")
+	r.NoError(err)
+	r.Equal("index", string(b))
+
+	p, err := res.Resolve("/templates/users/_form.html")
+	r.NoError(err)
+	r.Equal("templates/users/_form.html", p)
+
+	_, err = res.Read("templates/nope.html")
+	r.Error(err)
+
+	names := []string{}
+	err = res.Walk("templates", func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	r.NoError(err)
+	r.Equal([]string{"index.html", "users/_form.html"}, names)
+}
@@ -41,3 +41,19 @@ func (r *RiceBox) Resolve(name string) (string, error) {
 	}
 	return p, nil
 }
+
+// Walk all of the files in the rice.Box.
+func (r *RiceBox) Walk(root string, fn func(name string) error) error {
+	if root == "" {
+		root = "."
+	}
+	return r.Box.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return fn(strings.TrimPrefix(strings.TrimPrefix(path, root), "/"))
+	})
+}
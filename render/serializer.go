@@ -0,0 +1,368 @@
+package render
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Serializer returns what should be encoded in place of v. It lets a
+// type control how it appears in API responses without having to keep a
+// parallel set of structs around just for rendering.
+type Serializer func(v interface{}) (interface{}, error)
+
+var serializers = struct {
+	types map[reflect.Type]Serializer
+	needs map[reflect.Type]bool
+	moot  *sync.RWMutex
+}{
+	types: map[reflect.Type]Serializer{},
+	needs: map[reflect.Type]bool{},
+	moot:  &sync.RWMutex{},
+}
+
+// RegisterSerializer registers s to be used whenever a value of the same
+// type as v, or a pointer to it, is rendered as JSON.
+/*
+	render.RegisterSerializer(models.User{}, func(v interface{}) (interface{}, error) {
+		var u models.User
+		switch t := v.(type) {
+		case models.User:
+			u = t
+		case *models.User:
+			u = *t
+		}
+		return map[string]interface{}{
+			"id":    u.PublicID,
+			"email": u.Email,
+		}, nil
+	})
+*/
+func RegisterSerializer(v interface{}, s Serializer) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	serializers.moot.Lock()
+	defer serializers.moot.Unlock()
+	serializers.types[t] = s
+	serializers.types[reflect.PtrTo(t)] = s
+	// registrations can change which types need walking
+	serializers.needs = map[reflect.Type]bool{}
+}
+
+// Serialize returns v as it will be encoded by the JSON renderer:
+// registered Serializers are applied, and struct fields tagged with
+// `render:"-"` are removed, at any depth. Values that don't need to
+// change are returned as they are.
+/*
+	type User struct {
+		ID           int    `json:"id"`
+		Email        string `json:"email"`
+		PasswordHash string `json:"password_hash" render:"-"`
+	}
+*/
+func Serialize(v interface{}) (interface{}, error) {
+	return serializeValue(reflect.ValueOf(v))
+}
+
+func serializerFor(t reflect.Type) (Serializer, bool) {
+	serializers.moot.RLock()
+	defer serializers.moot.RUnlock()
+	s, ok := serializers.types[t]
+	return s, ok
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// needsSerialization reports whether values of type t might have to
+// be changed by Serialize.
+func needsSerialization(t reflect.Type) bool {
+	serializers.moot.RLock()
+	n, ok := serializers.needs[t]
+	serializers.moot.RUnlock()
+	if ok {
+		return n
+	}
+	serializers.moot.Lock()
+	defer serializers.moot.Unlock()
+	n = needs(t, map[reflect.Type]bool{})
+	serializers.needs[t] = n
+	return n
+}
+
+// needs must be called with the serializers lock held.
+func needs(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		// a recursive type, the outer call will decide
+		return false
+	}
+	seen[t] = true
+	n := false
+	switch {
+	case serializers.types[t] != nil:
+		n = true
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		n = false
+	default:
+		switch t.Kind() {
+		case reflect.Interface:
+			n = true
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			n = needs(t.Elem(), seen)
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				if f.Tag.Get("render") == "-" || needs(f.Type, seen) {
+					n = true
+					break
+				}
+			}
+		}
+	}
+	return n
+}
+
+// errHidden is returned for values, reached through an unexported
+// embedded struct, that can't be handed out, so the field holding them
+// is left out.
+var errHidden = errors.New("value of an unexported embedded struct")
+
+func serializeValue(rv reflect.Value) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	hidden := !rv.CanInterface()
+	if hidden {
+		if c, ok := copyBasic(rv); ok {
+			rv, hidden = c, false
+		}
+	}
+	t := rv.Type()
+	if s, ok := serializerFor(t); ok {
+		if t.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		if hidden {
+			return nil, errHidden
+		}
+		return s(rv.Interface())
+	}
+	if !hidden && !needsSerialization(t) {
+		return rv.Interface(), nil
+	}
+	if hidden && (t.Implements(marshalerType) || t.Implements(textMarshalerType)) {
+		return nil, errHidden
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return serializeValue(rv.Elem())
+	case reflect.Struct:
+		return serializeStruct(rv)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			v, err := serializeValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			v, err := serializeValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[mapKey(iter.Key())] = v
+		}
+		return out, nil
+	}
+	if hidden {
+		return nil, errHidden
+	}
+	return rv.Interface(), nil
+}
+
+// copyBasic returns a copy of rv, which reflect hands out even when rv
+// was reached through an unexported embedded struct, if it is of one of
+// the basic kinds.
+func copyBasic(rv reflect.Value) (reflect.Value, bool) {
+	c := reflect.New(rv.Type()).Elem()
+	switch rv.Kind() {
+	case reflect.Bool:
+		c.SetBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.SetInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		c.SetUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		c.SetFloat(rv.Float())
+	case reflect.String:
+		c.SetString(rv.String())
+	default:
+		return rv, false
+	}
+	return c, true
+}
+
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if c, ok := copyBasic(k); ok {
+		k = c
+	}
+	if !k.CanInterface() {
+		return ""
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, _ := tm.MarshalText()
+		return string(b)
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+// serializeStruct follows the encoding/json rules for field names,
+// "omitempty", and embedded structs, so the output matches what
+// encoding/json would have produced, minus the hidden fields.
+func serializeStruct(rv reflect.Value) (interface{}, error) {
+	t := rv.Type()
+	obj := &orderedObject{values: map[string]interface{}{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if f.Tag.Get("render") == "-" {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				v, err := serializeValue(fv)
+				if err == errHidden {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				if inner, ok := v.(*orderedObject); ok {
+					for _, k := range inner.keys {
+						obj.set(k, inner.values[k])
+					}
+					continue
+				}
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		v, err := serializeValue(fv)
+		if err == errHidden {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj.set(name, v)
+	}
+	return obj, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedObject is a JSON object that keeps its keys in struct field
+// order, instead of the sorted order encoding/json uses for maps.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) set(k string, v interface{}) {
+	if _, ok := o.values[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.values[k] = v
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	bb := &bytes.Buffer{}
+	bb.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			bb.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		bb.Write(kb)
+		bb.WriteByte(':')
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		bb.Write(vb)
+	}
+	bb.WriteByte('}')
+	return bb.Bytes(), nil
+}
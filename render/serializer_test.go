@@ -0,0 +1,140 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+type serialUser struct {
+	ID           int          `json:"id"`
+	Email        string       `json:"email"`
+	PasswordHash string       `json:"password_hash" render:"-"`
+	Nickname     string       `json:"nickname,omitempty"`
+	Friends      []serialUser `json:"friends,omitempty"`
+	JoinedAt     time.Time    `json:"joined_at"`
+}
+
+type serialAccount struct {
+	Number   string
+	Internal int64
+}
+
+// serialCard has a Serializer registered for it.
+type serialCard struct {
+	Number   string
+	Internal int64
+}
+
+type serialTimestamps struct {
+	CreatedAt string `json:"created_at"`
+	Secret    string `json:"secret" render:"-"`
+}
+
+type serialPost struct {
+	serialTimestamps
+	Title   string         `json:"title"`
+	Author  *serialUser    `json:"author"`
+	Account serialCard     `json:"account"`
+	Meta    map[string]int `json:"meta"`
+}
+
+func renderJSON(r *require.Assertions, v interface{}) string {
+	bb := &bytes.Buffer{}
+	r.NoError(render.JSON(v).Render(bb, nil))
+	return strings.TrimSpace(bb.String())
+}
+
+func Test_Serialize_Tags(t *testing.T) {
+	r := require.New(t)
+
+	joined := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	u := serialUser{
+		ID:           1,
+		Email:        "mark@example.com",
+		PasswordHash: "s3cret",
+		Friends:      []serialUser{{ID: 2, PasswordHash: "hunter2", JoinedAt: joined}},
+		JoinedAt:     joined,
+	}
+	out := renderJSON(r, u)
+	r.Equal(`{"id":1,"email":"mark@example.com","friends":[{"id":2,"email":"","joined_at":"2017-01-01T00:00:00Z"}],"joined_at":"2017-01-01T00:00:00Z"}`, out)
+
+	out = renderJSON(r, map[string]interface{}{"users": []*serialUser{&u}})
+	r.NotContains(out, "password_hash")
+	r.Contains(out, "mark@example.com")
+}
+
+func Test_Serialize_Unchanged(t *testing.T) {
+	r := require.New(t)
+
+	v := serialAccount{Number: "123"}
+	s, err := render.Serialize(v)
+	r.NoError(err)
+	r.Equal(v, s)
+
+	b, _ := json.Marshal(v)
+	r.Equal(string(b), renderJSON(r, v))
+}
+
+func Test_RegisterSerializer(t *testing.T) {
+	r := require.New(t)
+
+	render.RegisterSerializer(serialCard{}, func(v interface{}) (interface{}, error) {
+		var a serialCard
+		switch t := v.(type) {
+		case serialCard:
+			a = t
+		case *serialCard:
+			a = *t
+		}
+		return map[string]string{"number": "****" + a.Number[len(a.Number)-2:]}, nil
+	})
+
+	p := serialPost{
+		serialTimestamps: serialTimestamps{CreatedAt: "today", Secret: "shh"},
+		Title:            "hi",
+		Account:          serialCard{Number: "123456", Internal: 99},
+		Meta:             map[string]int{"views": 3},
+	}
+	r.Equal(`{"created_at":"today","title":"hi","author":null,"account":{"number":"****56"},"meta":{"views":3}}`, renderJSON(r, p))
+	r.Equal(`{"number":"****56"}`, renderJSON(r, &p.Account))
+}
+
+type serialAudit struct {
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type serialVersion int
+
+type serialPage struct {
+	serialAudit
+	*serialUser
+	serialVersion
+	Title string `json:"title"`
+}
+
+func Test_Serialize_UnexportedEmbedded(t *testing.T) {
+	r := require.New(t)
+
+	at := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := serialPage{
+		serialAudit:   serialAudit{UpdatedBy: "mark", UpdatedAt: at},
+		serialUser:    &serialUser{ID: 1, PasswordHash: "s3cret"},
+		serialVersion: 2,
+		Title:         "hi",
+	}
+	out := renderJSON(r, p)
+	r.NotContains(out, "password_hash")
+
+	// the same as encoding/json, minus the hidden fields
+	p.serialUser.PasswordHash = ""
+	b, err := json.Marshal(p)
+	r.NoError(err)
+	r.JSONEq(strings.Replace(string(b), `"password_hash":"",`, "", 1), out)
+}
@@ -1,16 +1,13 @@
 package render
 
 import (
-	"bytes"
 	"fmt"
 	"html/template"
 	"io"
 	"path/filepath"
-	"strings"
 
 	"github.com/gobuffalo/velvet"
 	"github.com/pkg/errors"
-	"github.com/shurcooL/github_flavored_markdown"
 )
 
 type templateRenderer struct {
@@ -47,8 +44,17 @@ func (s *templateRenderer) execute(name string, data *velvet.Context) (template.
 		return "", err
 	}
 
-	err = source.Helpers.Add("partial", func(name string, help velvet.HelperContext) (template.HTML, error) {
-		p, err := s.partial(name, help.Context)
+	err = source.Helpers.Add("partial", func(name string, locals map[string]interface{}, help velvet.HelperContext) (template.HTML, error) {
+		ctx := help.Context
+		if len(locals) > 0 {
+			// the locals are only seen by the partial, and the partials
+			// it renders
+			ctx = ctx.New()
+			for k, v := range locals {
+				ctx.Set(k, v)
+			}
+		}
+		p, err := s.partial(name, ctx)
 		if err != nil {
 			return template.HTML(fmt.Sprintf("<pre>%s: %s</pre>", name, err.Error())), err
 		}
@@ -65,11 +71,11 @@ func (s *templateRenderer) execute(name string, data *velvet.Context) (template.
 }
 
 func (s *templateRenderer) source(name string) (*velvet.Template, error) {
-	var t *velvet.Template
-	var ok bool
-	var err error
 	if s.CacheTemplates {
-		if t, ok = s.templateCache[name]; ok {
+		s.moot.Lock()
+		t, ok := s.templateCache[name]
+		s.moot.Unlock()
+		if ok {
 			return t.Clone(), nil
 		}
 	}
@@ -77,25 +83,16 @@ func (s *templateRenderer) source(name string) (*velvet.Template, error) {
 	if err != nil {
 		return nil, errors.WithStack(fmt.Errorf("could not find template: %s", name))
 	}
-	if strings.ToLower(filepath.Ext(name)) == ".md" {
-		b = github_flavored_markdown.Markdown(b)
-		// unescape quotes so raymond can parse the file correctly.
-		b = bytes.Replace(b, []byte("&#34;"), []byte("\""), -1)
-	}
-	source := string(b)
-	t, err = velvet.Parse(source)
-	if err != nil {
-		return t, errors.Errorf("Error parsing %s: %+v", name, errors.WithStack(err))
-	}
-
-	err = t.Helpers.AddMany(s.Helpers)
+	t, err := s.parse(name, b)
 	if err != nil {
 		return nil, err
 	}
 	if s.CacheTemplates {
+		s.moot.Lock()
 		s.templateCache[name] = t
+		s.moot.Unlock()
 	}
-	return t.Clone(), err
+	return t.Clone(), nil
 }
 
 func (s *templateRenderer) partial(name string, data *velvet.Context) (template.HTML, error) {
@@ -109,7 +106,14 @@ func (s *templateRenderer) partial(name string, data *velvet.Context) (template.
 // package for templating. If more than 1 file is provided
 // the second file will be considered a "layout" file
 // and the first file will be the "content" file which will
-// be placed into the "layout" using "{{yield}}".
+// be placed into the "layout" using "{{yield}}". Each file after
+// that is a layout around the one before it, so layouts can be
+// nested.
+//
+// Templates can render partials, files whose names start with "_",
+// with "{{partial "users/form.html"}}". Values given to the partial,
+// "{{partial "users/form.html" user=u submit="Save"}}", are only set
+// for the partial.
 func Template(c string, names ...string) Renderer {
 	e := New(Options{})
 	return e.Template(c, names...)
@@ -74,6 +74,28 @@ func Test_Template_Partial(t *testing.T) {
 	}
 }
 
+func Test_Template_Partial_Locals(t *testing.T) {
+	r := require.New(t)
+
+	tPath, err := ioutil.TempDir("", "")
+	r.NoError(err)
+	defer os.RemoveAll(tPath)
+
+	r.NoError(os.MkdirAll(filepath.Join(tPath, "users"), 0755))
+	r.NoError(ioutil.WriteFile(filepath.Join(tPath, "users", "_form.html"), []byte(`<form>{{user.Name}} {{submit}} {{name}}</form>`), 0644))
+	r.NoError(ioutil.WriteFile(filepath.Join(tPath, "edit.html"), []byte(`{{partial "users/form.html" user=current submit="Save"}}[{{submit}}]`), 0644))
+
+	re := render.New(render.Options{TemplatesPath: tPath}).Template("text/html", "edit.html")
+	bb := &bytes.Buffer{}
+	err = re.Render(bb, render.Data{
+		"name":    "Mark",
+		"current": struct{ Name string }{"Ann"},
+	})
+	r.NoError(err)
+	// the locals don't leak out of the partial
+	r.Equal("<form>Ann Save Mark</form>[]", strings.TrimSpace(bb.String()))
+}
+
 func Test_Template_WithCaching(t *testing.T) {
 	r := require.New(t)
 
@@ -0,0 +1,142 @@
+package buffalo
+
+import (
+	"io"
+	"sync"
+)
+
+// requestHooks holds the hooks of a request, it is shared by the
+// Context and its Forks, but not its Isolates.
+type requestHooks struct {
+	moot     sync.Mutex
+	after    []func()
+	cleanups []func() error
+}
+
+// merge moves the hooks of o to the end of h.
+func (h *requestHooks) merge(o *requestHooks) {
+	o.moot.Lock()
+	after, cleanups := o.after, o.cleanups
+	o.after, o.cleanups = nil, nil
+	o.moot.Unlock()
+	h.moot.Lock()
+	h.after = append(h.after, after...)
+	h.cleanups = append(h.cleanups, cleanups...)
+	h.moot.Unlock()
+}
+
+func (d *DefaultContext) hookSet() *requestHooks {
+	if d.hooks == nil {
+		d.hooks = &requestHooks{}
+	}
+	return d.hooks
+}
+
+// AfterResponse adds a function to run once the handler, its middleware,
+// and any ErrorHandler have finished, and the response has been written,
+// including a buffered one, see Options.ResponseBufferSize. It is for
+// work the response shouldn't wait on, or be broken by, such as audit
+// logging and metrics. Hooks run in the order they were added, on the
+// goroutine of the request, and before the Context is released, so they
+// can still use it. A hook that panics is logged, and doesn't stop the
+// others. Hooks added on a Fork run with those of the request.
+/*
+	func UsersUpdate(c buffalo.Context) error {
+		...
+		c.AfterResponse(func() {
+			audit.Log(c.Value("current_user_id"), "users.update", user.ID)
+		})
+		return c.Redirect(303, "/users/%s", user.ID)
+	}
+*/
+func (d *DefaultContext) AfterResponse(fn func()) {
+	h := d.hookSet()
+	h.moot.Lock()
+	h.after = append(h.after, fn)
+	h.moot.Unlock()
+}
+
+// Cleanup adds a function to run when the request is over, even if the
+// handler panicked, to release what was acquired for it, such as temp
+// files, pooled buffers, and connections. Cleanups run after the
+// AfterResponse hooks, in the reverse order they were added, like
+// defers. Errors, and panics, are logged, and don't stop the others.
+/*
+	buf := pool.Get().(*bytes.Buffer)
+	c.Cleanup(func() error {
+		buf.Reset()
+		pool.Put(buf)
+		return nil
+	})
+*/
+func (d *DefaultContext) Cleanup(fn func() error) {
+	h := d.hookSet()
+	h.moot.Lock()
+	h.cleanups = append(h.cleanups, fn)
+	h.moot.Unlock()
+}
+
+// OnClose closes c when the request is over, see Cleanup.
+/*
+	f, err := ioutil.TempFile("", "export")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	c.OnClose(f)
+	c.Cleanup(func() error { return os.Remove(f.Name()) })
+*/
+func (d *DefaultContext) OnClose(c io.Closer) {
+	d.Cleanup(c.Close)
+}
+
+// runAfterResponse saves the session, for handlers that wrote nothing,
+// sends the buffered response, if there is one, and runs the
+// AfterResponse hooks of the request.
+func runAfterResponse(c Context, br *BufferedResponse) {
+	d, ok := c.(*DefaultContext)
+	if ok {
+		d.saveSession()
+	}
+	if br != nil {
+		br.stream()
+	}
+	if !ok || d.hooks == nil {
+		return
+	}
+	d.hooks.moot.Lock()
+	hooks := d.hooks.after
+	d.hooks.after = nil
+	d.hooks.moot.Unlock()
+	for _, fn := range hooks {
+		d.safely("after response hook", func() error {
+			fn()
+			return nil
+		})
+	}
+}
+
+// runCleanups runs the Cleanups of the request, last added first.
+func (d *DefaultContext) runCleanups() {
+	if d.hooks == nil {
+		return
+	}
+	d.hooks.moot.Lock()
+	cleanups := d.hooks.cleanups
+	d.hooks.cleanups = nil
+	d.hooks.moot.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		d.safely("cleanup", cleanups[i])
+	}
+}
+
+// safely runs fn, logging its error, or panic.
+func (d *DefaultContext) safely(what string, fn func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.Logger().Errorf("%s panicked: %v", what, r)
+		}
+	}()
+	if err := fn(); err != nil {
+		d.Logger().Errorf("%s failed: %v", what, err)
+	}
+}
@@ -0,0 +1,80 @@
+package buffalo
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DefaultContext_AfterResponse(t *testing.T) {
+	r := require.New(t)
+
+	var ran []string
+	var written string
+	res := httptest.NewRecorder()
+	a := New(Options{ResponseBufferSize: 1024})
+	a.GET("/", func(c Context) error {
+		c.AfterResponse(func() {
+			// the buffered response has been sent by now
+			written = res.Body.String()
+			ran = append(ran, "first")
+		})
+		c.AfterResponse(func() {
+			panic("boom")
+		})
+		fc := c.(*DefaultContext).Fork(c.Request(), c.Response())
+		fc.AfterResponse(func() {
+			ran = append(ran, "fork")
+		})
+		return c.Render(200, render.String("hello"))
+	})
+
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	r.Equal("hello", res.Body.String())
+	r.Equal("hello", written)
+	r.Equal([]string{"first", "fork"}, ran)
+}
+
+type testCloser struct {
+	closed *[]string
+	name   string
+}
+
+func (t testCloser) Close() error {
+	*t.closed = append(*t.closed, t.name)
+	return nil
+}
+
+func Test_DefaultContext_Cleanup(t *testing.T) {
+	r := require.New(t)
+
+	var closed []string
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		c.OnClose(testCloser{&closed, "first"})
+		c.Cleanup(func() error {
+			return errors.New("boom")
+		})
+		c.OnClose(testCloser{&closed, "last"})
+		c.AfterResponse(func() {
+			closed = append(closed, "after")
+		})
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/panic", func(c Context) error {
+		c.OnClose(testCloser{&closed, "panic"})
+		panic("boom")
+	})
+
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	r.Equal([]string{"after", "last", "first"}, closed)
+
+	closed = nil
+	r.Panics(func() {
+		a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	})
+	r.Equal([]string{"panic"}, closed)
+}
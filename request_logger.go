@@ -1,10 +1,12 @@
 package buffalo
 
 import (
+	"io"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	humanize "github.com/dustin/go-humanize"
+	"github.com/markbates/going/defaults"
 	"github.com/markbates/going/randx"
 )
 
@@ -16,31 +18,144 @@ var RequestLogger = RequestLoggerFunc
 // By default it will log a uniq "request_id", the HTTP Method of the request,
 // the path that was requested, the duration (time) it took to process the
 // request, the size of the response (and the "human" size), and the status
-// code of the response.
+// code of the response. The "request_id" is also stored in the Context
+// so it can be shown on error pages. Query parameters redacted by
+// Redaction are filtered out of the logged path.
+//
+// The request's fields, along with the "route", and the "user_id" when
+// it is known, are put on c.Logger() before the Handler runs, so every
+// line it logs has them. Fields the Handler adds with LogField are on
+// its later lines, and the line logged once the request is done.
 func RequestLoggerFunc(h Handler) Handler {
+	return logRequest(h, nil, SessionUserID("current_user_id"))
+}
+
+// RequestLoggerOptions configure NewRequestLogger.
+type RequestLoggerOptions struct {
+	// Sinks each get a copy of every access log line, as well as the
+	// App's Logger. A RotatingFile, os.Stdout, a *syslog.Writer, or a
+	// UDP net.Conn, all work.
+	Sinks []io.Writer
+	// Formatter for the Sinks. Defaults to JSON.
+	Formatter logrus.Formatter
+	// UserID returns the ID of the request's user, or nil if it isn't
+	// known, which is logged as "user_id". It is asked before the
+	// Handler runs, and again once it's done, in case the Handler, or
+	// other middleware, logged the user in. Defaults to
+	// SessionUserID("current_user_id").
+	UserID func(Context) interface{}
+}
+
+// SessionUserID returns a RequestLoggerOptions.UserID that finds the ID
+// of the user in the session, under the key.
+func SessionUserID(key string) func(Context) interface{} {
+	return func(c Context) interface{} {
+		return c.Session().Get(key)
+	}
+}
+
+// NewRequestLogger returns a RequestLogger that also sends the access
+// log to other sinks, so it can go to stdout, a rotated file, and a log
+// collector all at once.
+/*
+	access, err := buffalo.NewRotatingFile("logs/access.log", buffalo.RotateOptions{
+		Interval:   24 * time.Hour,
+		MaxBackups: 14,
+	})
+	collector, err := net.Dial("udp", "logs.internal:5140")
+
+	buffalo.RequestLogger = buffalo.NewRequestLogger(buffalo.RequestLoggerOptions{
+		Sinks: []io.Writer{access, collector},
+	})
+	app := buffalo.Automatic(buffalo.Options{})
+*/
+func NewRequestLogger(opts RequestLoggerOptions) MiddlewareFunc {
+	if opts.Formatter == nil {
+		opts.Formatter = &logrus.JSONFormatter{}
+	}
+	if opts.UserID == nil {
+		opts.UserID = SessionUserID("current_user_id")
+	}
+	sinks := []*logrus.Logger{}
+	for _, w := range opts.Sinks {
+		l := logrus.New()
+		l.Out = w
+		l.Formatter = opts.Formatter
+		sinks = append(sinks, l)
+	}
+	return func(h Handler) Handler {
+		return logRequest(h, sinks, opts.UserID)
+	}
+}
+
+func logRequest(h Handler, sinks []*logrus.Logger, userID func(Context) interface{}) Handler {
 	return func(c Context) error {
 		var irid interface{}
 		if irid = c.Session().Get("requestor_id"); irid == nil {
 			irid = randx.String(10)
 			c.Session().Set("requestor_id", irid)
-			c.Session().Save()
 		}
 		now := time.Now()
-		c.LogFields(logrus.Fields{
-			"request_id": irid.(string) + "-" + randx.String(10),
+		rid := irid.(string) + "-" + randx.String(10)
+		c.Set("request_id", rid)
+		fields := logrus.Fields{
+			"request_id": rid,
 			"method":     c.Request().Method,
-			"path":       c.Request().URL.String(),
-		})
+			"path":       Redaction.RedactURL(c.Request().URL),
+			"client_ip":  c.ClientIP(),
+		}
+		if ri, ok := Get(c, CurrentRouteKey); ok && ri.Path != "" {
+			fields["route"] = defaults.String(ri.PathName, ri.Path)
+		}
+		uid := userID(c)
+		if uid != nil {
+			fields["user_id"] = uid
+		}
+		c.LogFields(fields)
 		defer func() {
-			ws := c.Response().(*buffaloResponse)
-			c.LogFields(logrus.Fields{
-				"duration":   time.Now().Sub(now),
-				"size":       ws.size,
-				"human_size": humanize.Bytes(uint64(ws.size)),
-				"status":     ws.status,
-			})
+			end := logrus.Fields{
+				"duration": time.Now().Sub(now),
+			}
+			if uid == nil {
+				if uid = userID(c); uid != nil {
+					end["user_id"] = uid
+				}
+			}
+			if ws, ok := c.Response().(*buffaloResponse); ok {
+				end["size"] = ws.size
+				end["human_size"] = humanize.Bytes(uint64(ws.size))
+				end["status"] = ws.status
+			}
+			c.LogFields(end)
 			c.Logger().Info()
+			if len(sinks) == 0 {
+				return
+			}
+			// the sinks get the fields set by the handler, and other
+			// middleware, too, if they can be found.
+			if lf := loggerFields(c.Logger()); lf != nil {
+				fields = lf
+			} else {
+				for k, v := range end {
+					fields[k] = v
+				}
+			}
+			for _, l := range sinks {
+				l.WithFields(fields).Info()
+			}
 		}()
 		return h(c)
 	}
 }
+
+// loggerFields returns the fields of the default Logger.
+func loggerFields(l Logger) logrus.Fields {
+	ml, ok := l.(*multiLogger)
+	if !ok || len(ml.Loggers) == 0 {
+		return nil
+	}
+	if e, ok := ml.Loggers[0].(*logrus.Entry); ok {
+		return e.Data
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package buffalo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewRequestLogger_Sinks(t *testing.T) {
+	r := require.New(t)
+
+	s1 := &bytes.Buffer{}
+	s2 := &bytes.Buffer{}
+	a := New(Options{})
+	a.Use(NewRequestLogger(RequestLoggerOptions{Sinks: []io.Writer{s1, s2}}))
+	a.GET("/", func(c Context) error {
+		c.LogField("db", 42)
+		return c.Render(201, render.String("ok"))
+	})
+
+	w := willie.New(a)
+	r.Equal(201, w.Request("/?password=hunter2").Get().Code)
+
+	for _, s := range []*bytes.Buffer{s1, s2} {
+		lines := strings.Split(strings.TrimSpace(s.String()), "\n")
+		r.Len(lines, 1)
+		m := map[string]interface{}{}
+		r.NoError(json.Unmarshal([]byte(lines[0]), &m))
+		r.Equal("GET", m["method"])
+		r.Equal("/?password=%5BFILTERED%5D", m["path"])
+		r.Equal(float64(201), m["status"])
+		r.Equal(float64(42), m["db"])
+		r.NotEmpty(m["request_id"])
+	}
+}
+
+func Test_RequestLogger_Fields(t *testing.T) {
+	r := require.New(t)
+
+	sink := &bytes.Buffer{}
+	a := New(Options{})
+	a.Use(NewRequestLogger(RequestLoggerOptions{Sinks: []io.Writer{sink}}))
+	a.GET("/users/{id}", func(c Context) error {
+		fields := loggerFields(c.Logger())
+		r.Equal("userPath", fields["route"])
+		r.Equal("GET", fields["method"])
+		r.NotEmpty(fields["request_id"])
+		r.Nil(fields["user_id"])
+
+		c.LogField("order_id", 7)
+		r.Equal(7, loggerFields(c.Logger())["order_id"])
+
+		// the user logs in while the request is handled
+		c.Session().Set("current_user_id", "u1")
+		return c.Render(200, render.String("ok"))
+	}).Name("userPath")
+
+	w := willie.New(a)
+	r.Equal(200, w.Request("/users/1").Get().Code)
+
+	m := map[string]interface{}{}
+	r.NoError(json.Unmarshal(sink.Bytes(), &m))
+	r.Equal("userPath", m["route"])
+	r.Equal("u1", m["user_id"])
+	r.Equal(float64(7), m["order_id"])
+}
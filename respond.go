@@ -0,0 +1,158 @@
+package buffalo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/pkg/errors"
+)
+
+// Respond renders v with the status. The format is chosen from the
+// request's "Accept" header, XML for clients that prefer XML and JSON
+// for everyone else, see Accepts. JSON is written with the JSONEncoder
+// of the App, see Context.JSON. A nil v only writes the status.
+/*
+	return c.Respond(202, job)
+*/
+func (d *DefaultContext) Respond(status int, v interface{}) error {
+	if v == nil {
+		return d.Render(status, nil)
+	}
+	if d.Accepts("json", "xml") == "xml" {
+		return d.XML(status, v)
+	}
+	return d.JSON(status, v)
+}
+
+// OK renders v with a 200 status, see Respond.
+/*
+	func UsersShow(c buffalo.Context) error {
+		...
+		return c.OK(user)
+	}
+*/
+func (d *DefaultContext) OK(v interface{}) error {
+	return d.Respond(200, v)
+}
+
+// Created renders v with a 201 status, and sets the "Location" header
+// to where the new resource can be found, see Respond.
+/*
+	return c.Created(user, fmt.Sprintf("/users/%d", user.ID))
+*/
+func (d *DefaultContext) Created(v interface{}, location string) error {
+	if location != "" {
+		d.Response().Header().Set("Location", location)
+	}
+	return d.Respond(201, v)
+}
+
+// NoContent responds with a 204 status and no body.
+func (d *DefaultContext) NoContent() error {
+	return d.Respond(204, nil)
+}
+
+// JSONEncoder writes v to w as JSON, see the JSONEncoder option.
+/*
+	a := buffalo.New(buffalo.WithJSONEncoder(func(w io.Writer, v interface{}) error {
+		return jsoniter.NewEncoder(w).Encode(v)
+	}))
+*/
+type JSONEncoder func(w io.Writer, v interface{}) error
+
+func defaultJSONEncoder(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// JSON writes v as JSON with the status, without going through a
+// render.Renderer. v is run through render.Serialize first, and then
+// the JSONEncoder of the App.
+/*
+	return c.JSON(200, user)
+*/
+func (d *DefaultContext) JSON(status int, v interface{}) error {
+	v, err := render.Serialize(v)
+	if err != nil {
+		return httpError{Status: 500, Cause: errors.WithStack(err)}
+	}
+	enc := defaultJSONEncoder
+	if d.app != nil && d.app.JSONEncoder != nil {
+		enc = d.app.JSONEncoder
+	}
+	bb := &bytes.Buffer{}
+	if err := enc(bb, v); err != nil {
+		return httpError{Status: 500, Cause: errors.WithStack(err)}
+	}
+	return d.write(status, "application/json", bb)
+}
+
+// XML writes v as XML with the status.
+func (d *DefaultContext) XML(status int, v interface{}) error {
+	bb := &bytes.Buffer{}
+	if err := xml.NewEncoder(bb).Encode(v); err != nil {
+		return httpError{Status: 500, Cause: errors.WithStack(err)}
+	}
+	return d.write(status, "application/xml", bb)
+}
+
+// String writes the formatted string as plain text with the status.
+// Without args the format is written as it is.
+/*
+	return c.String(200, "hello %s", name)
+*/
+func (d *DefaultContext) String(status int, format string, args ...interface{}) error {
+	s := format
+	if len(args) > 0 {
+		s = fmt.Sprintf(format, args...)
+	}
+	return d.write(status, "text/plain", strings.NewReader(s))
+}
+
+// Stream copies r to the response with the status and content type,
+// flushing as it goes, so large, or slow, bodies aren't held in memory.
+// r is closed afterwards if it is an io.Closer.
+/*
+	f, err := os.Open("export.csv")
+	...
+	return c.Stream(200, "text/csv", f)
+*/
+func (d *DefaultContext) Stream(status int, contentType string, r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	res := d.Response()
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(status)
+	var w io.Writer = res
+	if f, ok := res.(http.Flusher); ok {
+		w = flushWriter{w: res, f: f}
+	}
+	_, err := io.Copy(w, r)
+	return errors.WithStack(err)
+}
+
+// write the status and content type, and then copies r to the response.
+func (d *DefaultContext) write(status int, contentType string, r io.Reader) error {
+	res := d.Response()
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(status)
+	_, err := io.Copy(res, r)
+	return errors.WithStack(err)
+}
+
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(b []byte) (int, error) {
+	n, err := fw.w.Write(b)
+	fw.f.Flush()
+	return n, err
+}
@@ -0,0 +1,124 @@
+package buffalo
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+type respondWidget struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func respondApp() *App {
+	a := New(Options{})
+	a.GET("/ok", func(c Context) error {
+		return c.OK(respondWidget{Name: "thing"})
+	})
+	a.POST("/created", func(c Context) error {
+		return c.Created(respondWidget{Name: "thing"}, "/widgets/1")
+	})
+	a.DELETE("/none", func(c Context) error {
+		return c.NoContent()
+	})
+	return a
+}
+
+func Test_DefaultContext_OK(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(respondApp())
+
+	res := w.Request("/ok").Get()
+	r.Equal(200, res.Code)
+	r.Equal("application/json", res.Header().Get("Content-Type"))
+	r.Equal(`{"name":"thing"}`+"\n", res.Body.String())
+
+	req := w.Request("/ok")
+	req.Headers["Accept"] = "application/xml"
+	res = req.Get()
+	r.Equal("application/xml", res.Header().Get("Content-Type"))
+	r.Contains(res.Body.String(), "<name>thing</name>")
+}
+
+func Test_DefaultContext_Created(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(respondApp())
+
+	res := w.Request("/created").Post(nil)
+	r.Equal(201, res.Code)
+	r.Equal("/widgets/1", res.Header().Get("Location"))
+	r.Contains(res.Body.String(), `"name":"thing"`)
+}
+
+func Test_DefaultContext_NoContent(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(respondApp())
+
+	res := w.Request("/none").Delete()
+	r.Equal(204, res.Code)
+	r.Empty(res.Body.String())
+}
+
+func Test_DefaultContext_ResponseHelpers(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.GET("/json", func(c Context) error {
+		return c.JSON(201, respondWidget{Name: "thing"})
+	})
+	a.GET("/xml", func(c Context) error {
+		return c.XML(200, respondWidget{Name: "thing"})
+	})
+	a.GET("/string", func(c Context) error {
+		return c.String(200, "hello %s", "mark")
+	})
+	a.GET("/percent", func(c Context) error {
+		return c.String(200, "100%")
+	})
+	a.GET("/stream", func(c Context) error {
+		return c.Stream(200, "text/csv", strings.NewReader("a,b\n1,2\n"))
+	})
+	a.GET("/bad", func(c Context) error {
+		return c.JSON(200, func() {})
+	})
+	w := willie.New(a)
+
+	res := w.Request("/json").Get()
+	r.Equal(201, res.Code)
+	r.Equal("application/json", res.Header().Get("Content-Type"))
+	r.Equal(`{"name":"thing"}`+"\n", res.Body.String())
+
+	res = w.Request("/xml").Get()
+	r.Equal("application/xml", res.Header().Get("Content-Type"))
+	r.Equal("<respondWidget><name>thing</name></respondWidget>", res.Body.String())
+
+	res = w.Request("/string").Get()
+	r.Equal("text/plain", res.Header().Get("Content-Type"))
+	r.Equal("hello mark", res.Body.String())
+	r.Equal("100%", w.Request("/percent").Get().Body.String())
+
+	res = w.Request("/stream").Get()
+	r.Equal("text/csv", res.Header().Get("Content-Type"))
+	r.Equal("a,b\n1,2\n", res.Body.String())
+
+	r.Equal(500, w.Request("/bad").Get().Code)
+}
+
+func Test_Options_JSONEncoder(t *testing.T) {
+	r := require.New(t)
+	a := New(WithJSONEncoder(func(w io.Writer, v interface{}) error {
+		_, err := io.WriteString(w, "custom")
+		return err
+	}))
+	a.GET("/", func(c Context) error {
+		return c.JSON(200, respondWidget{Name: "thing"})
+	})
+	a.GET("/ok", func(c Context) error {
+		return c.OK(respondWidget{Name: "thing"})
+	})
+	w := willie.New(a)
+	r.Equal("custom", w.Request("/").Get().Body.String())
+	r.Equal("custom", w.Request("/ok").Get().Body.String())
+}
@@ -2,39 +2,147 @@ package buffalo
 
 import (
 	"bufio"
-	"encoding/binary"
+	"io"
 	"net"
 	"net/http"
 
 	"github.com/pkg/errors"
 )
 
+// ResponseWriter is the http.ResponseWriter that Handlers are given. It
+// records the status and size of the response, so middleware can read
+// them once the Handler has run, and passes the http.Flusher,
+// http.Hijacker, http.Pusher, and io.ReaderFrom interfaces through to
+// the writer it wraps.
+/*
+	func Measure(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			err := next(c)
+			if rw, ok := c.Response().(buffalo.ResponseWriter); ok {
+				c.LogFields(map[string]interface{}{"status": rw.Status(), "bytes": rw.Size()})
+			}
+			return err
+		}
+	}
+*/
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status of the response, 0 if nothing has been written yet.
+	Status() int
+	// Size is the number of bytes of the body written.
+	Size() int
+}
+
+// NewResponseWriter wraps w in a ResponseWriter, for middleware that
+// needs the status, and size, of just the part of the response written
+// by the Handlers after it.
+/*
+	rw := buffalo.NewResponseWriter(c.Response())
+	fc := c.(*buffalo.DefaultContext).Fork(c.Request(), rw)
+*/
+func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
+	return &buffaloResponse{ResponseWriter: w}
+}
+
 type buffaloResponse struct {
 	status int
 	size   int
+	// beforeWrite is run once, before the status is written, to save
+	// the session of the request.
+	beforeWrite func()
 	http.ResponseWriter
 }
 
+func (w *buffaloResponse) before() {
+	if fn := w.beforeWrite; fn != nil {
+		w.beforeWrite = nil
+		fn()
+	}
+}
+
 func (w *buffaloResponse) WriteHeader(i int) {
-	w.status = i
+	if i >= 200 || i == http.StatusSwitchingProtocols {
+		w.before()
+	}
+	// informational responses, such as 103 Early Hints, come before
+	// the real one, and only the first real one counts
+	if (i >= 200 || i == http.StatusSwitchingProtocols) && w.status == 0 {
+		w.status = i
+	}
 	w.ResponseWriter.WriteHeader(i)
 }
 
 func (w *buffaloResponse) Write(b []byte) (int, error) {
-	w.size = binary.Size(b)
-	return w.ResponseWriter.Write(b)
+	w.before()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Status of the response, see ResponseWriter.
+func (w *buffaloResponse) Status() int {
+	return w.status
 }
+
+// Size of the body of the response, see ResponseWriter.
+func (w *buffaloResponse) Size() int {
+	return w.size
+}
+
+// ReadFrom lets io.Copy use the io.ReaderFrom of the underlying writer,
+// such as sendfile for *os.Files.
+func (w *buffaloResponse) ReadFrom(r io.Reader) (int64, error) {
+	w.before()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+	w.size += int(n)
+	return n, err
+}
+
 func (w *buffaloResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
-		return hj.Hijack()
+		conn, rw, err := hj.Hijack()
+		if err == nil {
+			w.status = http.StatusSwitchingProtocols
+		}
+		return conn, rw, err
 	}
 	return nil, nil, errors.WithStack(errors.New("does not implement http.Hijack"))
 }
 
+// Push passes the push through to the underlying writer, see
+// Context.Push.
+func (w *buffaloResponse) Push(target string, opts *http.PushOptions) error {
+	return push(w.ResponseWriter, target, opts)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, allowing
+// http.ResponseController to reach it.
+func (w *buffaloResponse) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *buffaloResponse) Flush() {
-	w.ResponseWriter.(http.Flusher).Flush()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
 }
 
 func (w *buffaloResponse) CloseNotify() <-chan bool {
-	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	// the underlying writer can't tell us, so never notify
+	return make(chan bool)
 }
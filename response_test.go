@@ -0,0 +1,87 @@
+package buffalo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+func Test_ResponseWriter(t *testing.T) {
+	r := require.New(t)
+
+	res := httptest.NewRecorder()
+	rw := NewResponseWriter(res)
+	r.Equal(0, rw.Status())
+
+	rw.WriteHeader(201)
+	rw.WriteHeader(500)
+	r.Equal(201, rw.Status())
+
+	rw.Write([]byte("hello "))
+	n, err := io.Copy(rw, strings.NewReader("world"))
+	r.NoError(err)
+	r.Equal(int64(5), n)
+	r.Equal(11, rw.Size())
+	r.Equal("hello world", res.Body.String())
+
+	_, ok := rw.(http.Flusher)
+	r.True(ok)
+	_, ok = rw.(http.Hijacker)
+	r.True(ok)
+	_, ok = rw.(io.ReaderFrom)
+	r.True(ok)
+
+	// writing without a status is a 200
+	rw = NewResponseWriter(httptest.NewRecorder())
+	io.Copy(rw, strings.NewReader("ok"))
+	r.Equal(200, rw.Status())
+	r.Equal(2, rw.Size())
+}
+
+func Test_ResponseWriter_Push(t *testing.T) {
+	r := require.New(t)
+
+	pr := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rw := NewResponseWriter(NewResponseWriter(pr))
+	r.NoError(rw.(http.Pusher).Push("/app.css", nil))
+	r.Equal([]string{"/app.css"}, pr.pushed)
+
+	rw = NewResponseWriter(httptest.NewRecorder())
+	r.Equal(http.ErrNotSupported, rw.(http.Pusher).Push("/app.css", nil))
+}
+
+func Test_ResponseWriter_Context(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	var status, size int
+	a.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			err := next(c)
+			rw := c.Response().(ResponseWriter)
+			status, size = rw.Status(), rw.Size()
+			return err
+		}
+	})
+	a.GET("/", func(c Context) error {
+		return c.String(202, "accepted")
+	})
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	r.Equal(202, status)
+	r.Equal(8, size)
+}
@@ -0,0 +1,151 @@
+package buffalo
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RotateOptions control when a RotatingFile is rotated. A zero value
+// never rotates.
+type RotateOptions struct {
+	// MaxSize the file can grow to, in bytes, before it is rotated.
+	MaxSize int64
+	// Interval after which the file is rotated, for example daily.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept, the oldest are
+	// removed first. Zero keeps them all.
+	MaxBackups int
+}
+
+// RotatingFile is a log file that is rotated when it gets too big, or
+// too old. Rotated files are renamed with the time they were rotated
+// appended, "development.log.20170102T150405.000000000".
+type RotatingFile struct {
+	Path string
+	RotateOptions
+
+	moot   sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// NewRotatingFile opens, or creates, the file at path for appending.
+/*
+	f, err := buffalo.NewRotatingFile("logs/access.log", buffalo.RotateOptions{
+		MaxSize:    100 << 20,
+		Interval:   24 * time.Hour,
+		MaxBackups: 7,
+	})
+*/
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{Path: path, RotateOptions: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	r.f = f
+	r.size = info.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+// Write to the file, rotating it first if the write would take it over
+// MaxSize, or it is older than Interval.
+func (r *RotatingFile) Write(b []byte) (int, error) {
+	r.moot.Lock()
+	defer r.moot.Unlock()
+	if r.f == nil {
+		return 0, errors.New("rotating file is closed")
+	}
+	if r.due(int64(len(b))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) due(n int64) bool {
+	if r.MaxSize > 0 && r.size > 0 && r.size+n > r.MaxSize {
+		return true
+	}
+	return r.Interval > 0 && time.Since(r.opened) >= r.Interval
+}
+
+// Rotate the file now.
+func (r *RotatingFile) Rotate() error {
+	r.moot.Lock()
+	defer r.moot.Unlock()
+	return r.rotate()
+}
+
+func (r *RotatingFile) rotate() error {
+	if r.f != nil {
+		if err := r.f.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+		r.f = nil
+	}
+	backup := r.Path + "." + time.Now().Format("20060102T150405.000000000")
+	if err := os.Rename(r.Path, backup); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// prune removes the oldest backups over MaxBackups.
+func (r *RotatingFile) prune() error {
+	if r.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(r.Path + ".*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	sort.Strings(backups)
+	for len(backups) > r.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Close the file.
+func (r *RotatingFile) Close() error {
+	r.moot.Lock()
+	defer r.moot.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
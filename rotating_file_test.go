@@ -0,0 +1,68 @@
+package buffalo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RotatingFile_MaxSize(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "buffalo-logs")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "test.log")
+	f, err := NewRotatingFile(p, RotateOptions{MaxSize: 10, MaxBackups: 2})
+	r.NoError(err)
+	defer f.Close()
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		_, err = f.Write([]byte(s))
+		r.NoError(err)
+		// backups are named by time
+		time.Sleep(time.Millisecond)
+	}
+
+	b, err := ioutil.ReadFile(p)
+	r.NoError(err)
+	r.Equal("dddddddd\n", string(b))
+
+	backups, err := filepath.Glob(p + ".*")
+	r.NoError(err)
+	r.Len(backups, 2)
+	b, err = ioutil.ReadFile(backups[1])
+	r.NoError(err)
+	r.Equal("cccccccc\n", string(b))
+}
+
+func Test_RotatingFile_Interval(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "buffalo-logs")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "test.log")
+	f, err := NewRotatingFile(p, RotateOptions{Interval: 20 * time.Millisecond})
+	r.NoError(err)
+	defer f.Close()
+
+	f.Write([]byte("one\n"))
+	f.Write([]byte("two\n"))
+	time.Sleep(30 * time.Millisecond)
+	f.Write([]byte("three\n"))
+
+	b, err := ioutil.ReadFile(p)
+	r.NoError(err)
+	r.Equal("three\n", string(b))
+
+	backups, err := filepath.Glob(p + ".*")
+	r.NoError(err)
+	r.Len(backups, 1)
+}
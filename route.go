@@ -1,14 +1,25 @@
 package buffalo
 
-import "github.com/gorilla/mux"
+import (
+	"sort"
+
+	"github.com/gorilla/mux"
+)
 
 // Routes returns a list of all of the routes defined
 // in this application.
 func (a *App) Routes() RouteList {
+	root := a
 	if a.root != nil {
-		return a.root.routes
+		root = a.root
+	}
+	if len(root.mounted) == 0 {
+		return root.routes
 	}
-	return a.routes
+	routes := append(RouteList{}, root.routes...)
+	routes = append(routes, root.mountedRoutes()...)
+	sort.Sort(routes)
+	return routes
 }
 
 // RouteInfo provides information about the underlying route that
@@ -16,9 +27,37 @@ func (a *App) Routes() RouteList {
 type RouteInfo struct {
 	Method      string     `json:"method"`
 	Path        string     `json:"path"`
+	Host        string     `json:"host,omitempty"`
+	PathName    string     `json:"pathName,omitempty"`
 	HandlerName string     `json:"handler"`
 	MuxRoute    *mux.Route `json:"-"`
 	Handler     Handler    `json:"-"`
+	app         *App
+	limits      *RouteLimits
+	schema      *RouteSchema
+	// name is the PathName given to the route after its handler was
+	// built, see Name.
+	name *string
+}
+
+// Chain returns the names of the middleware that run for the route, in
+// the order they run, see MiddlewareStack.Chain.
+func (ri RouteInfo) Chain() []string {
+	if ri.app == nil {
+		return []string{}
+	}
+	return ri.app.Middleware.Chain(ri.Handler)
+}
+
+// routesFor returns the routes of the App handling the request.
+func routesFor(c Context) RouteList {
+	if d, ok := c.(*DefaultContext); ok && d.app != nil {
+		return d.app.Routes()
+	}
+	if rl, ok := Get(c, RoutesKey); ok {
+		return rl
+	}
+	return RouteList{}
 }
 
 // RouteList contains a mapping of the routes defined
@@ -0,0 +1,188 @@
+package buffalo
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// routeIndex is a tree of the static segments at the start of the
+// route paths, such as "users" for "/users/{id}/edit", used to narrow
+// down the routes a request is tried against. It isn't a radix router:
+// the candidate routes are still matched by mux, one by one, in the
+// order they were added, so the pattern syntax and precedence are the
+// same as mux's.
+//
+// It speeds up Apps with many routes that begin with static segments,
+// such as resources, where a request is only tried against the routes
+// under its first few segments, rather than against every route.
+// Routes that begin with a pattern, such as "/{lang}/about", are
+// candidates for every request, and requests that no candidate matches,
+// such as 404s and 405s, still go through all of the routes in mux.
+type routeIndex struct {
+	gen  int64
+	root *indexNode
+}
+
+// indexState is the route index of an App, and the generation of its
+// routes, which goes up every time a route is added.
+type indexState struct {
+	gen     int64
+	current atomic.Value
+}
+
+type indexNode struct {
+	children map[string]*indexNode
+	routes   []indexedRoute
+}
+
+type indexedRoute struct {
+	seq   int
+	route *mux.Route
+}
+
+// candidatePool holds the slices the candidate routes of requests are
+// gathered in.
+var candidatePool = sync.Pool{
+	New: func() interface{} {
+		s := make([]indexedRoute, 0, 16)
+		return &s
+	},
+}
+
+// routesChanged marks the route index of the App as out of date.
+func (a *App) routesChanged() {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	atomic.AddInt64(&root.idx.gen, 1)
+}
+
+// routeIndex returns the route index of the App, building it if routes
+// have been added since it was last built.
+func (a *App) routeIndex() *routeIndex {
+	gen := atomic.LoadInt64(&a.idx.gen)
+	if ri, ok := a.idx.current.Load().(*routeIndex); ok && ri.gen == gen {
+		return ri
+	}
+	a.moot.Lock()
+	defer a.moot.Unlock()
+	ri := &routeIndex{gen: gen, root: &indexNode{}}
+	seq := 0
+	a.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// a subrouter, its routes are walked next
+			return nil
+		}
+		ri.add(seq, route)
+		seq++
+		return nil
+	})
+	a.idx.current.Store(ri)
+	return ri
+}
+
+func (ri *routeIndex) add(seq int, route *mux.Route) {
+	n := ri.root
+	tmpl, err := route.GetPathTemplate()
+	if err == nil {
+		segs := strings.Split(strings.TrimPrefix(tmpl, "/"), "/")
+		if rx, _ := route.GetPathRegexp(); !strings.HasSuffix(rx, "$") {
+			// a path prefix, its last segment can be partial
+			segs = segs[:len(segs)-1]
+		}
+		for _, s := range segs {
+			if strings.Contains(s, "{") {
+				break
+			}
+			c, ok := n.children[s]
+			if !ok {
+				if n.children == nil {
+					n.children = map[string]*indexNode{}
+				}
+				c = &indexNode{}
+				n.children[s] = c
+			}
+			n = c
+		}
+	}
+	n.routes = append(n.routes, indexedRoute{seq: seq, route: route})
+}
+
+// candidates appends the routes that can match the path to c, in the
+// order they were added.
+func (ri *routeIndex) candidates(p string, c []indexedRoute) []indexedRoute {
+	n := ri.root
+	c = append(c, n.routes...)
+	p = strings.TrimPrefix(p, "/")
+	for n != nil {
+		var s string
+		i := strings.IndexByte(p, '/')
+		if i < 0 {
+			s, p = p, ""
+		} else {
+			s, p = p[:i], p[i+1:]
+		}
+		n = n.children[s]
+		if n == nil {
+			break
+		}
+		c = append(c, n.routes...)
+		if i < 0 {
+			break
+		}
+	}
+	// insertion sort, there are only ever a handful of candidates
+	for i := 1; i < len(c); i++ {
+		for j := i; j > 0 && c[j].seq < c[j-1].seq; j-- {
+			c[j], c[j-1] = c[j-1], c[j]
+		}
+	}
+	return c
+}
+
+// indexedRouter serves requests with the first of their candidate
+// routes that matches. Requests that none of them match, because they
+// are for the wrong method, or for no route at all, or need their path
+// cleaned, are left to mux.
+type indexedRouter struct {
+	app *App
+}
+
+func (ir indexedRouter) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	a := ir.app
+	if p := req.URL.Path; p != "" && p == cleanPath(p) {
+		cp := candidatePool.Get().(*[]indexedRoute)
+		c := a.routeIndex().candidates(p, (*cp)[:0])
+		var h http.Handler
+		var vars map[string]string
+		for _, ix := range c {
+			m := mux.RouteMatch{}
+			if ix.route.Match(req, &m) && m.MatchErr == nil {
+				h, vars = m.Handler, m.Vars
+				break
+			}
+		}
+		*cp = c[:0]
+		candidatePool.Put(cp)
+		if h != nil {
+			h.ServeHTTP(res, mux.SetURLVars(req, vars))
+			return
+		}
+	}
+	a.router.ServeHTTP(res, req)
+}
+
+// cleanPath is the path mux would redirect the request to.
+func cleanPath(p string) string {
+	np := path.Clean(p)
+	if strings.HasSuffix(p, "/") && np != "/" {
+		np += "/"
+	}
+	return np
+}
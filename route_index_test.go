@@ -0,0 +1,87 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RouteIndex_Candidates(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.GET("/", voidHandler)
+	a.GET("/users", voidHandler)
+	a.GET("/users/{id}", voidHandler)
+	a.GET("/users/{id}/edit", voidHandler)
+	a.GET("/posts/{id}", voidHandler)
+	a.GET("/{page}", voidHandler)
+	a.ServeFiles("/assets", http.Dir("."))
+
+	paths := func(p string) []string {
+		tt := []string{}
+		for _, c := range a.routeIndex().candidates(p, nil) {
+			tmpl, _ := c.route.GetPathTemplate()
+			tt = append(tt, tmpl)
+		}
+		return tt
+	}
+	r.Equal([]string{"/", "/{page}", "/assets"}, paths("/"))
+	r.Equal([]string{"/users", "/users/{id}", "/users/{id}/edit", "/{page}", "/assets"}, paths("/users/1/edit"))
+	r.Equal([]string{"/posts/{id}", "/{page}", "/assets"}, paths("/posts/1"))
+	r.Equal([]string{"/{page}", "/assets"}, paths("/about"))
+}
+
+func Test_RouteIndex_Precedence(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	text := func(s string) Handler {
+		return func(c Context) error {
+			return c.Render(200, render.String(s+" "+c.Param("id")))
+		}
+	}
+	a.GET("/users/new", text("new"))
+	a.GET("/users/{id}", text("show"))
+	a.GET("/{id:[a-z]+}", text("page"))
+
+	w := willie.New(a)
+	r.Equal("new ", w.Request("/users/new").Get().Body.String())
+	r.Equal("show 1", w.Request("/users/1").Get().Body.String())
+	r.Equal("page about", w.Request("/about").Get().Body.String())
+	r.Equal(405, w.Request("/users/1").Delete().Code)
+	r.Equal(404, w.Request("/users/1/edit").Get().Code)
+	r.Equal(301, w.Request("/users//1").Get().Code)
+
+	// routes added after requests have been served, including host
+	// routes, which take precedence over the others
+	a.Host("{sub}.example.com").GET("/users/{id}", text("host"))
+	a.GET("/users/{id}/edit", text("edit"))
+	r.Equal("edit 1", w.Request("/users/1/edit").Get().Body.String())
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Host = "acme.example.com"
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal("host 1", res.Body.String())
+}
+
+func Test_RouteIndex_Mount(t *testing.T) {
+	r := require.New(t)
+	a := New(Options{})
+	a.Mount("/legacy", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("legacy " + req.URL.Path))
+	}))
+	for i := 0; i < 10; i++ {
+		a.GET(fmt.Sprintf("/r%d/{id}", i), voidHandler)
+	}
+
+	w := willie.New(a)
+	r.Equal("legacy /", w.Request("/legacy").Get().Body.String())
+	r.Equal("legacy /a/b", w.Request("/legacy/a/b").Get().Body.String())
+	r.Equal(200, w.Request("/r9/1").Get().Code)
+	r.Equal(404, w.Request("/legacyx").Get().Code)
+}
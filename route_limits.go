@@ -0,0 +1,80 @@
+package buffalo
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RouteLimits are the limits of a route. Routes get the RouteLimits of
+// the App, or Group, they are added to, which can then be changed for
+// the route itself.
+/*
+	api := a.Group("/api")
+	api.RouteLimits = buffalo.RouteLimits{Timeout: 5 * time.Second, MaxBody: 1 << 20}
+	api.POST("/uploads", UploadsCreate).MaxBody(100 << 20).Timeout(time.Minute)
+	api.GET("/events", EventsPoll).Streaming()
+*/
+type RouteLimits struct {
+	// Timeout is the deadline of the request's context, and how long the
+	// response can take to write, overriding the WriteTimeout option.
+	Timeout time.Duration
+	// MaxBody is the most bytes of the request body that can be read.
+	MaxBody int64
+	// Streaming routes, such as long polls and event streams, aren't
+	// subject to the ReadTimeout and WriteTimeout options.
+	Streaming bool
+	// MaxMemory is how much of a multipart form is kept in memory, the
+	// rest of its files are written to temporary files. Default is 32MB.
+	MaxMemory int64
+	// MaxFileSize is the biggest file Context.File, and StreamFile, hand
+	// out. File only checks it once the whole form has been read, so
+	// MaxBody is what limits how much is uploaded.
+	MaxFileSize int64
+}
+
+// Timeout sets the Timeout of the route, see RouteLimits.
+func (ri RouteInfo) Timeout(d time.Duration) RouteInfo {
+	if ri.limits != nil {
+		ri.limits.Timeout = d
+	}
+	return ri
+}
+
+// MaxBody sets the MaxBody of the route, see RouteLimits.
+func (ri RouteInfo) MaxBody(n int64) RouteInfo {
+	if ri.limits != nil {
+		ri.limits.MaxBody = n
+	}
+	return ri
+}
+
+// Streaming marks the route as Streaming, see RouteLimits.
+func (ri RouteInfo) Streaming() RouteInfo {
+	if ri.limits != nil {
+		ri.limits.Streaming = true
+	}
+	return ri
+}
+
+// apply the limits to the request.
+func (l *RouteLimits) apply(res http.ResponseWriter, req *http.Request) (*http.Request, context.CancelFunc) {
+	cancel := func() {}
+	rc := http.NewResponseController(res)
+	if l.Streaming {
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+	}
+	if l.Timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), l.Timeout)
+		req = req.WithContext(ctx)
+		if !l.Streaming {
+			rc.SetWriteDeadline(time.Now().Add(l.Timeout))
+		}
+	}
+	if l.MaxBody > 0 && req.Body != nil {
+		req.Body = http.MaxBytesReader(res, req.Body, l.MaxBody)
+	}
+	return req, cancel
+}
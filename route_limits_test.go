@@ -0,0 +1,92 @@
+package buffalo
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RouteLimits_MaxBody(t *testing.T) {
+	r := require.New(t)
+
+	read := func(c Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.Error(413, err)
+		}
+		return c.Render(200, render.String(string(b)))
+	}
+
+	a := New(Options{})
+	api := a.Group("/api")
+	api.RouteLimits = RouteLimits{MaxBody: 4}
+	api.POST("/small", read)
+	api.POST("/big", read).MaxBody(10)
+	a.POST("/any", read)
+
+	w := willie.New(a)
+	r.Equal(413, w.Request("/api/small").Post("hello").Code)
+	r.Equal("hello", w.Request("/api/big").Post("hello").Body.String())
+	r.Equal("hello world!", w.Request("/any").Post("hello world!").Body.String())
+}
+
+func Test_RouteLimits_Timeout(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/slow", func(c Context) error {
+		select {
+		case <-c.Request().Context().Done():
+			return c.Error(503, c.Request().Context().Err())
+		case <-time.After(time.Second):
+			return c.Render(200, render.String("done"))
+		}
+	}).Timeout(10 * time.Millisecond)
+
+	w := willie.New(a)
+	r.Equal(503, w.Request("/slow").Get().Code)
+}
+
+func Test_RouteLimits_Streaming(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{WriteTimeout: 50 * time.Millisecond})
+	slow := func(c Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return c.Render(200, render.String("done"))
+	}
+	a.GET("/poll", slow).Streaming()
+	a.GET("/slow", slow)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.ServeListener(ctx, ln)
+
+	get := func(p string) (string, error) {
+		res, err := http.Get("http://" + ln.Addr().String() + p)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b := &bytes.Buffer{}
+		_, err = b.ReadFrom(res.Body)
+		return b.String(), err
+	}
+
+	body, err := get("/poll")
+	r.NoError(err)
+	r.Equal("done", body)
+
+	_, err = get("/slow")
+	r.Error(err)
+}
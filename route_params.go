@@ -0,0 +1,60 @@
+package buffalo
+
+import "strings"
+
+// ParamTypes are shorthands that can be used in place of a regular
+// expression in a route's variables. A request whose param doesn't
+// match falls through to the other routes, and then to a 404, without
+// ever reaching the Handler. More can be added before routes are
+// defined.
+/*
+	a.GET("/users/{user_id:int}", UsersShow)
+	a.GET("/posts/{slug:slug}", PostsShow)
+	a.GET("/files/{id:uuid}", FilesShow)
+	a.GET("/zips/{code:[0-9]{5}}", ZipsShow)
+	a.GET("/docs/{path:*}", DocsShow) // the rest of the path
+
+	buffalo.ParamTypes["hex"] = "[0-9a-f]+"
+*/
+var ParamTypes = map[string]string{
+	"*":     `.*`,
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// expandParamTypes replaces the ParamTypes used by variables in a
+// route's path with their regular expressions.
+func expandParamTypes(p string) string {
+	b := &strings.Builder{}
+	level := 0
+	start := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '{':
+			if level == 0 {
+				b.WriteString(p[start:i])
+				start = i
+			}
+			level++
+		case '}':
+			level--
+			if level != 0 {
+				continue
+			}
+			v := p[start : i+1]
+			start = i + 1
+			parts := strings.SplitN(v[1:len(v)-1], ":", 2)
+			if len(parts) == 2 {
+				if rx, ok := ParamTypes[parts[1]]; ok {
+					v = "{" + parts[0] + ":" + rx + "}"
+				}
+			}
+			b.WriteString(v)
+		}
+	}
+	b.WriteString(p[start:])
+	return b.String()
+}
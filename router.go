@@ -2,6 +2,7 @@ package buffalo
 
 import (
 	"fmt"
+	"io/fs"
 	"net/http"
 	"path"
 	"path/filepath"
@@ -46,18 +47,33 @@ func (a *App) PATCH(p string, h Handler) RouteInfo {
 }
 
 // ServeFiles maps an path to a directory on disk to serve static files.
-// Useful for JavaScript, images, CSS, etc...
+// Useful for JavaScript, images, CSS, etc... To list directories, or
+// to serve precompressed files, use ServeFilesWith.
 /*
 	a.ServeFiles("/assets", http.Dir("path/to/assets"))
 */
 func (a *App) ServeFiles(p string, root http.FileSystem) {
-	a.router.PathPrefix(p).Handler(http.StripPrefix(p, http.FileServer(root)))
+	a.ServeFilesWith(p, root, StaticOptions{})
+}
+
+// ServeFS maps a path to an fs.FS, such as an embed.FS, to serve
+// static files that have been compiled into the binary.
+/*
+	//go:embed public
+	var public embed.FS
+
+	assets, _ := fs.Sub(public, "public/assets")
+	a.ServeFS("/assets", assets)
+*/
+func (a *App) ServeFS(p string, fsys fs.FS) {
+	a.ServeFiles(p, http.FS(fsys))
 }
 
 // Resource maps an implementation of the Resource interface
 // to the appropriate RESTful mappings. Resource returns the *App
 // associated with this group of mappings so you can set middleware, etc...
 // on that group, just as if you had used the a.Group functionality.
+// Use the Only and Except options to map just some of the actions.
 /*
 	a.Resource("/users", &UsersResource{})
 
@@ -72,23 +88,91 @@ func (a *App) ServeFiles(p string, root http.FileSystem) {
 	g.POST("/", ur.Create) // POST /users => ur.Create
 	g.PUT("/{user_id}", ur.Update) PUT /users/{user_id} => ur.Update
 	g.DELETE("/{user_id}", ur.Destroy) DELETE /users/{user_id} => ur.Destroy
+
+	// A read only resource:
+
+	a.Resource("/reports", &ReportsResource{}, buffalo.Only("List", "Show"))
 */
-func (a *App) Resource(p string, r Resource) *App {
+func (a *App) Resource(p string, r Resource, opts ...ResourceOption) *App {
+	ro := resourceOptions{actions: map[string]bool{}}
+	for _, action := range resourceActions {
+		ro.actions[action] = true
+	}
+	for _, o := range opts {
+		o(&ro)
+	}
+
 	base := filepath.Base(p)
 	single := inflect.Singularize(base)
 	g := a.Group(p)
 	p = "/"
 	spath := filepath.Join(p, fmt.Sprintf("{%s_id}", single))
-	g.GET(p, r.List)
-	g.GET(filepath.Join(p, "new"), r.New)
-	g.GET(filepath.Join(spath), r.Show)
-	g.GET(filepath.Join(spath, "edit"), r.Edit)
-	g.POST(p, r.Create)
-	g.PUT(filepath.Join(spath), r.Update)
-	g.DELETE(filepath.Join(spath), r.Destroy)
+	g.member = spath
+	routes := []struct {
+		action string
+		method string
+		path   string
+		h      Handler
+	}{
+		{"List", "GET", p, r.List},
+		{"New", "GET", filepath.Join(p, "new"), r.New},
+		{"Show", "GET", spath, r.Show},
+		{"Edit", "GET", filepath.Join(spath, "edit"), r.Edit},
+		{"Create", "POST", p, r.Create},
+		{"Update", "PUT", spath, r.Update},
+		{"Destroy", "DELETE", spath, r.Destroy},
+	}
+	for _, rt := range routes {
+		if ro.actions[rt.action] {
+			g.addRoute(rt.method, rt.path, rt.h)
+		}
+	}
 	return g
 }
 
+// Member returns a Group for a single member of the Resource, so
+// resources can be nested. Called on anything other than the App
+// returned by Resource, it returns the App itself.
+/*
+	users := a.Resource("/users", &UsersResource{})
+	users.Member().Resource("/posts", &PostsResource{})
+	// GET /users/{user_id}/posts/{post_id} => PostsResource.Show
+*/
+func (a *App) Member() *App {
+	if a.member == "" {
+		return a
+	}
+	return a.Group(a.member)
+}
+
+var resourceActions = []string{"List", "Show", "New", "Create", "Edit", "Update", "Destroy"}
+
+type resourceOptions struct {
+	actions map[string]bool
+}
+
+// ResourceOption changes which routes are mapped by Resource.
+type ResourceOption func(*resourceOptions)
+
+// Only maps just the named actions of a Resource.
+func Only(actions ...string) ResourceOption {
+	return func(ro *resourceOptions) {
+		ro.actions = map[string]bool{}
+		for _, a := range actions {
+			ro.actions[a] = true
+		}
+	}
+}
+
+// Except maps all of the actions of a Resource but the named ones.
+func Except(actions ...string) ResourceOption {
+	return func(ro *resourceOptions) {
+		for _, a := range actions {
+			delete(ro.actions, a)
+		}
+	}
+}
+
 // ANY accepts a request across any HTTP method for the specified path
 // and routes it to the specified Handler.
 func (a *App) ANY(p string, h Handler) {
@@ -103,10 +187,14 @@ func (a *App) ANY(p string, h Handler) {
 
 // Group creates a new `*App` that inherits from it's parent `*App`.
 // This is useful for creating groups of end-points that need to share
-// common functionality, like middleware.
+// common functionality, like middleware. The Group gets copies of its
+// parent's middleware and ErrorHandlers, which it can add to, or
+// override, without changing the parent, and shares its Logger and
+// host.
 /*
 	g := a.Group("/api/v1")
 	g.Use(AuthorizeAPIMiddleware)
+	g.ErrorHandlers[500] = APIErrorHandler
 	g.GET("/users, APIUsersHandler)
 	g.GET("/users/:user_id, APIUserShowHandler)
 */
@@ -114,7 +202,16 @@ func (a *App) Group(path string) *App {
 	g := New(a.Options)
 	g.prefix = filepath.Join(a.prefix, path)
 	g.router = a.router
+	g.host = a.host
+	g.Logger = a.Logger
+	g.RouteLimits = a.RouteLimits
+	g.HealthChecks = a.HealthChecks
 	g.Middleware = a.Middleware.clone()
+	g.Middleware.app = g
+	g.ErrorHandlers = ErrorHandlers{}
+	for k, v := range a.ErrorHandlers {
+		g.ErrorHandlers[k] = v
+	}
 	g.root = a
 	if a.root != nil {
 		g.root = a.root
@@ -122,6 +219,30 @@ func (a *App) Group(path string) *App {
 	return g
 }
 
+// Host creates a Group whose routes only match requests for the host.
+// The host can contain variables, which are available as params.
+// Routes for a host are matched before routes that aren't for a host,
+// even if they were added later, so one App can serve a main site, an
+// admin subdomain, and tenant subdomains.
+/*
+	api := a.Host("api.example.com")
+	api.GET("/users", APIUsersHandler)
+
+	tenant := a.Host("{subdomain}.example.com")
+	tenant.GET("/", TenantHomeHandler) // c.Param("subdomain")
+*/
+func (a *App) Host(host string) *App {
+	g := a.Group("/")
+	g.prefix = a.prefix
+	g.host = host
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	g.router = root.hosts.Host(host).Subrouter()
+	return g
+}
+
 func (a *App) addRoute(method string, url string, h Handler) RouteInfo {
 	a.moot.Lock()
 	defer a.moot.Unlock()
@@ -133,18 +254,24 @@ func (a *App) addRoute(method string, url string, h Handler) RouteInfo {
 		Path:        url,
 		HandlerName: hs,
 		Handler:     h,
+		Host:        a.host,
+		app:         a,
 	}
+	limits := a.RouteLimits
+	r.limits = &limits
+	r.schema = &RouteSchema{}
+	r.name = new(string)
 
-	r.MuxRoute = a.router.Handle(url, a.handlerToHandler(r, h)).Methods(method)
+	r.MuxRoute = a.router.Handle(expandParamTypes(url), a.handlerToHandler(r, h)).Methods(method)
+	a.routesChanged()
 
-	routes := a.Routes()
-	routes = append(routes, r)
-	sort.Sort(routes)
+	root := a
 	if a.root != nil {
-		a.root.routes = routes
-	} else {
-		a.routes = routes
+		root = a.root
 	}
+	routes := append(root.routes, r)
+	sort.Sort(routes)
+	root.routes = routes
 
 	return r
 }
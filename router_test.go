@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"runtime/pprof"
 	"testing"
+	"testing/fstest"
 
 	"github.com/gobuffalo/buffalo/render"
 	"github.com/markbates/willie"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -193,6 +196,47 @@ func Test_Resource(t *testing.T) {
 
 }
 
+func Test_Resource_Only_Except(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.Resource("/users", &userResource{}, Only("List", "Show"))
+	a.Resource("/admins", &userResource{}, Except("Destroy", "New"))
+
+	w := willie.New(a)
+	r.Equal("list", w.Request("/users").Get().Body.String())
+	r.Equal("show 1", w.Request("/users/1").Get().Body.String())
+	r.NotEqual(200, w.Request("/users").Post(nil).Code)
+	r.NotEqual(200, w.Request("/users/1").Delete().Code)
+
+	r.Equal("create", w.Request("/admins").Post(nil).Body.String())
+	r.Equal(200, w.Request("/admins/1/edit").Get().Code)
+	r.NotEqual(200, w.Request("/admins/1").Delete().Code)
+	r.NotEqual("new", w.Request("/admins/new").Get().Body.String())
+	r.Len(a.Routes(), 7)
+}
+
+func Test_Resource_Nested(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	users := a.Resource("/users", &userResource{})
+	users.Member().Resource("/posts", &postResource{}, Only("Show"))
+
+	w := willie.New(a)
+	r.Equal("show 1", w.Request("/users/1").Get().Body.String())
+	r.Equal("post 1 2", w.Request("/users/1/posts/2").Get().Body.String())
+	r.Equal(a, a.Member())
+}
+
+type postResource struct {
+	BaseResource
+}
+
+func (p *postResource) Show(c Context) error {
+	return c.Render(200, render.String("post {{params.user_id}} {{params.post_id}}"))
+}
+
 type userResource struct{}
 
 func (u *userResource) List(c Context) error {
@@ -222,3 +266,166 @@ func (u *userResource) Update(c Context) error {
 func (u *userResource) Destroy(c Context) error {
 	return c.Render(200, render.String("destroy {{params.user_id}}"))
 }
+
+func Test_Router_ProfileLabels(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{ProfileLabels: true})
+	g := a.Group("/api")
+	g.GET("/users/{id}", func(c Context) error {
+		route, _ := pprof.Label(c.Request().Context(), "route")
+		method, _ := pprof.Label(c.Request().Context(), "method")
+		return c.Render(200, render.String(method+" "+route))
+	})
+
+	w := willie.New(a)
+	res := w.Request("/api/users/1").Get()
+	r.Equal("GET /api/users/{id}", res.Body.String())
+}
+
+func Test_Router_ServeFS(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.ServeFS("/assets", fstest.MapFS{
+		"app.css": {Data: []byte("body {}")},
+	})
+
+	w := willie.New(a)
+	res := w.Request("/assets/app.css").Get()
+
+	r.Equal(200, res.Code)
+	r.Equal("body {}", res.Body.String())
+}
+
+func Test_Router_Group_ErrorHandlers(t *testing.T) {
+	r := require.New(t)
+
+	a := testApp()
+	a.ErrorHandlers[418] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, err = c.Response().Write([]byte("teapot"))
+		return err
+	}
+	boom := func(c Context) error {
+		return c.Error(418, errors.New("boom"))
+	}
+	a.GET("/boom", boom)
+
+	g := a.Group("/api")
+	g.ErrorHandlers[418] = func(status int, err error, c Context) error {
+		c.Response().WriteHeader(status)
+		_, err = c.Response().Write([]byte(`{"error":"teapot"}`))
+		return err
+	}
+	g.GET("/boom", boom)
+	r.Equal(a.Logger, g.Logger)
+
+	w := willie.New(a)
+	res := w.Request("/boom").Get()
+	r.Equal(418, res.Code)
+	r.Equal("teapot", res.Body.String())
+
+	res = w.Request("/api/boom").Get()
+	r.Equal(418, res.Code)
+	r.Equal(`{"error":"teapot"}`, res.Body.String())
+}
+
+func Test_Router_Host(t *testing.T) {
+	r := require.New(t)
+
+	a := testApp()
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("main"))
+	})
+	api := a.Host("api.example.com").Group("/v1")
+	api.GET("/users", func(c Context) error {
+		return c.Render(200, render.String("api users"))
+	})
+	tenant := a.Host("{subdomain}.example.com")
+	tenant.GET("/home", func(c Context) error {
+		return c.Render(200, render.String(c.Param("subdomain")))
+	})
+
+	w := willie.New(a)
+	req, _ := http.NewRequest("GET", "http://api.example.com/v1/users", nil)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal(200, res.Code)
+	r.Equal("api users", res.Body.String())
+
+	req, _ = http.NewRequest("GET", "http://acme.example.com/home", nil)
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal("acme", res.Body.String())
+
+	// the host routes don't match other hosts
+	r.Equal(404, w.Request("/v1/users").Get().Code)
+	r.Equal("main", w.Request("/").Get().Body.String())
+
+	routes := a.Routes()
+	found := false
+	for _, rt := range routes {
+		if rt.Path == "/v1/users" {
+			found = true
+			r.Equal("api.example.com", rt.Host)
+		}
+	}
+	r.True(found)
+}
+
+func Test_Router_Host_Precedence(t *testing.T) {
+	r := require.New(t)
+
+	a := testApp()
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("main"))
+	})
+	admin := a.Host("admin.example.com")
+	admin.GET("/", func(c Context) error {
+		return c.Render(200, render.String("admin"))
+	})
+
+	req, _ := http.NewRequest("GET", "http://admin.example.com:3000/", nil)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal("admin", res.Body.String())
+
+	req, _ = http.NewRequest("GET", "http://www.example.com/", nil)
+	res = httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	r.Equal("main", res.Body.String())
+}
+
+func Test_Router_ParamTypes(t *testing.T) {
+	r := require.New(t)
+
+	a := testApp()
+	a.GET("/users/{id:int}", func(c Context) error {
+		return c.Render(200, render.String("user {{params.id}}"))
+	})
+	a.GET("/users/{name:alpha}", func(c Context) error {
+		return c.Render(200, render.String("name {{params.name}}"))
+	})
+	a.GET("/files/{id:uuid}", func(c Context) error {
+		return c.Render(200, render.String("file"))
+	})
+	a.GET("/zips/{code:[0-9]{5}}", func(c Context) error {
+		return c.Render(200, render.String("zip {{params.code}}"))
+	})
+
+	w := willie.New(a)
+	r.Equal("user 42", w.Request("/users/42").Get().Body.String())
+	r.Equal("name mark", w.Request("/users/mark").Get().Body.String())
+	r.Equal(404, w.Request("/users/mark-1").Get().Code)
+	r.Equal("file", w.Request("/files/6ba7b810-9dad-11d1-80b4-00c04fd430c8").Get().Body.String())
+	r.Equal(404, w.Request("/files/nope").Get().Code)
+	r.Equal("zip 02134", w.Request("/zips/02134").Get().Body.String())
+	r.Equal(404, w.Request("/zips/2134").Get().Code)
+
+	paths := []string{}
+	for _, rt := range a.Routes() {
+		paths = append(paths, rt.Path)
+	}
+	r.Contains(paths, "/users/{id:int}")
+}
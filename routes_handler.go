@@ -0,0 +1,126 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gobuffalo/velvet"
+	"github.com/pkg/errors"
+)
+
+// RouteDescription describes a route, and the middleware that runs
+// for it, for the RoutesHandler.
+type RouteDescription struct {
+	RouteInfo
+	Middleware []string `json:"middleware"`
+}
+
+// Describe returns a RouteDescription for every route in the App.
+func (a *App) Describe() []RouteDescription {
+	descs := []RouteDescription{}
+	for _, ri := range a.Routes() {
+		descs = append(descs, RouteDescription{
+			RouteInfo:  ri,
+			Middleware: ri.Chain(),
+		})
+	}
+	return descs
+}
+
+// RoutesHandler lists the routes of the App, with their names,
+// handlers, and middleware, as HTML, or as JSON if the request accepts
+// it, or has ?format=json. It is only available in development,
+// everywhere else it is a 404.
+/*
+	a.GET("/_routes", a.RoutesHandler)
+*/
+func (a *App) RoutesHandler(c Context) error {
+	if a.Env() != "development" {
+		return c.Error(404, errors.Errorf("path not found: %s", c.Request().URL.Path))
+	}
+	descs := a.Describe()
+	req := c.Request()
+	res := c.Response()
+	asJSON := isJSON(strings.ToLower(req.Header.Get("Content-Type"))) ||
+		strings.Contains(req.Header.Get("Accept"), "json") ||
+		req.URL.Query().Get("format") == "json"
+	if asJSON {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(200)
+		return json.NewEncoder(res).Encode(descs)
+	}
+	routes := []map[string]interface{}{}
+	for _, d := range descs {
+		routes = append(routes, map[string]interface{}{
+			"method":     d.Method,
+			"path":       d.Path,
+			"host":       d.Host,
+			"name":       d.PathName,
+			"handler":    d.HandlerName,
+			"middleware": strings.Join(d.Middleware, " → "),
+		})
+	}
+	t, err := velvet.Render(htmlRoutes, velvet.NewContextWith(map[string]interface{}{
+		"routes": routes,
+	}))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.WriteHeader(200)
+	_, err = res.Write([]byte(t))
+	return err
+}
+
+var htmlRoutes = `
+<html>
+<head>
+	<title>Routes</title>
+	<style>
+		body {
+			font-family: helvetica;
+		}
+		table {
+			width: 100%;
+		}
+		th {
+			text-align: left;
+		}
+		tr:nth-child(even) {
+		  background-color: #dddddd;
+		}
+		td {
+			margin: 0px;
+			padding: 10px;
+		}
+	</style>
+</head>
+<body>
+<h1>Routes</h1>
+<table id="buffalo-routes-table">
+	<thead>
+		<tr>
+			<th>METHOD</th>
+			<th>HOST</th>
+			<th>PATH</th>
+			<th>NAME</th>
+			<th>HANDLER</th>
+			<th>MIDDLEWARE</th>
+		</tr>
+	</thead>
+	<tbody>
+		{{#each routes as |route|}}
+			<tr>
+				<td>{{route.method}}</td>
+				<td>{{route.host}}</td>
+				<td>{{route.path}}</td>
+				<td>{{route.name}}</td>
+				<td><code>{{route.handler}}</code></td>
+				<td><code>{{route.middleware}}</code></td>
+			</tr>
+		{{/each}}
+	</tbody>
+</table>
+</body>
+</html>
+`
@@ -0,0 +1,35 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_RoutesHandler(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	a := New(Options{Env: "development"})
+	a.Middleware.UseNamed("csrf", logMiddleware(&log, "csrf"))
+	a.GET("/users/{id}", voidHandler).Name("userPath")
+	a.GET("/_routes", a.RoutesHandler)
+
+	w := willie.New(a)
+	res := w.JSON("/_routes").Get()
+	r.Equal(200, res.Code)
+
+	descs := []RouteDescription{}
+	r.NoError(json.Unmarshal(res.Body.Bytes(), &descs))
+	r.Len(descs, 2)
+	r.Equal("/users/{id}", descs[1].Path)
+	r.Equal("userPath", descs[1].PathName)
+	r.Equal([]string{"csrf"}, descs[1].Middleware)
+
+	r.Equal(200, w.Request("/_routes").Get().Code)
+
+	a.Options.Env = "production"
+	r.Equal(404, w.JSON("/_routes").Get().Code)
+}
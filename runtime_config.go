@@ -0,0 +1,203 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/pkg/errors"
+)
+
+// RuntimeConfig holds the configuration that can be changed while the
+// application is running, without needing a restart.
+type RuntimeConfig struct {
+	// LogLevel of the App's Logger.
+	LogLevel string `json:"log_level"`
+	// Features is a set of feature flags.
+	Features map[string]bool `json:"features"`
+	// RateLimits are named limits that can be looked up by the
+	// application, for example when building RateLimit middleware.
+	RateLimits map[string]int `json:"rate_limits"`
+	// Maintenance turns on the MaintenanceMode middleware.
+	Maintenance bool `json:"maintenance"`
+}
+
+// Feature returns true if the named feature flag is turned on.
+func (r RuntimeConfig) Feature(name string) bool {
+	return r.Features[name]
+}
+
+// RateLimit returns the named rate limit, or d if it has not been set.
+func (r RuntimeConfig) RateLimit(name string, d int) int {
+	if l, ok := r.RateLimits[name]; ok {
+		return l
+	}
+	return d
+}
+
+// ConfigLoader loads the latest RuntimeConfig, from a file, the ENV,
+// or wherever else you keep it.
+type ConfigLoader func() (RuntimeConfig, error)
+
+// Config returns the current RuntimeConfig for the App, or an empty one
+// if none has been loaded.
+func (a *App) Config() RuntimeConfig {
+	if a.root != nil {
+		return a.root.Config()
+	}
+	if a.runtimeConfig == nil {
+		return RuntimeConfig{}
+	}
+	rc, _ := a.runtimeConfig.Load().(RuntimeConfig)
+	return rc
+}
+
+// ReloadConfig loads a new RuntimeConfig and swaps it in. The new log
+// level is applied straight away, and a config with a log level logrus
+// doesn't know is rejected, leaving the current config in place. A "buffalo:config:reload" event is
+// emitted with the "old" and "new" configs, along with the names of the
+// settings that "changed".
+func (a *App) ReloadConfig(load ConfigLoader) error {
+	if a.root != nil {
+		return a.root.ReloadConfig(load)
+	}
+	if a.runtimeConfig == nil {
+		return errors.New("app was not made with New, so has no runtime config")
+	}
+	a.reloading.Lock()
+	defer a.reloading.Unlock()
+	rc, err := load()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if rc.LogLevel != "" {
+		if _, err := logrus.ParseLevel(rc.LogLevel); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	old := a.Config()
+	a.runtimeConfig.Store(rc)
+	if rc.LogLevel != old.LogLevel {
+		setLogLevel(a.Logger, rc.LogLevel)
+	}
+
+	changed := []string{}
+	if rc.LogLevel != old.LogLevel {
+		changed = append(changed, "log_level")
+	}
+	if !reflect.DeepEqual(rc.Features, old.Features) {
+		changed = append(changed, "features")
+	}
+	if !reflect.DeepEqual(rc.RateLimits, old.RateLimits) {
+		changed = append(changed, "rate_limits")
+	}
+	if rc.Maintenance != old.Maintenance {
+		changed = append(changed, "maintenance")
+	}
+	a.Logger.WithField("changed", changed).Info("reloaded runtime config")
+	return events.Emit(events.Event{
+		Kind: "buffalo:config:reload",
+		Payload: events.Payload{
+			"old":     old,
+			"new":     rc,
+			"changed": changed,
+		},
+	})
+}
+
+// WatchConfig reloads the RuntimeConfig using the ConfigLoader every
+// time the process receives a SIGHUP. Calling the returned function
+// stops watching.
+func (a *App) WatchConfig(load ConfigLoader) func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigs:
+				if err := a.ReloadConfig(load); err != nil {
+					a.Logger.Error(err)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// ConfigReloadHandler returns a Handler that reloads the RuntimeConfig
+// and renders it back as JSON. Requests that aren't authorized get a 401.
+// Remember to skip MaintenanceMode for this handler, otherwise you won't
+// be able to turn maintenance mode back off!
+/*
+	reload := app.ConfigReloadHandler(loadConfig, func(c buffalo.Context) bool {
+		return c.Request().Header.Get("X-Admin-Token") == os.Getenv("ADMIN_TOKEN")
+	})
+	app.POST("/admin/config/reload", reload)
+	app.Middleware.Skip(app.MaintenanceMode, reload)
+*/
+func (a *App) ConfigReloadHandler(load ConfigLoader, authorized func(Context) bool) Handler {
+	return func(c Context) error {
+		if authorized == nil || !authorized(c) {
+			return c.Error(401, errors.New("not authorized to reload config"))
+		}
+		if err := a.ReloadConfig(load); err != nil {
+			return errors.WithStack(err)
+		}
+		c.Response().Header().Set("Content-Type", "application/json")
+		c.Response().WriteHeader(200)
+		return json.NewEncoder(c.Response()).Encode(a.Config())
+	}
+}
+
+// MaintenanceMode is a piece of Middleware that sends every request to
+// the 503 ErrorHandler while the RuntimeConfig has Maintenance turned on.
+/*
+	app.Use(app.MaintenanceMode)
+*/
+func (a *App) MaintenanceMode(next Handler) Handler {
+	return func(c Context) error {
+		if a.Config().Maintenance {
+			c.Response().Header().Set("Retry-After", "120")
+			return c.Error(503, errors.New("down for maintenance"))
+		}
+		return next(c)
+	}
+}
+
+func newRuntimeConfig(opts Options) *atomic.Value {
+	v := &atomic.Value{}
+	v.Store(RuntimeConfig{LogLevel: opts.LogLevel})
+	return v
+}
+
+func setLogLevel(l Logger, level string) {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return
+	}
+	ml, ok := l.(*multiLogger)
+	if !ok {
+		return
+	}
+	for _, fl := range ml.Loggers {
+		// SetLevel is atomic, so requests being logged can read the
+		// level while it changes
+		switch t := fl.(type) {
+		case *logrus.Logger:
+			t.SetLevel(lvl)
+		case *logrus.Entry:
+			t.Logger.SetLevel(lvl)
+		}
+	}
+}
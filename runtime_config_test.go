@@ -0,0 +1,98 @@
+package buffalo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/events"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_ReloadConfig(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	g := a.Group("/api")
+	r.Equal("debug", a.Config().LogLevel)
+
+	var changed []string
+	del := events.Listen("buffalo:config:reload", func(e events.Event) {
+		changed = e.Payload["changed"].([]string)
+	})
+	defer del()
+
+	err := g.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{
+			LogLevel: "debug",
+			Features: map[string]bool{"beta": true},
+		}, nil
+	})
+	r.NoError(err)
+	r.True(a.Config().Feature("beta"))
+	r.False(a.Config().Feature("alpha"))
+	r.Equal(10, a.Config().RateLimit("api", 10))
+	r.Equal([]string{"features"}, changed)
+
+	err = a.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{}, errors.New("boom")
+	})
+	r.Error(err)
+	r.True(a.Config().Feature("beta"))
+
+	err = a.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{LogLevel: "loud"}, nil
+	})
+	r.Error(err)
+	r.Equal("debug", a.Config().LogLevel)
+	r.True(a.Config().Feature("beta"))
+}
+
+func Test_App_MaintenanceMode(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.Use(a.MaintenanceMode)
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("ok"))
+	})
+	reload := a.ConfigReloadHandler(func() (RuntimeConfig, error) {
+		return RuntimeConfig{Maintenance: false}, nil
+	}, func(c Context) bool {
+		return c.Request().Header.Get("X-Token") == "secret"
+	})
+	a.POST("/reload", reload)
+	a.Middleware.Skip(a.MaintenanceMode, reload)
+
+	w := willie.New(a)
+	res := w.Request("/").Get()
+	r.Equal(200, res.Code)
+
+	r.NoError(a.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{Maintenance: true}, nil
+	}))
+	res = w.Request("/").Get()
+	r.Equal(503, res.Code)
+
+	res = w.Request("/reload").Post(nil)
+	r.Equal(401, res.Code)
+
+	req := w.Request("/reload")
+	req.Headers["X-Token"] = "secret"
+	res = req.Post(nil)
+	r.Equal(200, res.Code)
+
+	res = w.Request("/").Get()
+	r.Equal(200, res.Code)
+}
+
+func Test_App_Config_Empty(t *testing.T) {
+	r := require.New(t)
+
+	a := &App{}
+	r.Equal(RuntimeConfig{}, a.Config())
+	r.Error(a.ReloadConfig(func() (RuntimeConfig, error) {
+		return RuntimeConfig{}, nil
+	}))
+}
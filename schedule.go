@@ -0,0 +1,184 @@
+package buffalo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo/cron"
+)
+
+// ScheduledFunc is run by a ScheduledTask. ctx is done when the App
+// shuts down.
+type ScheduledFunc func(ctx context.Context) error
+
+// ScheduledTask is a recurring task, added with App.Schedule. Its
+// methods configure it, and can be chained.
+type ScheduledTask struct {
+	Name     string
+	Spec     string
+	schedule cron.Schedule
+	fn       ScheduledFunc
+	loc      *time.Location
+	jitter   time.Duration
+	locker   cron.Locker
+	overlap  bool
+	running  sync.Mutex
+}
+
+// Schedule runs fn whenever the cron expression spec is due, see
+// cron.Parse, while the App is being served. Runs that are due while
+// the last one is still going are skipped, unless AllowOverlap is set.
+// It panics if spec is invalid.
+//
+//	app.Schedule("*/5 * * * *", func(ctx context.Context) error {
+//		return reports.Refresh(ctx)
+//	}).In(tz).Jitter(30 * time.Second)
+//
+//	// with many instances of the App, only one runs each
+//	app.Schedule("@daily", cleanup).Named("cleanup").Lock(cron.RedisLocker(conn, "cron:"))
+func (a *App) Schedule(spec string, fn ScheduledFunc) *ScheduledTask {
+	t := &ScheduledTask{
+		Name:     spec,
+		Spec:     spec,
+		schedule: cron.MustParse(spec),
+		fn:       fn,
+		loc:      time.Local,
+	}
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.moot.Lock()
+	root.scheduled = append(root.scheduled, t)
+	root.moot.Unlock()
+	return t
+}
+
+// Named names the task, for the logs, and the Lock.
+func (t *ScheduledTask) Named(name string) *ScheduledTask {
+	t.Name = name
+	return t
+}
+
+// In sets the time zone the cron expression is read in, default is
+// the local time zone.
+func (t *ScheduledTask) In(loc *time.Location) *ScheduledTask {
+	t.loc = loc
+	return t
+}
+
+// Jitter delays each run by a random duration, up to d, so many
+// instances, or tasks, don't all start at once.
+func (t *ScheduledTask) Jitter(d time.Duration) *ScheduledTask {
+	t.jitter = d
+	return t
+}
+
+// Lock makes sure only one instance of the App runs each run of the
+// task, see cron.Locker. The Name of the task must be the same across
+// instances, and unique.
+func (t *ScheduledTask) Lock(l cron.Locker) *ScheduledTask {
+	t.locker = l
+	return t
+}
+
+// AllowOverlap lets a run start while the last one is still going.
+func (t *ScheduledTask) AllowOverlap() *ScheduledTask {
+	t.overlap = true
+	return t
+}
+
+// runScheduled runs the ScheduledTasks of the App until ctx is done, the
+// returned function waits for the runs in progress.
+func (a *App) runScheduled(ctx context.Context) func() {
+	a.moot.Lock()
+	tasks := append([]*ScheduledTask{}, a.scheduled...)
+	a.moot.Unlock()
+	wg := &sync.WaitGroup{}
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t *ScheduledTask) {
+			defer wg.Done()
+			t.loop(ctx, a.Logger, wg)
+		}(t)
+	}
+	return wg.Wait
+}
+
+func (t *ScheduledTask) loop(ctx context.Context, logger Logger, wg *sync.WaitGroup) {
+	for {
+		next := t.schedule.Next(time.Now().In(t.loc))
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.run(ctx, logger.WithField("task", t.Name), next)
+		}()
+	}
+}
+
+// run the task for the time it was due.
+func (t *ScheduledTask) run(ctx context.Context, logger Logger, due time.Time) {
+	if !t.overlap {
+		if !t.running.TryLock() {
+			logger.Warn("skipping scheduled task, the last run is still going")
+			return
+		}
+		defer t.running.Unlock()
+	}
+	if t.jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(rand.Int63n(int64(t.jitter)))):
+		}
+	}
+	if t.locker != nil {
+		key := fmt.Sprintf("%s:%d", t.Name, due.Unix())
+		ok, err := t.locker.Acquire(key, t.lockTTL(due))
+		if err != nil {
+			logger.Errorf("could not lock scheduled task: %v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("scheduled task panicked: %v", r)
+			}
+		}()
+		return t.fn(ctx)
+	}()
+	logger = logger.WithField("duration", time.Since(start))
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	logger.Debug("ran scheduled task")
+}
+
+// lockTTL keeps the lock of a run until the next run is due, so slow
+// clocks on other instances can't run it again.
+func (t *ScheduledTask) lockTTL(due time.Time) time.Duration {
+	ttl := t.schedule.Next(due).Sub(due)
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return ttl
+}
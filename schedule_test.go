@@ -0,0 +1,123 @@
+package buffalo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testLocker struct {
+	moot sync.Mutex
+	keys map[string]time.Duration
+}
+
+func (l *testLocker) Acquire(key string, ttl time.Duration) (bool, error) {
+	l.moot.Lock()
+	defer l.moot.Unlock()
+	if _, ok := l.keys[key]; ok {
+		return false, nil
+	}
+	l.keys[key] = ttl
+	return true, nil
+}
+
+func Test_App_Schedule(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	g := a.Group("/admin")
+	tz, err := time.LoadLocation("America/New_York")
+	r.NoError(err)
+	st := g.Schedule("*/5 * * * *", func(ctx context.Context) error {
+		return nil
+	}).Named("refresh").In(tz).Jitter(time.Second)
+	r.Equal("refresh", st.Name)
+	r.Equal(tz, st.loc)
+	r.Equal([]*ScheduledTask{st}, a.scheduled)
+
+	r.Panics(func() {
+		a.Schedule("* * *", func(ctx context.Context) error { return nil })
+	})
+}
+
+func Test_ScheduledTask_Overlap(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	release := make(chan struct{})
+	runs := make(chan struct{}, 2)
+	task := func(ctx context.Context) error {
+		runs <- struct{}{}
+		<-release
+		return nil
+	}
+	st := a.Schedule("@hourly", task)
+	due := time.Now()
+	go st.run(context.Background(), a.Logger, due)
+	<-runs
+	// the first run is still going, so this one is skipped
+	st.run(context.Background(), a.Logger, due.Add(time.Hour))
+	r.Len(runs, 0)
+
+	st = a.Schedule("@hourly", task).AllowOverlap()
+	go st.run(context.Background(), a.Logger, due)
+	go st.run(context.Background(), a.Logger, due.Add(time.Hour))
+	<-runs
+	<-runs
+	close(release)
+}
+
+func Test_ScheduledTask_Lock(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	l := &testLocker{keys: map[string]time.Duration{}}
+	runs := 0
+	st := a.Schedule("@hourly", func(ctx context.Context) error {
+		runs++
+		return errors.New("logged, not returned")
+	}).Named("report").Lock(l)
+
+	due := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	// two instances of the App, due at the same time
+	st.run(context.Background(), a.Logger, due)
+	st.run(context.Background(), a.Logger, due)
+	r.Equal(1, runs)
+	r.Equal(time.Hour, l.keys["report:1488369600"])
+
+	st.run(context.Background(), a.Logger, due.Add(time.Hour))
+	r.Equal(2, runs)
+}
+
+func Test_ScheduledTask_Panic(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	st := a.Schedule("@hourly", func(ctx context.Context) error {
+		panic("boom")
+	})
+	r.NotPanics(func() {
+		st.run(context.Background(), a.Logger, time.Now())
+	})
+	// the panic doesn't leave the task marked as running
+	r.True(st.running.TryLock())
+}
+
+func Test_ScheduledTask_Jitter_Canceled(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	ran := false
+	st := a.Schedule("@hourly", func(ctx context.Context) error {
+		ran = true
+		return nil
+	}).Jitter(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	st.run(ctx, a.Logger, time.Now())
+	r.False(ran)
+}
@@ -0,0 +1,504 @@
+package buffalo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONSchema is a JSON Schema document. The keywords that requests are
+// validated against are "type", "properties", "required", "items",
+// "enum", "minLength", "maxLength", "pattern", "format" ("email" and
+// "date-time"), "minimum", "maximum", "minItems", and "maxItems".
+type JSONSchema map[string]interface{}
+
+// RouteSchema describes the requests a route accepts. Requests that
+// don't match it are rejected with a 422 and ValidationErrors, after the
+// middleware has run, but before the route's handler.
+type RouteSchema struct {
+	// Body is the schema of the JSON, or form, request body. It is either
+	// a JSONSchema or a struct, see SchemaFor.
+	Body interface{}
+	// Query is the schema of the query params, a JSONSchema or a struct.
+	Query interface{}
+	// Doc and Responses document the route for OpenAPI, see
+	// RouteInfo.Doc and RouteInfo.Returns.
+	Doc       string
+	Responses map[int]interface{}
+}
+
+// Body sets the schema of the route's request body, see RouteSchema.
+/*
+	type NewUser struct {
+		Name  string `json:"name" validate:"required,max=50"`
+		Email string `json:"email" validate:"required,email"`
+		Age   int    `json:"age" validate:"min=18"`
+	}
+
+	a.POST("/users", UsersCreate).Body(NewUser{})
+	a.GET("/users", UsersList).Query(buffalo.JSONSchema{
+		"type": "object",
+		"properties": buffalo.JSONSchema{
+			"page": buffalo.JSONSchema{"type": "integer", "minimum": 1},
+		},
+	})
+*/
+func (ri RouteInfo) Body(schema interface{}) RouteInfo {
+	if ri.schema != nil {
+		ri.schema.Body = schema
+	}
+	return ri
+}
+
+// Query sets the schema of the route's query params, see RouteSchema.
+func (ri RouteInfo) Query(schema interface{}) RouteInfo {
+	if ri.schema != nil {
+		ri.schema.Query = schema
+	}
+	return ri
+}
+
+// Schema returns the RouteSchema of the route.
+func (ri RouteInfo) Schema() RouteSchema {
+	if ri.schema == nil {
+		return RouteSchema{}
+	}
+	return *ri.schema
+}
+
+// ValidationErrors are the problems with a request, keyed by the path of
+// the param or field, such as "email" or "address.city".
+type ValidationErrors map[string][]string
+
+// Add a problem with the field.
+func (v ValidationErrors) Add(field, msg string) {
+	v[field] = append(v[field], msg)
+}
+
+func (v ValidationErrors) Error() string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	msgs := []string{}
+	for _, k := range keys {
+		for _, m := range v[k] {
+			msgs = append(msgs, k+" "+m)
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// handler validates the request against the schema before calling h.
+func (rs *RouteSchema) handler(h Handler) Handler {
+	return func(c Context) error {
+		if rs.Body == nil && rs.Query == nil {
+			return h(c)
+		}
+		errs := ValidationErrors{}
+		req := c.Request()
+		if rs.Query != nil {
+			s := SchemaFor(rs.Query)
+			validateSchema(s, valuesFor(s, req.URL.Query(), errs), "", errs)
+		}
+		if rs.Body != nil && req.Body != nil {
+			s := SchemaFor(rs.Body)
+			n := len(errs)
+			var v interface{}
+			if isJSON(strings.ToLower(req.Header.Get("Content-Type"))) {
+				b, err := io.ReadAll(req.Body)
+				if err != nil {
+					return c.Error(400, err)
+				}
+				req.Body = io.NopCloser(bytes.NewReader(b))
+				if len(bytes.TrimSpace(b)) > 0 {
+					if err := json.Unmarshal(b, &v); err != nil {
+						errs.Add("body", "is not valid JSON")
+					}
+				}
+			} else {
+				if err := req.ParseForm(); err != nil {
+					return c.Error(400, err)
+				}
+				v = valuesFor(s, req.PostForm, errs)
+			}
+			if len(errs) == n {
+				validateSchema(s, v, "", errs)
+			}
+		}
+		if len(errs) > 0 {
+			return c.Error(422, errs)
+		}
+		return h(c)
+	}
+}
+
+// ValidationErrorHandler is the default ErrorHandler for 422 responses.
+// When the error is ValidationErrors it responds with them, as JSON, if
+// the request was JSON, or as text. Other errors are handled by the
+// default ErrorHandler.
+func ValidationErrorHandler(status int, err error, c Context) error {
+	ve, ok := errorCause(err).(ValidationErrors)
+	if !ok {
+		return defaultErrorHandler(status, err, c)
+	}
+	res := c.Response()
+	if isJSON(strings.ToLower(c.Request().Header.Get("Content-Type"))) {
+		m := errorJSON(ve.Error(), status, errorReferences(c))
+		m["errors"] = ve
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(status)
+		return json.NewEncoder(res).Encode(m)
+	}
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	res.WriteHeader(status)
+	_, err = res.Write([]byte(strings.Replace(ve.Error(), "; ", "\n", -1)))
+	return err
+}
+
+// errorCause unwraps err down to the error it was made from.
+func errorCause(err error) error {
+	for {
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok || u.Unwrap() == nil {
+			return err
+		}
+		err = u.Unwrap()
+	}
+}
+
+var schemaCache = &sync.Map{}
+
+// SchemaFor returns the JSONSchema of v. A JSONSchema, or a map, is
+// returned as is. The schema of a struct is built from its fields, using
+// their "json" tags (or "schema" tags, for query params) for names, and
+// their "validate" tags for the rules. The rules are "required",
+// "min=N" and "max=N" (the length of strings and arrays, or the value
+// of numbers), "email", "oneof=a b c", and "pattern=regexp".
+func SchemaFor(v interface{}) JSONSchema {
+	switch s := v.(type) {
+	case JSONSchema:
+		return s
+	case map[string]interface{}:
+		return JSONSchema(s)
+	}
+	t := reflect.TypeOf(v)
+	if s, ok := schemaCache.Load(t); ok {
+		return s.(JSONSchema)
+	}
+	s := schemaForType(t)
+	schemaCache.Store(t, s)
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaForType(t reflect.Type) JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return JSONSchema{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return JSONSchema{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return JSONSchema{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return JSONSchema{"type": "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return JSONSchema{"type": "array", "items": schemaForType(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return JSONSchema{"type": "object"}
+	case t.Kind() != reflect.Struct:
+		return JSONSchema{}
+	}
+	props := JSONSchema{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		fs := schemaForType(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			k, arg := rule, ""
+			if i := strings.Index(rule, "="); i >= 0 {
+				k, arg = rule[:i], rule[i+1:]
+			}
+			switch k {
+			case "required":
+				required = append(required, name)
+			case "email":
+				fs["format"] = "email"
+			case "pattern":
+				fs["pattern"] = arg
+			case "oneof":
+				enum := []interface{}{}
+				for _, e := range strings.Fields(arg) {
+					enum = append(enum, e)
+				}
+				fs["enum"] = enum
+			case "min", "max":
+				n, err := strconv.ParseFloat(arg, 64)
+				if err != nil {
+					continue
+				}
+				fs[limitKeyword(fs["type"], k)] = n
+			}
+		}
+		props[name] = fs
+	}
+	s := JSONSchema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "schema"} {
+		if n := strings.Split(f.Tag.Get(tag), ",")[0]; n != "" {
+			return n
+		}
+	}
+	return f.Name
+}
+
+// limitKeyword is the JSON Schema keyword for a "min" or "max" rule
+// of a field of the type.
+func limitKeyword(typ interface{}, k string) string {
+	switch typ {
+	case "string":
+		return k + "Length"
+	case "array":
+		return k + "Items"
+	}
+	return k + "imum"
+}
+
+// valuesFor converts query, or form, values to the types of the
+// schema's properties, so they can be validated like JSON.
+func valuesFor(s JSONSchema, vals url.Values, errs ValidationErrors) map[string]interface{} {
+	m := map[string]interface{}{}
+	for name, p := range asSchema(s["properties"]) {
+		vv, ok := vals[name]
+		if !ok || len(vv) == 0 {
+			continue
+		}
+		ps := asSchema(p)
+		if ps["type"] == "array" {
+			items := []interface{}{}
+			for _, v := range vv {
+				if x, ok := convertValue(asSchema(ps["items"]), v); ok {
+					items = append(items, x)
+				} else {
+					errs.Add(name, "must be an array of "+fmt.Sprint(asSchema(ps["items"])["type"]))
+				}
+			}
+			m[name] = items
+			continue
+		}
+		if x, ok := convertValue(ps, vv[0]); ok {
+			m[name] = x
+		} else {
+			errs.Add(name, "must be "+article(fmt.Sprint(ps["type"])))
+		}
+	}
+	return m
+}
+
+func convertValue(s JSONSchema, v string) (interface{}, bool) {
+	switch s["type"] {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return v, true
+}
+
+var emailRx = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+var patterns = &sync.Map{}
+
+// validateSchema adds the ways v doesn't match s to errs.
+func validateSchema(s JSONSchema, v interface{}, path string, errs ValidationErrors) {
+	field := path
+	if field == "" {
+		field = "body"
+	}
+	if typ, ok := s["type"].(string); ok && !isType(v, typ) {
+		errs.Add(field, "must be "+article(typ))
+		return
+	}
+	if enum, ok := s["enum"]; ok {
+		found := false
+		opts := []string{}
+		for _, e := range asSlice(enum) {
+			opts = append(opts, fmt.Sprint(e))
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+			}
+		}
+		if !found {
+			errs.Add(field, "must be one of "+strings.Join(opts, ", "))
+		}
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for _, r := range asSlice(s["required"]) {
+			if _, ok := x[fmt.Sprint(r)]; !ok {
+				errs.Add(join(path, fmt.Sprint(r)), "is required")
+			}
+		}
+		for name, p := range asSchema(s["properties"]) {
+			if pv, ok := x[name]; ok {
+				validateSchema(asSchema(p), pv, join(path, name), errs)
+			}
+		}
+	case []interface{}:
+		if n, ok := asFloat(s["minItems"]); ok && float64(len(x)) < n {
+			errs.Add(field, fmt.Sprintf("must have at least %v items", n))
+		}
+		if n, ok := asFloat(s["maxItems"]); ok && float64(len(x)) > n {
+			errs.Add(field, fmt.Sprintf("must have at most %v items", n))
+		}
+		if items, ok := s["items"]; ok {
+			for i, iv := range x {
+				validateSchema(asSchema(items), iv, fmt.Sprintf("%s[%d]", field, i), errs)
+			}
+		}
+	case string:
+		l := float64(len([]rune(x)))
+		if n, ok := asFloat(s["minLength"]); ok && l < n {
+			errs.Add(field, fmt.Sprintf("must be at least %v characters", n))
+		}
+		if n, ok := asFloat(s["maxLength"]); ok && l > n {
+			errs.Add(field, fmt.Sprintf("must be at most %v characters", n))
+		}
+		if p, ok := s["pattern"].(string); ok {
+			rx, ok := patterns.Load(p)
+			if !ok {
+				rx = regexp.MustCompile(p)
+				patterns.Store(p, rx)
+			}
+			if !rx.(*regexp.Regexp).MatchString(x) {
+				errs.Add(field, "must match "+p)
+			}
+		}
+		switch s["format"] {
+		case "email":
+			if !emailRx.MatchString(x) {
+				errs.Add(field, "must be an email address")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, x); err != nil {
+				errs.Add(field, "must be an RFC 3339 date-time")
+			}
+		}
+	case float64:
+		if n, ok := asFloat(s["minimum"]); ok && x < n {
+			errs.Add(field, fmt.Sprintf("must be at least %v", n))
+		}
+		if n, ok := asFloat(s["maximum"]); ok && x > n {
+			errs.Add(field, fmt.Sprintf("must be at most %v", n))
+		}
+	}
+}
+
+func isType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	case "null":
+		return typ
+	}
+	return "a " + typ
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func asSchema(v interface{}) JSONSchema {
+	switch s := v.(type) {
+	case JSONSchema:
+		return s
+	case map[string]interface{}:
+		return JSONSchema(s)
+	}
+	return JSONSchema{}
+}
+
+func asSlice(v interface{}) []interface{} {
+	switch s := v.(type) {
+	case []interface{}:
+		return s
+	case []string:
+		out := make([]interface{}, len(s))
+		for i, x := range s {
+			out[i] = x
+		}
+		return out
+	}
+	return nil
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package buffalo
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+type newUser struct {
+	Name  string   `json:"name" validate:"required,max=10"`
+	Email string   `json:"email" validate:"required,email"`
+	Age   int      `json:"age" validate:"min=18"`
+	Role  string   `json:"role" validate:"oneof=admin member"`
+	Tags  []string `json:"tags" validate:"max=2"`
+	Notes *string  `json:"notes,omitempty"`
+	skip  string
+}
+
+func schemaApp() *App {
+	a := New(Options{})
+	a.POST("/users", func(c Context) error {
+		u := &newUser{}
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		return c.Render(201, nil)
+	}).Body(newUser{})
+	a.GET("/users", voidHandler).Query(JSONSchema{
+		"type": "object",
+		"properties": JSONSchema{
+			"page": JSONSchema{"type": "integer", "minimum": 1},
+			"sort": JSONSchema{"type": "string", "enum": []interface{}{"name", "age"}},
+		},
+	})
+	return a
+}
+
+func Test_RouteSchema_Body(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(schemaApp())
+
+	res := w.JSON("/users").Post(map[string]interface{}{
+		"name":  "mark",
+		"email": "mark@example.com",
+		"age":   30,
+		"role":  "admin",
+		"tags":  []string{"a"},
+	})
+	r.Equal(201, res.Code)
+
+	res = w.JSON("/users").Post(map[string]interface{}{
+		"name": "a very long name",
+		"age":  12,
+		"role": "owner",
+		"tags": []string{"a", "b", "c"},
+	})
+	r.Equal(422, res.Code)
+	body := struct {
+		Code   int              `json:"code"`
+		Errors ValidationErrors `json:"errors"`
+	}{}
+	r.NoError(json.NewDecoder(res.Body).Decode(&body))
+	r.Equal(422, body.Code)
+	r.Equal([]string{"is required"}, body.Errors["email"])
+	r.Equal([]string{"must be at most 10 characters"}, body.Errors["name"])
+	r.Equal([]string{"must be at least 18"}, body.Errors["age"])
+	r.Equal([]string{"must be one of admin, member"}, body.Errors["role"])
+	r.Equal([]string{"must have at most 2 items"}, body.Errors["tags"])
+
+	fres := w.Request("/users").Post(url.Values{"name": {"mark"}, "email": {"nope"}})
+	r.Equal(422, fres.Code)
+	r.Contains(fres.Body.String(), "email must be an email address")
+}
+
+func Test_RouteSchema_Query(t *testing.T) {
+	r := require.New(t)
+	w := willie.New(schemaApp())
+
+	r.Equal(200, w.Request("/users?page=2&sort=name").Get().Code)
+
+	res := w.Request("/users?page=zero").Get()
+	r.Equal(422, res.Code)
+	r.Equal("page must be an integer", strings.TrimSpace(res.Body.String()))
+
+	res = w.Request("/users?page=0&sort=email").Get()
+	r.Equal(422, res.Code)
+	r.Contains(res.Body.String(), "page must be at least 1")
+	r.Contains(res.Body.String(), "sort must be one of name, age")
+}
+
+func Test_SchemaFor(t *testing.T) {
+	r := require.New(t)
+	s := SchemaFor(newUser{})
+	r.Equal("object", s["type"])
+	r.Equal([]string{"name", "email"}, s["required"])
+
+	props := s["properties"].(JSONSchema)
+	r.Len(props, 6)
+	r.Equal(JSONSchema{"type": "string", "maxLength": float64(10)}, props["name"])
+	r.Equal(JSONSchema{"type": "integer", "minimum": float64(18)}, props["age"])
+	r.Equal(JSONSchema{"type": "string"}, props["notes"])
+	r.Equal(JSONSchema{"type": "array", "items": JSONSchema{"type": "string"}, "maxItems": float64(2)}, props["tags"])
+}
@@ -0,0 +1,267 @@
+package buffalo
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// ShutdownHook is run when the App is stopped, after the server has
+// stopped taking requests. It should give up when the context is done.
+type ShutdownHook func(context.Context) error
+
+type shutdownHook struct {
+	name string
+	fn   ShutdownHook
+}
+
+// ShutdownError holds the errors from stopping the server, and from the
+// ShutdownHooks, when the App is stopped.
+type ShutdownError struct {
+	Errors []error
+}
+
+func (e ShutdownError) Error() string {
+	msgs := []string{}
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// server is the state of an App being served.
+type server struct {
+	moot    *sync.Mutex
+	hooks   []shutdownHook
+	stop    context.CancelFunc
+	done    chan struct{}
+	closing chan struct{}
+	sockets map[*websocket.Conn]bool
+}
+
+// stopping returns a channel that is closed when the App starts to
+// shut down, or nil if it isn't being served.
+func (a *App) stopping() <-chan struct{} {
+	s := a.server()
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	return s.closing
+}
+
+func (a *App) server() *server {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	if root.parent != nil {
+		return root.parent.server()
+	}
+	root.moot.Lock()
+	defer root.moot.Unlock()
+	if root.srv == nil {
+		root.srv = &server{moot: &sync.Mutex{}}
+	}
+	return root.srv
+}
+
+// OnShutdown registers a hook that is run when the App is stopped.
+// Hooks are run in the reverse order they were registered, so
+// something registered after the things it depends on is shut down
+// before them.
+/*
+	a.OnShutdown("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+*/
+func (a *App) OnShutdown(name string, fn ShutdownHook) {
+	s := a.server()
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Serve the App on the Addr option, which can also be a unix socket or
+// a socket passed by systemd, until the context is done, the
+// process receives a SIGINT or SIGTERM, or Stop is called. The server
+// then stops taking new connections, waits up to ShutdownTimeout for
+// the requests in flight, and scheduled tasks, stops the Worker, and
+// runs the ShutdownHooks.
+// Websockets are closed with websocket.CloseGoingAway, and EventStreams
+// are Done. Any errors from stopping are returned as a ShutdownError.
+/*
+	if err := a.Serve(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	// behind nginx
+	a := buffalo.Automatic(buffalo.Options{
+		Addr:       "unix:/run/myapp/myapp.sock",
+		SocketMode: 0660,
+	})
+
+	// with systemd socket activation
+	a := buffalo.Automatic(buffalo.Options{Addr: "systemd"})
+*/
+func (a *App) Serve(ctx context.Context) error {
+	ln, err := a.listen(a.Addr)
+	if err != nil {
+		return err
+	}
+	return a.ServeListener(ctx, ln)
+}
+
+// ServeListener is like Serve, but serves the App on the Listener.
+func (a *App) ServeListener(ctx context.Context, ln net.Listener) error {
+	return a.serve(ctx, ln, nil, nil)
+}
+
+// serve the App on the Listener, with TLS if there is a config, and
+// with a server on the RedirectAddr if there is a redirect handler.
+func (a *App) serve(ctx context.Context, ln net.Listener, cfg *tls.Config, redirect http.Handler) error {
+	s := a.server()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s.moot.Lock()
+	if s.done != nil {
+		s.moot.Unlock()
+		ln.Close()
+		return errors.New("app is already being served")
+	}
+	s.stop = stop
+	s.done = make(chan struct{})
+	s.closing = make(chan struct{})
+	closing := s.closing
+	s.moot.Unlock()
+	defer func() {
+		s.moot.Lock()
+		close(s.done)
+		s.stop = nil
+		s.done = nil
+		s.closing = nil
+		s.moot.Unlock()
+	}()
+
+	// the worker gets its own context, rather than ctx, so it keeps
+	// running while the requests in flight, which may enqueue jobs, are
+	// drained. It is stopped once they are.
+	wctx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	if err := a.Worker.Start(wctx); err != nil {
+		ln.Close()
+		return errors.WithStack(err)
+	}
+	tctx, stopScheduled := context.WithCancel(context.Background())
+	waitScheduled := a.runScheduled(tctx)
+	defer stopScheduled()
+
+	srv := &http.Server{
+		Handler:      a,
+		TLSConfig:    cfg,
+		ReadTimeout:  a.ReadTimeout,
+		WriteTimeout: a.WriteTimeout,
+		IdleTimeout:  a.IdleTimeout,
+	}
+	errs := make(chan error, 2)
+	go func() {
+		if cfg != nil {
+			errs <- srv.ServeTLS(ln, "", "")
+			return
+		}
+		errs <- srv.Serve(ln)
+	}()
+	a.Logger.Infof("Starting application at %s", ln.Addr())
+	AppStarted.Emit(a)
+	defer AppStopped.Emit(a)
+
+	var rsrv *http.Server
+	if redirect != nil {
+		rln, err := a.listen(a.RedirectAddr)
+		if err != nil {
+			srv.Close()
+			return err
+		}
+		rsrv = &http.Server{Handler: redirect}
+		go func() {
+			errs <- rsrv.Serve(rln)
+		}()
+		a.Logger.Infof("Redirecting %s to HTTPS", rln.Addr())
+	}
+
+	select {
+	case err := <-errs:
+		srv.Close()
+		if rsrv != nil {
+			rsrv.Close()
+		}
+		stopScheduled()
+		waitScheduled()
+		a.Worker.Stop()
+		if err != http.ErrServerClosed {
+			return errors.WithStack(err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	a.Logger.Info("Shutting down application")
+	sctx, cancel := context.WithTimeout(context.Background(), a.ShutdownTimeout)
+	defer cancel()
+
+	se := ShutdownError{}
+	close(closing)
+	a.closeSockets()
+	if err := srv.Shutdown(sctx); err != nil {
+		se.Errors = append(se.Errors, errors.Wrap(err, "could not drain requests"))
+	}
+	if rsrv != nil {
+		if err := rsrv.Shutdown(sctx); err != nil {
+			se.Errors = append(se.Errors, errors.Wrap(err, "could not stop redirecting"))
+		}
+	}
+	// the requests, and scheduled tasks, are done, so nothing else
+	// will be enqueued
+	stopScheduled()
+	waitScheduled()
+	if err := a.Worker.Stop(); err != nil {
+		se.Errors = append(se.Errors, errors.Wrap(err, "could not stop the worker"))
+	}
+
+	s.moot.Lock()
+	hooks := append([]shutdownHook{}, s.hooks...)
+	s.moot.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.fn(sctx); err != nil {
+			se.Errors = append(se.Errors, errors.Wrapf(err, "shutdown hook %q failed", h.name))
+		}
+	}
+	if len(se.Errors) > 0 {
+		return se
+	}
+	return nil
+}
+
+// Stop the App being served by Serve, and wait for it to shut down.
+// It is an error if the App isn't being served.
+func (a *App) Stop() error {
+	s := a.server()
+	s.moot.Lock()
+	stop, done := s.stop, s.done
+	s.moot.Unlock()
+	if stop == nil {
+		return errors.New("app is not being served")
+	}
+	stop()
+	<-done
+	return nil
+}
@@ -0,0 +1,172 @@
+package buffalo
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_App_Serve_Stop(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	started := make(chan struct{})
+	a.GET("/slow", func(c Context) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return c.Render(200, render.String("done"))
+	})
+
+	log := []string{}
+	a.OnShutdown("db", func(ctx context.Context) error {
+		log = append(log, "db")
+		return errors.New("boom")
+	})
+	a.OnShutdown("workers", func(ctx context.Context) error {
+		log = append(log, "workers")
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(context.Background(), ln)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	r.NoError(a.Stop())
+	r.Equal("done", <-body)
+
+	err = <-served
+	r.Error(err)
+	se, ok := err.(ShutdownError)
+	r.True(ok)
+	r.Len(se.Errors, 1)
+	r.Contains(err.Error(), `shutdown hook "db" failed: boom`)
+	r.Equal([]string{"workers", "db"}, log)
+
+	r.Error(a.Stop())
+}
+
+func Test_App_Serve_Context(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+	cancel()
+	r.NoError(<-served)
+}
+
+type testWorker struct {
+	*worker.Simple
+	log *[]string
+}
+
+func (w testWorker) Start(ctx context.Context) error {
+	*w.log = append(*w.log, "start")
+	return w.Simple.Start(ctx)
+}
+
+func (w testWorker) Stop() error {
+	*w.log = append(*w.log, "stop")
+	return w.Simple.Stop()
+}
+
+func Test_App_Serve_Worker(t *testing.T) {
+	r := require.New(t)
+
+	log := []string{}
+	a := New(Options{Worker: testWorker{Simple: worker.NewSimple(), log: &log}})
+	a.OnShutdown("db", func(ctx context.Context) error {
+		log = append(log, "db")
+		return nil
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	r.NoError(<-served)
+	// the worker is stopped before the hooks, which may close what its
+	// jobs use
+	r.Equal([]string{"start", "stop", "db"}, log)
+}
+
+func Test_App_Serve_Worker_Drain(t *testing.T) {
+	r := require.New(t)
+
+	w := worker.NewSimple()
+	performed := make(chan string, 1)
+	r.NoError(w.Register("send_email", func(args worker.Args) error {
+		performed <- args["to"].(string)
+		return nil
+	}))
+	a := New(Options{Worker: w})
+	started := make(chan struct{})
+	a.GET("/", func(c Context) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		// the server is shutting down, but the worker is still running
+		if err := a.Worker.Perform(worker.Job{Handler: "send_email", Args: worker.Args{"to": "mark"}}); err != nil {
+			return err
+		}
+		return c.Render(200, render.String("sent"))
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+	res := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			res <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		res <- string(b)
+	}()
+	<-started
+	cancel()
+	r.Equal("sent", <-res)
+	r.NoError(<-served)
+	r.Equal("mark", <-performed)
+}
@@ -3,44 +3,220 @@ package buffalo
 import (
 	"net/http"
 
+	"github.com/gobuffalo/buffalo/sessionstore"
 	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
 )
 
 // Session wraps the "github.com/gorilla/sessions" API
 // in something a little cleaner and a bit more useable.
+//
+// A Session that has been changed, through its methods, is saved
+// before the response is written, so handlers don't need to call Save.
+// Changes made to Session.Values directly must be saved by hand.
 type Session struct {
 	Session *sessions.Session
 	req     *http.Request
 	res     http.ResponseWriter
+	changed bool
+	// previousID is the ID the session had before Regenerate.
+	previousID string
 }
 
 // Save the current session.
 func (s *Session) Save() error {
+	s.changed = false
+	if s.previousID != "" {
+		if _, ok := s.Session.Store().(*sessionstore.Store); ok {
+			s.Session.Values[sessionstore.PreviousIDKey] = s.previousID
+		}
+		s.previousID = ""
+	}
 	return s.Session.Save(s.req, s.res)
 }
 
+// copy returns a Session with its own copy of the values, that is
+// saved to res, see DefaultContext.Isolate.
+func (s *Session) copy(res http.ResponseWriter) *Session {
+	gs := sessions.NewSession(s.Session.Store(), s.Session.Name())
+	gs.ID = s.Session.ID
+	gs.IsNew = s.Session.IsNew
+	if s.Session.Options != nil {
+		opts := *s.Session.Options
+		gs.Options = &opts
+	}
+	for k, v := range s.Session.Values {
+		gs.Values[k] = v
+	}
+	return &Session{
+		Session:    gs,
+		req:        s.req,
+		res:        res,
+		changed:    s.changed,
+		previousID: s.previousID,
+	}
+}
+
+// merge takes the values, and ID, of a copy back.
+func (s *Session) merge(c *Session) {
+	s.Session.ID = c.Session.ID
+	s.Session.IsNew = c.Session.IsNew
+	s.Session.Options = c.Session.Options
+	s.Session.Values = c.Session.Values
+	s.changed = c.changed
+	s.previousID = c.previousID
+}
+
 // Get a value from the current session.
 func (s *Session) Get(name interface{}) interface{} {
 	return s.Session.Values[name]
 }
 
+// GetString returns the value of name, if it is a string.
+func (s *Session) GetString(name interface{}) (string, bool) {
+	return SessionValue[string](s, name)
+}
+
+// GetInt returns the value of name, if it is a number. Stores that
+// serialize to JSON give back float64s, they count as long as they are
+// whole.
+func (s *Session) GetInt(name interface{}) (int, bool) {
+	i, ok := s.GetInt64(name)
+	return int(i), ok
+}
+
+// GetInt64 returns the value of name, if it is a number, see GetInt.
+func (s *Session) GetInt64(name interface{}) (int64, bool) {
+	switch v := s.Get(name).(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// GetBool returns the value of name, if it is a bool.
+func (s *Session) GetBool(name interface{}) (bool, bool) {
+	return SessionValue[bool](s, name)
+}
+
+// SessionValue returns the value of name in the Session, if it is a T.
+/*
+	cart, ok := buffalo.SessionValue[*Cart](c.Session(), "cart")
+*/
+func SessionValue[T any](s *Session, name interface{}) (T, bool) {
+	v, ok := s.Get(name).(T)
+	return v, ok
+}
+
 // Set a value onto the current session. If a value with that name
 // already exists it will be overridden with the new value.
 func (s *Session) Set(name, value interface{}) {
 	s.Session.Values[name] = value
+	s.changed = true
 }
 
 // Delete a value from the current session.
 func (s *Session) Delete(name interface{}) {
 	delete(s.Session.Values, name)
+	s.changed = true
+}
+
+// Clear deletes all the values, and flash messages, from the session,
+// for logging out.
+func (s *Session) Clear() {
+	for k := range s.Session.Values {
+		delete(s.Session.Values, k)
+	}
+	s.changed = true
+}
+
+// Regenerate gives the session a new ID, keeping its values. Call it
+// whenever the privileges of the session change, such as logging in or
+// out, so an ID planted, or leaked, beforehand is worth nothing, see
+// session fixation. Stores that keep sessions on the server issue the
+// new ID when the session is saved, and a sessionstore.Store deletes the
+// old one then, so it can't be used again.
+/*
+	func Login(c buffalo.Context) error {
+		...
+		c.Session().Regenerate()
+		c.Session().Set("current_user_id", u.ID)
+		return c.Redirect(303, "/")
+	}
+*/
+func (s *Session) Regenerate() {
+	if s.previousID == "" {
+		s.previousID = s.Session.ID
+	}
+	s.Session.ID = ""
+	s.Session.IsNew = true
+	s.changed = true
+}
+
+// Changed reports whether the session has been changed since it was
+// loaded, or last saved.
+func (s *Session) Changed() bool {
+	return s.changed
+}
+
+// AddFlash adds a flash message of the kind, such as "success", to be
+// shown on the next request. The session must be saved, as Redirect
+// does, for the message to be kept.
+func (s *Session) AddFlash(kind string, msg interface{}) {
+	s.Session.AddFlash(msg, kind)
+	s.changed = true
+}
+
+// Flashes returns the flash messages of the kind, and removes them from
+// the session.
+func (s *Session) Flashes(kind string) []interface{} {
+	f := s.Session.Flashes(kind)
+	if len(f) > 0 {
+		s.changed = true
+	}
+	return f
+}
+
+// saveSession saves the session of the request, if it has been
+// changed. It is called before the response is written.
+func (d *DefaultContext) saveSession() {
+	if d.session == nil || !d.session.changed {
+		return
+	}
+	if err := d.session.Save(); err != nil {
+		d.Logger().Error(errors.WithStack(err))
+	}
 }
 
 // Get a session using a request and response.
 func (a *App) getSession(r *http.Request, w http.ResponseWriter) *Session {
-	session, _ := a.SessionStore.Get(r, a.SessionName)
-	return &Session{
+	store, name := a.SessionStore, a.SessionName
+	if t := tenantFromRequest(r); t != nil {
+		if t.SessionStore != nil {
+			store = t.SessionStore
+		}
+		if t.SessionName != "" {
+			name = t.SessionName
+		}
+	}
+	session, _ := store.Get(r, name)
+	s := &Session{
 		Session: session,
 		req:     r,
 		res:     w,
 	}
+	a.secureSession(s, r)
+	return s
 }
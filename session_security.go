@@ -0,0 +1,148 @@
+package buffalo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/gorilla/sessions"
+)
+
+// SessionOptions harden the sessions of an App, see Options.Sessions.
+type SessionOptions struct {
+	// Cookie attributes of the session cookie. Default is HTTPOnly,
+	// SameSite Lax, and Secure in production, see CookieOptions.
+	Cookie CookieOptions
+	// IdleTimeout ends sessions that haven't been used for this long.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends sessions this long after they began, however
+	// much they are used.
+	AbsoluteTimeout time.Duration
+	// SlidingExpiration makes the session cookie expire this long after
+	// the session was last used, rather than after the MaxAge of the
+	// store. The cookie is sent again as the session is used, at most
+	// once a minute.
+	SlidingExpiration time.Duration
+	// BindIP ends sessions used from another IP than the one they began
+	// on, see Context.ClientIP. Clients on mobile networks change IPs
+	// often, so it is best kept for admin areas and the like.
+	BindIP bool
+	// BindUserAgent ends sessions used from another User-Agent than the
+	// one they began with.
+	BindUserAgent bool
+	// Secrets sign the sessions of the default cookie store. The first
+	// signs sessions, the others are still accepted, so the secret can
+	// be rotated without ending every session: add the new secret in
+	// front, and drop the old one once the sessions it signed have
+	// expired. Default is $SESSION_SECRET, and $SESSION_SECRET_PREVIOUS
+	// if it is set.
+	Secrets []string
+}
+
+// the session values that keep track of when, and where, sessions began.
+const (
+	sessionCreatedKey  = "_buffalo_created_at"
+	sessionSeenKey     = "_buffalo_seen_at"
+	sessionIPKey       = "_buffalo_ip"
+	sessionUAKey       = "_buffalo_ua"
+	sessionSeenRefresh = time.Minute
+)
+
+func sessionOptionsWithDefaults(so SessionOptions, env string) SessionOptions {
+	so.Cookie = cookieOptionsWithDefaults(so.Cookie, env)
+	if len(so.Secrets) == 0 {
+		so.Secrets = []string{envy.Get("SESSION_SECRET", "")}
+		if prev := envy.Get("SESSION_SECRET_PREVIOUS", ""); prev != "" {
+			so.Secrets = append(so.Secrets, prev)
+		}
+	}
+	return so
+}
+
+// newCookieStore returns a cookie store that signs sessions with the
+// first of the secrets, and accepts all of them.
+func newCookieStore(secrets []string) *sessions.CookieStore {
+	pairs := make([][]byte, 0, len(secrets)*2)
+	for _, s := range secrets {
+		pairs = append(pairs, []byte(s), nil)
+	}
+	return sessions.NewCookieStore(pairs...)
+}
+
+// secureSession applies the SessionOptions to a session that has been
+// loaded: its cookie gets their attributes, and it is emptied, and
+// given a new ID, if it has timed out, or is being used from another
+// client.
+func (a *App) secureSession(s *Session, r *http.Request) {
+	so := a.Sessions
+	so.Cookie = cookieOptionsWithDefaults(so.Cookie, a.Env())
+	opts := sessions.Options{}
+	if s.Session.Options != nil {
+		opts = *s.Session.Options
+	}
+	opts.Path = so.Cookie.Path
+	if so.Cookie.Domain != "" {
+		opts.Domain = so.Cookie.Domain
+	}
+	opts.Secure = so.Cookie.Secure
+	opts.HttpOnly = !so.Cookie.AllowJS
+	opts.SameSite = so.Cookie.SameSite
+	if so.SlidingExpiration > 0 {
+		opts.MaxAge = int(so.SlidingExpiration / time.Second)
+	}
+	s.Session.Options = &opts
+
+	now := time.Now()
+	ip, ua := "", ""
+	if so.BindIP {
+		if cip := ClientIP(r, a.TrustedProxies); cip != nil {
+			ip = cip.String()
+		}
+	}
+	if so.BindUserAgent {
+		sum := sha256.Sum256([]byte(r.UserAgent()))
+		ua = hex.EncodeToString(sum[:8])
+	}
+
+	created, ok := s.GetInt64(sessionCreatedKey)
+	if ok {
+		seen, _ := s.GetInt64(sessionSeenKey)
+		reason := ""
+		switch {
+		case so.AbsoluteTimeout > 0 && now.Sub(time.Unix(created, 0)) > so.AbsoluteTimeout:
+			reason = "absolute timeout"
+		case so.IdleTimeout > 0 && now.Sub(time.Unix(seen, 0)) > so.IdleTimeout:
+			reason = "idle timeout"
+		case so.SlidingExpiration > 0 && now.Sub(time.Unix(seen, 0)) > so.SlidingExpiration:
+			reason = "expired"
+		case so.BindIP && s.Get(sessionIPKey) != ip:
+			reason = "IP changed"
+		case so.BindUserAgent && s.Get(sessionUAKey) != ua:
+			reason = "User-Agent changed"
+		}
+		if reason == "" {
+			refresh := so.IdleTimeout > 0 || so.SlidingExpiration > 0
+			if refresh && now.Sub(time.Unix(seen, 0)) >= sessionSeenRefresh {
+				s.Set(sessionSeenKey, now.Unix())
+			}
+			return
+		}
+		a.Logger.WithField("reason", reason).Info("session ended")
+		s.Clear()
+		s.Regenerate()
+	}
+	// a new session isn't worth a cookie until something is put in it,
+	// so responses that don't use it, can be cached, see ResponseCache.
+	changed := s.changed
+	defer func() { s.changed = changed }()
+	s.Set(sessionCreatedKey, now.Unix())
+	s.Set(sessionSeenKey, now.Unix())
+	if so.BindIP {
+		s.Set(sessionIPKey, ip)
+	}
+	if so.BindUserAgent {
+		s.Set(sessionUAKey, ua)
+	}
+}
@@ -0,0 +1,123 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sessionSecurityApp(so SessionOptions) *App {
+	return sessionSecurityAppIn("test", so)
+}
+
+func sessionSecurityAppIn(env string, so SessionOptions) *App {
+	a := New(Options{Env: env, Sessions: so})
+	a.GET("/login", func(c Context) error {
+		c.Session().Set("user_id", 1)
+		return c.String(200, "ok")
+	})
+	a.GET("/age", func(c Context) error {
+		old := time.Now().Add(-2 * time.Hour).Unix()
+		c.Session().Set(sessionCreatedKey, old)
+		c.Session().Set(sessionSeenKey, old)
+		return c.String(200, "ok")
+	})
+	a.GET("/whoami", func(c Context) error {
+		id, _ := c.Session().GetInt("user_id")
+		return c.String(200, "%d", id)
+	})
+	return a
+}
+
+func sessionGet(a *App, path string, cookies []*http.Cookie, ua string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("User-Agent", ua)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_Sessions_CookieAttributes(t *testing.T) {
+	r := require.New(t)
+
+	a := sessionSecurityAppIn("production", SessionOptions{})
+	ck := sessionGet(a, "/login", nil, "").Result().Cookies()[0]
+	r.True(ck.HttpOnly)
+	r.True(ck.Secure)
+	r.Equal(http.SameSiteLaxMode, ck.SameSite)
+	r.Equal("/", ck.Path)
+
+	a = sessionSecurityAppIn("production", SessionOptions{Cookie: CookieOptions{Domain: "example.com"}})
+	ck = sessionGet(a, "/login", nil, "").Result().Cookies()[0]
+	r.True(ck.HttpOnly)
+	r.True(ck.Secure)
+	r.Equal("example.com", ck.Domain)
+}
+
+func Test_Sessions_Timeouts(t *testing.T) {
+	r := require.New(t)
+
+	for _, so := range []SessionOptions{{IdleTimeout: time.Hour}, {AbsoluteTimeout: time.Hour}} {
+		a := sessionSecurityApp(so)
+		cookies := sessionGet(a, "/login", nil, "").Result().Cookies()
+		r.Equal("1", sessionGet(a, "/whoami", cookies, "").Body.String())
+
+		cookies = sessionGet(a, "/age", cookies, "").Result().Cookies()
+		r.Equal("0", sessionGet(a, "/whoami", cookies, "").Body.String())
+	}
+}
+
+func Test_Sessions_BindUserAgent(t *testing.T) {
+	r := require.New(t)
+
+	a := sessionSecurityApp(SessionOptions{BindUserAgent: true})
+	cookies := sessionGet(a, "/login", nil, "firefox").Result().Cookies()
+	r.Equal("1", sessionGet(a, "/whoami", cookies, "firefox").Body.String())
+	r.Equal("0", sessionGet(a, "/whoami", cookies, "curl").Body.String())
+}
+
+func Test_Sessions_SecretRotation(t *testing.T) {
+	r := require.New(t)
+
+	old := sessionSecurityApp(SessionOptions{Secrets: []string{"old"}})
+	cookies := sessionGet(old, "/login", nil, "").Result().Cookies()
+
+	rotated := sessionSecurityApp(SessionOptions{Secrets: []string{"new", "old"}})
+	r.Equal("1", sessionGet(rotated, "/whoami", cookies, "").Body.String())
+
+	dropped := sessionSecurityApp(SessionOptions{Secrets: []string{"new"}})
+	r.Equal("0", sessionGet(dropped, "/whoami", cookies, "").Body.String())
+}
+
+func Test_Sessions_SlidingExpiration(t *testing.T) {
+	r := require.New(t)
+
+	a := sessionSecurityApp(SessionOptions{SlidingExpiration: time.Hour})
+	a.GET("/seen", func(c Context) error {
+		c.Session().Set(sessionSeenKey, time.Now().Add(-10*time.Minute).Unix())
+		return c.String(200, "ok")
+	})
+	cookies := sessionGet(a, "/login", nil, "").Result().Cookies()
+	r.Equal(3600, cookies[0].MaxAge)
+
+	// recently used sessions aren't sent again
+	res := sessionGet(a, "/whoami", cookies, "")
+	r.Equal("1", res.Body.String())
+	r.Empty(res.Result().Cookies())
+
+	// but are once a minute has passed
+	cookies = sessionGet(a, "/seen", cookies, "").Result().Cookies()
+	res = sessionGet(a, "/whoami", cookies, "")
+	r.Len(res.Result().Cookies(), 1)
+	r.Equal(3600, res.Result().Cookies()[0].MaxAge)
+
+	// and end once unused for longer
+	cookies = sessionGet(a, "/age", cookies, "").Result().Cookies()
+	r.Equal("0", sessionGet(a, "/whoami", cookies, "").Body.String())
+}
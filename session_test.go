@@ -0,0 +1,108 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Session_TypedGetters(t *testing.T) {
+	r := require.New(t)
+
+	s := &Session{Session: sessions.NewSession(nil, "test")}
+	s.Set("name", "mark")
+	s.Set("id", 42)
+	s.Set("json_id", float64(7))
+	s.Set("half", 1.5)
+	s.Set("admin", true)
+	r.True(s.Changed())
+
+	v, ok := s.GetString("name")
+	r.True(ok)
+	r.Equal("mark", v)
+	_, ok = s.GetString("id")
+	r.False(ok)
+
+	i, ok := s.GetInt("id")
+	r.True(ok)
+	r.Equal(42, i)
+	i64, ok := s.GetInt64("json_id")
+	r.True(ok)
+	r.Equal(int64(7), i64)
+	_, ok = s.GetInt("half")
+	r.False(ok)
+
+	b, ok := s.GetBool("admin")
+	r.True(ok)
+	r.True(b)
+
+	f, ok := SessionValue[float64](s, "half")
+	r.True(ok)
+	r.Equal(1.5, f)
+
+	s.Clear()
+	r.Empty(s.Session.Values)
+}
+
+func Test_Session_SavedBeforeResponse(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{SessionStore: sessions.NewCookieStore([]byte("secret"))})
+	a.GET("/set", func(c Context) error {
+		c.Session().Set("user_id", 1)
+		return c.Render(200, render.String("ok"))
+	})
+	a.GET("/quiet", func(c Context) error {
+		c.Session().Set("user_id", 2)
+		return nil
+	})
+	a.GET("/get", func(c Context) error {
+		id, _ := c.Session().GetInt("user_id")
+		return c.String(200, "%d", id)
+	})
+	a.GET("/read", func(c Context) error {
+		c.Session().Get("user_id")
+		return c.Render(200, render.String("ok"))
+	})
+
+	get := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+
+	res := get("/set", nil)
+	cookies := res.Result().Cookies()
+	r.Len(cookies, 1)
+	r.Equal("1", get("/get", cookies).Body.String())
+
+	// handlers that write nothing still get their session saved
+	res = get("/quiet", nil)
+	r.Equal("2", get("/get", res.Result().Cookies()).Body.String())
+
+	// unchanged sessions aren't
+	res = get("/read", cookies)
+	r.Empty(res.Result().Cookies())
+}
+
+func Test_Session_Regenerate(t *testing.T) {
+	r := require.New(t)
+
+	s := &Session{Session: sessions.NewSession(nil, "test")}
+	s.Session.ID = "planted"
+	s.Session.IsNew = false
+	s.Set("user_id", 1)
+	s.Regenerate()
+	r.Equal("", s.Session.ID)
+	r.True(s.Session.IsNew)
+	r.Equal(1, s.Get("user_id"))
+	r.True(s.Changed())
+}
@@ -0,0 +1,35 @@
+package sessionstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/gorilla/sessions"
+)
+
+// NewCookieStore returns a sessions.CookieStore whose cookies are
+// signed, and encrypted with AES-256, by keys derived from the
+// secrets, so their values can't be read, or changed, by the client.
+// Sessions are limited to the 4KB a cookie can hold, use New to keep
+// them on the server instead. Cookies are written with the first
+// secret, and read with any of them, so secrets can be rotated, see
+// buffalo.SessionOptions.Secrets.
+/*
+	app := buffalo.New(buffalo.Options{
+		SessionStore: sessionstore.NewCookieStore(envy.Get("SESSION_SECRET", "")),
+	})
+*/
+func NewCookieStore(secrets ...string) *sessions.CookieStore {
+	pairs := make([][]byte, 0, len(secrets)*2)
+	for _, secret := range secrets {
+		pairs = append(pairs, deriveKey(secret, "hash"), deriveKey(secret, "encrypt"))
+	}
+	return sessions.NewCookieStore(pairs...)
+}
+
+// deriveKey derives a 32 byte key, for the purpose, from secret.
+func deriveKey(secret string, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("buffalo sessions " + purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,86 @@
+package sessionstore
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MemcacheConn is the small slice of a memcached client that the
+// Memcache Backend needs. Adapting github.com/bradfitz/gomemcache takes
+// a few lines:
+/*
+	type mc struct{ *memcache.Client }
+
+	func (m mc) Get(key string) ([]byte, error) {
+		it, err := m.Client.Get(key)
+		if err == memcache.ErrCacheMiss {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return it.Value, nil
+	}
+
+	func (m mc) Set(key string, value []byte, expiration int32) error {
+		return m.Client.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration})
+	}
+
+	func (m mc) Delete(key string) error {
+		err := m.Client.Delete(key)
+		if err == memcache.ErrCacheMiss {
+			return nil
+		}
+		return err
+	}
+*/
+type MemcacheConn interface {
+	// Get returns the value of key, nil if there is none.
+	Get(key string) ([]byte, error)
+	// Set the value of key, to expire after expiration seconds.
+	Set(key string, value []byte, expiration int32) error
+	// Delete key, it isn't an error if there is none.
+	Delete(key string) error
+}
+
+type memcacheBackend struct {
+	conn   MemcacheConn
+	prefix string
+}
+
+// maxMemcacheTTL is the longest expiration memcached takes in seconds,
+// longer ones are read as a unix time.
+const maxMemcacheTTL = 30 * 24 * time.Hour
+
+// Memcache returns a Backend that keeps sessions in memcached, under
+// the prefix. Memcached evicts entries when it runs out of memory, so
+// sessions can end early.
+func Memcache(conn MemcacheConn, prefix string) Backend {
+	return memcacheBackend{conn: conn, prefix: prefix}
+}
+
+func (m memcacheBackend) Load(id string) ([]byte, bool, error) {
+	v, err := m.conn.Get(m.prefix + id)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return v, v != nil, nil
+}
+
+func (m memcacheBackend) Save(id string, data []byte, ttl time.Duration) error {
+	var exp int32
+	if ttl > maxMemcacheTTL {
+		exp = int32(time.Now().Add(ttl).Unix())
+	} else {
+		exp = int32(ttl / time.Second)
+		if exp < 1 {
+			exp = 1
+		}
+	}
+	return errors.WithStack(m.conn.Set(m.prefix+id, data, exp))
+}
+
+func (m memcacheBackend) Delete(id string) error {
+	return errors.WithStack(m.conn.Delete(m.prefix + id))
+}
@@ -0,0 +1,51 @@
+package sessionstore
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+type memory struct {
+	moot     sync.Mutex
+	sessions map[string]memoryEntry
+}
+
+// Memory returns a Backend that keeps sessions in the memory of the
+// process. Sessions don't survive restarts, and aren't shared between
+// processes, so it is meant for development and tests.
+func Memory() Backend {
+	return &memory{sessions: map[string]memoryEntry{}}
+}
+
+func (m *memory) Load(id string) ([]byte, bool, error) {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	e, ok := m.sessions[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(m.sessions, id)
+		return nil, false, nil
+	}
+	return e.data, true, nil
+}
+
+func (m *memory) Save(id string, data []byte, ttl time.Duration) error {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	m.sessions[id] = memoryEntry{data: data, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *memory) Delete(id string) error {
+	m.moot.Lock()
+	defer m.moot.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
@@ -0,0 +1,56 @@
+package sessionstore
+
+import (
+	"time"
+
+	"github.com/gobuffalo/buffalo/redisconn"
+	"github.com/pkg/errors"
+)
+
+// RedisConn is the small slice of a Redis client that the Redis Backend
+// needs, see redisconn.Conn.
+type RedisConn = redisconn.Conn
+
+type redisBackend struct {
+	conn   RedisConn
+	prefix string
+}
+
+// Redis returns a Backend that keeps sessions in Redis, under the
+// prefix, expiring them with Redis' own TTLs.
+/*
+	store := sessionstore.New(sessionstore.Redis(conn, "session:"), []byte(secret))
+*/
+func Redis(conn RedisConn, prefix string) Backend {
+	return redisBackend{conn: conn, prefix: prefix}
+}
+
+func (r redisBackend) Load(id string) ([]byte, bool, error) {
+	reply, err := r.conn.Do("GET", r.prefix+id)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	switch v := reply.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return v, true, nil
+	case string:
+		return []byte(v), true, nil
+	}
+	return nil, false, errors.Errorf("unexpected redis reply %T", reply)
+}
+
+func (r redisBackend) Save(id string, data []byte, ttl time.Duration) error {
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := r.conn.Do("SET", r.prefix+id, data, "PX", ms)
+	return errors.WithStack(err)
+}
+
+func (r redisBackend) Delete(id string) error {
+	_, err := r.conn.Do("DEL", r.prefix+id)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,165 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SQL is a Backend that keeps sessions in a table of a SQL database:
+/*
+	CREATE TABLE sessions (
+		id VARCHAR(64) PRIMARY KEY,
+		data BYTEA NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX sessions_expires_at ON sessions (expires_at);
+*/
+// Expired sessions are never loaded, but stay in the table until they
+// are cleaned up, see Cleanup and CleanupEvery.
+type SQL struct {
+	DB *sql.DB
+	// Table of the sessions, default is "sessions".
+	Table string
+	// Dollar placeholders, $1, are used instead of ?, for Postgres.
+	Dollar bool
+	// Dialect is the name of the database, such as "postgres" or
+	// "mysql", and picks the statement sessions are saved with.
+	Dialect string
+}
+
+// NewSQL returns a SQL Backend for the table of db, see SQL. The
+// dialect is the name of the database, such as "postgres" or "mysql".
+func NewSQL(db *sql.DB, table string, dialect string) *SQL {
+	return &SQL{
+		DB:      db,
+		Table:   table,
+		Dollar:  dialect == "postgres" || dialect == "cockroach",
+		Dialect: dialect,
+	}
+}
+
+func (s *SQL) table() string {
+	if s.Table == "" {
+		return "sessions"
+	}
+	return s.Table
+}
+
+// query replaces the ? placeholders of q with $n ones, if s.Dollar.
+func (s *SQL) query(q string) string {
+	q = fmt.Sprintf(q, s.table())
+	if !s.Dollar {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Load the session, if it hasn't expired.
+func (s *SQL) Load(id string) ([]byte, bool, error) {
+	var data []byte
+	err := s.DB.QueryRow(s.query("SELECT data FROM %s WHERE id = ? AND expires_at > ?"), id, time.Now().UTC()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return data, true, nil
+}
+
+// upsert returns the statement that inserts a session, or updates it
+// if it already exists, in a single step, or "" if the Dialect has none.
+func (s *SQL) upsert() string {
+	switch s.Dialect {
+	case "postgres", "cockroach", "sqlite", "sqlite3":
+		return s.query("INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at")
+	case "mysql":
+		return s.query("INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)")
+	}
+	return ""
+}
+
+// Save the session, replacing it if it exists.
+func (s *SQL) Save(id string, data []byte, ttl time.Duration) error {
+	expires := time.Now().Add(ttl).UTC()
+	if q := s.upsert(); q != "" {
+		_, err := s.DB.Exec(q, id, data, expires)
+		return errors.WithStack(err)
+	}
+	update := func() (bool, error) {
+		res, err := s.DB.Exec(s.query("UPDATE %s SET data = ?, expires_at = ? WHERE id = ?"), data, expires, id)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		n, err := res.RowsAffected()
+		return err == nil && n > 0, nil
+	}
+	if ok, err := update(); ok || err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(s.query("INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?)"), id, data, expires)
+	if err == nil {
+		return nil
+	}
+	// another request may have inserted the session since it was
+	// updated, in which case it can be updated now
+	if ok, uerr := update(); ok && uerr == nil {
+		return nil
+	}
+	return errors.WithStack(err)
+}
+
+// Delete the session.
+func (s *SQL) Delete(id string) error {
+	_, err := s.DB.Exec(s.query("DELETE FROM %s WHERE id = ?"), id)
+	return errors.WithStack(err)
+}
+
+// Cleanup deletes the sessions that have expired, and returns how many
+// there were.
+func (s *SQL) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, s.query("DELETE FROM %s WHERE expires_at <= ?"), time.Now().UTC())
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// CleanupEvery runs Cleanup every interval, until ctx is done. Errors
+// are passed to onError, if it isn't nil.
+/*
+	backend := sessionstore.NewSQL(db, "sessions", "postgres")
+	go backend.CleanupEvery(ctx, time.Hour, func(err error) {
+		app.Logger.Error(err)
+	})
+*/
+func (s *SQL) CleanupEvery(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Cleanup(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
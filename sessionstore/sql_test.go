@@ -0,0 +1,25 @@
+package sessionstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SQL_query(t *testing.T) {
+	r := require.New(t)
+
+	s := NewSQL(nil, "", "mysql")
+	r.Equal("DELETE FROM sessions WHERE id = ?", s.query("DELETE FROM %s WHERE id = ?"))
+
+	s = NewSQL(nil, "web_sessions", "postgres")
+	r.Equal("UPDATE web_sessions SET data = $1, expires_at = $2 WHERE id = $3", s.query("UPDATE %s SET data = ?, expires_at = ? WHERE id = ?"))
+
+	r.Equal("INSERT INTO web_sessions (id, data, expires_at) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at", s.upsert())
+
+	s = NewSQL(nil, "", "mysql")
+	r.Equal("INSERT INTO sessions (id, data, expires_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)", s.upsert())
+
+	s = NewSQL(nil, "", "mssql")
+	r.Equal("", s.upsert())
+}
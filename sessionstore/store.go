@@ -0,0 +1,180 @@
+// Package sessionstore provides session stores for Buffalo Apps that
+// keep sessions on the server, in Redis, memcached, or a SQL database,
+// and an encrypted cookie store. They are all sessions.Stores, from
+// github.com/gorilla/sessions, so they are set with Options.SessionStore
+// and handlers don't change when the store does.
+/*
+	store := sessionstore.New(sessionstore.Redis(conn, "session:"), []byte(envy.Get("SESSION_SECRET", "")))
+	app := buffalo.New(buffalo.Options{
+		SessionStore: store,
+	})
+*/
+package sessionstore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+)
+
+// Backend is where a Store keeps the data of its sessions, by ID.
+type Backend interface {
+	// Load the data of the session, false if there is none, or it has
+	// expired.
+	Load(id string) ([]byte, bool, error)
+	// Save the data of the session, to expire after ttl.
+	Save(id string, data []byte, ttl time.Duration) error
+	// Delete the session.
+	Delete(id string) error
+}
+
+// PreviousIDKey is the session value buffalo.Session.Save sets to the ID
+// the session had before it was regenerated, so Save deletes the old one
+// from the Backend. It is never saved itself.
+const PreviousIDKey = "_buffalo_previous_id"
+
+// DefaultTTL is how long the sessions of a Store are kept when their
+// cookie lasts as long as the browser is open, that is MaxAge is 0.
+const DefaultTTL = 24 * time.Hour
+
+// Store is a sessions.Store that keeps the values of sessions in a
+// Backend. The session cookie only holds the session ID, signed, so the
+// values can't be read, or changed, by the client, and sessions can be
+// revoked by deleting them.
+type Store struct {
+	Backend Backend
+	// Options of the session cookies.
+	Options *sessions.Options
+	// TTL of sessions whose cookie has no MaxAge, see DefaultTTL.
+	TTL time.Duration
+	// Serializer turns the session values into the data saved in the
+	// Backend. Default is gob, like the cookie store, so the types of
+	// the values need to be registered with gob.Register.
+	Serializer securecookie.Serializer
+	codecs     []securecookie.Codec
+}
+
+// New returns a Store that keeps sessions in b. The session IDs are
+// signed with the hash keys of keyPairs, see sessions.NewCookieStore.
+func New(b Backend, keyPairs ...[]byte) *Store {
+	return &Store{
+		Backend: b,
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   86400 * 30,
+			HttpOnly: true,
+		},
+		TTL:        DefaultTTL,
+		Serializer: securecookie.GobEncoder{},
+		codecs:     securecookie.CodecsFromPairs(keyPairs...),
+	}
+}
+
+// Get returns the named session of the request, see sessions.Store.
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the named session of the request, loaded from the
+// Backend, or a new one if the request has none, see sessions.Store.
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var id string
+	if err := securecookie.DecodeMulti(name, c.Value, &id, s.codecs...); err != nil {
+		// a forged, or expired, cookie gets a new session
+		return session, nil
+	}
+	data, ok, err := s.Backend.Load(id)
+	if err != nil {
+		return session, errors.WithStack(err)
+	}
+	if !ok {
+		return session, nil
+	}
+	if err := s.Serializer.Deserialize(data, &session.Values); err != nil {
+		return session, errors.WithStack(err)
+	}
+	session.ID = id
+	session.IsNew = false
+	return session, nil
+}
+
+// Save the session to the Backend, and its ID to the cookie. Sessions
+// with a negative MaxAge are deleted, as is the session the ID was in
+// before it was regenerated, see PreviousIDKey, and sessions.Store.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if prev, ok := session.Values[PreviousIDKey].(string); ok {
+		delete(session.Values, PreviousIDKey)
+		if prev != session.ID {
+			if err := s.Backend.Delete(prev); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.Backend.Delete(session.ID); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+	if session.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+	data, err := s.Serializer.Serialize(session.Values)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ttl := time.Duration(session.Options.MaxAge) * time.Second
+	if ttl == 0 {
+		ttl = s.TTL
+	}
+	if err := s.Backend.Save(session.ID, data, ttl); err != nil {
+		return errors.WithStack(err)
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// MaxAge sets the MaxAge of the session cookies, and of the signatures
+// of the session IDs.
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, c := range s.codecs {
+		if sc, ok := c.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,187 @@
+package sessionstore_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/sessionstore"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionApp counts the requests of each session.
+func sessionApp(store sessions.Store) *buffalo.App {
+	a := buffalo.New(buffalo.Options{SessionStore: store})
+	a.GET("/", func(c buffalo.Context) error {
+		n, _ := c.Session().GetInt("n")
+		c.Session().Set("n", n+1)
+		return c.String(200, "%d", n+1)
+	})
+	a.GET("/logout", func(c buffalo.Context) error {
+		c.Session().Session.Options.MaxAge = -1
+		return c.Session().Save()
+	})
+	return a
+}
+
+func get(a *buffalo.App, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_Store(t *testing.T) {
+	r := require.New(t)
+
+	backend := sessionstore.Memory()
+	store := sessionstore.New(backend, []byte("secret"))
+	a := sessionApp(store)
+
+	res := get(a, "/", nil)
+	r.Equal("1", res.Body.String())
+	cookies := res.Result().Cookies()
+	r.Len(cookies, 1)
+
+	res = get(a, "/", cookies)
+	r.Equal("2", res.Body.String())
+
+	// forged cookies get a new session
+	res = get(a, "/", []*http.Cookie{{Name: "_buffalo_session", Value: "forged"}})
+	r.Equal("1", res.Body.String())
+
+	// deleted sessions are gone
+	get(a, "/logout", cookies)
+	res = get(a, "/", cookies)
+	r.Equal("1", res.Body.String())
+}
+
+func Test_Store_Regenerate(t *testing.T) {
+	r := require.New(t)
+
+	store := sessionstore.New(sessionstore.Memory(), []byte("secret"))
+	a := sessionApp(store)
+	a.GET("/login", func(c buffalo.Context) error {
+		c.Session().Regenerate()
+		return c.String(200, "ok")
+	})
+
+	res := get(a, "/", nil)
+	r.Equal("1", res.Body.String())
+	before := res.Result().Cookies()
+
+	res = get(a, "/login", before)
+	after := res.Result().Cookies()
+	r.Len(after, 1)
+	r.NotEqual(before[0].Value, after[0].Value)
+
+	// the values move to the new ID, and the old one is gone
+	r.Equal("2", get(a, "/", after).Body.String())
+	r.Equal("1", get(a, "/", before).Body.String())
+}
+
+func Test_Memory_Expires(t *testing.T) {
+	r := require.New(t)
+
+	m := sessionstore.Memory()
+	r.NoError(m.Save("a", []byte("data"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err := m.Load("a")
+	r.NoError(err)
+	r.False(ok)
+}
+
+func Test_NewCookieStore(t *testing.T) {
+	r := require.New(t)
+
+	a := sessionApp(sessionstore.NewCookieStore("secret"))
+	res := get(a, "/", nil)
+	cookies := res.Result().Cookies()
+	r.Len(cookies, 1)
+	r.Equal("2", get(a, "/", cookies).Body.String())
+}
+
+type fakeRedis map[string]interface{}
+
+func (f fakeRedis) Do(cmd string, args ...interface{}) (interface{}, error) {
+	key := args[0].(string)
+	switch cmd {
+	case "GET":
+		return f[key], nil
+	case "SET":
+		if args[2] != "PX" {
+			return nil, nil
+		}
+		f[key] = args[1]
+	case "DEL":
+		delete(f, key)
+	}
+	return "OK", nil
+}
+
+func Test_Redis(t *testing.T) {
+	r := require.New(t)
+
+	conn := fakeRedis{}
+	b := sessionstore.Redis(conn, "session:")
+	r.NoError(b.Save("abc", []byte("data"), time.Hour))
+	r.Equal([]byte("data"), conn["session:abc"])
+
+	data, ok, err := b.Load("abc")
+	r.NoError(err)
+	r.True(ok)
+	r.Equal("data", string(data))
+
+	r.NoError(b.Delete("abc"))
+	_, ok, err = b.Load("abc")
+	r.NoError(err)
+	r.False(ok)
+}
+
+type fakeMemcache struct {
+	values map[string][]byte
+	exp    int32
+}
+
+func (f *fakeMemcache) Get(key string) ([]byte, error) {
+	return f.values[key], nil
+}
+
+func (f *fakeMemcache) Set(key string, value []byte, expiration int32) error {
+	f.values[key] = value
+	f.exp = expiration
+	return nil
+}
+
+func (f *fakeMemcache) Delete(key string) error {
+	delete(f.values, key)
+	return nil
+}
+
+func Test_Memcache(t *testing.T) {
+	r := require.New(t)
+
+	conn := &fakeMemcache{values: map[string][]byte{}}
+	b := sessionstore.Memcache(conn, "s:")
+	r.NoError(b.Save("abc", []byte("data"), time.Hour))
+	r.Equal(int32(3600), conn.exp)
+
+	// longer than 30 days is a unix time
+	r.NoError(b.Save("abc", []byte("data"), 60*24*time.Hour))
+	r.True(conn.exp > int32(time.Now().Unix()))
+
+	data, ok, err := b.Load("abc")
+	r.NoError(err)
+	r.True(ok)
+	r.Equal("data", string(data))
+
+	r.NoError(b.Delete("abc"))
+	_, ok, _ = b.Load("abc")
+	r.False(ok)
+}
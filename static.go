@@ -0,0 +1,163 @@
+package buffalo
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticOptions change how ServeFilesWith serves static files.
+type StaticOptions struct {
+	// Listing shows the contents of directories that don't have an
+	// index.html. Directories aren't listed by default.
+	Listing bool
+	// Precompressed serves "app.js.br", or "app.js.gz", in place of
+	// "app.js" when it exists and the client accepts that encoding.
+	Precompressed bool
+	// Fallback is the file served for paths, without an extension,
+	// that don't exist, such as "index.html" for single page apps.
+	Fallback string
+	// MaxAge sets the max-age of the Cache-Control header.
+	MaxAge time.Duration
+}
+
+// ServeFilesWith maps a path to a http.FileSystem to serve static files,
+// see StaticOptions. Files are served with a Content-Type, an ETag and
+// Last-Modified, and support conditional and range requests.
+/*
+	a.ServeFilesWith("/", http.Dir("public"), buffalo.StaticOptions{
+		Precompressed: true,
+		Fallback:      "index.html",
+		MaxAge:        time.Hour,
+	})
+*/
+func (a *App) ServeFilesWith(p string, root http.FileSystem, opts StaticOptions) {
+	p = path.Join(a.prefix, p)
+	sh := &staticHandler{
+		prefix: strings.TrimSuffix(p, "/"),
+		root:   root,
+		opts:   opts,
+		app:    a,
+	}
+	a.router.PathPrefix(p).Handler(sh)
+	a.routesChanged()
+}
+
+type staticHandler struct {
+	prefix string
+	root   http.FileSystem
+	opts   StaticOptions
+	app    *App
+}
+
+func (s *staticHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		res.Header().Set("Allow", "GET, HEAD")
+		http.Error(res, http.StatusText(405), 405)
+		return
+	}
+	name := path.Clean("/" + strings.TrimPrefix(req.URL.Path, s.prefix))
+
+	f, err := s.root.Open(name)
+	if err != nil && s.opts.Fallback != "" && path.Ext(name) == "" {
+		name = path.Clean("/" + s.opts.Fallback)
+		f, err = s.root.Open(name)
+	}
+	if err != nil {
+		s.notFound(res, req)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		s.notFound(res, req)
+		return
+	}
+
+	if fi.IsDir() {
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			http.Redirect(res, req, req.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		index := path.Join(name, "index.html")
+		if ff, err := s.root.Open(index); err == nil {
+			defer ff.Close()
+			if ffi, err := ff.Stat(); err == nil {
+				s.serve(res, req, index, ff, ffi)
+				return
+			}
+		}
+		if !s.opts.Listing {
+			s.notFound(res, req)
+			return
+		}
+		r := new(http.Request)
+		*r = *req
+		u := *req.URL
+		u.Path = strings.TrimSuffix(name, "/") + "/"
+		r.URL = &u
+		http.FileServer(s.root).ServeHTTP(res, r)
+		return
+	}
+	s.serve(res, req, name, f, fi)
+}
+
+// encodings are the precompressed variants looked for, in order of
+// preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+func (s *staticHandler) serve(res http.ResponseWriter, req *http.Request, name string, f http.File, fi os.FileInfo) {
+	h := res.Header()
+	if s.opts.MaxAge > 0 {
+		h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.opts.MaxAge.Seconds())))
+	}
+	if s.opts.Precompressed {
+		h.Add("Vary", "Accept-Encoding")
+		ae := req.Header.Get("Accept-Encoding")
+		for _, e := range encodings {
+			if !strings.Contains(ae, e.name) {
+				continue
+			}
+			cf, err := s.root.Open(name + e.ext)
+			if err != nil {
+				continue
+			}
+			defer cf.Close()
+			cfi, err := cf.Stat()
+			if err != nil || cfi.IsDir() {
+				continue
+			}
+			if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+				h.Set("Content-Type", ct)
+			}
+			h.Set("Content-Encoding", e.name)
+			h.Set("ETag", etag(cfi))
+			http.ServeContent(res, req, name, cfi.ModTime(), cf)
+			return
+		}
+	}
+	h.Set("ETag", etag(fi))
+	http.ServeContent(res, req, name, fi.ModTime(), f)
+}
+
+func (s *staticHandler) notFound(res http.ResponseWriter, req *http.Request) {
+	root := s.app
+	if s.app.root != nil {
+		root = s.app.root
+	}
+	root.router.NotFoundHandler.ServeHTTP(res, req)
+}
+
+func etag(fi os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size())
+}
@@ -0,0 +1,82 @@
+package buffalo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func staticApp(opts StaticOptions) *App {
+	a := New(Options{})
+	a.ServeFilesWith("/app", http.FS(fstest.MapFS{
+		"index.html":  {Data: []byte("<html>spa</html>")},
+		"app.js":      {Data: []byte("console.log('hi')"), ModTime: time.Now()},
+		"app.js.gz":   {Data: []byte("gzipped")},
+		"docs/a.txt":  {Data: []byte("a")},
+		"empty/b.txt": {Data: []byte("b")},
+	}), opts)
+	return a
+}
+
+func Test_ServeFilesWith(t *testing.T) {
+	r := require.New(t)
+
+	a := staticApp(StaticOptions{})
+	w := willie.New(a)
+
+	res := w.Request("/app/app.js").Get()
+	r.Equal(200, res.Code)
+	r.Equal("console.log('hi')", res.Body.String())
+	r.Contains(res.Header().Get("Content-Type"), "javascript")
+	etag := res.Header().Get("ETag")
+	r.NotEmpty(etag)
+	r.NotEmpty(res.Header().Get("Last-Modified"))
+
+	req, _ := http.NewRequest("GET", "/app/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal(304, rec.Code)
+
+	req, _ = http.NewRequest("GET", "/app/app.js", nil)
+	req.Header.Set("Range", "bytes=0-6")
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal(206, rec.Code)
+	r.Equal("console", rec.Body.String())
+
+	r.Equal(404, w.Request("/app/empty/").Get().Code)
+	r.Equal(404, w.Request("/app/nope").Get().Code)
+}
+
+func Test_ServeFilesWith_Options(t *testing.T) {
+	r := require.New(t)
+
+	a := staticApp(StaticOptions{
+		Listing:       true,
+		Precompressed: true,
+		Fallback:      "index.html",
+	})
+	w := willie.New(a)
+
+	res := w.Request("/app/empty/").Get()
+	r.Equal(200, res.Code)
+	r.Contains(res.Body.String(), "b.txt")
+
+	r.Equal("<html>spa</html>", w.Request("/app/users/1").Get().Body.String())
+	r.Equal(404, w.Request("/app/nope.js").Get().Code)
+
+	req, _ := http.NewRequest("GET", "/app/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	r.Equal(200, rec.Code)
+	r.Equal("gzip", rec.Header().Get("Content-Encoding"))
+	r.Contains(rec.Header().Get("Content-Type"), "javascript")
+	r.Equal("gzipped", rec.Body.String())
+}
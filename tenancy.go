@@ -0,0 +1,180 @@
+package buffalo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownTenant is returned by a TenantLookup when there is no tenant
+// for the id. Requests for an unknown tenant are handled as a 404.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Tenant of an App serving many tenants, see Options.TenantResolver.
+// The fields other than ID override the App's configuration for the
+// requests of the tenant, when they are set.
+type Tenant struct {
+	ID string
+	// SessionName is the name of the tenant's session cookie.
+	SessionName string
+	// SessionStore backs the tenant's sessions, so each tenant can have
+	// its own keys.
+	SessionStore sessions.Store
+	// ErrorHandlers are used, for the statuses they have, before the
+	// App's ErrorHandlers.
+	ErrorHandlers ErrorHandlers
+	// Templates renders the tenant's templates, see Tenant.Renderer.
+	Templates *render.Engine
+	// Data is added to the data of the Context, for use in templates.
+	Data map[string]interface{}
+}
+
+// Renderer returns the tenant's render.Engine, or the given one if the
+// tenant doesn't have its own templates. It is safe to call on a nil
+// Tenant.
+/*
+	func Home(c buffalo.Context) error {
+		e := buffalo.TenantFrom(c).Renderer(r)
+		return c.Render(200, e.HTML("index.html"))
+	}
+*/
+func (t *Tenant) Renderer(def *render.Engine) *render.Engine {
+	if t == nil || t.Templates == nil {
+		return def
+	}
+	return t.Templates
+}
+
+// TenantResolver finds the Tenant for a request. A nil Tenant and nil
+// error mean the request isn't for a tenant, such as a request for the
+// main site.
+type TenantResolver func(*http.Request) (*Tenant, error)
+
+// TenantLookup finds a Tenant by its id, returning ErrUnknownTenant if
+// there isn't one.
+type TenantLookup func(id string) (*Tenant, error)
+
+// TenantMap is a fixed set of tenants, keyed by id.
+type TenantMap map[string]*Tenant
+
+// Lookup is a TenantLookup for the tenants of the map.
+func (m TenantMap) Lookup(id string) (*Tenant, error) {
+	if t, ok := m[id]; ok {
+		return t, nil
+	}
+	return nil, ErrUnknownTenant
+}
+
+// TenantByHost resolves tenants by the host of the request, without
+// the port. The whole host is looked up first, then its first label, so
+// "acme.example.com" can be the tenant "acme.example.com" or "acme".
+/*
+	a := buffalo.New(buffalo.Options{
+		TenantResolver: buffalo.TenantByHost(buffalo.TenantMap{
+			"acme":   {ID: "acme", SessionName: "_acme_session"},
+			"globex": {ID: "globex"},
+		}.Lookup),
+	})
+*/
+func TenantByHost(lookup TenantLookup) TenantResolver {
+	return func(req *http.Request) (*Tenant, error) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		t, err := lookup(host)
+		if errors.Cause(err) != ErrUnknownTenant {
+			return t, err
+		}
+		if i := strings.Index(host, "."); i > 0 {
+			return lookup(host[:i])
+		}
+		return nil, err
+	}
+}
+
+// TenantByHeader resolves tenants by the value of a request header, such
+// as "X-Tenant-ID". Requests without the header aren't for a tenant.
+func TenantByHeader(name string, lookup TenantLookup) TenantResolver {
+	return func(req *http.Request) (*Tenant, error) {
+		id := req.Header.Get(name)
+		if id == "" {
+			return nil, nil
+		}
+		return lookup(id)
+	}
+}
+
+// TenantByPath resolves tenants by the first segment of the path, such
+// as "acme" for "/acme/users". The routes of the tenants still need to
+// include the segment, for example by adding them to a Group.
+/*
+	t := a.Group("/{tenant}")
+	t.GET("/users", UsersHandler)
+*/
+func TenantByPath(lookup TenantLookup) TenantResolver {
+	return func(req *http.Request) (*Tenant, error) {
+		p := strings.TrimPrefix(req.URL.Path, "/")
+		if i := strings.Index(p, "/"); i >= 0 {
+			p = p[:i]
+		}
+		if p == "" {
+			return nil, nil
+		}
+		return lookup(p)
+	}
+}
+
+// TenantFrom returns the Tenant of the request being handled, or nil if
+// it isn't for a tenant.
+func TenantFrom(c Context) *Tenant {
+	t, _ := Get(c, TenantKey)
+	return t
+}
+
+type tenantKey struct{}
+
+func tenantFromRequest(req *http.Request) *Tenant {
+	t, _ := req.Context().Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// resolveTenant adds the Tenant of the request to its context. Errors
+// from the resolver are returned with a status of 404, unless they
+// already have one.
+func (a *App) resolveTenant(req *http.Request) (*http.Request, error) {
+	resolve := a.TenantResolver
+	if a.root != nil {
+		resolve = a.root.TenantResolver
+	}
+	if resolve == nil || tenantFromRequest(req) != nil {
+		return req, nil
+	}
+	t, err := resolve(req)
+	if err != nil {
+		if _, ok := err.(httpError); !ok {
+			err = httpError{Status: 404, Cause: errors.WithStack(err)}
+		}
+		return req, err
+	}
+	if t == nil {
+		return req, nil
+	}
+	return req.WithContext(context.WithValue(req.Context(), tenantKey{}, t)), nil
+}
+
+// errorHandler returns the ErrorHandler for the status, preferring the
+// ErrorHandlers of the request's Tenant.
+func (a *App) errorHandler(c Context, status int) ErrorHandler {
+	if t := TenantFrom(c); t != nil {
+		if eh, ok := t.ErrorHandlers[status]; ok {
+			return eh
+		}
+	}
+	return a.ErrorHandlers.Get(status)
+}
@@ -0,0 +1,120 @@
+package buffalo
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/require"
+)
+
+func tenancyApp() *App {
+	tenants := TenantMap{
+		"acme": {
+			ID:           "acme",
+			SessionName:  "_acme_session",
+			SessionStore: sessions.NewCookieStore([]byte("acme")),
+			Data:         map[string]interface{}{"brand": "Acme"},
+			ErrorHandlers: ErrorHandlers{
+				404: func(status int, err error, c Context) error {
+					return c.Render(status, render.String("acme not found"))
+				},
+			},
+		},
+		"globex": {ID: "globex"},
+	}
+	a := New(Options{TenantResolver: TenantByHost(tenants.Lookup)})
+	a.GET("/", func(c Context) error {
+		c.Session().Set("seen", true)
+		if err := c.Session().Save(); err != nil {
+			return err
+		}
+		t := TenantFrom(c)
+		if t == nil {
+			return c.Render(200, render.String("main"))
+		}
+		return c.Render(200, render.String(t.ID+" {{brand}}"))
+	})
+	return a
+}
+
+func tenantRequest(a *App, host, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Host = host
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_Tenancy_ByHost(t *testing.T) {
+	r := require.New(t)
+	a := tenancyApp()
+
+	res := tenantRequest(a, "acme.example.com:3000", "/")
+	r.Equal(200, res.Code)
+	r.Equal("acme Acme", res.Body.String())
+	r.Contains(res.Header().Get("Set-Cookie"), "_acme_session=")
+
+	res = tenantRequest(a, "globex", "/")
+	r.Equal("globex ", res.Body.String())
+	r.Contains(res.Header().Get("Set-Cookie"), "_buffalo_session=")
+}
+
+func Test_Tenancy_Unknown(t *testing.T) {
+	r := require.New(t)
+	a := tenancyApp()
+
+	res := tenantRequest(a, "initech.example.com", "/")
+	r.Equal(404, res.Code)
+	r.Contains(res.Body.String(), "unknown tenant")
+}
+
+func Test_Tenancy_ErrorHandlers(t *testing.T) {
+	r := require.New(t)
+	a := tenancyApp()
+
+	res := tenantRequest(a, "acme.example.com", "/missing")
+	r.Equal(404, res.Code)
+	r.Equal("acme not found", res.Body.String())
+
+	res = tenantRequest(a, "globex.example.com", "/missing")
+	r.Equal(404, res.Code)
+	r.NotEqual("acme not found", res.Body.String())
+}
+
+func Test_Tenancy_Resolvers(t *testing.T) {
+	r := require.New(t)
+	tenants := TenantMap{"acme": {ID: "acme"}}
+
+	req := httptest.NewRequest("GET", "/acme/users", nil)
+	tn, err := TenantByPath(tenants.Lookup)(req)
+	r.NoError(err)
+	r.Equal("acme", tn.ID)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	tn, err = TenantByPath(tenants.Lookup)(req)
+	r.NoError(err)
+	r.Nil(tn)
+
+	resolve := TenantByHeader("X-Tenant-ID", tenants.Lookup)
+	req.Header.Set("X-Tenant-ID", "acme")
+	tn, err = resolve(req)
+	r.NoError(err)
+	r.Equal("acme", tn.ID)
+
+	req.Header.Set("X-Tenant-ID", "initech")
+	_, err = resolve(req)
+	r.Equal(ErrUnknownTenant, err)
+}
+
+func Test_Tenant_Renderer(t *testing.T) {
+	r := require.New(t)
+	def := render.New(render.Options{})
+	own := render.New(render.Options{})
+
+	var tn *Tenant
+	r.Equal(def, tn.Renderer(def))
+	r.Equal(def, (&Tenant{}).Renderer(def))
+	r.Equal(own, (&Tenant{Templates: own}).Renderer(def))
+}
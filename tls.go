@@ -0,0 +1,99 @@
+package buffalo
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ServeTLS is like Serve, but serves the App over HTTPS with the
+// certificate and key files. If the RedirectAddr option is set, such as
+// to ":80", requests to it are redirected to HTTPS.
+/*
+	a.ServeTLS(context.Background(), "cert.pem", "key.pem")
+*/
+func (a *App) ServeTLS(ctx context.Context, certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+	return a.serveTLS(ctx, cfg, a.redirectHandler())
+}
+
+// AutoCertOptions configure ServeAutoCert.
+type AutoCertOptions struct {
+	// Domains certificates are requested for. Requests for any other
+	// host are refused.
+	Domains []string
+	// CacheDir is where certificates are kept between restarts. Default
+	// is "certs".
+	CacheDir string
+	// Email the certificate authority can contact about problems with
+	// the certificates. Optional.
+	Email string
+}
+
+// ServeAutoCert is like ServeTLS, but gets, and renews, certificates
+// for the Domains from Let's Encrypt. Challenges are answered over
+// TLS-ALPN on the Addr, and over HTTP on the RedirectAddr, if it is set.
+/*
+	a := buffalo.Automatic(buffalo.Options{Addr: ":443", RedirectAddr: ":80"})
+	a.ServeAutoCert(context.Background(), buffalo.AutoCertOptions{
+		Domains: []string{"example.com", "www.example.com"},
+	})
+*/
+func (a *App) ServeAutoCert(ctx context.Context, opts AutoCertOptions) error {
+	if len(opts.Domains) == 0 {
+		return errors.New("no domains to get certificates for")
+	}
+	if opts.CacheDir == "" {
+		opts.CacheDir = "certs"
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(opts.Domains...),
+		Cache:      autocert.DirCache(opts.CacheDir),
+		Email:      opts.Email,
+	}
+	var redirect http.Handler
+	if rh := a.redirectHandler(); rh != nil {
+		redirect = m.HTTPHandler(rh)
+	}
+	return a.serveTLS(ctx, m.TLSConfig(), redirect)
+}
+
+func (a *App) serveTLS(ctx context.Context, cfg *tls.Config, redirect http.Handler) error {
+	ln, err := a.listen(a.Addr)
+	if err != nil {
+		return err
+	}
+	return a.serve(ctx, ln, cfg, redirect)
+}
+
+// redirectHandler returns a handler that redirects requests to HTTPS on
+// the Addr, or nil if the RedirectAddr option isn't set.
+func (a *App) redirectHandler() http.Handler {
+	if a.RedirectAddr == "" {
+		return nil
+	}
+	_, port, _ := net.SplitHostPort(a.Addr)
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		status := http.StatusPermanentRedirect
+		if req.Method == "GET" || req.Method == "HEAD" {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(res, req, "https://"+host+req.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,91 @@
+package buffalo
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/stretchr/testify/require"
+)
+
+func testCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func Test_App_ServeTLS(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.GET("/", func(c Context) error {
+		return c.Render(200, render.String("secure"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	cfg := &tls.Config{Certificates: []tls.Certificate{testCert(t)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.serve(ctx, ln, cfg, nil)
+	}()
+
+	c := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	res, err := c.Get("https://" + ln.Addr().String() + "/")
+	r.NoError(err)
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	r.Equal("secure", string(b))
+	r.NotNil(res.TLS)
+
+	cancel()
+	r.NoError(<-served)
+
+	r.Error(a.ServeTLS(context.Background(), "nope.pem", "nope.key"))
+	r.Error(a.ServeAutoCert(context.Background(), AutoCertOptions{}))
+}
+
+func Test_App_RedirectHandler(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{Addr: ":443"})
+	r.Nil(a.redirectHandler())
+
+	a = New(Options{Addr: ":443", RedirectAddr: ":80"})
+	req, _ := http.NewRequest("GET", "http://example.com/users?page=2", nil)
+	res := httptest.NewRecorder()
+	a.redirectHandler().ServeHTTP(res, req)
+	r.Equal(301, res.Code)
+	r.Equal("https://example.com/users?page=2", res.Header().Get("Location"))
+
+	a = New(Options{Addr: ":8443", RedirectAddr: ":8080"})
+	req, _ = http.NewRequest("POST", "http://example.com:8080/users", nil)
+	res = httptest.NewRecorder()
+	a.redirectHandler().ServeHTTP(res, req)
+	r.Equal(308, res.Code)
+	r.Equal("https://example.com:8443/users", res.Header().Get("Location"))
+}
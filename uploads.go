@@ -0,0 +1,226 @@
+package buffalo
+
+import (
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFileTooLarge is returned for uploaded files that are bigger than
+// the MaxFileSize of their route.
+var ErrFileTooLarge = errors.New("uploaded file is too large")
+
+// defaultMaxMemory is how much of a multipart form is kept in memory,
+// by default, the rest of its files are written to temporary files.
+const defaultMaxMemory = 32 << 20
+
+// File is a file uploaded in a multipart form, see Context.File.
+type File struct {
+	io.ReadSeeker
+	// Name is the file name given by the client.
+	Name string
+	// Size of the file in bytes.
+	Size int64
+	// ContentType given by the client, "application/octet-stream" if
+	// it didn't give one.
+	ContentType string
+	// Header is the header of the file's part of the form.
+	Header *multipart.FileHeader
+}
+
+// Close the file. Files are closed, and their temporary files removed,
+// once the request has been handled.
+func (f File) Close() error {
+	if c, ok := f.ReadSeeker.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MaxMemory sets the MaxMemory of the route, see RouteLimits.
+func (ri RouteInfo) MaxMemory(n int64) RouteInfo {
+	if ri.limits != nil {
+		ri.limits.MaxMemory = n
+	}
+	return ri
+}
+
+// MaxFileSize sets the MaxFileSize of the route, see RouteLimits.
+func (ri RouteInfo) MaxFileSize(n int64) RouteInfo {
+	if ri.limits != nil {
+		ri.limits.MaxFileSize = n
+	}
+	return ri
+}
+
+func (d *DefaultContext) limits() RouteLimits {
+	if d.route.limits == nil {
+		return RouteLimits{}
+	}
+	return *d.route.limits
+}
+
+// parseMultipartForm parses the multipart form of the request, keeping
+// up to the MaxMemory of the route in memory.
+func (d *DefaultContext) parseMultipartForm() error {
+	max := d.limits().MaxMemory
+	if max <= 0 {
+		max = defaultMaxMemory
+	}
+	return errors.WithStack(d.request.ParseMultipartForm(max))
+}
+
+// File returns the file uploaded as the form field name. Files that are
+// bigger than the MaxFileSize of the route return ErrFileTooLarge.
+//
+// The whole form is read before the size of the file is known, so
+// MaxFileSize doesn't stop a bigger file from being uploaded, to memory,
+// and temporary files: set the MaxBody of the route for that, or use
+// StreamFile, which stops reading once a file is too large.
+/*
+	a.POST("/avatar", func(c buffalo.Context) error {
+		f, err := c.File("avatar")
+		if err != nil {
+			return c.Error(422, err)
+		}
+		defer f.Close()
+		...
+	}).MaxMemory(1 << 20).MaxFileSize(5 << 20).MaxBody(6 << 20)
+*/
+func (d *DefaultContext) File(name string) (File, error) {
+	if err := d.parseMultipartForm(); err != nil {
+		return File{}, err
+	}
+	fhs := d.request.MultipartForm.File[name]
+	if len(fhs) == 0 {
+		return File{}, errors.Wrap(http.ErrMissingFile, name)
+	}
+	fh := fhs[0]
+	if max := d.limits().MaxFileSize; max > 0 && fh.Size > max {
+		return File{}, errors.Wrap(ErrFileTooLarge, fh.Filename)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return File{}, errors.WithStack(err)
+	}
+	return File{
+		ReadSeeker:  f,
+		Name:        fh.Filename,
+		Size:        fh.Size,
+		ContentType: fileContentType(fh.Header.Get("Content-Type")),
+		Header:      fh,
+	}, nil
+}
+
+// StreamFile copies the file uploaded as the form field name, as it is
+// read from the request, to the writer returned by dst, without holding
+// the whole file in memory, or on disk. dst is given the File, without
+// its ReadSeeker, and its writer is closed once the file is copied if
+// it is an io.Closer. The returned File has the size of the copy.
+// Files bigger than the MaxFileSize of the route return ErrFileTooLarge,
+// after some of them has been written, so dst should be cleaned up.
+//
+// The other fields of the form are added to the form of the request,
+// and other files are skipped. StreamFile reads the body of the
+// request, so can't be used with Bind, or File, for the same request.
+/*
+	a.POST("/videos", func(c buffalo.Context) error {
+		f, err := c.StreamFile("video", func(f buffalo.File) (io.Writer, error) {
+			return os.Create(filepath.Join("uploads", filepath.Base(f.Name)))
+		})
+		...
+	}).MaxBody(1 << 30).Streaming()
+*/
+func (d *DefaultContext) StreamFile(name string, dst func(File) (io.Writer, error)) (File, error) {
+	mr, err := d.request.MultipartReader()
+	if err != nil {
+		return File{}, errors.WithStack(err)
+	}
+	req := d.request
+	if req.PostForm == nil {
+		req.PostForm = map[string][]string{}
+	}
+	if req.Form == nil {
+		req.Form = req.URL.Query()
+	}
+	fieldMemory := d.limits().MaxMemory
+	if fieldMemory <= 0 {
+		fieldMemory = defaultMaxMemory
+	}
+
+	var file File
+	found := false
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return file, errors.WithStack(err)
+		}
+		switch {
+		case p.FileName() == "":
+			b, err := ioutil.ReadAll(io.LimitReader(p, fieldMemory+1))
+			if err != nil {
+				return file, errors.WithStack(err)
+			}
+			fieldMemory -= int64(len(b))
+			if fieldMemory < 0 {
+				return file, errors.New("multipart: message too large")
+			}
+			req.PostForm.Add(p.FormName(), string(b))
+			req.Form.Add(p.FormName(), string(b))
+		case p.FormName() == name && !found:
+			found = true
+			file, err = d.streamPart(p, dst)
+			if err != nil {
+				return file, err
+			}
+		}
+		p.Close()
+	}
+	if !found {
+		return file, errors.Wrap(http.ErrMissingFile, name)
+	}
+	return file, nil
+}
+
+func (d *DefaultContext) streamPart(p *multipart.Part, dst func(File) (io.Writer, error)) (File, error) {
+	file := File{
+		Name:        p.FileName(),
+		ContentType: fileContentType(p.Header.Get("Content-Type")),
+	}
+	w, err := dst(file)
+	if err != nil {
+		return file, errors.WithStack(err)
+	}
+	var r io.Reader = p
+	max := d.limits().MaxFileSize
+	if max > 0 {
+		r = io.LimitReader(p, max+1)
+	}
+	file.Size, err = io.Copy(w, r)
+	if c, ok := w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return file, errors.WithStack(err)
+	}
+	if max > 0 && file.Size > max {
+		return file, errors.Wrap(ErrFileTooLarge, file.Name)
+	}
+	return file, nil
+}
+
+func fileContentType(ct string) string {
+	if strings.TrimSpace(ct) == "" {
+		return "application/octet-stream"
+	}
+	return ct
+}
@@ -0,0 +1,110 @@
+package buffalo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func serveUpload(a *App, fields map[string]string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for k, v := range files {
+		fw, _ := mw.CreateFormFile(k, k+".txt")
+		fw.Write([]byte(v))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	return res
+}
+
+func Test_DefaultContext_File(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	a.POST("/upload", func(c Context) error {
+		user := struct {
+			Name string `schema:"name"`
+		}{}
+		if err := c.Bind(&user); err != nil {
+			return err
+		}
+		f, err := c.File("avatar")
+		if err != nil {
+			if errors.Cause(err) == ErrFileTooLarge {
+				return c.Error(413, err)
+			}
+			return c.Error(422, err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		s := fmt.Sprintf("%s %s %d %s %s", user.Name, f.Name, f.Size, f.ContentType, b)
+		return c.Render(200, render.String(s))
+	}).MaxMemory(2).MaxFileSize(5)
+
+	res := serveUpload(a, map[string]string{"name": "Mark"}, map[string]string{"avatar": "hello"})
+	r.Equal(200, res.Code)
+	r.Equal("Mark avatar.txt 5 application/octet-stream hello", res.Body.String())
+
+	r.Equal(413, serveUpload(a, nil, map[string]string{"avatar": "hello!"}).Code)
+	r.Equal(422, serveUpload(a, nil, map[string]string{"other": "hello"}).Code)
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func Test_DefaultContext_StreamFile(t *testing.T) {
+	r := require.New(t)
+
+	var dst *closingBuffer
+	a := New(Options{})
+	a.POST("/upload", func(c Context) error {
+		dst = &closingBuffer{}
+		f, err := c.StreamFile("video", func(f File) (io.Writer, error) {
+			r.Equal("video.txt", f.Name)
+			return dst, nil
+		})
+		if err != nil {
+			if errors.Cause(err) == ErrFileTooLarge {
+				return c.Error(413, err)
+			}
+			return c.Error(422, err)
+		}
+		s := fmt.Sprintf("%s %s %d", c.Request().FormValue("title"), f.Name, f.Size)
+		return c.Render(200, render.String(s))
+	}).MaxFileSize(10)
+
+	res := serveUpload(a, map[string]string{"title": "Cats"}, map[string]string{"video": "meow", "other": "x"})
+	r.Equal(200, res.Code)
+	r.Equal("Cats video.txt 4", res.Body.String())
+	r.Equal("meow", dst.String())
+	r.True(dst.closed)
+
+	r.Equal(413, serveUpload(a, nil, map[string]string{"video": "meow meow meow"}).Code)
+	r.Equal(422, serveUpload(a, nil, map[string]string{"other": "x"}).Code)
+}
@@ -0,0 +1,197 @@
+package buffalo
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Name the route, so URLs to it can be built with URLFor, and in
+// templates with the RouteHelpers.
+/*
+	a.GET("/users/{user_id}", UsersShow).Name("userPath")
+*/
+func (ri RouteInfo) Name(name string) RouteInfo {
+	a := ri.app
+	if a == nil {
+		return ri
+	}
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.moot.Lock()
+	defer root.moot.Unlock()
+	ri.PathName = name
+	if ri.name != nil {
+		*ri.name = name
+	}
+	if root.routeNames == nil {
+		root.routeNames = map[string]RouteInfo{}
+	}
+	root.routeNames[name] = ri
+	for i, r := range root.routes {
+		if r.Method == ri.Method && r.Path == ri.Path && r.Host == ri.Host {
+			root.routes[i].PathName = name
+		}
+	}
+	return ri
+}
+
+// routeVars matches the variables in a route's path or host.
+var routeVars = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+func (ri RouteInfo) vars() []string {
+	names := []string{}
+	for _, m := range routeVars.FindAllStringSubmatch(ri.Host+ri.Path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// URLFor builds the path of the named route. Params are given in
+// pairs of name and value, every variable in the route must be given,
+// any extra params are added as the query string.
+/*
+	a.URLFor("userPath", "user_id", 1, "tab", "settings")
+	// => "/users/1?tab=settings"
+*/
+func (a *App) URLFor(name string, params ...interface{}) (string, error) {
+	u, err := a.buildURL(name, params)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = ""
+	u.Host = ""
+	return u.String(), nil
+}
+
+// AbsoluteURLFor builds the full URL of the named route, see URLFor.
+// Routes added to a Host group use that host, everything else uses the
+// Host option.
+func (a *App) AbsoluteURLFor(name string, params ...interface{}) (string, error) {
+	u, err := a.buildURL(name, params)
+	if err != nil {
+		return "", err
+	}
+	if u.Host != "" {
+		return u.String(), nil
+	}
+	return strings.TrimSuffix(a.Options.Host, "/") + u.String(), nil
+}
+
+func (a *App) hasRouteNamed(name string) bool {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.moot.Lock()
+	defer root.moot.Unlock()
+	_, ok := root.routeNames[name]
+	return ok
+}
+
+func (a *App) buildURL(name string, params []interface{}) (*url.URL, error) {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.moot.Lock()
+	ri, ok := root.routeNames[name]
+	root.moot.Unlock()
+	if !ok {
+		return nil, errors.Errorf("no route named %q", name)
+	}
+	if len(params)%2 != 0 {
+		return nil, errors.Errorf("params for route %q must be name and value pairs", name)
+	}
+
+	given := map[string]string{}
+	for i := 0; i < len(params); i += 2 {
+		given[fmt.Sprint(params[i])] = fmt.Sprint(params[i+1])
+	}
+	pairs := []string{}
+	missing := []string{}
+	for _, v := range ri.vars() {
+		val, ok := given[v]
+		if !ok {
+			missing = append(missing, v)
+			continue
+		}
+		pairs = append(pairs, v, val)
+		delete(given, v)
+	}
+	if len(missing) > 0 {
+		return nil, errors.Errorf("route %q is missing params: %s", name, strings.Join(missing, ", "))
+	}
+
+	u, err := ri.MuxRoute.URL(pairs...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not build route %q", name)
+	}
+	if len(given) > 0 {
+		q := url.Values{}
+		for k, v := range given {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u, nil
+}
+
+// RouteHelpers returns a template helper for every named route, which
+// returns the route's path. Params are given as hash arguments.
+/*
+	r := render.New(render.Options{
+		Helpers: app.RouteHelpers(),
+	})
+
+	<a href="{{userPath user_id=user.ID}}">{{user.Name}}</a>
+*/
+func (a *App) RouteHelpers() map[string]interface{} {
+	root := a
+	if a.root != nil {
+		root = a.root
+	}
+	root.moot.Lock()
+	names := []string{}
+	for n := range root.routeNames {
+		names = append(names, n)
+	}
+	root.moot.Unlock()
+	sort.Strings(names)
+
+	helpers := map[string]interface{}{}
+	for _, n := range names {
+		name := n
+		helpers[name] = func(opts map[string]interface{}) (string, error) {
+			params := []interface{}{}
+			for k, v := range opts {
+				params = append(params, k, v)
+			}
+			return a.URLFor(name, params...)
+		}
+	}
+	return helpers
+}
+
+// URLFor builds the path of the named route, see App.URLFor.
+func (d *DefaultContext) URLFor(name string, params ...interface{}) (string, error) {
+	if d.app == nil {
+		return "", errors.Errorf("no route named %q", name)
+	}
+	return d.app.URLFor(name, params...)
+}
+
+// AbsoluteURLFor builds the full URL of the named route, see
+// App.AbsoluteURLFor.
+func (d *DefaultContext) AbsoluteURLFor(name string, params ...interface{}) (string, error) {
+	if d.app == nil {
+		return "", errors.Errorf("no route named %q", name)
+	}
+	return d.app.AbsoluteURLFor(name, params...)
+}
@@ -0,0 +1,100 @@
+package buffalo
+
+import (
+	"testing"
+
+	"github.com/markbates/willie"
+	"github.com/stretchr/testify/require"
+)
+
+func urlForApp() *App {
+	a := New(Options{Host: "https://example.com/"})
+	a.GET("/users/{user_id}", voidHandler).Name("userPath")
+	g := a.Group("/admin")
+	g.GET("/users/{user_id}/posts/{post_id:[0-9]+}", voidHandler).Name("adminUserPostPath")
+	a.Host("{tenant}.example.com").GET("/dashboard", voidHandler).Name("dashboardPath")
+	a.GET("/redirect", func(c Context) error {
+		u, err := c.URLFor("userPath", "user_id", 42)
+		if err != nil {
+			return err
+		}
+		return c.Redirect(302, u)
+	})
+	return a
+}
+
+func Test_App_URLFor(t *testing.T) {
+	r := require.New(t)
+	a := urlForApp()
+
+	u, err := a.URLFor("userPath", "user_id", 1)
+	r.NoError(err)
+	r.Equal("/users/1", u)
+
+	u, err = a.URLFor("userPath", "user_id", 1, "tab", "settings")
+	r.NoError(err)
+	r.Equal("/users/1?tab=settings", u)
+
+	u, err = a.URLFor("adminUserPostPath", "user_id", "mark", "post_id", 7)
+	r.NoError(err)
+	r.Equal("/admin/users/mark/posts/7", u)
+
+	_, err = a.URLFor("adminUserPostPath", "user_id", "mark")
+	r.EqualError(err, `route "adminUserPostPath" is missing params: post_id`)
+
+	_, err = a.URLFor("adminUserPostPath", "user_id", "mark", "post_id", "abc")
+	r.Error(err)
+
+	_, err = a.URLFor("userPath", "user_id")
+	r.Error(err)
+
+	_, err = a.URLFor("nope")
+	r.EqualError(err, `no route named "nope"`)
+
+	found := false
+	for _, rt := range a.Routes() {
+		if rt.Path == "/users/{user_id}" {
+			found = true
+			r.Equal("userPath", rt.PathName)
+		}
+	}
+	r.True(found)
+}
+
+func Test_App_AbsoluteURLFor(t *testing.T) {
+	r := require.New(t)
+	a := urlForApp()
+
+	u, err := a.AbsoluteURLFor("userPath", "user_id", 1)
+	r.NoError(err)
+	r.Equal("https://example.com/users/1", u)
+
+	u, err = a.AbsoluteURLFor("dashboardPath", "tenant", "acme")
+	r.NoError(err)
+	r.Equal("http://acme.example.com/dashboard", u)
+
+	u, err = a.URLFor("dashboardPath", "tenant", "acme")
+	r.NoError(err)
+	r.Equal("/dashboard", u)
+}
+
+func Test_App_RouteHelpers(t *testing.T) {
+	r := require.New(t)
+	a := urlForApp()
+
+	h := a.RouteHelpers()
+	r.Len(h, 3)
+	fn := h["userPath"].(func(map[string]interface{}) (string, error))
+	u, err := fn(map[string]interface{}{"user_id": 3})
+	r.NoError(err)
+	r.Equal("/users/3", u)
+}
+
+func Test_DefaultContext_URLFor(t *testing.T) {
+	r := require.New(t)
+
+	w := willie.New(urlForApp())
+	res := w.Request("/redirect").Get()
+	r.Equal(302, res.Code)
+	r.Equal("/users/42", res.Header().Get("Location"))
+}
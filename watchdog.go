@@ -0,0 +1,183 @@
+package buffalo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WatchdogOptions are used to configure a Watchdog.
+type WatchdogOptions struct {
+	// HeapLimit is the number of bytes of allocated heap that will
+	// trip the Watchdog. Zero disables the heap check.
+	HeapLimit uint64
+	// GoroutineLimit is the number of running goroutines that will
+	// trip the Watchdog. Zero disables the goroutine check.
+	GoroutineLimit int
+	// Interval between checks. Defaults to 10 seconds.
+	Interval time.Duration
+	// ProfileDir is where heap and goroutine profiles are written when
+	// the Watchdog trips. If blank no profiles are written.
+	ProfileDir string
+	// Logger to report on. Defaults to a new Logger at "info" level.
+	Logger Logger
+}
+
+// WatchdogReport describes the state of the process when a Watchdog
+// was tripped.
+type WatchdogReport struct {
+	Time       time.Time
+	HeapAlloc  uint64
+	Goroutines int
+	// Reasons lists the limits that were crossed.
+	Reasons []string
+	// Profiles contains the paths of any profiles written to disk.
+	Profiles []string
+}
+
+// WatchdogHook is called each time a Watchdog trips. This is a good
+// place to trigger a graceful restart of the application.
+type WatchdogHook func(WatchdogReport)
+
+// Watchdog periodically checks the heap size and goroutine count of the
+// process and reports when they cross the configured limits. This helps
+// catch leaks before the process is killed for running out of memory.
+// A Watchdog only trips once per crossing; it will trip again after the
+// process drops back under its limits and then crosses them again.
+type Watchdog struct {
+	WatchdogOptions
+	hooks   []WatchdogHook
+	tripped bool
+	stop    chan struct{}
+	moot    *sync.Mutex
+}
+
+// NewWatchdog returns a new Watchdog. Call Start to begin monitoring.
+func NewWatchdog(opts WatchdogOptions) *Watchdog {
+	if opts.Interval == 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = NewLogger("info")
+	}
+	return &Watchdog{
+		WatchdogOptions: opts,
+		moot:            &sync.Mutex{},
+	}
+}
+
+// OnTrip registers a WatchdogHook to be called when the Watchdog trips.
+func (w *Watchdog) OnTrip(h WatchdogHook) {
+	w.moot.Lock()
+	defer w.moot.Unlock()
+	w.hooks = append(w.hooks, h)
+}
+
+// Start monitoring in a new goroutine. Calling Start on a running
+// Watchdog does nothing.
+func (w *Watchdog) Start() {
+	w.moot.Lock()
+	defer w.moot.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+// Stop monitoring.
+func (w *Watchdog) Stop() {
+	w.moot.Lock()
+	defer w.moot.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *Watchdog) run(stop chan struct{}) {
+	t := time.NewTicker(w.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			w.Check()
+		}
+	}
+}
+
+// Check the process against the limits right now. If the Watchdog trips
+// the WatchdogReport is returned, otherwise nil is returned.
+func (w *Watchdog) Check() *WatchdogReport {
+	ms := &runtime.MemStats{}
+	runtime.ReadMemStats(ms)
+	rep := WatchdogReport{
+		Time:       time.Now(),
+		HeapAlloc:  ms.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if w.HeapLimit > 0 && rep.HeapAlloc > w.HeapLimit {
+		rep.Reasons = append(rep.Reasons, fmt.Sprintf("heap %d > %d", rep.HeapAlloc, w.HeapLimit))
+	}
+	if w.GoroutineLimit > 0 && rep.Goroutines > w.GoroutineLimit {
+		rep.Reasons = append(rep.Reasons, fmt.Sprintf("goroutines %d > %d", rep.Goroutines, w.GoroutineLimit))
+	}
+
+	w.moot.Lock()
+	if len(rep.Reasons) == 0 || w.tripped {
+		w.tripped = len(rep.Reasons) > 0
+		w.moot.Unlock()
+		return nil
+	}
+	w.tripped = true
+	hooks := w.hooks
+	w.moot.Unlock()
+
+	if w.ProfileDir != "" {
+		ps, err := w.writeProfiles(rep.Time)
+		if err != nil {
+			w.Logger.Error(err)
+		}
+		rep.Profiles = ps
+	}
+	w.Logger.WithFields(map[string]interface{}{
+		"heap_alloc": rep.HeapAlloc,
+		"goroutines": rep.Goroutines,
+		"profiles":   rep.Profiles,
+	}).Warnf("watchdog tripped: %v", rep.Reasons)
+	for _, h := range hooks {
+		h(rep)
+	}
+	return &rep
+}
+
+func (w *Watchdog) writeProfiles(t time.Time) ([]string, error) {
+	err := os.MkdirAll(w.ProfileDir, 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stamp := t.Format("20060102150405")
+	ps := []string{}
+	for _, name := range []string{"heap", "goroutine"} {
+		p := filepath.Join(w.ProfileDir, fmt.Sprintf("%s-%s.pprof", name, stamp))
+		f, err := os.Create(p)
+		if err != nil {
+			return ps, errors.WithStack(err)
+		}
+		err = pprof.Lookup(name).WriteTo(f, 0)
+		f.Close()
+		if err != nil {
+			return ps, errors.WithStack(err)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
@@ -0,0 +1,54 @@
+package buffalo
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Watchdog_Check(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "watchdog")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	w := NewWatchdog(WatchdogOptions{
+		GoroutineLimit: 1,
+		ProfileDir:     dir,
+		Logger:         NewLogger("error"),
+	})
+	var hooked *WatchdogReport
+	w.OnTrip(func(rep WatchdogReport) {
+		hooked = &rep
+	})
+
+	rep := w.Check()
+	r.NotNil(rep)
+	r.Len(rep.Reasons, 1)
+	r.Len(rep.Profiles, 2)
+	r.NotNil(hooked)
+	for _, p := range rep.Profiles {
+		_, err := os.Stat(p)
+		r.NoError(err)
+	}
+
+	// only trips once per crossing
+	r.Nil(w.Check())
+}
+
+func Test_Watchdog_Under_Limits(t *testing.T) {
+	r := require.New(t)
+
+	w := NewWatchdog(WatchdogOptions{
+		HeapLimit:      1 << 40,
+		GoroutineLimit: 1 << 20,
+		Interval:       time.Millisecond,
+	})
+	r.Nil(w.Check())
+	w.Start()
+	w.Stop()
+}
@@ -0,0 +1,142 @@
+package buffalo
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Socket wraps a websocket.Conn, from Context.Websocket, with helpers
+// for sending and receiving JSON, keeping the connection alive, and
+// closing it cleanly. Send, Close, and the pings of KeepAlive can be
+// used from different goroutines.
+/*
+	a.GET("/chat", func(c buffalo.Context) error {
+		conn, err := c.Websocket()
+		if err != nil {
+			return err
+		}
+		s := buffalo.NewSocket(conn)
+		defer s.KeepAlive(30 * time.Second)()
+		for {
+			msg := Message{}
+			if err := s.Receive(&msg); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			s.Send(msg)
+		}
+	})
+*/
+type Socket struct {
+	*websocket.Conn
+	// WriteWait is how long a write can take. Default is 10 seconds.
+	WriteWait time.Duration
+	moot      *sync.Mutex
+}
+
+// NewSocket wraps the websocket.Conn in a Socket.
+func NewSocket(conn *websocket.Conn) *Socket {
+	return &Socket{
+		Conn:      conn,
+		WriteWait: 10 * time.Second,
+		moot:      &sync.Mutex{},
+	}
+}
+
+// Send v as JSON.
+func (s *Socket) Send(v interface{}) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	s.SetWriteDeadline(time.Now().Add(s.WriteWait))
+	return errors.WithStack(s.WriteJSON(v))
+}
+
+// Receive the next message, as JSON, into v. It returns io.EOF when the
+// client closes the connection normally.
+func (s *Socket) Receive(v interface{}) error {
+	err := s.ReadJSON(v)
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return io.EOF
+	}
+	return errors.WithStack(err)
+}
+
+// KeepAlive pings the client every interval, and gives up on reading
+// from it if it doesn't answer within two intervals. Calling the
+// returned function stops the pings.
+func (s *Socket) KeepAlive(interval time.Duration) func() {
+	s.SetReadDeadline(time.Now().Add(2 * interval))
+	s.SetPongHandler(func(string) error {
+		return s.SetReadDeadline(time.Now().Add(2 * interval))
+	})
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if err := s.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.WriteWait)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Close the connection, telling the client why with the close code,
+// such as websocket.CloseNormalClosure, and reason.
+func (s *Socket) Close(code int, reason string) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	msg := websocket.FormatCloseMessage(code, reason)
+	s.WriteControl(websocket.CloseMessage, msg, time.Now().Add(s.WriteWait))
+	return errors.WithStack(s.Conn.Close())
+}
+
+// trackSocket keeps the connection until the request is done, so it can
+// be closed when the App is stopped.
+func (a *App) trackSocket(conn *websocket.Conn, done <-chan struct{}) {
+	s := a.server()
+	s.moot.Lock()
+	if s.sockets == nil {
+		s.sockets = map[*websocket.Conn]bool{}
+	}
+	s.sockets[conn] = true
+	s.moot.Unlock()
+	go func() {
+		<-done
+		s.moot.Lock()
+		delete(s.sockets, conn)
+		s.moot.Unlock()
+	}()
+}
+
+// closeSockets tells the clients of all open websockets that the App is
+// going away, and closes them.
+func (a *App) closeSockets() {
+	s := a.server()
+	s.moot.Lock()
+	conns := make([]*websocket.Conn, 0, len(s.sockets))
+	for c := range s.sockets {
+		conns = append(conns, c)
+	}
+	s.moot.Unlock()
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down")
+	for _, c := range conns {
+		c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		c.Close()
+	}
+}
@@ -0,0 +1,94 @@
+package buffalo
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Socket(t *testing.T) {
+	r := require.New(t)
+
+	type Message struct {
+		Text string `json:"text"`
+	}
+
+	a := New(Options{})
+	done := make(chan error, 1)
+	a.GET("/socket", func(c Context) error {
+		conn, err := c.Websocket()
+		if err != nil {
+			return err
+		}
+		s := NewSocket(conn)
+		defer s.KeepAlive(time.Second)()
+		for {
+			msg := Message{}
+			if err := s.Receive(&msg); err != nil {
+				done <- err
+				return nil
+			}
+			msg.Text = strings.ToUpper(msg.Text)
+			if err := s.Send(msg); err != nil {
+				return err
+			}
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- a.ServeListener(ctx, ln)
+	}()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/socket", nil)
+	r.NoError(err)
+	r.NoError(ws.WriteJSON(Message{Text: "hello"}))
+	msg := Message{}
+	r.NoError(ws.ReadJSON(&msg))
+	r.Equal("HELLO", msg.Text)
+
+	// stopping the app closes the socket
+	cancel()
+	_, _, err = ws.ReadMessage()
+	r.True(websocket.IsCloseError(err, websocket.CloseGoingAway))
+	r.Error(<-done)
+	r.NoError(<-served)
+}
+
+func Test_Socket_Receive_EOF(t *testing.T) {
+	r := require.New(t)
+
+	a := New(Options{})
+	done := make(chan error, 1)
+	a.GET("/socket", func(c Context) error {
+		conn, err := c.Websocket()
+		if err != nil {
+			return err
+		}
+		s := NewSocket(conn)
+		v := map[string]interface{}{}
+		done <- s.Receive(&v)
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.ServeListener(ctx, ln)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/socket", nil)
+	r.NoError(err)
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+	r.NoError(ws.WriteMessage(websocket.CloseMessage, msg))
+	r.Equal(io.EOF, <-done)
+}
@@ -0,0 +1,349 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo/redisconn"
+	"github.com/pkg/errors"
+)
+
+// RedisConn is the small slice of a Redis client the Redis Worker
+// needs, see redisconn.Conn. Blocking commands are used, so it must
+// check a connection out of a pool for each command.
+type RedisConn = redisconn.Conn
+
+// RedisOptions configure a Redis Worker.
+type RedisOptions struct {
+	// Prefix of the keys, default is "buffalo:worker:".
+	Prefix string
+	// Queues to take jobs from, default is "default".
+	Queues []string
+	// Concurrency is how many jobs are performed at once, default is 5.
+	Concurrency int
+	// PollInterval is how often scheduled jobs are checked for, and how
+	// long a wait for a job lasts, default is a second.
+	PollInterval time.Duration
+	Logger       Logger
+	// Retry is the RetryPolicy of failed jobs, see DeadLetters.
+	Retry RetryPolicy
+	// OnReport is called with the Report of each job performed.
+	OnReport func(Report)
+}
+
+// Redis is a Worker that queues jobs in Redis lists, and scheduled jobs
+// in a sorted set, so they survive restarts, and are performed by
+// whichever instance of the App takes them first. Jobs taken by an
+// instance that dies before finishing them are lost. Retries are
+// scheduled jobs, and dead jobs are kept in a hash.
+type Redis struct {
+	conn     RedisConn
+	opts     RedisOptions
+	moot     sync.Mutex
+	handlers map[string]Handler
+	cancel   context.CancelFunc
+	running  sync.WaitGroup
+}
+
+// NewRedis returns a Redis Worker using conn.
+/*
+	pool := &redis.Pool{...}
+	w := worker.NewRedis(redisconn.Func(func(cmd string, args ...interface{}) (interface{}, error) {
+		c := pool.Get()
+		defer c.Close()
+		return c.Do(cmd, args...)
+	}), worker.RedisOptions{Concurrency: 10})
+*/
+func NewRedis(conn RedisConn, opts RedisOptions) *Redis {
+	if opts.Prefix == "" {
+		opts.Prefix = "buffalo:worker:"
+	}
+	if len(opts.Queues) == 0 {
+		opts.Queues = []string{"default"}
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 5
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = time.Second
+	}
+	return &Redis{
+		conn:     conn,
+		opts:     opts,
+		handlers: map[string]Handler{},
+	}
+}
+
+func (r *Redis) queueKey(q string) string {
+	return r.opts.Prefix + "queue:" + q
+}
+
+func (r *Redis) scheduledKey() string {
+	return r.opts.Prefix + "scheduled"
+}
+
+func (r *Redis) deadKey() string {
+	return r.opts.Prefix + "dead"
+}
+
+// Register the Handler for the jobs of a name.
+func (r *Redis) Register(name string, h Handler) error {
+	r.moot.Lock()
+	defer r.moot.Unlock()
+	if _, ok := r.handlers[name]; ok {
+		return errors.Errorf("a handler is already registered for %q", name)
+	}
+	r.handlers[name] = h
+	return nil
+}
+
+// Perform puts the job on its queue.
+func (r *Redis) Perform(job Job) error {
+	if job.ID == "" {
+		job.ID = newID()
+	}
+	job.EnqueuedAt = time.Now()
+	b, err := json.Marshal(job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = r.conn.Do("LPUSH", r.queueKey(queueOf(job)), b)
+	return errors.WithStack(err)
+}
+
+// PerformAt schedules the job for t.
+func (r *Redis) PerformAt(job Job, t time.Time) error {
+	if job.ID == "" {
+		job.ID = newID()
+	}
+	b, err := json.Marshal(job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = r.conn.Do("ZADD", r.scheduledKey(), t.UnixNano()/int64(time.Millisecond), b)
+	return errors.WithStack(err)
+}
+
+// PerformIn schedules the job for once d has passed.
+func (r *Redis) PerformIn(job Job, d time.Duration) error {
+	return r.PerformAt(job, time.Now().Add(d))
+}
+
+// Start taking jobs from the queues, and moving scheduled jobs onto
+// them once they are due.
+func (r *Redis) Start(ctx context.Context) error {
+	r.moot.Lock()
+	if r.cancel != nil {
+		r.moot.Unlock()
+		return errors.New("worker is already started")
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.moot.Unlock()
+
+	r.running.Add(1 + r.opts.Concurrency)
+	go r.schedule(ctx)
+	for i := 0; i < r.opts.Concurrency; i++ {
+		go r.work(ctx)
+	}
+	return nil
+}
+
+// Stop taking jobs, and wait for the ones being performed.
+func (r *Redis) Stop() error {
+	r.moot.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.moot.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	r.running.Wait()
+	return nil
+}
+
+// schedule moves due jobs onto their queues. ZREM makes sure only one
+// instance moves each job.
+func (r *Redis) schedule(ctx context.Context) {
+	defer r.running.Done()
+	t := time.NewTicker(r.opts.PollInterval)
+	defer t.Stop()
+	for {
+		if err := r.enqueueDue(); err != nil {
+			r.logf("could not enqueue scheduled jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *Redis) enqueueDue() error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	reply, err := r.conn.Do("ZRANGEBYSCORE", r.scheduledKey(), "-inf", now, "LIMIT", 0, 100)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, raw := range values(reply) {
+		n, err := r.conn.Do("ZREM", r.scheduledKey(), raw)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if i, ok := n.(int64); !ok || i == 0 {
+			// another instance got it first
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(raw, &job); err != nil {
+			r.logf("could not decode scheduled job: %v", err)
+			continue
+		}
+		if err := r.Perform(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// work takes jobs from the queues, and performs them, until ctx is
+// done.
+func (r *Redis) work(ctx context.Context) {
+	defer r.running.Done()
+	args := []interface{}{}
+	for _, q := range r.opts.Queues {
+		args = append(args, r.queueKey(q))
+	}
+	args = append(args, int(r.opts.PollInterval/time.Second)+1)
+	for ctx.Err() == nil {
+		reply, err := r.conn.Do("BRPOP", args...)
+		if err != nil {
+			r.logf("could not take a job: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.opts.PollInterval):
+			}
+			continue
+		}
+		vs := values(reply)
+		if len(vs) != 2 {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(vs[1], &job); err != nil {
+			r.logf("could not decode job: %v", err)
+			continue
+		}
+		r.perform(job)
+	}
+}
+
+func (r *Redis) perform(job Job) {
+	r.moot.Lock()
+	h, ok := r.handlers[job.Handler]
+	r.moot.Unlock()
+	var rep Report
+	if ok {
+		rep = process(h, job, r.opts.Retry)
+	} else {
+		// kept, to be reprocessed once a Handler is registered
+		job.Error = ErrUnknownHandler.Error()
+		rep = Report{Job: job, Err: ErrUnknownHandler, Dead: true}
+	}
+	report(r.opts.Logger, r.opts.OnReport, rep)
+	switch {
+	case rep.RetryIn > 0:
+		if err := r.PerformIn(rep.Job, rep.RetryIn); err != nil {
+			r.logf("job %s could not be retried: %v", job.Handler, err)
+		}
+	case rep.Dead:
+		if err := r.bury(rep.Job); err != nil {
+			r.logf("job %s could not be kept as dead: %v", job.Handler, err)
+		}
+	}
+}
+
+func (r *Redis) bury(job Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = r.conn.Do("HSET", r.deadKey(), job.ID, b)
+	return errors.WithStack(err)
+}
+
+// Dead returns the jobs that failed on their last attempt, see
+// DeadLetters.
+func (r *Redis) Dead() ([]Job, error) {
+	reply, err := r.conn.Do("HGETALL", r.deadKey())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	vs := values(reply)
+	jobs := make([]Job, 0, len(vs)/2)
+	for i := 1; i < len(vs); i += 2 {
+		var job Job
+		if err := json.Unmarshal(vs[i], &job); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Reprocess puts the dead job back on its queue, see DeadLetters. HDEL
+// makes sure only one instance reprocesses it.
+func (r *Redis) Reprocess(id string) error {
+	reply, err := r.conn.Do("HGET", r.deadKey(), id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	raw := values([]interface{}{reply})
+	if len(raw) == 0 {
+		return errors.Errorf("no dead job %q", id)
+	}
+	var job Job
+	if err := json.Unmarshal(raw[0], &job); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := r.Discard(id); err != nil {
+		return err
+	}
+	job.Attempts = 0
+	job.Error = ""
+	return r.Perform(job)
+}
+
+// Discard the dead job, see DeadLetters.
+func (r *Redis) Discard(id string) error {
+	n, err := r.conn.Do("HDEL", r.deadKey(), id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if i, ok := n.(int64); !ok || i == 0 {
+		return errors.Errorf("no dead job %q", id)
+	}
+	return nil
+}
+
+func (r *Redis) logf(format string, args ...interface{}) {
+	logf(r.opts.Logger, true, format, args...)
+}
+
+// values returns the bulk strings of a Redis array reply.
+func values(reply interface{}) [][]byte {
+	arr, _ := reply.([]interface{})
+	out := make([][]byte, 0, len(arr))
+	for _, v := range arr {
+		switch t := v.(type) {
+		case []byte:
+			out = append(out, t)
+		case string:
+			out = append(out, []byte(t))
+		}
+	}
+	return out
+}
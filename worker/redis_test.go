@@ -0,0 +1,204 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis implements the commands the Redis Worker uses.
+type fakeRedis struct {
+	moot   sync.Mutex
+	lists  map[string][][]byte
+	zset   map[string]int64
+	hash   map[string][]byte
+	pushed chan struct{}
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{lists: map[string][][]byte{}, zset: map[string]int64{}, hash: map[string][]byte{}, pushed: make(chan struct{}, 100)}
+}
+
+func (f *fakeRedis) Do(cmd string, args ...interface{}) (interface{}, error) {
+	switch cmd {
+	case "LPUSH":
+		f.moot.Lock()
+		k := args[0].(string)
+		f.lists[k] = append([][]byte{args[1].([]byte)}, f.lists[k]...)
+		f.moot.Unlock()
+		f.pushed <- struct{}{}
+		return int64(1), nil
+	case "BRPOP":
+		deadline := time.Now().Add(50 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			f.moot.Lock()
+			for _, k := range args[:len(args)-1] {
+				l := f.lists[k.(string)]
+				if len(l) > 0 {
+					v := l[len(l)-1]
+					f.lists[k.(string)] = l[:len(l)-1]
+					f.moot.Unlock()
+					return []interface{}{[]byte(k.(string)), v}, nil
+				}
+			}
+			f.moot.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+		return nil, nil
+	case "ZADD":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		f.zset[string(args[2].([]byte))] = args[1].(int64)
+		return int64(1), nil
+	case "ZRANGEBYSCORE":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		max := args[2].(int64)
+		out := []string{}
+		for k, score := range f.zset {
+			if score <= max {
+				out = append(out, k)
+			}
+		}
+		sort.Strings(out)
+		reply := []interface{}{}
+		for _, k := range out {
+			reply = append(reply, []byte(k))
+		}
+		return reply, nil
+	case "ZREM":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		k := string(args[1].([]byte))
+		if _, ok := f.zset[k]; !ok {
+			return int64(0), nil
+		}
+		delete(f.zset, k)
+		return int64(1), nil
+	case "HSET":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		f.hash[args[1].(string)] = args[2].([]byte)
+		return int64(1), nil
+	case "HGET":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		if v, ok := f.hash[args[1].(string)]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case "HGETALL":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		reply := []interface{}{}
+		for k, v := range f.hash {
+			reply = append(reply, []byte(k), v)
+		}
+		return reply, nil
+	case "HDEL":
+		f.moot.Lock()
+		defer f.moot.Unlock()
+		if _, ok := f.hash[args[1].(string)]; !ok {
+			return int64(0), nil
+		}
+		delete(f.hash, args[1].(string))
+		return int64(1), nil
+	}
+	return nil, nil
+}
+
+func Test_Redis(t *testing.T) {
+	r := require.New(t)
+
+	conn := newFakeRedis()
+	w := worker.NewRedis(conn, worker.RedisOptions{PollInterval: 10 * time.Millisecond, Concurrency: 2})
+	done := make(chan string, 3)
+	r.NoError(w.Register("echo", func(args worker.Args) error {
+		done <- args["msg"].(string) + ":" + strconv.Itoa(int(args["n"].(float64)))
+		return nil
+	}))
+	r.NoError(w.Start(context.Background()))
+	defer w.Stop()
+
+	r.NoError(w.Perform(worker.Job{Handler: "echo", Args: worker.Args{"msg": "now", "n": 1}}))
+	r.Equal("now:1", <-done)
+
+	r.NoError(w.PerformIn(worker.Job{Handler: "echo", Args: worker.Args{"msg": "later", "n": 2}}, 20*time.Millisecond))
+	select {
+	case got := <-done:
+		r.Equal("later:2", got)
+	case <-time.After(time.Second):
+		r.Fail("scheduled job wasn't performed")
+	}
+	r.Empty(conn.zset)
+}
+
+func Test_Redis_Retry(t *testing.T) {
+	r := require.New(t)
+
+	conn := newFakeRedis()
+	reports := make(chan worker.Report, 10)
+	w := worker.NewRedis(conn, worker.RedisOptions{
+		PollInterval: 10 * time.Millisecond,
+		Retry:        worker.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+		OnReport: func(rep worker.Report) {
+			reports <- rep
+		},
+	})
+	var moot sync.Mutex
+	fail := true
+	r.NoError(w.Register("flaky", func(args worker.Args) error {
+		moot.Lock()
+		defer moot.Unlock()
+		if fail {
+			return errors.New("flaked")
+		}
+		return nil
+	}))
+	r.NoError(w.Start(context.Background()))
+	defer w.Stop()
+
+	r.NoError(w.Perform(worker.Job{Handler: "flaky", Args: worker.Args{"n": 1}}))
+	rep := <-reports
+	r.Equal(1, rep.Job.Attempts)
+	r.Equal(time.Millisecond, rep.RetryIn)
+	r.True(rep.QueueLatency >= 0)
+	id := rep.Job.ID
+	r.NotEmpty(id)
+
+	// retried through the scheduled jobs, then dead
+	rep = <-reports
+	r.Equal(2, rep.Job.Attempts)
+	r.Equal(id, rep.Job.ID)
+	r.True(rep.Dead)
+
+	dead, err := w.Dead()
+	r.NoError(err)
+	r.Len(dead, 1)
+	r.Equal(id, dead[0].ID)
+	r.Equal("flaked", dead[0].Error)
+	r.Equal(float64(1), dead[0].Args["n"])
+
+	moot.Lock()
+	fail = false
+	moot.Unlock()
+	r.NoError(w.Reprocess(id))
+	rep = <-reports
+	r.NoError(rep.Err)
+	r.Equal(1, rep.Job.Attempts)
+	r.Empty(conn.hash)
+	r.Error(w.Discard(id))
+
+	// jobs without a Handler are kept, to be reprocessed later
+	r.NoError(w.Perform(worker.Job{Handler: "unknown"}))
+	rep = <-reports
+	r.True(rep.Dead)
+	r.NoError(w.Discard(rep.Job.ID))
+}
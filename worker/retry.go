@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gobuffalo/buffalo/events"
+)
+
+// RetryPolicy decides how often, and when, failed jobs are performed
+// again. Jobs that fail on their last attempt are dead, and are kept
+// by the Worker until they are reprocessed or discarded, see
+// DeadLetters. The zero RetryPolicy performs jobs once.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a job is performed before it is
+	// dead, Job.MaxAttempts takes precedence. Default is 1.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry, each retry
+	// after that waits twice as long. Default is a second.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries. Default is an hour.
+	MaxBackoff time.Duration
+}
+
+// Delay before performing a job again after its nth attempt failed.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d, max := p.Backoff, p.MaxBackoff
+	if d <= 0 {
+		d = time.Second
+	}
+	if max <= 0 {
+		max = time.Hour
+	}
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+func (p RetryPolicy) maxAttempts(job Job) int {
+	switch {
+	case job.MaxAttempts > 0:
+		return job.MaxAttempts
+	case p.MaxAttempts > 0:
+		return p.MaxAttempts
+	}
+	return 1
+}
+
+// Report is what happened to a job that was performed, for metrics.
+/*
+	w := worker.NewSimple()
+	w.OnReport = func(rep worker.Report) {
+		jobDuration.WithLabelValues(rep.Job.Handler).Observe(rep.Duration.Seconds())
+		jobLatency.WithLabelValues(rep.Job.Queue).Observe(rep.QueueLatency.Seconds())
+	}
+*/
+type Report struct {
+	Job Job
+	// QueueLatency is how long the job waited to be performed, from when
+	// it was due.
+	QueueLatency time.Duration
+	// Duration is how long the Handler took.
+	Duration time.Duration
+	// Err is the error the Handler returned, if any.
+	Err error
+	// RetryIn is how long until the job is performed again, 0 if it
+	// isn't.
+	RetryIn time.Duration
+	// Dead is set when the job failed on its last attempt.
+	Dead bool
+}
+
+// DeadLetters are the jobs that failed on their last attempt. Simple
+// and Redis Workers keep them.
+/*
+	if dl, ok := app.Worker.(worker.DeadLetters); ok {
+		jobs, err := dl.Dead()
+		...
+		err = dl.Reprocess(jobs[0].ID)
+	}
+*/
+type DeadLetters interface {
+	// Dead returns the dead jobs, with the Error of their last attempt.
+	Dead() ([]Job, error)
+	// Reprocess performs the dead job again, with its attempts reset.
+	Reprocess(id string) error
+	// Discard the dead job.
+	Discard(id string) error
+}
+
+// process performs the job with h, and works out what happens to it
+// next.
+func process(h Handler, job Job, retry RetryPolicy) Report {
+	start := time.Now()
+	job.Attempts++
+	rep := Report{Job: job}
+	if !job.EnqueuedAt.IsZero() {
+		rep.QueueLatency = start.Sub(job.EnqueuedAt)
+	}
+	rep.Err = run(h, job.Args)
+	rep.Duration = time.Since(start)
+	if rep.Err == nil {
+		return rep
+	}
+	rep.Job.Error = rep.Err.Error()
+	if job.Attempts >= retry.maxAttempts(job) {
+		rep.Dead = true
+		return rep
+	}
+	rep.RetryIn = retry.Delay(job.Attempts)
+	return rep
+}
+
+var (
+	// JobPerformed is emitted with the Report of each job performed.
+	JobPerformed = events.NewTopic[Report]("worker:job:performed")
+	// JobFailed is emitted with the Report of each attempt at a job
+	// that failed, Report.Dead is set on the last one.
+	JobFailed = events.NewTopic[Report]("worker:job:failed")
+)
+
+// report the outcome of a job: log it, hand it to onReport, and emit
+// its events.
+func report(l Logger, onReport func(Report), rep Report) {
+	logReport(l, rep)
+	if onReport != nil {
+		onReport(rep)
+	}
+	JobPerformed.Emit(rep)
+	if rep.Err != nil {
+		JobFailed.Emit(rep)
+	}
+}
+
+// logReport logs the outcome of a job, with its metrics. Without a
+// Logger only failures are logged.
+func logReport(l Logger, rep Report) {
+	job := rep.Job
+	fields := fmt.Sprintf("id=%s queue=%s attempt=%d queue_latency=%s duration=%s",
+		job.ID, queueOf(job), job.Attempts, rep.QueueLatency, rep.Duration)
+	switch {
+	case rep.Err == nil:
+		logf(l, false, "job %s performed %s", job.Handler, fields)
+	case rep.Dead:
+		logf(l, true, "job %s failed, and is dead: %v %s", job.Handler, rep.Err, fields)
+	default:
+		logf(l, true, "job %s failed, retrying in %s: %v %s", job.Handler, rep.RetryIn, rep.Err, fields)
+	}
+}
+
+func logf(l Logger, failed bool, format string, args ...interface{}) {
+	switch {
+	case l == nil && failed:
+		log.Printf(format, args...)
+	case l == nil:
+	case failed:
+		l.Errorf(format, args...)
+	default:
+		l.Infof(format, args...)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Simple is a Worker that performs each job in a goroutine of the
+// process. Jobs are lost if the process stops before they are
+// performed, so it is meant for development, tests, and jobs that
+// don't matter much.
+type Simple struct {
+	Logger Logger
+	// Retry is the RetryPolicy of failed jobs, see DeadLetters.
+	Retry RetryPolicy
+	// OnReport is called with the Report of each job performed.
+	OnReport func(Report)
+	moot     sync.Mutex
+	handlers map[string]Handler
+	timers   map[*time.Timer]bool
+	dead     []Job
+	running  sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewSimple returns a Simple Worker, it performs jobs right away, with
+// or without being started.
+func NewSimple() *Simple {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Simple{
+		handlers: map[string]Handler{},
+		timers:   map[*time.Timer]bool{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start the Worker, it is stopped when ctx is done. A stopped Simple
+// can be started again.
+func (s *Simple) Start(ctx context.Context) error {
+	s.moot.Lock()
+	if s.ctx.Err() != nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	done := s.ctx.Done()
+	s.moot.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-done:
+		}
+	}()
+	return nil
+}
+
+// Stop the Worker. Jobs waiting for their time are dropped, and the ones
+// being performed are waited for.
+func (s *Simple) Stop() error {
+	s.moot.Lock()
+	s.cancel()
+	for t := range s.timers {
+		t.Stop()
+	}
+	s.timers = map[*time.Timer]bool{}
+	s.moot.Unlock()
+	s.running.Wait()
+	return nil
+}
+
+// Register the Handler for the jobs of a name.
+func (s *Simple) Register(name string, h Handler) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	if _, ok := s.handlers[name]; ok {
+		return errors.Errorf("a handler is already registered for %q", name)
+	}
+	s.handlers[name] = h
+	return nil
+}
+
+// Perform the job in a goroutine.
+func (s *Simple) Perform(job Job) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	if s.ctx.Err() != nil {
+		return errors.New("worker is stopped")
+	}
+	h, ok := s.handlers[job.Handler]
+	if !ok {
+		return errors.Wrap(ErrUnknownHandler, job.Handler)
+	}
+	if job.ID == "" {
+		job.ID = newID()
+	}
+	job.EnqueuedAt = time.Now()
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.finish(process(h, job, s.Retry))
+	}()
+	return nil
+}
+
+// finish the job: report it, and retry it, or keep it as dead.
+func (s *Simple) finish(rep Report) {
+	report(s.Logger, s.OnReport, rep)
+	switch {
+	case rep.RetryIn > 0:
+		if err := s.PerformIn(rep.Job, rep.RetryIn); err != nil {
+			logf(s.Logger, true, "job %s could not be retried: %v", rep.Job.Handler, err)
+		}
+	case rep.Dead:
+		s.moot.Lock()
+		s.dead = append(s.dead, rep.Job)
+		s.moot.Unlock()
+	}
+}
+
+// Dead returns the jobs that failed on their last attempt, see
+// DeadLetters. They are lost when the process stops.
+func (s *Simple) Dead() ([]Job, error) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	return append([]Job{}, s.dead...), nil
+}
+
+// Reprocess performs the dead job again, see DeadLetters.
+func (s *Simple) Reprocess(id string) error {
+	job, ok := s.takeDead(id)
+	if !ok {
+		return errors.Errorf("no dead job %q", id)
+	}
+	job.Attempts = 0
+	job.Error = ""
+	if err := s.Perform(job); err != nil {
+		s.moot.Lock()
+		s.dead = append(s.dead, job)
+		s.moot.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Discard the dead job, see DeadLetters.
+func (s *Simple) Discard(id string) error {
+	if _, ok := s.takeDead(id); !ok {
+		return errors.Errorf("no dead job %q", id)
+	}
+	return nil
+}
+
+func (s *Simple) takeDead(id string) (Job, bool) {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	for i, job := range s.dead {
+		if job.ID == id {
+			s.dead = append(s.dead[:i], s.dead[i+1:]...)
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// PerformAt performs the job at t.
+func (s *Simple) PerformAt(job Job, t time.Time) error {
+	return s.PerformIn(job, time.Until(t))
+}
+
+// PerformIn performs the job once d has passed.
+func (s *Simple) PerformIn(job Job, d time.Duration) error {
+	s.moot.Lock()
+	defer s.moot.Unlock()
+	if s.ctx.Err() != nil {
+		return errors.New("worker is stopped")
+	}
+	if _, ok := s.handlers[job.Handler]; !ok {
+		return errors.Wrap(ErrUnknownHandler, job.Handler)
+	}
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		s.moot.Lock()
+		delete(s.timers, t)
+		s.moot.Unlock()
+		if err := s.Perform(job); err != nil {
+			logf(s.Logger, true, "job %s failed: %v", job.Handler, err)
+		}
+	})
+	s.timers[t] = true
+	return nil
+}
@@ -0,0 +1,113 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Simple(t *testing.T) {
+	r := require.New(t)
+
+	w := worker.NewSimple()
+	done := make(chan worker.Args, 3)
+	r.NoError(w.Register("echo", func(args worker.Args) error {
+		done <- args
+		return nil
+	}))
+	r.Error(w.Register("echo", func(worker.Args) error { return nil }))
+	r.NoError(w.Start(context.Background()))
+
+	r.NoError(w.Perform(worker.Job{Handler: "echo", Args: worker.Args{"n": 1}}))
+	r.Equal(1, (<-done)["n"])
+
+	start := time.Now()
+	r.NoError(w.PerformIn(worker.Job{Handler: "echo", Args: worker.Args{"n": 2}}, 20*time.Millisecond))
+	r.Equal(2, (<-done)["n"])
+	r.True(time.Since(start) >= 20*time.Millisecond)
+
+	r.Error(w.Perform(worker.Job{Handler: "unknown"}))
+
+	// scheduled jobs are dropped on Stop
+	r.NoError(w.PerformAt(worker.Job{Handler: "echo"}, time.Now().Add(time.Hour)))
+	r.NoError(w.Stop())
+	r.Error(w.Perform(worker.Job{Handler: "echo"}))
+
+	// and it can be started again
+	r.NoError(w.Start(context.Background()))
+	r.NoError(w.Perform(worker.Job{Handler: "echo", Args: worker.Args{"n": 3}}))
+	r.Equal(3, (<-done)["n"])
+}
+
+func Test_Simple_Retry(t *testing.T) {
+	r := require.New(t)
+
+	w := worker.NewSimple()
+	w.Retry = worker.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	reports := make(chan worker.Report, 10)
+	w.OnReport = func(rep worker.Report) {
+		reports <- rep
+	}
+	failed := make(chan worker.Report, 10)
+	defer worker.JobFailed.Listen(func(rep worker.Report) {
+		failed <- rep
+	})()
+	fail := true
+	r.NoError(w.Register("flaky", func(args worker.Args) error {
+		if fail {
+			return errors.New("flaked")
+		}
+		return nil
+	}))
+	r.NoError(w.Perform(worker.Job{Handler: "flaky"}))
+
+	for i := 1; i <= 3; i++ {
+		rep := <-reports
+		r.Equal(i, rep.Job.Attempts)
+		r.Error(rep.Err)
+		r.Equal(i == 3, rep.Dead)
+		if i < 3 {
+			r.Equal(time.Duration(1<<uint(i-1))*time.Millisecond, rep.RetryIn)
+		}
+	}
+
+	for i := 1; i <= 3; i++ {
+		r.Equal(i, (<-failed).Job.Attempts)
+	}
+
+	dead, err := w.Dead()
+	r.NoError(err)
+	r.Len(dead, 1)
+	r.Equal("flaked", dead[0].Error)
+	r.NotEmpty(dead[0].ID)
+
+	fail = false
+	r.NoError(w.Reprocess(dead[0].ID))
+	rep := <-reports
+	r.NoError(rep.Err)
+	r.Equal(1, rep.Job.Attempts)
+	r.True(rep.Duration > 0)
+	dead, err = w.Dead()
+	r.NoError(err)
+	r.Empty(dead)
+	r.Error(w.Reprocess("unknown"))
+	r.NoError(w.Stop())
+	r.Empty(failed)
+}
+
+func Test_RetryPolicy_Delay(t *testing.T) {
+	r := require.New(t)
+
+	p := worker.RetryPolicy{}
+	r.Equal(time.Second, p.Delay(1))
+	r.Equal(4*time.Second, p.Delay(3))
+	r.Equal(time.Hour, p.Delay(50))
+
+	p = worker.RetryPolicy{Backoff: time.Minute, MaxBackoff: 5 * time.Minute}
+	r.Equal(2*time.Minute, p.Delay(2))
+	r.Equal(5*time.Minute, p.Delay(4))
+}
@@ -0,0 +1,103 @@
+// Package worker runs jobs in the background, outside of the requests
+// that ask for them. Jobs are performed by the Handler registered under
+// their name, by a Worker: Simple runs them in goroutines of the
+// process, for development, and Redis queues them in Redis, for
+// production, where any instance of the App can pick them up. Failed
+// jobs are retried by the RetryPolicy of the Worker, and kept once they
+// run out of attempts, see DeadLetters.
+/*
+	app := buffalo.New(buffalo.Options{
+		Worker: worker.NewRedis(conn, worker.RedisOptions{Concurrency: 10}),
+	})
+
+	app.Worker.Register("welcome_email", func(args worker.Args) error {
+		return mailers.SendWelcome(args["email"].(string))
+	})
+
+	func UsersCreate(c buffalo.Context) error {
+		...
+		return app.Worker.Perform(worker.Job{
+			Handler: "welcome_email",
+			Args:    worker.Args{"email": u.Email},
+		})
+	}
+*/
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Args are the arguments of a Job. Workers that queue jobs outside of
+// the process encode them as JSON, so numbers come back as float64s.
+type Args map[string]interface{}
+
+// Job to be performed by the Handler registered under its name.
+type Job struct {
+	// Queue the job is put on, default is "default".
+	Queue string `json:"queue,omitempty"`
+	// Handler is the name of the Handler that performs the job.
+	Handler string `json:"handler"`
+	// Args given to the Handler.
+	Args Args `json:"args,omitempty"`
+	// MaxAttempts overrides the RetryPolicy of the Worker for the job.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// ID of the job, set by the Worker when it is queued.
+	ID string `json:"id,omitempty"`
+	// Attempts is how many times the job has been performed.
+	Attempts int `json:"attempts,omitempty"`
+	// EnqueuedAt is when the job was queued, or due.
+	EnqueuedAt time.Time `json:"enqueued_at,omitempty"`
+	// Error of the last attempt, for jobs that failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Handler performs the jobs of a name.
+type Handler func(Args) error
+
+// Worker performs Jobs in the background.
+type Worker interface {
+	// Start the Worker, it is stopped when ctx is done, or by Stop.
+	Start(ctx context.Context) error
+	// Stop the Worker, waiting for the jobs it is performing.
+	Stop() error
+	// Perform the job as soon as possible.
+	Perform(job Job) error
+	// PerformAt performs the job at t.
+	PerformAt(job Job, t time.Time) error
+	// PerformIn performs the job once d has passed.
+	PerformIn(job Job, d time.Duration) error
+	// Register the Handler for the jobs of a name.
+	Register(name string, h Handler) error
+}
+
+// Logger is what Workers log errors to. A buffalo.Logger is one.
+type Logger interface {
+	Infof(string, ...interface{})
+	Errorf(string, ...interface{})
+}
+
+// ErrUnknownHandler is returned for jobs whose Handler hasn't been
+// registered.
+var ErrUnknownHandler = errors.New("no handler registered for the job")
+
+func queueOf(job Job) string {
+	if job.Queue == "" {
+		return "default"
+	}
+	return job.Queue
+}
+
+// run calls h, turning a panic into an error.
+func run(h Handler, args Args) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(args)
+}